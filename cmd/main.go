@@ -1,13 +1,30 @@
 package main
 
 import (
+    "context"
     "log"
     "os"
 
     "github.com/xkillx/go-gin-order-settlement/middlewares"
+    "github.com/xkillx/go-gin-order-settlement/modules/event"
+    eventService "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+    "github.com/xkillx/go-gin-order-settlement/modules/ledger"
+    "github.com/xkillx/go-gin-order-settlement/modules/mail"
+    mailService "github.com/xkillx/go-gin-order-settlement/modules/mail/service"
+    "github.com/xkillx/go-gin-order-settlement/modules/merchant"
     "github.com/xkillx/go-gin-order-settlement/modules/order"
+    orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
     "github.com/xkillx/go-gin-order-settlement/modules/product"
+    "github.com/xkillx/go-gin-order-settlement/modules/reconciliation"
+    "github.com/xkillx/go-gin-order-settlement/modules/inventory"
+    "github.com/xkillx/go-gin-order-settlement/modules/reservation"
+    reservationService "github.com/xkillx/go-gin-order-settlement/modules/reservation/service"
     "github.com/xkillx/go-gin-order-settlement/modules/settlement"
+    "github.com/xkillx/go-gin-order-settlement/modules/statement"
+    "github.com/xkillx/go-gin-order-settlement/modules/transaction"
+    "github.com/xkillx/go-gin-order-settlement/modules/warehouse"
+    "github.com/xkillx/go-gin-order-settlement/modules/webhook"
+    webhookService "github.com/xkillx/go-gin-order-settlement/modules/webhook/service"
     "github.com/xkillx/go-gin-order-settlement/providers"
     "github.com/xkillx/go-gin-order-settlement/script"
     "github.com/samber/do"
@@ -64,8 +81,26 @@ func main() {
 
     // Register module routes
     product.RegisterRoutes(server, injector)
+    merchant.RegisterRoutes(server, injector)
     order.RegisterRoutes(server, injector)
+    reservation.RegisterRoutes(server, injector)
+    inventory.RegisterRoutes(server, injector)
+    warehouse.RegisterRoutes(server, injector)
     settlement.RegisterRoutes(server, injector)
+    transaction.RegisterRoutes(server, injector)
+    reconciliation.RegisterRoutes(server, injector)
+    ledger.RegisterRoutes(server, injector)
+    statement.RegisterRoutes(server, injector)
+    mail.RegisterRoutes(server, injector)
+    webhook.RegisterRoutes(server, injector)
+    event.RegisterRoutes(server, injector)
+
+    // Relay domain events, deliver queued mail and webhooks, release expired holds and sweep old idempotency keys in the background
+    go do.MustInvoke[*eventService.Relay](injector).Run(context.Background())
+    go do.MustInvoke[*mailService.Dispatcher](injector).Run(context.Background())
+    go do.MustInvoke[*webhookService.Dispatcher](injector).Run(context.Background())
+    go do.MustInvoke[*reservationService.Sweeper](injector).Run(context.Background())
+    go do.MustInvoke[*orderService.IdempotencySweeper](injector).Run(context.Background())
 
     run(server)
 }
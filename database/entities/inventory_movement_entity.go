@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InventoryMovement is one change to a product's on-hand stock at a warehouse: a sale, a
+// cancellation or refund returning it, a manual adjustment, a restock or a transfer. Movements
+// are only ever appended, so a product's Stock always equals the sum of its movements' Delta.
+type InventoryMovement struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	// WarehouseID is where the stock changed; a transfer records one movement at each end
+	WarehouseID *uuid.UUID `gorm:"type:uuid;index" json:"warehouse_id"`
+	Kind        string     `gorm:"type:text;not null" json:"kind"`
+	Delta       int        `gorm:"type:int;not null;check:delta <> 0" json:"delta"`
+	// Reference names what caused the movement, e.g. the order id
+	Reference string `gorm:"type:text" json:"reference,omitempty"`
+	Actor     string `gorm:"type:text;not null" json:"actor"`
+	Reason    string `gorm:"type:text" json:"reason,omitempty"`
+
+	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (m *InventoryMovement) BeforeCreate(_ *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
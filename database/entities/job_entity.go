@@ -4,7 +4,9 @@ import "time"
 
 type Job struct {
 	ID              string    `gorm:"type:text;primaryKey" db:"id" json:"id"`
+	Type            string    `gorm:"type:text;not null;default:'settlement';index" db:"type" json:"type"`
 	Status          string    `gorm:"type:text;not null" db:"status" json:"status"`
+	Payload         string    `gorm:"type:text" db:"payload" json:"payload"`
 	FromDate        time.Time `gorm:"type:date;not null" db:"from_date" json:"from_date"`
 	ToDate          time.Time `gorm:"type:date;not null" db:"to_date" json:"to_date"`
 	Progress        int       `gorm:"type:int;not null;default:0" db:"progress" json:"progress"`
@@ -12,6 +14,18 @@ type Job struct {
 	Total           int64     `gorm:"type:bigint;not null;default:0" db:"total" json:"total"`
 	ResultPath      string    `gorm:"type:text" db:"result_path" json:"result_path"`
 	CancelRequested bool      `gorm:"type:boolean;not null;default:false" db:"cancel_requested" json:"cancel_requested"`
+	Error           string    `gorm:"type:text" db:"error" json:"error"`
+	Attempts        int       `gorm:"type:int;not null;default:1" db:"attempts" json:"attempts"`
+	WorkflowID      string    `gorm:"type:text;index" db:"workflow_id" json:"workflow_id,omitempty"`
+	WorkflowStep    string    `gorm:"type:text" db:"workflow_step" json:"workflow_step,omitempty"`
+
+	Timestamp
+}
+
+// JobDependency links a job to a parent job that must COMPLETE before it starts.
+type JobDependency struct {
+	JobID    string `gorm:"type:text;primaryKey" db:"job_id" json:"job_id"`
+	ParentID string `gorm:"type:text;primaryKey;index" db:"parent_id" json:"parent_id"`
 
 	Timestamp
 }
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrJournalImmutable is returned when a posted journal entry or line is updated or deleted.
+// Corrections are posted as new entries.
+var ErrJournalImmutable = errors.New("journal entries are immutable")
+
+// LedgerAccount is one account of the double-entry ledger. Code is unique and derived from
+// the type and, for merchant accounts, the merchant ID (e.g. "merchant_payable:m-1").
+type LedgerAccount struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	Code          string    `gorm:"type:text;not null;uniqueIndex" db:"code" json:"code"`
+	Type          string    `gorm:"type:text;not null" db:"type" json:"type"`
+	MerchantID    string    `gorm:"type:text;not null;default:'';index" db:"merchant_id" json:"merchant_id,omitempty"`
+	NormalBalance string    `gorm:"type:text;not null" db:"normal_balance" json:"normal_balance"`
+
+	Timestamp
+}
+
+// JournalEntry groups balanced journal lines. Settlement entries carry the (merchant_id,
+// settlement_date) they were posted for and the job that posted them in SourceRef.
+type JournalEntry struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	Source         string     `gorm:"type:text;not null" db:"source" json:"source"`
+	SourceRef      string     `gorm:"type:text;not null;default:'';index" db:"source_ref" json:"source_ref,omitempty"`
+	ExternalRef    *string    `gorm:"type:text;uniqueIndex" db:"external_ref" json:"external_ref,omitempty"`
+	MerchantID     string     `gorm:"type:text;not null;default:'';index:idx_journal_merchant_date,priority:1" db:"merchant_id" json:"merchant_id,omitempty"`
+	SettlementDate *time.Time `gorm:"type:date;index:idx_journal_merchant_date,priority:2" db:"settlement_date" json:"settlement_date,omitempty"`
+	Description    string     `gorm:"type:text" db:"description" json:"description"`
+	// EffectiveAt is when the entry counts towards balances; PostedAt is when it was recorded.
+	EffectiveAt time.Time `gorm:"type:timestamp with time zone;not null;index" db:"effective_at" json:"effective_at"`
+	PostedAt    time.Time `gorm:"type:timestamp with time zone;not null" db:"posted_at" json:"posted_at"`
+
+	Lines []JournalLine `gorm:"foreignKey:EntryID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"lines,omitempty"`
+}
+
+// JournalLine debits or credits one account; exactly one of the two amounts is positive.
+type JournalLine struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	EntryID     uuid.UUID `gorm:"type:uuid;not null;index" db:"entry_id" json:"entry_id"`
+	AccountID   uuid.UUID `gorm:"type:uuid;not null;index" db:"account_id" json:"account_id"`
+	DebitCents  int64     `gorm:"type:bigint;not null;default:0;check:chk_journal_line_debit,debit_cents >= 0" db:"debit_cents" json:"debit_cents"`
+	CreditCents int64     `gorm:"type:bigint;not null;default:0;check:chk_journal_line_credit,credit_cents >= 0" db:"credit_cents" json:"credit_cents"`
+
+	Account LedgerAccount `gorm:"foreignKey:AccountID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"account"`
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (a *LedgerAccount) BeforeCreate(_ *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *JournalEntry) BeforeCreate(_ *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *JournalEntry) BeforeUpdate(_ *gorm.DB) error { return ErrJournalImmutable }
+
+func (e *JournalEntry) BeforeDelete(_ *gorm.DB) error { return ErrJournalImmutable }
+
+func (l *JournalLine) BeforeCreate(_ *gorm.DB) (err error) {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *JournalLine) BeforeUpdate(_ *gorm.DB) error { return ErrJournalImmutable }
+
+func (l *JournalLine) BeforeDelete(_ *gorm.DB) error { return ErrJournalImmutable }
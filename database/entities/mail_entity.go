@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MailMessage is one rendered mail in the outbox. The dispatcher claims due messages, sends them
+// through the configured transport and reschedules failures with backoff.
+type MailMessage struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	Template      string     `gorm:"type:text;not null;index" db:"template" json:"template"`
+	ToEmail       string     `gorm:"type:text;not null" db:"to_email" json:"to_email"`
+	Subject       string     `gorm:"type:text;not null" db:"subject" json:"subject"`
+	Body          string     `gorm:"type:text;not null" db:"body" json:"-"`
+	Attachments   string     `gorm:"type:text" db:"attachments" json:"-"`
+	Status        string     `gorm:"type:text;not null;index:idx_mail_outbox_due,priority:1" db:"status" json:"status"`
+	Attempts      int        `gorm:"type:int;not null;default:0" db:"attempts" json:"attempts"`
+	MaxAttempts   int        `gorm:"type:int;not null" db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_mail_outbox_due,priority:2" db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" db:"last_error" json:"last_error,omitempty"`
+	SentAt        *time.Time `gorm:"type:timestamp with time zone" db:"sent_at" json:"sent_at"`
+	// DedupeKey makes enqueueing idempotent for callers that may run twice, such as event subscribers
+	DedupeKey *string `gorm:"type:text;uniqueIndex" db:"dedupe_key" json:"-"`
+
+	Timestamp
+}
+
+func (MailMessage) TableName() string {
+	return "mail_outbox"
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (m *MailMessage) BeforeCreate(_ *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Merchant is the owner of the free-text merchant_id carried by transactions and settlements.
+// Payout bank details are stored encrypted and never serialized.
+type Merchant struct {
+	ID                  string `gorm:"type:text;primaryKey" json:"id"`
+	LegalName           string `gorm:"type:text;not null" json:"legal_name"`
+	Status              string `gorm:"type:text;not null;default:'active';index" json:"status"`
+	SettlementSchedule  string `gorm:"type:text;not null;default:'daily'" json:"settlement_schedule"`
+	ContactEmail        string `gorm:"type:text;not null" json:"contact_email"`
+	PayoutBankEncrypted string `gorm:"type:text" json:"-"`
+
+	Timestamp
+}
+
+// MerchantAPIKey is a credential a merchant uses to read its own data. Only the SHA-256 of the
+// key is stored; Prefix identifies the key in listings.
+type MerchantAPIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	MerchantID string     `gorm:"type:text;not null;index" json:"merchant_id"`
+	Prefix     string     `gorm:"type:text;not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+
+	Timestamp
+}
@@ -5,13 +5,77 @@ import (
 	"gorm.io/gorm"
 )
 
+// Order totals are in the minor unit of Currency; every line of an order shares that currency.
 type Order struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
-	BuyerID   string    `gorm:"type:text;not null" json:"buyer_id"`
-	Quantity  int       `gorm:"type:int;not null" json:"quantity"`
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BuyerID       string    `gorm:"type:text;not null;index" json:"buyer_id"`
+	Status        string    `gorm:"type:text;not null;default:'pending';index" json:"status"`
+	Currency      string    `gorm:"type:char(3);not null;default:'USD'" json:"currency"`
+	SubtotalCents int64     `gorm:"type:bigint;not null;default:0" json:"subtotal_cents"`
+	TaxCents      int64     `gorm:"type:bigint;not null;default:0" json:"tax_cents"`
+	TotalCents    int64     `gorm:"type:bigint;not null;default:0" json:"total_cents"`
 
-	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"product"`
+	Items []OrderItem `gorm:"foreignKey:OrderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	Timestamp
+}
+
+// OrderItem is one line of an order. Name and price are copied from the product when the order
+// is placed, so later product changes do not alter past orders.
+type OrderItem struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	OrderID        uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	Line           int       `gorm:"type:int;not null" json:"line"`
+	ProductID      uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	ProductName    string    `gorm:"type:text;not null" json:"product_name"`
+	Quantity       int       `gorm:"type:int;not null;check:quantity > 0" json:"quantity"`
+	UnitPriceCents int64     `gorm:"type:bigint;not null" json:"unit_price_cents"`
+	Currency       string    `gorm:"type:char(3);not null" json:"currency"`
+	LineTotalCents int64     `gorm:"type:bigint;not null" json:"line_total_cents"`
+
+	Product     Product           `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
+	Allocations []OrderAllocation `gorm:"foreignKey:OrderItemID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"allocations"`
+
+	Timestamp
+}
+
+// OrderAllocation is the part of an order line shipped from one warehouse. Cancelling or
+// refunding the order returns each part to the warehouse it came from.
+type OrderAllocation struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	OrderItemID uuid.UUID `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	WarehouseID uuid.UUID `gorm:"type:uuid;not null;index" json:"warehouse_id"`
+	Quantity    int       `gorm:"type:int;not null;check:quantity > 0" json:"quantity"`
+
+	Warehouse Warehouse `gorm:"foreignKey:WarehouseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
+
+	Timestamp
+}
+
+// OrderTransition records one status change of an order. The first row of every order has an
+// empty FromStatus and marks its creation.
+type OrderTransition struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	OrderID    uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	FromStatus string    `gorm:"type:text;not null" json:"from_status"`
+	ToStatus   string    `gorm:"type:text;not null" json:"to_status"`
+	Reason     string    `gorm:"type:text" json:"reason,omitempty"`
+
+	Order Order `gorm:"foreignKey:OrderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
+
+// OrderIdempotencyKey remembers an order creation by its buyer and Idempotency-Key, so two buyers
+// picking the same key never see each other's orders. It is written in the order's transaction,
+// so the key exists exactly when its order does, until it is swept after its TTL. RequestHash
+// tells a retry from a reuse of the key with another body, and Response is what a retry replays.
+type OrderIdempotencyKey struct {
+	BuyerID     string     `gorm:"type:text;primaryKey" json:"buyer_id"`
+	Key         string     `gorm:"type:text;primaryKey" json:"key"`
+	RequestHash string     `gorm:"type:text;not null" json:"request_hash"`
+	OrderID     *uuid.UUID `gorm:"type:uuid;index" json:"order_id"`
+	Response    string     `gorm:"type:text" json:"response"`
 
 	Timestamp
 }
@@ -23,3 +87,27 @@ func (o *Order) BeforeCreate(_ *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (i *OrderItem) BeforeCreate(_ *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (a *OrderAllocation) BeforeCreate(_ *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (t *OrderTransition) BeforeCreate(_ *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
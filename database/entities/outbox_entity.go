@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the state change it
+// describes. The relay publishes it to the event bus at least once and then stamps PublishedAt.
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	Type          string     `gorm:"type:text;not null;index" db:"type" json:"type"`
+	Payload       string     `gorm:"type:text;not null" db:"payload" json:"payload"`
+	Status        string     `gorm:"type:text;not null;index:idx_outbox_due,priority:1" db:"status" json:"status"`
+	Attempts      int        `gorm:"type:int;not null;default:0" db:"attempts" json:"attempts"`
+	MaxAttempts   int        `gorm:"type:int;not null" db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_outbox_due,priority:2" db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" db:"last_error" json:"last_error,omitempty"`
+	PublishedAt   *time.Time `gorm:"type:timestamp with time zone" db:"published_at" json:"published_at"`
+
+	Timestamp
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (e *OutboxEvent) BeforeCreate(_ *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -1,18 +1,31 @@
 package entities
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Product stock is on hand; Reserved is the part of it held by active reservations, so only
+// Stock - Reserved can be sold. Deleted products are soft deleted, so the orders that reference
+// them still resolve.
 type Product struct {
-	ID    uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Name  string    `gorm:"type:text;not null" json:"name"`
-	Stock int       `gorm:"type:int;not null;check:stock >= 0" json:"stock"`
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	SKU        string    `gorm:"type:text;not null;uniqueIndex:idx_products_sku,where:deleted_at IS NULL" json:"sku"`
+	Name       string    `gorm:"type:text;not null" json:"name"`
+	Stock      int       `gorm:"type:int;not null;check:stock >= 0" json:"stock"`
+	Reserved   int       `gorm:"type:int;not null;default:0;check:reserved >= 0" json:"reserved"`
+	PriceCents int64     `gorm:"type:bigint;not null;default:0;check:price_cents >= 0" json:"price_cents"`
+	Currency   string    `gorm:"type:char(3);not null;default:'USD'" json:"currency"`
+	Status     string    `gorm:"type:text;not null;default:'active';index" json:"status"`
+	Categories []string  `gorm:"type:jsonb;not null;default:'[]';serializer:json" json:"categories"`
+	Tags       []string  `gorm:"type:jsonb;not null;default:'[]';serializer:json" json:"tags"`
 
-	Orders []Order `gorm:"foreignKey:ProductID" json:"-"`
+	OrderItems []OrderItem `gorm:"foreignKey:ProductID" json:"-"`
 
 	Timestamp
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
@@ -20,5 +33,21 @@ func (p *Product) BeforeCreate(_ *gorm.DB) (err error) {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
 	}
+	if p.SKU == "" {
+		p.SKU = DefaultSKU(p.ID)
+	}
+	// Stored as [] rather than null
+	if p.Categories == nil {
+		p.Categories = []string{}
+	}
+	if p.Tags == nil {
+		p.Tags = []string{}
+	}
 	return nil
 }
+
+// DefaultSKU is the SKU of a product created without one, the same the migration gave products
+// that predate SKUs.
+func DefaultSKU(id uuid.UUID) string {
+	return "SKU-" + strings.ToUpper(strings.ReplaceAll(id.String(), "-", ""))
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReconciliationItem is one classified row of a reconciliation job: a statement line, one of
+// our transactions, or both when they were paired by reference.
+type ReconciliationItem struct {
+	ID                   uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	JobID                string     `gorm:"type:text;not null;index:idx_reconciliation_job_result,priority:1" db:"job_id" json:"job_id"`
+	Result               string     `gorm:"type:text;not null;index:idx_reconciliation_job_result,priority:2" db:"result" json:"result"`
+	ExternalRef          string     `gorm:"type:text" db:"external_ref" json:"external_ref"`
+	StatementLine        int        `gorm:"type:int" db:"statement_line" json:"statement_line,omitempty"`
+	StatementAmountCents *int64     `gorm:"type:bigint" db:"statement_amount_cents" json:"statement_amount_cents"`
+	StatementPaidAt      *time.Time `gorm:"type:timestamp with time zone" db:"statement_paid_at" json:"statement_paid_at"`
+	TransactionID        *uuid.UUID `gorm:"type:uuid" db:"transaction_id" json:"transaction_id"`
+	OurAmountCents       *int64     `gorm:"type:bigint" db:"our_amount_cents" json:"our_amount_cents"`
+	OurPaidAt            *time.Time `gorm:"type:timestamp with time zone" db:"our_paid_at" json:"our_paid_at"`
+	Detail               string     `gorm:"type:text" db:"detail" json:"detail,omitempty"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (r *ReconciliationItem) BeforeCreate(_ *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reservation holds stock for a cart until ExpiresAt. While active its quantities are counted in
+// Product.Reserved; confirming turns them into an order, releasing or expiring gives them back.
+type Reservation struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CartID    string     `gorm:"type:text;not null;index" json:"cart_id"`
+	Status    string     `gorm:"type:text;not null;index:idx_reservations_expiry,priority:1" json:"status"`
+	ExpiresAt time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_reservations_expiry,priority:2" json:"expires_at"`
+	OrderID   *uuid.UUID `gorm:"type:uuid" json:"order_id"`
+
+	Items []ReservationItem `gorm:"foreignKey:ReservationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	Timestamp
+}
+
+type ReservationItem struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ReservationID uuid.UUID `gorm:"type:uuid;not null;index" json:"reservation_id"`
+	Line          int       `gorm:"type:int;not null" json:"line"`
+	ProductID     uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	Quantity      int       `gorm:"type:int;not null;check:quantity > 0" json:"quantity"`
+
+	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (r *Reservation) BeforeCreate(_ *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (i *ReservationItem) BeforeCreate(_ *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -9,6 +9,9 @@ type Settlement struct {
 	FeeCents   int64     `gorm:"type:bigint;not null" db:"fee_cents" json:"fee_cents"`
 	NetCents   int64     `gorm:"type:bigint;not null" db:"net_cents" json:"net_cents"`
 	TxnCount   int64     `gorm:"type:bigint;not null" db:"txn_count" json:"txn_count"`
+	// Refunds and chargebacks issued on Date, already deducted from NetCents
+	RefundCents     int64 `gorm:"type:bigint;not null;default:0" db:"refund_cents" json:"refund_cents"`
+	ChargebackCents int64 `gorm:"type:bigint;not null;default:0" db:"chargeback_cents" json:"chargeback_cents"`
 
 	Timestamp
 }
@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StatementDelivery tracks sending one merchant's monthly statement within a statement job.
+type StatementDelivery struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	JobID      string     `gorm:"type:text;not null;uniqueIndex:idx_statement_delivery_job_merchant,priority:1" db:"job_id" json:"job_id"`
+	MerchantID string     `gorm:"type:text;not null;uniqueIndex:idx_statement_delivery_job_merchant,priority:2" db:"merchant_id" json:"merchant_id"`
+	Month      string     `gorm:"type:text;not null" db:"month" json:"month"`
+	Email      string     `gorm:"type:text;not null" db:"email" json:"email"`
+	Status     string     `gorm:"type:text;not null;index" db:"status" json:"status"`
+	Attempts   int        `gorm:"type:int;not null;default:0" db:"attempts" json:"attempts"`
+	Error      string     `gorm:"type:text" db:"error" json:"error,omitempty"`
+	SentAt     *time.Time `gorm:"type:timestamp with time zone" db:"sent_at" json:"sent_at"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (d *StatementDelivery) BeforeCreate(_ *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransactionAdjustment is a refund or chargeback against an earlier transaction. It settles on
+// the day it was issued, independent of the original transaction's PaidAt.
+type TransactionAdjustment struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null;index" db:"transaction_id" json:"transaction_id"`
+	Type          string    `gorm:"type:text;not null" db:"type" json:"type"`
+	ExternalRef   string    `gorm:"type:text;not null;uniqueIndex" db:"external_ref" json:"external_ref"`
+	MerchantID    string    `gorm:"type:text;not null;index:idx_adjustment_merchant_issued,priority:1" db:"merchant_id" json:"merchant_id"`
+	AmountCents   int64     `gorm:"type:bigint;not null" db:"amount_cents" json:"amount_cents"`
+	Reason        string    `gorm:"type:text" db:"reason" json:"reason"`
+	IssuedAt      time.Time `gorm:"type:timestamp with time zone;not null;index;index:idx_adjustment_merchant_issued,priority:2" db:"issued_at" json:"issued_at"`
+
+	Transaction Transaction `gorm:"foreignKey:TransactionID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (a *TransactionAdjustment) BeforeCreate(_ *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
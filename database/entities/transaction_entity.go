@@ -4,11 +4,29 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
 	"gorm.io/gorm"
 )
 
+// SettleableTransactionStatuses are the statuses of transactions that were paid and so are settled
+// to the merchant. Refunds and chargebacks come off as adjustments, so refunded and disputed
+// transactions still count; authorized, captured and failed ones never do.
+var SettleableTransactionStatuses = []string{
+	constants.ENUM_TRANSACTION_STATUS_PAID,
+	constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+	constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+	constants.ENUM_TRANSACTION_STATUS_DISPUTED,
+}
+
+// SettleableTransactions scopes a transaction query to SettleableTransactionStatuses. Settlement,
+// its verifier and merchant statements all select through it, so they sum the same rows.
+func SettleableTransactions(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN ?", SettleableTransactionStatuses)
+}
+
 type Transaction struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	ExternalRef string    `gorm:"type:text;uniqueIndex:idx_transactions_external_ref_set,where:external_ref <> ''" db:"external_ref" json:"external_ref"`
 	MerchantID  string    `gorm:"type:text;not null;index" db:"merchant_id" json:"merchant_id"`
 	AmountCents int64     `gorm:"type:bigint;not null" db:"amount_cents" json:"amount_cents"`
 	FeeCents    int64     `gorm:"type:bigint;not null" db:"fee_cents" json:"fee_cents"`
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Warehouse is a stock location. Latitude and Longitude are optional; warehouses without them
+// are considered farthest away when allocating by distance.
+type Warehouse struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Code      string    `gorm:"type:text;not null;uniqueIndex" json:"code"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+	Latitude  *float64  `gorm:"type:double precision" json:"latitude"`
+	Longitude *float64  `gorm:"type:double precision" json:"longitude"`
+
+	Timestamp
+}
+
+// WarehouseStock is a product's on-hand stock at one warehouse. A product's Stock is the sum of
+// its warehouse stock; both are changed together while the product row is locked.
+type WarehouseStock struct {
+	WarehouseID uuid.UUID `gorm:"type:uuid;primaryKey" json:"warehouse_id"`
+	ProductID   uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"product_id"`
+	Stock       int       `gorm:"type:int;not null;default:0;check:stock >= 0" json:"stock"`
+
+	Warehouse Warehouse `gorm:"foreignKey:WarehouseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
+	Product   Product   `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
+
+// StockTransfer moves stock of one product between warehouses. Its two movements, out of the
+// source and into the destination, reference the transfer.
+type StockTransfer struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ProductID       uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	FromWarehouseID uuid.UUID `gorm:"type:uuid;not null" json:"from_warehouse_id"`
+	ToWarehouseID   uuid.UUID `gorm:"type:uuid;not null" json:"to_warehouse_id"`
+	Quantity        int       `gorm:"type:int;not null;check:quantity > 0" json:"quantity"`
+	Actor           string    `gorm:"type:text;not null" json:"actor"`
+	Reason          string    `gorm:"type:text" json:"reason,omitempty"`
+
+	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (w *Warehouse) BeforeCreate(_ *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate hook to ensure UUID is set for databases without uuid_generate_v4 (e.g., SQLite tests)
+func (t *StockTransfer) BeforeCreate(_ *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
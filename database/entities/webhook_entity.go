@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint is an integrator URL subscribed to a set of event types. The signing secret is
+// stored AES encrypted and only shown when the endpoint is created.
+type WebhookEndpoint struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	URL             string    `gorm:"type:text;not null" db:"url" json:"url"`
+	Description     string    `gorm:"type:text" db:"description" json:"description"`
+	EventTypes      string    `gorm:"type:text;not null" db:"event_types" json:"-"`
+	SecretEncrypted string    `gorm:"type:text;not null" db:"secret_encrypted" json:"-"`
+	Active          bool      `gorm:"type:boolean;not null;default:true" db:"active" json:"active"`
+
+	Timestamp
+}
+
+// WebhookEvent is a domain event taken off the event bus for delivery; its id is the outbox
+// event id. The dispatcher fans it out into one delivery per subscribed endpoint and stamps
+// DispatchedAt.
+type WebhookEvent struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	Type         string     `gorm:"type:text;not null;index" db:"type" json:"type"`
+	Payload      string     `gorm:"type:text;not null" db:"payload" json:"payload"`
+	DispatchedAt *time.Time `gorm:"type:timestamp with time zone;index" db:"dispatched_at" json:"dispatched_at"`
+
+	Timestamp
+}
+
+// WebhookDelivery tracks sending one event to one endpoint, with retries and backoff.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	EventID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event_endpoint,priority:1" db:"event_id" json:"event_id"`
+	EndpointID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event_endpoint,priority:2;index" db:"endpoint_id" json:"endpoint_id"`
+	EventType      string     `gorm:"type:text;not null" db:"event_type" json:"event_type"`
+	Status         string     `gorm:"type:text;not null;index:idx_webhook_delivery_due,priority:1" db:"status" json:"status"`
+	Attempts       int        `gorm:"type:int;not null;default:0" db:"attempts" json:"attempts"`
+	MaxAttempts    int        `gorm:"type:int;not null" db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_webhook_delivery_due,priority:2" db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus int        `gorm:"type:int" db:"response_status" json:"response_status"`
+	LastError      string     `gorm:"type:text" db:"last_error" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp with time zone" db:"delivered_at" json:"delivered_at"`
+
+	Event WebhookEvent `gorm:"foreignKey:EventID" json:"-"`
+
+	Timestamp
+}
+
+// WebhookDeliveryAttempt is one HTTP request made for a delivery: the log integrators and
+// operators read to see why an endpoint is not receiving events.
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" db:"id" json:"id"`
+	DeliveryID     uuid.UUID `gorm:"type:uuid;not null;index" db:"delivery_id" json:"delivery_id"`
+	Attempt        int       `gorm:"type:int;not null" db:"attempt" json:"attempt"`
+	ResponseStatus int       `gorm:"type:int" db:"response_status" json:"response_status"`
+	ResponseBody   string    `gorm:"type:text" db:"response_body" json:"response_body"`
+	Error          string    `gorm:"type:text" db:"error" json:"error,omitempty"`
+	DurationMS     int64     `gorm:"type:bigint;not null" db:"duration_ms" json:"duration_ms"`
+	CreatedAt      time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+}
+
+func (e *WebhookEndpoint) BeforeCreate(_ *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *WebhookEvent) BeforeCreate(_ *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (d *WebhookDelivery) BeforeCreate(_ *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *WebhookDeliveryAttempt) BeforeCreate(_ *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
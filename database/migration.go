@@ -2,19 +2,208 @@ package database
 
 import (
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) error {
+	if err := addProductSKUs(db); err != nil {
+		return err
+	}
+	if err := scopeIdempotencyKeys(db); err != nil {
+		return err
+	}
 	if err := db.AutoMigrate(
 		&entities.Product{},
+		&entities.Merchant{},
+		&entities.MerchantAPIKey{},
 		&entities.Order{},
+		&entities.Warehouse{},
+		&entities.WarehouseStock{},
+		&entities.StockTransfer{},
+		&entities.OrderItem{},
+		&entities.OrderAllocation{},
+		&entities.OrderTransition{},
+		&entities.OrderIdempotencyKey{},
+		&entities.Reservation{},
+		&entities.ReservationItem{},
+		&entities.InventoryMovement{},
 		&entities.Transaction{},
+		&entities.TransactionAdjustment{},
 		&entities.Settlement{},
 		&entities.Job{},
+		&entities.JobDependency{},
+		&entities.ReconciliationItem{},
+		&entities.StatementDelivery{},
+		&entities.OutboxEvent{},
+		&entities.MailMessage{},
+		&entities.WebhookEndpoint{},
+		&entities.WebhookEvent{},
+		&entities.WebhookDelivery{},
+		&entities.WebhookDeliveryAttempt{},
+		&entities.LedgerAccount{},
+		&entities.JournalEntry{},
+		&entities.JournalLine{},
 	); err != nil {
 		return err
 	}
+	if err := migrateSingleLineOrders(db); err != nil {
+		return err
+	}
+	if err := backfillInventoryMovements(db); err != nil {
+		return err
+	}
+	if err := migrateToWarehouses(db); err != nil {
+		return err
+	}
+	if err := indexOrderSearch(db); err != nil {
+		return err
+	}
+	if err := indexProductLabels(db); err != nil {
+		return err
+	}
+	if err := dropFullExternalRefIndex(db); err != nil {
+		return err
+	}
+	if err := indexIdempotencyKeys(db); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// addProductSKUs gives products that predate SKUs one derived from their id, as entities.DefaultSKU
+// does, before AutoMigrate makes the column NOT NULL and unique.
+func addProductSKUs(db *gorm.DB) error {
+	if !db.Migrator().HasTable("products") || db.Migrator().HasColumn("products", "sku") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE products ADD COLUMN sku text`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE products SET sku = 'SKU-' || upper(replace(id::text, '-', ''))`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`ALTER TABLE products ALTER COLUMN sku SET NOT NULL`).Error
+	})
+}
+
+// scopeIdempotencyKeys moves order idempotency keys from one key space to one per buyer, taking
+// each key's buyer from its order, before AutoMigrate expects the (buyer_id, key) primary key.
+func scopeIdempotencyKeys(db *gorm.DB) error {
+	if !db.Migrator().HasTable("order_idempotency_keys") || db.Migrator().HasColumn("order_idempotency_keys", "buyer_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE order_idempotency_keys ADD COLUMN buyer_id text`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE order_idempotency_keys k SET buyer_id = o.buyer_id FROM orders o WHERE o.id = k.order_id`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM order_idempotency_keys WHERE buyer_id IS NULL`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`ALTER TABLE order_idempotency_keys ALTER COLUMN buyer_id SET NOT NULL,
+			DROP CONSTRAINT order_idempotency_keys_pkey, ADD PRIMARY KEY (buyer_id, key)`).Error
+	})
+}
+
+// indexIdempotencyKeys backs the sweep of order idempotency keys older than their TTL.
+func indexIdempotencyKeys(db *gorm.DB) error {
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_order_idempotency_keys_created_at ON order_idempotency_keys (created_at)`).Error
+}
+
+// indexOrderSearch backs the order list: keyset pages by creation time and full-text search on
+// product names. The expression must match the one the order repository searches with.
+func indexOrderSearch(db *gorm.DB) error {
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_orders_created_at_id ON orders (created_at, id)`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name_search ON products
+		USING gin (to_tsvector('simple', name))`).Error
+}
+
+// indexProductLabels backs the product list's category and tag filters, which match with @>.
+func indexProductLabels(db *gorm.DB) error {
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_categories ON products USING gin (categories)`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_tags ON products USING gin (tags)`).Error
+}
+
+// dropFullExternalRefIndex drops the unique index transactions.external_ref used to have, which made
+// every empty ref collide; idx_transactions_external_ref_set only covers non-empty refs.
+func dropFullExternalRefIndex(db *gorm.DB) error {
+	return db.Exec(`DROP INDEX IF EXISTS idx_transactions_external_ref`).Error
+}
+
+// migrateSingleLineOrders moves orders placed before line items existed, which kept one
+// product_id and quantity on the order itself, into order_items and drops those columns.
+// Their prices were never recorded, so the product's current price is the best snapshot left.
+func migrateSingleLineOrders(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("orders", "product_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO order_items
+				(id, order_id, line, product_id, product_name, quantity, unit_price_cents, currency, line_total_cents, created_at, updated_at)
+			SELECT uuid_generate_v4(), o.id, 1, o.product_id, p.name, o.quantity, p.price_cents, p.currency,
+				p.price_cents * o.quantity, o.created_at, o.updated_at
+			FROM orders o JOIN products p ON p.id = o.product_id`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE orders o SET currency = i.currency, subtotal_cents = i.line_total_cents,
+				tax_cents = 0, total_cents = i.line_total_cents
+			FROM order_items i WHERE i.order_id = o.id`).Error; err != nil {
+			return err
+		}
+		for _, column := range []string{"product_id", "quantity"} {
+			if err := tx.Migrator().DropColumn("orders", column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillInventoryMovements opens the ledger for products that predate it: each product with
+// stock but no movements gets one initial movement for its current stock, so stock and ledger agree.
+func backfillInventoryMovements(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO inventory_movements (id, product_id, kind, delta, actor, reason, created_at, updated_at)
+		SELECT uuid_generate_v4(), p.id, ?, p.stock, ?, 'opening balance', NOW(), NOW()
+		FROM products p
+		WHERE p.stock <> 0 AND NOT EXISTS (SELECT 1 FROM inventory_movements m WHERE m.product_id = p.id)`,
+		constants.ENUM_INVENTORY_MOVEMENT_INITIAL, constants.ENUM_INVENTORY_ACTOR_SYSTEM).Error
+}
+
+// migrateToWarehouses creates the default warehouse and moves everything that predates
+// warehouses into it: product stock without any warehouse stock, movements without a warehouse,
+// and order lines without allocations.
+func migrateToWarehouses(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO warehouses (id, code, name, created_at, updated_at)
+			VALUES (uuid_generate_v4(), ?, 'Default warehouse', NOW(), NOW())
+			ON CONFLICT (code) DO NOTHING`, constants.ENUM_WAREHOUSE_DEFAULT_CODE).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO warehouse_stocks (warehouse_id, product_id, stock, created_at, updated_at)
+			SELECT w.id, p.id, p.stock, NOW(), NOW()
+			FROM products p JOIN warehouses w ON w.code = ?
+			WHERE p.stock > 0 AND NOT EXISTS (SELECT 1 FROM warehouse_stocks s WHERE s.product_id = p.id)`,
+			constants.ENUM_WAREHOUSE_DEFAULT_CODE).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE inventory_movements m SET warehouse_id = w.id
+			FROM warehouses w WHERE w.code = ? AND m.warehouse_id IS NULL`,
+			constants.ENUM_WAREHOUSE_DEFAULT_CODE).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO order_allocations (id, order_item_id, warehouse_id, quantity, created_at, updated_at)
+			SELECT uuid_generate_v4(), i.id, w.id, i.quantity, i.created_at, i.updated_at
+			FROM order_items i JOIN warehouses w ON w.code = ?
+			WHERE NOT EXISTS (SELECT 1 FROM order_allocations a WHERE a.order_item_id = i.id)`,
+			constants.ENUM_WAREHOUSE_DEFAULT_CODE).Error
+	})
+}
@@ -56,6 +56,9 @@ func BulkTransactionSeeder(_ *gorm.DB, rows, merchants, days, batchSize int) err
 	for i := 0; i < merchants; i++ {
 		merchantIDs[i] = fmt.Sprintf("merchant-%d", i+1)
 	}
+	if err := seedMerchants(ctx, pool, merchantIDs); err != nil {
+		return err
+	}
 
 	end := time.Now().UTC()
 	start := end.Add(-time.Duration(days) * 24 * time.Hour)
@@ -105,6 +108,28 @@ func BulkTransactionSeeder(_ *gorm.DB, rows, merchants, days, batchSize int) err
 	return nil
 }
 
+// seedMerchants registers the generated merchant IDs so ingestion and settlement see them as
+// active merchants. Existing merchants are left untouched.
+func seedMerchants(ctx context.Context, pool *pgxpool.Pool, merchantIDs []string) error {
+	var reg *string
+	if err := pool.QueryRow(ctx, "select to_regclass('public.merchants')").Scan(&reg); err != nil {
+		return fmt.Errorf("check table: %w", err)
+	}
+	if reg == nil || *reg == "" {
+		return fmt.Errorf("table public.merchants not found. Run migrations first")
+	}
+	_, err := pool.Exec(ctx, `
+        insert into merchants (id, legal_name, status, settlement_schedule, contact_email, created_at, updated_at)
+        select id, 'Merchant ' || id, 'active', 'daily', id || '@example.com', now(), now()
+        from unnest($1::text[]) as id
+        on conflict (id) do nothing
+    `, merchantIDs)
+	if err != nil {
+		return fmt.Errorf("seed merchants: %w", err)
+	}
+	return nil
+}
+
 func ensureTransactionsTableExists(ctx context.Context, pool *pgxpool.Pool) error {
 	var reg *string
 	if err := pool.QueryRow(ctx, "select to_regclass('public.transactions')").Scan(&reg); err != nil {
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+var (
+	ErrMissingAdminToken = errors.New("missing admin token")
+	ErrInvalidAdminToken = errors.New("invalid admin token")
+)
+
+// AdminAuthentication requires the operator token, sent as "Authorization: Bearer <token>" or
+// "X-Admin-Token". An empty token rejects every request, so routes stay closed until one is set.
+func AdminAuthentication(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader("X-Admin-Token")
+		if header := c.GetHeader("Authorization"); got == "" && strings.HasPrefix(header, "Bearer ") {
+			got = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+		if got == "" {
+			res := utils.BuildResponseFailed(MESSAGE_FAILED_UNAUTHORIZED, ErrMissingAdminToken.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			res := utils.BuildResponseFailed(MESSAGE_FAILED_UNAUTHORIZED, ErrInvalidAdminToken.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+const (
+	MESSAGE_FAILED_UNAUTHORIZED = "unauthorized"
+	MESSAGE_FAILED_FORBIDDEN    = "forbidden"
+)
+
+var (
+	ErrMissingAPIKey = errors.New("missing api key")
+	// ErrInvalidAPIKey is returned by a MerchantAuthenticator for unknown or revoked keys
+	ErrInvalidAPIKey = errors.New("invalid or revoked api key")
+	ErrMerchantScope = errors.New("api key does not belong to this merchant")
+)
+
+// MerchantAuthenticator resolves a merchant API key to its merchant ID.
+type MerchantAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (string, error)
+}
+
+// MerchantAuthentication requires a merchant API key, sent as "Authorization: Bearer <key>" or
+// "X-API-Key", and rejects requests whose :id path parameter names a different merchant.
+func MerchantAuthentication(auth MerchantAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if header := c.GetHeader("Authorization"); key == "" && strings.HasPrefix(header, "Bearer ") {
+			key = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		}
+		if key == "" {
+			res := utils.BuildResponseFailed(MESSAGE_FAILED_UNAUTHORIZED, ErrMissingAPIKey.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			return
+		}
+
+		merchantID, err := auth.AuthenticateAPIKey(c.Request.Context(), key)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidAPIKey) {
+				status = http.StatusUnauthorized
+			}
+			res := utils.BuildResponseFailed(MESSAGE_FAILED_UNAUTHORIZED, err.Error(), nil)
+			c.AbortWithStatusJSON(status, res)
+			return
+		}
+		if id := c.Param("id"); id != "" && id != merchantID {
+			res := utils.BuildResponseFailed(MESSAGE_FAILED_FORBIDDEN, ErrMerchantScope.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Set(constants.CTX_MERCHANT_ID, merchantID)
+		c.Next()
+	}
+}
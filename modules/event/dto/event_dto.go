@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_LIST_EVENT = "failed get list event"
+	MESSAGE_FAILED_GET_EVENT      = "failed get event"
+	MESSAGE_FAILED_RETRY_EVENT    = "failed retry event"
+	MESSAGE_FAILED_PROSES_REQUEST = "failed proses request"
+
+	// Success
+	MESSAGE_SUCCESS_GET_LIST_EVENT = "success get list event"
+	MESSAGE_SUCCESS_GET_EVENT      = "success get event"
+	MESSAGE_SUCCESS_RETRY_EVENT    = "success retry event"
+)
+
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrEventNotRetryable  = errors.New("only failed events can be retried")
+	ErrUnknownEventStatus = errors.New("unknown event status")
+	ErrEventTypeRequired  = errors.New("event type is required")
+)
+
+type (
+	EventListRequest struct {
+		Status string `form:"status"`
+		Type   string `form:"type"`
+	}
+
+	EventResponse struct {
+		ID            string          `json:"id"`
+		Type          string          `json:"type"`
+		Payload       json.RawMessage `json:"payload"`
+		Status        string          `json:"status"`
+		Attempts      int             `json:"attempts"`
+		MaxAttempts   int             `json:"max_attempts"`
+		NextAttemptAt time.Time       `json:"next_attempt_at"`
+		LastError     string          `json:"last_error,omitempty"`
+		PublishedAt   *time.Time      `json:"published_at"`
+		CreatedAt     time.Time       `json:"created_at"`
+	}
+)
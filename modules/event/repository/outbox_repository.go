@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, ev entities.OutboxEvent) (entities.OutboxEvent, error)
+	FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.OutboxEvent, error)
+	List(ctx context.Context, tx *gorm.DB, status, eventType string, q query.Request) ([]entities.OutboxEvent, pkgdto.PaginationResponse, error)
+	// ClaimDue leases up to limit due events, oldest first, until leaseUntil and counts the
+	// attempt. Events whose lease ran out (a relay died mid-publish) are claimed again.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	MarkRetry(ctx context.Context, id string, next time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, id string, lastErr string) error
+	Requeue(ctx context.Context, tx *gorm.DB, id string, at time.Time) (bool, error)
+}
+
+// outboxSchema is what the event list may be filtered and sorted by.
+var outboxSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":              {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"type":            {Column: "type", Type: query.String, Ops: query.Text},
+		"status":          {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"attempts":        {Column: "attempts", Type: query.Int, Ops: query.Range, Sort: true},
+		"next_attempt_at": {Column: "next_attempt_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"created_at":      {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *outboxRepository) Create(ctx context.Context, tx *gorm.DB, ev entities.OutboxEvent) (entities.OutboxEvent, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&ev).Error; err != nil {
+		return entities.OutboxEvent{}, err
+	}
+	return ev, nil
+}
+
+func (r *outboxRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.OutboxEvent, error) {
+	db := r.getDB(tx)
+	var ev entities.OutboxEvent
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&ev).Error; err != nil {
+		return entities.OutboxEvent{}, err
+	}
+	return ev, nil
+}
+
+func (r *outboxRepository) List(ctx context.Context, tx *gorm.DB, status, eventType string, q query.Request) ([]entities.OutboxEvent, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	events := func() *gorm.DB {
+		e := db.WithContext(ctx).Model(&entities.OutboxEvent{})
+		if status != "" {
+			e = e.Where("status = ?", status)
+		}
+		if eventType != "" {
+			e = e.Where("type = ?", eventType)
+		}
+		return e
+	}
+	return query.Find[entities.OutboxEvent](events, q, outboxSchema)
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.OutboxEvent, error) {
+	var claimed []entities.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several relays share the outbox without publishing an event twice at once
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?",
+				[]string{constants.ENUM_OUTBOX_STATUS_PENDING, constants.ENUM_OUTBOX_STATUS_PUBLISHING}, now).
+			Order("created_at ASC, id ASC").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]string, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID.String()
+			claimed[i].Status = constants.ENUM_OUTBOX_STATUS_PUBLISHING
+			claimed[i].Attempts++
+			claimed[i].NextAttemptAt = leaseUntil
+		}
+		return tx.Model(&entities.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":          constants.ENUM_OUTBOX_STATUS_PUBLISHING,
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": leaseUntil,
+				"updated_at":      now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":       constants.ENUM_OUTBOX_STATUS_PUBLISHED,
+			"published_at": at,
+			"last_error":   "",
+			"updated_at":   at,
+		}).Error
+}
+
+func (r *outboxRepository) MarkRetry(ctx context.Context, id string, next time.Time, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          constants.ENUM_OUTBOX_STATUS_PENDING,
+			"next_attempt_at": next,
+			"last_error":      lastErr,
+			"updated_at":      time.Now().UTC(),
+		}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     constants.ENUM_OUTBOX_STATUS_FAILED,
+			"last_error": lastErr,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// Requeue gives a failed event a fresh set of attempts. It reports whether the event was failed.
+func (r *outboxRepository) Requeue(ctx context.Context, tx *gorm.DB, id string, at time.Time) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).
+		Model(&entities.OutboxEvent{}).
+		Where("id = ? AND status = ?", id, constants.ENUM_OUTBOX_STATUS_FAILED).
+		Updates(map[string]any{
+			"status":          constants.ENUM_OUTBOX_STATUS_PENDING,
+			"attempts":        0,
+			"next_attempt_at": at,
+			"updated_at":      at,
+		})
+	return res.RowsAffected > 0, res.Error
+}
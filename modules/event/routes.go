@@ -0,0 +1,20 @@
+package event
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/event/controller"
+)
+
+// RegisterRoutes exposes the domain event outbox so operators can see what was published and
+// retry events no subscriber could handle.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.EventController](injector)
+
+	r := server.Group("/api/events")
+	{
+		r.GET("", ctrl.List)
+		r.GET("/:id", ctrl.Get)
+		r.POST("/:id/retry", ctrl.Retry)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event is a domain event as delivered to subscribers. ID is the outbox row id and stays the
+// same when the relay publishes the event again, so subscribers can use it to deduplicate.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Decode unmarshals the payload into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler reacts to one event. Delivery is at-least-once, so handlers must be idempotent.
+type Handler func(ctx context.Context, ev Event) error
+
+// Sink is where the relay publishes outbox events. Bus is the in-process sink; a message broker
+// can be plugged in by implementing Publish.
+type Sink interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+// Bus fans events out to in-process subscribers.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]subscription)}
+}
+
+var _ Sink = (*Bus)(nil)
+
+// Subscribe registers handler under name for eventType, or AllEvents. The name shows up in
+// errors so a failing subscriber can be told apart.
+func (b *Bus) Subscribe(eventType, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[eventType] = append(b.subs[eventType], subscription{name: name, handler: handler})
+}
+
+// Publish calls every subscriber of the event and returns their errors joined. A failing
+// subscriber does not stop the others; the relay then publishes the whole event again, which
+// is why subscribers must tolerate seeing an event twice.
+func (b *Bus) Publish(ctx context.Context, ev Event) error {
+	b.mu.RLock()
+	subs := append(append([]subscription(nil), b.subs[ev.Type]...), b.subs[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range subs {
+		if err := s.handler(ctx, ev); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
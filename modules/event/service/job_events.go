@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// JobEvent is the payload of job.completed, job.failed and job.cancelled events.
+type JobEvent struct {
+	JobID      string    `json:"job_id"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts"`
+	Processed  int64     `json:"processed"`
+	Total      int64     `json:"total"`
+	FromDate   string    `json:"from_date,omitempty"`
+	ToDate     string    `json:"to_date,omitempty"`
+	WorkflowID string    `json:"workflow_id,omitempty"`
+	ResultPath string    `json:"result_path,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// JobEvents writes a job's final status to the outbox in the transaction that stores it.
+type JobEvents struct {
+	outbox OutboxService
+}
+
+func NewJobEvents(outbox OutboxService) *JobEvents {
+	return &JobEvents{outbox: outbox}
+}
+
+var _ jobservice.TxListener = (*JobEvents)(nil)
+
+var jobEventTypes = map[string]string{
+	jobservice.StatusCompleted: constants.ENUM_EVENT_JOB_COMPLETED,
+	jobservice.StatusFailed:    constants.ENUM_EVENT_JOB_FAILED,
+	jobservice.StatusCancelled: constants.ENUM_EVENT_JOB_CANCELLED,
+}
+
+func (j *JobEvents) JobFinishedTx(ctx context.Context, tx *gorm.DB, job entities.Job) error {
+	eventType, ok := jobEventTypes[job.Status]
+	if !ok {
+		return nil
+	}
+	data := JobEvent{
+		JobID:      job.ID,
+		Type:       job.Type,
+		Status:     job.Status,
+		Error:      job.Error,
+		Attempts:   job.Attempts,
+		Processed:  job.Processed,
+		Total:      job.Total,
+		WorkflowID: job.WorkflowID,
+		ResultPath: job.ResultPath,
+		StartedAt:  job.CreatedAt.UTC(),
+		FinishedAt: time.Now().UTC(),
+	}
+	if !job.FromDate.IsZero() {
+		data.FromDate = job.FromDate.Format("2006-01-02")
+		data.ToDate = job.ToDate.Format("2006-01-02")
+	}
+	return j.outbox.Publish(ctx, tx, eventType, data)
+}
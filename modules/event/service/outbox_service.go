@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/event/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/event/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxAttempts is how often the relay publishes an event before marking it failed.
+const DefaultMaxAttempts = 10
+
+var knownEventStatuses = map[string]struct{}{
+	constants.ENUM_OUTBOX_STATUS_PENDING:    {},
+	constants.ENUM_OUTBOX_STATUS_PUBLISHING: {},
+	constants.ENUM_OUTBOX_STATUS_PUBLISHED:  {},
+	constants.ENUM_OUTBOX_STATUS_FAILED:     {},
+}
+
+type OutboxService interface {
+	// Publish writes an event to the outbox inside tx. Pass the transaction that makes the
+	// state change so the event exists if and only if the change was committed.
+	Publish(ctx context.Context, tx *gorm.DB, eventType string, data any) error
+	List(ctx context.Context, req dto.EventListRequest, q query.Request) ([]dto.EventResponse, pkgdto.PaginationResponse, error)
+	Get(ctx context.Context, id string) (dto.EventResponse, error)
+	// Retry puts a failed event back in the outbox with a fresh set of attempts.
+	Retry(ctx context.Context, id string) (dto.EventResponse, error)
+}
+
+type outboxService struct {
+	repo        repository.OutboxRepository
+	db          *gorm.DB
+	maxAttempts int
+}
+
+func NewOutboxService(repo repository.OutboxRepository, db *gorm.DB, maxAttempts int) OutboxService {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &outboxService{repo: repo, db: db, maxAttempts: maxAttempts}
+}
+
+func (s *outboxService) Publish(ctx context.Context, tx *gorm.DB, eventType string, data any) error {
+	if eventType == "" {
+		return dto.ErrEventTypeRequired
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		tx = s.db
+	}
+	_, err = s.repo.Create(ctx, tx, entities.OutboxEvent{
+		Type:          eventType,
+		Payload:       string(raw),
+		Status:        constants.ENUM_OUTBOX_STATUS_PENDING,
+		MaxAttempts:   s.maxAttempts,
+		NextAttemptAt: time.Now().UTC(),
+	})
+	return err
+}
+
+func (s *outboxService) List(ctx context.Context, req dto.EventListRequest, q query.Request) ([]dto.EventResponse, pkgdto.PaginationResponse, error) {
+	if req.Status != "" {
+		if _, ok := knownEventStatuses[req.Status]; !ok {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownEventStatus
+		}
+	}
+	items, meta, err := s.repo.List(ctx, s.db, req.Status, req.Type, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	out := make([]dto.EventResponse, len(items))
+	for i := range items {
+		out[i] = toEventResponse(items[i])
+	}
+	return out, meta, nil
+}
+
+func (s *outboxService) Get(ctx context.Context, id string) (dto.EventResponse, error) {
+	ev, err := s.find(ctx, id)
+	if err != nil {
+		return dto.EventResponse{}, err
+	}
+	return toEventResponse(ev), nil
+}
+
+func (s *outboxService) Retry(ctx context.Context, id string) (dto.EventResponse, error) {
+	if _, err := s.find(ctx, id); err != nil {
+		return dto.EventResponse{}, err
+	}
+	ok, err := s.repo.Requeue(ctx, s.db, id, time.Now().UTC())
+	if err != nil {
+		return dto.EventResponse{}, err
+	}
+	if !ok {
+		return dto.EventResponse{}, dto.ErrEventNotRetryable
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *outboxService) find(ctx context.Context, id string) (entities.OutboxEvent, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.OutboxEvent{}, dto.ErrEventNotFound
+	}
+	ev, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.OutboxEvent{}, dto.ErrEventNotFound
+		}
+		return entities.OutboxEvent{}, err
+	}
+	return ev, nil
+}
+
+func toEvent(ev entities.OutboxEvent) Event {
+	return Event{
+		ID:         ev.ID.String(),
+		Type:       ev.Type,
+		Payload:    json.RawMessage(ev.Payload),
+		OccurredAt: ev.CreatedAt,
+	}
+}
+
+func toEventResponse(ev entities.OutboxEvent) dto.EventResponse {
+	return dto.EventResponse{
+		ID:            ev.ID.String(),
+		Type:          ev.Type,
+		Payload:       json.RawMessage(ev.Payload),
+		Status:        ev.Status,
+		Attempts:      ev.Attempts,
+		MaxAttempts:   ev.MaxAttempts,
+		NextAttemptAt: ev.NextAttemptAt,
+		LastError:     ev.LastError,
+		PublishedAt:   ev.PublishedAt,
+		CreatedAt:     ev.CreatedAt,
+	}
+}
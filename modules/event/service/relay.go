@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/modules/event/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+// Relay publishes outbox events to a sink at least once: an event is only marked published
+// after the sink accepted it, so a crash in between publishes it again. Failures are retried
+// with exponential backoff until the event runs out of attempts.
+type Relay struct {
+	repo repository.OutboxRepository
+	sink Sink
+
+	interval   time.Duration
+	batch      int
+	lease      time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func NewRelay(repo repository.OutboxRepository, sink Sink, interval, backoff time.Duration) *Relay {
+	return &Relay{
+		repo:       repo,
+		sink:       sink,
+		interval:   interval,
+		batch:      100,
+		lease:      time.Minute,
+		backoff:    backoff,
+		maxBackoff: time.Hour,
+	}
+}
+
+// Run relays every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	utils.RunEvery(ctx, r.interval, "outbox relay", func(ctx context.Context) error {
+		_, err := r.RelayOnce(ctx)
+		return err
+	})
+}
+
+// RelayOnce publishes every event due now, oldest first, and returns how many were published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		now := time.Now().UTC()
+		batch, err := r.repo.ClaimDue(ctx, now, now.Add(r.lease), r.batch)
+		if err != nil {
+			return published, err
+		}
+		for _, ev := range batch {
+			id := ev.ID.String()
+			err := r.sink.Publish(ctx, toEvent(ev))
+			switch {
+			case err == nil:
+				if err := r.repo.MarkPublished(ctx, id, time.Now().UTC()); err != nil {
+					return published, err
+				}
+				published++
+			case ev.Attempts >= ev.MaxAttempts:
+				log.Printf("outbox relay: giving up on %s event %s: %v", ev.Type, id, err)
+				if err := r.repo.MarkFailed(ctx, id, err.Error()); err != nil {
+					return published, err
+				}
+			default:
+				if err := r.repo.MarkRetry(ctx, id, time.Now().UTC().Add(utils.Backoff(r.backoff, r.maxBackoff, ev.Attempts)), err.Error()); err != nil {
+					return published, err
+				}
+			}
+		}
+		if len(batch) < r.batch {
+			return published, nil
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package event_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	eventModule "github.com/xkillx/go-gin-order-settlement/modules/event"
+	eventController "github.com/xkillx/go-gin-order-settlement/modules/event/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/event/dto"
+	eventRepo "github.com/xkillx/go-gin-order-settlement/modules/event/repository"
+	eventService "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	"gorm.io/gorm"
+)
+
+// Events published by these tests use their own type so other suites sharing the database
+// never pick them up by accident.
+const testEventType = "test.pinged"
+
+// flakySink fails the first failures publishes, then records every event it receives.
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	received []eventService.Event
+}
+
+func (s *flakySink) Publish(_ context.Context, ev eventService.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.Type != testEventType {
+		return nil
+	}
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("subscriber unavailable")
+	}
+	s.received = append(s.received, ev)
+	return nil
+}
+
+func (s *flakySink) events() []eventService.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]eventService.Event(nil), s.received...)
+}
+
+type testEnv struct {
+	server *gin.Engine
+	db     *gorm.DB
+	repo   eventRepo.OutboxRepository
+	outbox eventService.OutboxService
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if err := db.Exec("DELETE FROM outbox WHERE type = ?", testEventType).Error; err != nil {
+		t.Fatalf("failed to truncate outbox: %v", err)
+	}
+
+	repo := eventRepo.NewOutboxRepository(db)
+	svc := eventService.NewOutboxService(repo, db, 3)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (eventController.EventController, error) {
+		return eventController.NewEventController(i, svc), nil
+	})
+
+	engine := gin.New()
+	eventModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, db: db, repo: repo, outbox: svc}
+}
+
+func publish(t *testing.T, env testEnv, data any) entities.OutboxEvent {
+	t.Helper()
+	if err := env.db.Transaction(func(tx *gorm.DB) error {
+		return env.outbox.Publish(context.Background(), tx, testEventType, data)
+	}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	var ev entities.OutboxEvent
+	if err := env.db.Where("type = ?", testEventType).Order("created_at DESC").First(&ev).Error; err != nil {
+		t.Fatalf("load outbox row: %v", err)
+	}
+	return ev
+}
+
+func TestBusFansOutAndJoinsSubscriberErrors(t *testing.T) {
+	bus := eventService.NewBus()
+	var got []string
+	bus.Subscribe("order.created", "audit", func(_ context.Context, ev eventService.Event) error {
+		got = append(got, "audit:"+ev.ID)
+		return nil
+	})
+	bus.Subscribe(eventService.AllEvents, "broken", func(context.Context, eventService.Event) error {
+		return errors.New("boom")
+	})
+	bus.Subscribe(eventService.AllEvents, "search", func(_ context.Context, ev eventService.Event) error {
+		got = append(got, "search:"+ev.ID)
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), eventService.Event{ID: "e1", Type: "order.created"})
+	if err == nil || err.Error() != "broken: boom" {
+		t.Fatalf("expected the failing subscriber to be named, got %v", err)
+	}
+	if len(got) != 2 || got[0] != "audit:e1" || got[1] != "search:e1" {
+		t.Fatalf("a failing subscriber must not stop the others, got %v", got)
+	}
+
+	got = nil
+	_ = bus.Publish(context.Background(), eventService.Event{ID: "e2", Type: "job.completed"})
+	if len(got) != 1 || got[0] != "search:e2" {
+		t.Fatalf("only wildcard subscribers should see other types, got %v", got)
+	}
+}
+
+func TestOutboxRowIsRolledBackWithTheTransaction(t *testing.T) {
+	env := setupTestServer(t)
+
+	err := env.db.Transaction(func(tx *gorm.DB) error {
+		if err := env.outbox.Publish(context.Background(), tx, testEventType, map[string]string{"n": "1"}); err != nil {
+			return err
+		}
+		return errors.New("state change failed")
+	})
+	if err == nil {
+		t.Fatalf("expected the transaction to fail")
+	}
+	var count int64
+	env.db.Model(&entities.OutboxEvent{}).Where("type = ?", testEventType).Count(&count)
+	if count != 0 {
+		t.Fatalf("an event must not outlive the rolled back change, found %d", count)
+	}
+}
+
+func TestRelayRetriesUntilPublishedAndOperatorCanRetryFailed(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+
+	ev := publish(t, env, map[string]string{"order_id": "o-1"})
+	if ev.Status != "pending" || ev.MaxAttempts != 3 {
+		t.Fatalf("expected a pending event with 3 attempts, got %#v", ev)
+	}
+
+	// One failure, then the sink accepts it; no backoff so the retry is due immediately
+	sink := &flakySink{failures: 1}
+	relay := eventService.NewRelay(env.repo, sink, time.Hour, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := relay.RelayOnce(ctx); err != nil {
+			t.Fatalf("relay: %v", err)
+		}
+	}
+	received := sink.events()
+	if len(received) != 1 || received[0].ID != ev.ID.String() {
+		t.Fatalf("expected the event once after a retry, got %#v", received)
+	}
+	var data map[string]string
+	if err := received[0].Decode(&data); err != nil || data["order_id"] != "o-1" {
+		t.Fatalf("payload not delivered intact: %v %v", data, err)
+	}
+
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events/"+ev.ID.String(), nil))
+	var resp struct {
+		Data dto.EventResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Data.Status != "published" || resp.Data.Attempts != 2 || resp.Data.PublishedAt == nil {
+		t.Fatalf("expected a published event after 2 attempts, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A sink that never accepts exhausts the attempts
+	failing := publish(t, env, map[string]string{"order_id": "o-2"})
+	sink.failures = 3
+	for i := 0; i < 3; i++ {
+		if _, err := relay.RelayOnce(ctx); err != nil {
+			t.Fatalf("relay: %v", err)
+		}
+	}
+	rec = httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events?status=failed&type="+testEventType, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		Data struct {
+			Items []dto.EventResponse `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data.Items) != 1 || list.Data.Items[0].ID != failing.ID.String() || list.Data.Items[0].LastError == "" {
+		t.Fatalf("expected the exhausted event to be listed as failed, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events/"+failing.ID.String()+"/retry", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("retry expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := relay.RelayOnce(ctx); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if received := sink.events(); len(received) != 2 || received[1].ID != failing.ID.String() {
+		t.Fatalf("expected the retried event to be published, got %#v", received)
+	}
+
+	rec = httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events/"+failing.ID.String()+"/retry", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("retrying a published event expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
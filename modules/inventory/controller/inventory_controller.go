@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	InventoryController interface {
+		Adjust(ctx *gin.Context)
+		Movements(ctx *gin.Context)
+		Verify(ctx *gin.Context)
+		Rebuild(ctx *gin.Context)
+		Transfer(ctx *gin.Context)
+		Transfers(ctx *gin.Context)
+		Locations(ctx *gin.Context)
+	}
+
+	inventoryController struct {
+		service   service.InventoryService
+		validator *validation.InventoryValidation
+	}
+)
+
+func NewInventoryController(_ *do.Injector, s service.InventoryService) InventoryController {
+	return &inventoryController{
+		service:   s,
+		validator: validation.NewInventoryValidation(),
+	}
+}
+
+func (c *inventoryController) Adjust(ctx *gin.Context) {
+	var req dto.StockAdjustmentRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateStockAdjustmentRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_INVENTORY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Adjust(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ADJUST_STOCK, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ADJUST_STOCK, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *inventoryController) Movements(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.Movements(ctx.Request.Context(), ctx.Param("id"), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_MOVEMENTS, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_MOVEMENTS, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *inventoryController) Verify(ctx *gin.Context) {
+	result, err := c.service.Verify(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VERIFY_STOCK, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_VERIFY_STOCK, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *inventoryController) Rebuild(ctx *gin.Context) {
+	result, err := c.service.Rebuild(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REBUILD_STOCK, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REBUILD_STOCK, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *inventoryController) Transfer(ctx *gin.Context) {
+	var req dto.StockTransferRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateStockTransferRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_INVENTORY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Transfer(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_TRANSFER_STOCK, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_TRANSFER_STOCK, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *inventoryController) Transfers(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var filter dto.TransferListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.Transfers(ctx.Request.Context(), filter.ProductID, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRANSFERS, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TRANSFERS, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *inventoryController) Locations(ctx *gin.Context) {
+	result, err := c.service.Locations(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LOCATIONS, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LOCATIONS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrProductNotFound), errors.Is(err, dto.ErrWarehouseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrInsufficientStock):
+		return http.StatusConflict
+	case errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,125 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	warehouseDto "github.com/xkillx/go-gin-order-settlement/modules/warehouse/dto"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY   = "failed get data from body"
+	MESSAGE_FAILED_PROSES_REQUEST       = "failed proses request"
+	MESSAGE_FAILED_VALIDATION_INVENTORY = "failed validation inventory"
+	MESSAGE_FAILED_ADJUST_STOCK         = "failed adjust stock"
+	MESSAGE_FAILED_GET_MOVEMENTS        = "failed get inventory movements"
+	MESSAGE_FAILED_VERIFY_STOCK         = "failed verify stock"
+	MESSAGE_FAILED_REBUILD_STOCK        = "failed rebuild stock"
+	MESSAGE_FAILED_TRANSFER_STOCK       = "failed transfer stock"
+	MESSAGE_FAILED_GET_TRANSFERS        = "failed get stock transfers"
+	MESSAGE_FAILED_GET_LOCATIONS        = "failed get stock locations"
+
+	// Success
+	MESSAGE_SUCCESS_ADJUST_STOCK   = "success adjust stock"
+	MESSAGE_SUCCESS_GET_MOVEMENTS  = "success get inventory movements"
+	MESSAGE_SUCCESS_VERIFY_STOCK   = "success verify stock"
+	MESSAGE_SUCCESS_REBUILD_STOCK  = "success rebuild stock"
+	MESSAGE_SUCCESS_TRANSFER_STOCK = "success transfer stock"
+	MESSAGE_SUCCESS_GET_TRANSFERS  = "success get stock transfers"
+	MESSAGE_SUCCESS_GET_LOCATIONS  = "success get stock locations"
+)
+
+var (
+	ErrRestockMustAdd = errors.New("a restock must add stock")
+	ErrSameWarehouse  = errors.New("a transfer needs two different warehouses")
+	// An adjustment may not take stock below what active reservations hold
+	ErrInsufficientStock = orderDto.ErrInsufficientStock
+	ErrProductNotFound   = orderDto.ErrProductNotFound
+	ErrWarehouseNotFound = warehouseDto.ErrWarehouseNotFound
+)
+
+type (
+	// StockAdjustmentRequest changes stock at a warehouse, the default one when WarehouseID is
+	// empty, by Delta; kind is adjustment (either sign) or restock (adds).
+	StockAdjustmentRequest struct {
+		Kind        string `json:"kind" form:"kind" binding:"required,oneof=adjustment restock"`
+		Delta       int    `json:"delta" form:"delta" binding:"required"`
+		WarehouseID string `json:"warehouse_id" form:"warehouse_id" binding:"omitempty,uuid"`
+		Actor       string `json:"actor" form:"actor" binding:"required,max=200"`
+		Reason      string `json:"reason" form:"reason" binding:"required,max=500"`
+	}
+
+	StockTransferRequest struct {
+		ProductID       string `json:"product_id" form:"product_id" binding:"required,uuid"`
+		FromWarehouseID string `json:"from_warehouse_id" form:"from_warehouse_id" binding:"required,uuid"`
+		ToWarehouseID   string `json:"to_warehouse_id" form:"to_warehouse_id" binding:"required,uuid"`
+		Quantity        int    `json:"quantity" form:"quantity" binding:"required,min=1"`
+		Actor           string `json:"actor" form:"actor" binding:"required,max=200"`
+		Reason          string `json:"reason" form:"reason" binding:"omitempty,max=500"`
+	}
+
+	// TransferListRequest filters transfers by product
+	TransferListRequest struct {
+		ProductID string `form:"product_id" binding:"omitempty,uuid"`
+	}
+
+	StockTransferResponse struct {
+		ID              string    `json:"id"`
+		ProductID       string    `json:"product_id"`
+		FromWarehouseID string    `json:"from_warehouse_id"`
+		ToWarehouseID   string    `json:"to_warehouse_id"`
+		Quantity        int       `json:"quantity"`
+		Actor           string    `json:"actor"`
+		Reason          string    `json:"reason,omitempty"`
+		CreatedAt       time.Time `json:"created_at"`
+	}
+
+	StockLocationResponse struct {
+		WarehouseID string `json:"warehouse_id"`
+		Code        string `json:"code"`
+		Stock       int    `json:"stock"`
+	}
+
+	MovementResponse struct {
+		ID          string    `json:"id"`
+		ProductID   string    `json:"product_id"`
+		WarehouseID string    `json:"warehouse_id,omitempty"`
+		Kind        string    `json:"kind"`
+		Delta       int       `json:"delta"`
+		Reference   string    `json:"reference,omitempty"`
+		Actor       string    `json:"actor"`
+		Reason      string    `json:"reason,omitempty"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	StockAdjustmentResponse struct {
+		Movement  MovementResponse `json:"movement"`
+		Stock     int              `json:"stock"`
+		Available int              `json:"available"`
+	}
+
+	// WarehouseStockMismatch is a warehouse level that differs from the movements at that warehouse.
+	WarehouseStockMismatch struct {
+		WarehouseID string `json:"warehouse_id"`
+		Stock       int    `json:"stock"`
+		LedgerStock int    `json:"ledger_stock"`
+	}
+
+	StockMismatch struct {
+		ProductID   string                   `json:"product_id"`
+		Stock       int                      `json:"stock"`
+		LedgerStock int                      `json:"ledger_stock"`
+		Warehouses  []WarehouseStockMismatch `json:"warehouses,omitempty"`
+		// Repaired is set by a rebuild; a product is left alone when its ledger stock would not
+		// cover its reservations
+		Repaired bool `json:"repaired"`
+	}
+
+	StockVerifyResponse struct {
+		Products   int64           `json:"products"`
+		Consistent bool            `json:"consistent"`
+		Mismatches []StockMismatch `json:"mismatches"`
+	}
+)
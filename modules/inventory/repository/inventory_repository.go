@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockLevel is a product's stock at one warehouse, with the warehouse's location.
+type StockLevel struct {
+	WarehouseID uuid.UUID
+	Code        string
+	Stock       int
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// StockBalance compares a product's stock with the sum of its movements.
+type StockBalance struct {
+	ProductID   uuid.UUID
+	Stock       int
+	Reserved    int
+	LedgerStock int
+}
+
+// WarehouseBalance compares a product's stock at one warehouse with the sum of the movements
+// recorded there.
+type WarehouseBalance struct {
+	ProductID   uuid.UUID
+	WarehouseID uuid.UUID
+	Stock       int
+	LedgerStock int
+}
+
+type InventoryRepository interface {
+	// Record appends movements in tx, which should be the transaction that changed the stock.
+	Record(ctx context.Context, tx *gorm.DB, movements ...entities.InventoryMovement) error
+	ListByProduct(ctx context.Context, tx *gorm.DB, productID string, q query.Request) ([]entities.InventoryMovement, pkgdto.PaginationResponse, error)
+	CountProducts(ctx context.Context, tx *gorm.DB) (int64, error)
+	// Mismatches returns the products whose stock differs from their ledger, or that hold a
+	// warehouse level differing from the movements at that warehouse, limited to ids when given.
+	Mismatches(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]StockBalance, error)
+	// LevelMismatches returns the warehouse levels of the given products that differ from the
+	// movements at that warehouse, including levels missing on either side.
+	LevelMismatches(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]WarehouseBalance, error)
+	// Levels returns the product's stock at each warehouse that has held it.
+	Levels(ctx context.Context, tx *gorm.DB, productID uuid.UUID) ([]StockLevel, error)
+	// Take removes qty from a warehouse and reports false when it holds less.
+	Take(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, qty int) (bool, error)
+	// Put adds qty to a warehouse, creating the level on first use.
+	Put(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, qty int) error
+	// SetLevel overwrites a warehouse level, creating it if needed.
+	SetLevel(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, stock int) error
+	CreateTransfer(ctx context.Context, tx *gorm.DB, t entities.StockTransfer) (entities.StockTransfer, error)
+	ListTransfers(ctx context.Context, tx *gorm.DB, productID string, q query.Request) ([]entities.StockTransfer, pkgdto.PaginationResponse, error)
+}
+
+// What a product's movements and the transfers may be filtered and sorted by; movement search
+// matches part of the reference or reason.
+var (
+	movementSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":           {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"warehouse_id": {Column: "warehouse_id", Type: query.UUID, Ops: query.Equality},
+			"kind":         {Column: "kind", Type: query.String, Ops: query.Equality},
+			"delta":        {Column: "delta", Type: query.Int, Ops: query.Range, Sort: true},
+			"reference":    {Column: "reference", Type: query.String, Ops: query.Text},
+			"actor":        {Column: "actor", Type: query.String, Ops: query.Text},
+			"created_at":   {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "-created_at",
+		Search: func(db *gorm.DB, term string) *gorm.DB {
+			pattern := query.Contains(term)
+			return db.Where("reference ILIKE ? OR reason ILIKE ?", pattern, pattern)
+		},
+	}
+
+	transferSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":                {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"product_id":        {Column: "product_id", Type: query.UUID, Ops: query.Equality},
+			"from_warehouse_id": {Column: "from_warehouse_id", Type: query.UUID, Ops: query.Equality},
+			"to_warehouse_id":   {Column: "to_warehouse_id", Type: query.UUID, Ops: query.Equality},
+			"quantity":          {Column: "quantity", Type: query.Int, Ops: query.Range, Sort: true},
+			"actor":             {Column: "actor", Type: query.String, Ops: query.Text},
+			"created_at":        {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "-created_at",
+	}
+)
+
+// levelDrift pairs each warehouse level with the sum of the movements at that warehouse and keeps
+// the pairs that differ. Movements without a warehouse predate warehouses and were moved to the
+// default one by the migrations.
+const levelDrift = `SELECT COALESCE(s.product_id, l.product_id) AS product_id,
+	COALESCE(s.warehouse_id, l.warehouse_id) AS warehouse_id,
+	COALESCE(s.stock, 0) AS stock, COALESCE(l.stock, 0) AS ledger_stock
+FROM warehouse_stocks s
+FULL JOIN (SELECT product_id, warehouse_id, SUM(delta) AS stock FROM inventory_movements
+	WHERE warehouse_id IS NOT NULL GROUP BY product_id, warehouse_id) l
+	ON l.product_id = s.product_id AND l.warehouse_id = s.warehouse_id
+WHERE COALESCE(s.stock, 0) <> COALESCE(l.stock, 0)`
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+func (r *inventoryRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *inventoryRepository) Record(ctx context.Context, tx *gorm.DB, movements ...entities.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Create(&movements).Error
+}
+
+func (r *inventoryRepository) ListByProduct(ctx context.Context, tx *gorm.DB, productID string, q query.Request) ([]entities.InventoryMovement, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	return query.Find[entities.InventoryMovement](func() *gorm.DB {
+		return db.WithContext(ctx).Model(&entities.InventoryMovement{}).Where("product_id = ?", productID)
+	}, q, movementSchema)
+}
+
+func (r *inventoryRepository) CountProducts(ctx context.Context, tx *gorm.DB) (int64, error) {
+	db := r.getDB(tx)
+	var total int64
+	if err := db.WithContext(ctx).Model(&entities.Product{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *inventoryRepository) Mismatches(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]StockBalance, error) {
+	db := r.getDB(tx)
+	// One statement, so stock, levels and movements are read from the same snapshot
+	q := db.WithContext(ctx).Table("products p").
+		Select("p.id AS product_id, p.stock, p.reserved, COALESCE(l.stock, 0) AS ledger_stock").
+		Joins("LEFT JOIN (SELECT product_id, SUM(delta) AS stock FROM inventory_movements GROUP BY product_id) l ON l.product_id = p.id").
+		Where("p.deleted_at IS NULL").
+		Where("(p.stock <> COALESCE(l.stock, 0) OR p.id IN (SELECT d.product_id FROM (" + levelDrift + ") d))").
+		Order("p.id")
+	if len(ids) > 0 {
+		q = q.Where("p.id IN ?", ids)
+	}
+	var out []StockBalance
+	if err := q.Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *inventoryRepository) LevelMismatches(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]WarehouseBalance, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	db := r.getDB(tx)
+	var out []WarehouseBalance
+	if err := db.WithContext(ctx).
+		Raw("SELECT * FROM ("+levelDrift+") d WHERE d.product_id IN ? ORDER BY d.product_id, d.warehouse_id", ids).
+		Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *inventoryRepository) Levels(ctx context.Context, tx *gorm.DB, productID uuid.UUID) ([]StockLevel, error) {
+	db := r.getDB(tx)
+	var out []StockLevel
+	if err := db.WithContext(ctx).Table("warehouse_stocks s").
+		Select("s.warehouse_id, w.code, s.stock, w.latitude, w.longitude").
+		Joins("JOIN warehouses w ON w.id = s.warehouse_id").
+		Where("s.product_id = ?", productID).
+		Order("w.code").
+		Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *inventoryRepository) Take(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, qty int) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).Model(&entities.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND stock >= ?", warehouseID, productID, qty).
+		Update("stock", gorm.Expr("stock - ?", qty))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *inventoryRepository) Put(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, qty int) error {
+	db := r.getDB(tx)
+	level := entities.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, Stock: qty}
+	return db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"stock":      gorm.Expr("warehouse_stocks.stock + EXCLUDED.stock"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&level).Error
+}
+
+func (r *inventoryRepository) SetLevel(ctx context.Context, tx *gorm.DB, warehouseID, productID uuid.UUID, stock int) error {
+	db := r.getDB(tx)
+	level := entities.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, Stock: stock}
+	return db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"stock", "updated_at"}),
+	}).Create(&level).Error
+}
+
+func (r *inventoryRepository) CreateTransfer(ctx context.Context, tx *gorm.DB, t entities.StockTransfer) (entities.StockTransfer, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&t).Error; err != nil {
+		return entities.StockTransfer{}, err
+	}
+	return t, nil
+}
+
+func (r *inventoryRepository) ListTransfers(ctx context.Context, tx *gorm.DB, productID string, q query.Request) ([]entities.StockTransfer, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	transfers := func() *gorm.DB {
+		t := db.WithContext(ctx).Model(&entities.StockTransfer{})
+		if productID != "" {
+			t = t.Where("product_id = ?", productID)
+		}
+		return t
+	}
+	return query.Find[entities.StockTransfer](transfers, q, transferSchema)
+}
@@ -0,0 +1,24 @@
+package inventory
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/controller"
+)
+
+// RegisterRoutes exposes the stock ledger: adjustments and transfers between warehouses, a
+// product's movements and locations, and checking or rebuilding stock from the recorded movements.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.InventoryController](injector)
+
+	r := server.Group("/api/inventory")
+	{
+		r.POST("/products/:id/adjustments", ctrl.Adjust)
+		r.GET("/products/:id/movements", ctrl.Movements)
+		r.GET("/products/:id/locations", ctrl.Locations)
+		r.POST("/transfers", ctrl.Transfer)
+		r.GET("/transfers", ctrl.Transfers)
+		r.GET("/verify", ctrl.Verify)
+		r.POST("/rebuild", ctrl.Rebuild)
+	}
+}
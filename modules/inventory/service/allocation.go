@@ -0,0 +1,105 @@
+package service
+
+import (
+	"math"
+	"os"
+	"sort"
+
+	"github.com/google/uuid"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// Level is a product's stock at one warehouse, with the warehouse's location if it has one.
+type Level struct {
+	WarehouseID uuid.UUID
+	Stock       int
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// Pick is the quantity a line takes from one warehouse.
+type Pick struct {
+	WarehouseID uuid.UUID
+	Quantity    int
+}
+
+// AllocationRuleFromEnv reads ORDER_ALLOCATION_RULE, defaulting to split so an order is filled
+// whenever the warehouses hold enough between them.
+func AllocationRuleFromEnv() string {
+	switch v := os.Getenv("ORDER_ALLOCATION_RULE"); v {
+	case constants.ENUM_ALLOCATION_NEAREST, constants.ENUM_ALLOCATION_MOST_STOCK, constants.ENUM_ALLOCATION_SPLIT:
+		return v
+	}
+	return constants.ENUM_ALLOCATION_SPLIT
+}
+
+// Allocate picks the warehouses qty ships from under rule:
+//   - nearest: the nearest warehouse to shipTo that holds all of qty
+//   - most_stock: the warehouse holding the most, if that covers qty
+//   - split: as many warehouses as needed, nearest first when shipTo is given, else fullest first
+//
+// Ties go to the warehouse with more stock, then the lower id, so the choice is deterministic.
+// It returns orderDto.ErrAllocationFailed when the rule cannot be met.
+func Allocate(rule string, shipTo *orderDto.Location, levels []Level, qty int) ([]Pick, error) {
+	ranked := make([]Level, 0, len(levels))
+	for _, l := range levels {
+		if l.Stock > 0 {
+			ranked = append(ranked, l)
+		}
+	}
+	byDistance := shipTo != nil && rule != constants.ENUM_ALLOCATION_MOST_STOCK
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if byDistance {
+			di, dj := distanceKm(shipTo, ranked[i]), distanceKm(shipTo, ranked[j])
+			if di != dj {
+				return di < dj
+			}
+		}
+		if ranked[i].Stock != ranked[j].Stock {
+			return ranked[i].Stock > ranked[j].Stock
+		}
+		return ranked[i].WarehouseID.String() < ranked[j].WarehouseID.String()
+	})
+
+	switch rule {
+	case constants.ENUM_ALLOCATION_NEAREST:
+		if shipTo == nil {
+			return nil, orderDto.ErrShipToRequired
+		}
+		for _, l := range ranked {
+			if l.Stock >= qty {
+				return []Pick{{WarehouseID: l.WarehouseID, Quantity: qty}}, nil
+			}
+		}
+	case constants.ENUM_ALLOCATION_MOST_STOCK:
+		if len(ranked) > 0 && ranked[0].Stock >= qty {
+			return []Pick{{WarehouseID: ranked[0].WarehouseID, Quantity: qty}}, nil
+		}
+	default:
+		var picks []Pick
+		for _, l := range ranked {
+			take := min(l.Stock, qty)
+			picks = append(picks, Pick{WarehouseID: l.WarehouseID, Quantity: take})
+			if qty -= take; qty == 0 {
+				return picks, nil
+			}
+		}
+	}
+	return nil, orderDto.ErrAllocationFailed
+}
+
+// distanceKm is the great-circle distance from to to the warehouse; warehouses without a
+// location are infinitely far.
+func distanceKm(to *orderDto.Location, l Level) float64 {
+	if l.Latitude == nil || l.Longitude == nil {
+		return math.Inf(1)
+	}
+	const earthRadiusKm = 6371.0
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(*l.Latitude - to.Latitude)
+	dLng := rad(*l.Longitude - to.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(to.Latitude))*math.Cos(rad(*l.Latitude))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
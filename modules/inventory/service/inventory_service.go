@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type InventoryService interface {
+	// Adjust changes a product's stock at a warehouse by req.Delta and records why in the same
+	// transaction.
+	Adjust(ctx context.Context, productID string, req dto.StockAdjustmentRequest) (dto.StockAdjustmentResponse, error)
+	// Transfer moves stock between warehouses; the product's total stock does not change.
+	Transfer(ctx context.Context, req dto.StockTransferRequest) (dto.StockTransferResponse, error)
+	Transfers(ctx context.Context, productID string, q query.Request) ([]dto.StockTransferResponse, pkgdto.PaginationResponse, error)
+	// Locations lists a product's stock per warehouse.
+	Locations(ctx context.Context, productID string) ([]dto.StockLocationResponse, error)
+	Movements(ctx context.Context, productID string, q query.Request) ([]dto.MovementResponse, pkgdto.PaginationResponse, error)
+	// Verify lists every product whose stock, or stock at some warehouse, is not the sum of the
+	// movements recorded for it.
+	Verify(ctx context.Context) (dto.StockVerifyResponse, error)
+	// Rebuild sets each mismatched warehouse level to the sum of its movements, then the
+	// product's stock to the sum of its warehouse levels.
+	Rebuild(ctx context.Context) (dto.StockVerifyResponse, error)
+
+	// The methods below run in the caller's transaction, which holds the product's row lock.
+
+	// Open puts a new product's opening stock in the default warehouse.
+	Open(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error
+	// Allocate takes qty of an order line from the warehouses chosen by shipping's rule.
+	Allocate(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int, shipping orderDto.Shipping) ([]entities.OrderAllocation, error)
+	// Return puts allocated stock back where it was taken from.
+	Return(ctx context.Context, tx *gorm.DB, productID uuid.UUID, allocations []entities.OrderAllocation) error
+	Record(ctx context.Context, tx *gorm.DB, movements ...entities.InventoryMovement) error
+}
+
+type inventoryService struct {
+	repo                repository.InventoryRepository
+	productRepository   productRepo.ProductRepository
+	warehouseRepository warehouseRepo.WarehouseRepository
+	allocationRule      string
+	db                  *gorm.DB
+}
+
+// NewInventoryService builds the service; allocationRule applies to orders that do not pick one.
+func NewInventoryService(repo repository.InventoryRepository, prodRepo productRepo.ProductRepository, whRepo warehouseRepo.WarehouseRepository, allocationRule string, db *gorm.DB) InventoryService {
+	return &inventoryService{repo: repo, productRepository: prodRepo, warehouseRepository: whRepo, allocationRule: allocationRule, db: db}
+}
+
+func (s *inventoryService) Adjust(ctx context.Context, productID string, req dto.StockAdjustmentRequest) (dto.StockAdjustmentResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return dto.StockAdjustmentResponse{}, dto.ErrProductNotFound
+	}
+	warehouse, err := s.warehouse(ctx, req.WarehouseID)
+	if err != nil {
+		return dto.StockAdjustmentResponse{}, err
+	}
+	var out dto.StockAdjustmentResponse
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.productRepository.LockForUpdate(ctx, tx, []uuid.UUID{pid})
+		if err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			return dto.ErrProductNotFound
+		}
+		p := locked[0]
+		stock := p.Stock + req.Delta
+		if stock < p.Reserved {
+			return dto.ErrInsufficientStock
+		}
+		if req.Delta > 0 {
+			if err := s.repo.Put(ctx, tx, warehouse.ID, pid, req.Delta); err != nil {
+				return err
+			}
+		} else {
+			ok, err := s.repo.Take(ctx, tx, warehouse.ID, pid, -req.Delta)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return dto.ErrInsufficientStock
+			}
+		}
+		if err := s.productRepository.SetStock(ctx, tx, pid, stock); err != nil {
+			return err
+		}
+		// Passed as a slice so the stored id and timestamp come back for the response
+		recorded := []entities.InventoryMovement{{
+			ProductID:   pid,
+			WarehouseID: &warehouse.ID,
+			Kind:        req.Kind,
+			Delta:       req.Delta,
+			Actor:       req.Actor,
+			Reason:      req.Reason,
+		}}
+		if err := s.repo.Record(ctx, tx, recorded...); err != nil {
+			return err
+		}
+		out = dto.StockAdjustmentResponse{Movement: toMovementResponse(recorded[0]), Stock: stock, Available: stock - p.Reserved}
+		return nil
+	})
+	if err != nil {
+		return dto.StockAdjustmentResponse{}, err
+	}
+	return out, nil
+}
+
+func (s *inventoryService) Movements(ctx context.Context, productID string, q query.Request) ([]dto.MovementResponse, pkgdto.PaginationResponse, error) {
+	if _, err := uuid.Parse(productID); err != nil {
+		return nil, pkgdto.PaginationResponse{}, dto.ErrProductNotFound
+	}
+	if _, err := s.productRepository.FindByID(ctx, s.db, productID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrProductNotFound
+		}
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	items, meta, err := s.repo.ListByProduct(ctx, s.db, productID, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.MovementResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toMovementResponse(it))
+	}
+	return resp, meta, nil
+}
+
+func (s *inventoryService) Verify(ctx context.Context) (dto.StockVerifyResponse, error) {
+	total, err := s.repo.CountProducts(ctx, s.db)
+	if err != nil {
+		return dto.StockVerifyResponse{}, err
+	}
+	var (
+		mismatches []repository.StockBalance
+		levels     []repository.WarehouseBalance
+	)
+	// Both reads come from one snapshot so the warehouse detail matches the products listed
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if mismatches, err = s.repo.Mismatches(ctx, tx, nil); err != nil {
+			return err
+		}
+		levels, err = s.repo.LevelMismatches(ctx, tx, productIDs(mismatches))
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return dto.StockVerifyResponse{}, err
+	}
+	return toVerifyResponse(total, mismatches, levels, nil), nil
+}
+
+func (s *inventoryService) Rebuild(ctx context.Context) (dto.StockVerifyResponse, error) {
+	total, err := s.repo.CountProducts(ctx, s.db)
+	if err != nil {
+		return dto.StockVerifyResponse{}, err
+	}
+	var (
+		mismatches []repository.StockBalance
+		levels     []repository.WarehouseBalance
+		repaired   = map[uuid.UUID]bool{}
+	)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		found, err := s.repo.Mismatches(ctx, tx, nil)
+		if err != nil || len(found) == 0 {
+			return err
+		}
+		ids := productIDs(found)
+		if _, err := s.productRepository.LockForUpdate(ctx, tx, ids); err != nil {
+			return err
+		}
+		// Read again under the locks: an order that committed meanwhile may have settled a difference
+		if mismatches, err = s.repo.Mismatches(ctx, tx, ids); err != nil {
+			return err
+		}
+		if levels, err = s.repo.LevelMismatches(ctx, tx, productIDs(mismatches)); err != nil {
+			return err
+		}
+		drift := make(map[uuid.UUID][]repository.WarehouseBalance, len(mismatches))
+		for _, l := range levels {
+			drift[l.ProductID] = append(drift[l.ProductID], l)
+		}
+		for _, b := range mismatches {
+			if b.LedgerStock < b.Reserved {
+				continue
+			}
+			for _, l := range drift[b.ProductID] {
+				if err := s.repo.SetLevel(ctx, tx, l.WarehouseID, l.ProductID, l.LedgerStock); err != nil {
+					return err
+				}
+			}
+			// The product's stock is derived from its warehouses, never set on its own
+			current, err := s.repo.Levels(ctx, tx, b.ProductID)
+			if err != nil {
+				return err
+			}
+			stock := 0
+			for _, l := range current {
+				stock += l.Stock
+			}
+			if err := s.productRepository.SetStock(ctx, tx, b.ProductID, stock); err != nil {
+				return err
+			}
+			repaired[b.ProductID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.StockVerifyResponse{}, err
+	}
+	return toVerifyResponse(total, mismatches, levels, repaired), nil
+}
+
+func (s *inventoryService) Transfer(ctx context.Context, req dto.StockTransferRequest) (dto.StockTransferResponse, error) {
+	pid, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return dto.StockTransferResponse{}, dto.ErrProductNotFound
+	}
+	from, err := s.warehouse(ctx, req.FromWarehouseID)
+	if err != nil {
+		return dto.StockTransferResponse{}, err
+	}
+	to, err := s.warehouse(ctx, req.ToWarehouseID)
+	if err != nil {
+		return dto.StockTransferResponse{}, err
+	}
+	var created entities.StockTransfer
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Every warehouse stock change of a product happens under its row lock
+		locked, err := s.productRepository.LockForUpdate(ctx, tx, []uuid.UUID{pid})
+		if err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			return dto.ErrProductNotFound
+		}
+		ok, err := s.repo.Take(ctx, tx, from.ID, pid, req.Quantity)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return dto.ErrInsufficientStock
+		}
+		if err := s.repo.Put(ctx, tx, to.ID, pid, req.Quantity); err != nil {
+			return err
+		}
+		if created, err = s.repo.CreateTransfer(ctx, tx, entities.StockTransfer{
+			ProductID:       pid,
+			FromWarehouseID: from.ID,
+			ToWarehouseID:   to.ID,
+			Quantity:        req.Quantity,
+			Actor:           req.Actor,
+			Reason:          req.Reason,
+		}); err != nil {
+			return err
+		}
+		out := entities.InventoryMovement{ProductID: pid, WarehouseID: &from.ID, Kind: constants.ENUM_INVENTORY_MOVEMENT_TRANSFER,
+			Delta: -req.Quantity, Reference: created.ID.String(), Actor: req.Actor, Reason: req.Reason}
+		in := out
+		in.WarehouseID, in.Delta = &to.ID, req.Quantity
+		return s.repo.Record(ctx, tx, out, in)
+	})
+	if err != nil {
+		return dto.StockTransferResponse{}, err
+	}
+	return toTransferResponse(created), nil
+}
+
+func (s *inventoryService) Transfers(ctx context.Context, productID string, q query.Request) ([]dto.StockTransferResponse, pkgdto.PaginationResponse, error) {
+	items, meta, err := s.repo.ListTransfers(ctx, s.db, productID, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.StockTransferResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toTransferResponse(it))
+	}
+	return resp, meta, nil
+}
+
+func (s *inventoryService) Locations(ctx context.Context, productID string) ([]dto.StockLocationResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, dto.ErrProductNotFound
+	}
+	if _, err := s.productRepository.FindByID(ctx, s.db, productID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, dto.ErrProductNotFound
+		}
+		return nil, err
+	}
+	levels, err := s.repo.Levels(ctx, s.db, pid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.StockLocationResponse, 0, len(levels))
+	for _, l := range levels {
+		out = append(out, dto.StockLocationResponse{WarehouseID: l.WarehouseID.String(), Code: l.Code, Stock: l.Stock})
+	}
+	return out, nil
+}
+
+func (s *inventoryService) Open(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error {
+	if qty == 0 {
+		return nil
+	}
+	warehouse, err := s.warehouseRepository.FindByCode(ctx, tx, constants.ENUM_WAREHOUSE_DEFAULT_CODE)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Put(ctx, tx, warehouse.ID, productID, qty); err != nil {
+		return err
+	}
+	return s.repo.Record(ctx, tx, entities.InventoryMovement{
+		ProductID:   productID,
+		WarehouseID: &warehouse.ID,
+		Kind:        constants.ENUM_INVENTORY_MOVEMENT_INITIAL,
+		Delta:       qty,
+		Actor:       constants.ENUM_INVENTORY_ACTOR_SYSTEM,
+		Reason:      "product created",
+	})
+}
+
+func (s *inventoryService) Allocate(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int, shipping orderDto.Shipping) ([]entities.OrderAllocation, error) {
+	rule := shipping.Allocation
+	if rule == "" {
+		rule = s.allocationRule
+	}
+	found, err := s.repo.Levels(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	levels := make([]Level, 0, len(found))
+	for _, l := range found {
+		levels = append(levels, Level{WarehouseID: l.WarehouseID, Stock: l.Stock, Latitude: l.Latitude, Longitude: l.Longitude})
+	}
+	picks, err := Allocate(rule, shipping.ShipTo, levels, qty)
+	if err != nil {
+		return nil, err
+	}
+	allocations := make([]entities.OrderAllocation, 0, len(picks))
+	for _, p := range picks {
+		// Conditional, so stock is never oversold even if a level changed since it was read
+		ok, err := s.repo.Take(ctx, tx, p.WarehouseID, productID, p.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, dto.ErrInsufficientStock
+		}
+		allocations = append(allocations, entities.OrderAllocation{WarehouseID: p.WarehouseID, Quantity: p.Quantity})
+	}
+	return allocations, nil
+}
+
+func (s *inventoryService) Return(ctx context.Context, tx *gorm.DB, productID uuid.UUID, allocations []entities.OrderAllocation) error {
+	for _, a := range allocations {
+		if err := s.repo.Put(ctx, tx, a.WarehouseID, productID, a.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *inventoryService) Record(ctx context.Context, tx *gorm.DB, movements ...entities.InventoryMovement) error {
+	return s.repo.Record(ctx, tx, movements...)
+}
+
+// warehouse resolves an optional warehouse id, defaulting to the default warehouse.
+func (s *inventoryService) warehouse(ctx context.Context, id string) (entities.Warehouse, error) {
+	var (
+		w   entities.Warehouse
+		err error
+	)
+	if id == "" {
+		w, err = s.warehouseRepository.FindByCode(ctx, s.db, constants.ENUM_WAREHOUSE_DEFAULT_CODE)
+	} else {
+		w, err = s.warehouseRepository.FindByID(ctx, s.db, id)
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Warehouse{}, dto.ErrWarehouseNotFound
+		}
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func toTransferResponse(t entities.StockTransfer) dto.StockTransferResponse {
+	return dto.StockTransferResponse{
+		ID:              t.ID.String(),
+		ProductID:       t.ProductID.String(),
+		FromWarehouseID: t.FromWarehouseID.String(),
+		ToWarehouseID:   t.ToWarehouseID.String(),
+		Quantity:        t.Quantity,
+		Actor:           t.Actor,
+		Reason:          t.Reason,
+		CreatedAt:       t.CreatedAt,
+	}
+}
+
+func productIDs(balances []repository.StockBalance) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(balances))
+	for _, b := range balances {
+		ids = append(ids, b.ProductID)
+	}
+	return ids
+}
+
+func toVerifyResponse(total int64, mismatches []repository.StockBalance, levels []repository.WarehouseBalance, repaired map[uuid.UUID]bool) dto.StockVerifyResponse {
+	warehouses := make(map[uuid.UUID][]dto.WarehouseStockMismatch, len(mismatches))
+	for _, l := range levels {
+		warehouses[l.ProductID] = append(warehouses[l.ProductID], dto.WarehouseStockMismatch{
+			WarehouseID: l.WarehouseID.String(),
+			Stock:       l.Stock,
+			LedgerStock: l.LedgerStock,
+		})
+	}
+	out := dto.StockVerifyResponse{Products: total, Consistent: true, Mismatches: make([]dto.StockMismatch, 0, len(mismatches))}
+	for _, b := range mismatches {
+		out.Mismatches = append(out.Mismatches, dto.StockMismatch{
+			ProductID:   b.ProductID.String(),
+			Stock:       b.Stock,
+			LedgerStock: b.LedgerStock,
+			Warehouses:  warehouses[b.ProductID],
+			Repaired:    repaired[b.ProductID],
+		})
+		if !repaired[b.ProductID] {
+			out.Consistent = false
+		}
+	}
+	return out
+}
+
+func toMovementResponse(m entities.InventoryMovement) dto.MovementResponse {
+	var warehouseID string
+	if m.WarehouseID != nil {
+		warehouseID = m.WarehouseID.String()
+	}
+	return dto.MovementResponse{
+		ID:          m.ID.String(),
+		WarehouseID: warehouseID,
+		ProductID:   m.ProductID.String(),
+		Kind:        m.Kind,
+		Delta:       m.Delta,
+		Reference:   m.Reference,
+		Actor:       m.Actor,
+		Reason:      m.Reason,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package inventory_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestAllocationRules(t *testing.T) {
+	var (
+		berlin  = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+		munich  = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+		nowhere = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	)
+	levels := []inventoryService.Level{
+		{WarehouseID: berlin, Stock: 3, Latitude: float(52.52), Longitude: float(13.40)},
+		{WarehouseID: munich, Stock: 5, Latitude: float(48.14), Longitude: float(11.58)},
+		{WarehouseID: nowhere, Stock: 9},
+	}
+	hamburg := &orderDto.Location{Latitude: 53.55, Longitude: 9.99}
+
+	tests := []struct {
+		name   string
+		rule   string
+		shipTo *orderDto.Location
+		qty    int
+		want   []inventoryService.Pick
+		err    error
+	}{
+		{"nearest holding all", constants.ENUM_ALLOCATION_NEAREST, hamburg, 3, []inventoryService.Pick{{WarehouseID: berlin, Quantity: 3}}, nil},
+		{"nearest skips a short warehouse", constants.ENUM_ALLOCATION_NEAREST, hamburg, 4, []inventoryService.Pick{{WarehouseID: munich, Quantity: 4}}, nil},
+		{"nearest falls back to unlocated", constants.ENUM_ALLOCATION_NEAREST, hamburg, 8, []inventoryService.Pick{{WarehouseID: nowhere, Quantity: 8}}, nil},
+		{"nearest never splits", constants.ENUM_ALLOCATION_NEAREST, hamburg, 10, nil, orderDto.ErrAllocationFailed},
+		{"nearest needs ship_to", constants.ENUM_ALLOCATION_NEAREST, nil, 1, nil, orderDto.ErrShipToRequired},
+		{"most stock ignores distance", constants.ENUM_ALLOCATION_MOST_STOCK, hamburg, 2, []inventoryService.Pick{{WarehouseID: nowhere, Quantity: 2}}, nil},
+		{"most stock never splits", constants.ENUM_ALLOCATION_MOST_STOCK, nil, 10, nil, orderDto.ErrAllocationFailed},
+		{"split by distance", constants.ENUM_ALLOCATION_SPLIT, hamburg, 10, []inventoryService.Pick{
+			{WarehouseID: berlin, Quantity: 3}, {WarehouseID: munich, Quantity: 5}, {WarehouseID: nowhere, Quantity: 2},
+		}, nil},
+		{"split fullest first", constants.ENUM_ALLOCATION_SPLIT, nil, 12, []inventoryService.Pick{
+			{WarehouseID: nowhere, Quantity: 9}, {WarehouseID: munich, Quantity: 3},
+		}, nil},
+		{"split beyond total stock", constants.ENUM_ALLOCATION_SPLIT, nil, 18, nil, orderDto.ErrAllocationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inventoryService.Allocate(tt.rule, tt.shipTo, levels, tt.qty)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected picks %+v, got %+v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected picks %+v, got %+v", tt.want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package inventory_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	inventoryModule "github.com/xkillx/go-gin-order-settlement/modules/inventory"
+	inventoryController "github.com/xkillx/go-gin-order-settlement/modules/inventory/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/dto"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productModule "github.com/xkillx/go-gin-order-settlement/modules/product"
+	productController "github.com/xkillx/go-gin-order-settlement/modules/product/controller"
+	productDto "github.com/xkillx/go-gin-order-settlement/modules/product/dto"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	productService "github.com/xkillx/go-gin-order-settlement/modules/product/service"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+type testEnv struct {
+	server   *gin.Engine
+	db       *gorm.DB
+	products productService.ProductService
+	orders   orderService.OrderService
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"reservations", "orders", "products"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	prdRepo := productRepo.NewProductRepository(db)
+	svc := inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(db), prdRepo,
+		warehouseRepo.NewWarehouseRepository(db), constants.ENUM_ALLOCATION_SPLIT, db)
+	products := productService.NewProductService(prdRepo, svc, db)
+	orders := orderService.NewOrderService(orderRepo.NewOrderRepository(db), prdRepo, svc, nil, 0, db)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (inventoryController.InventoryController, error) {
+		return inventoryController.NewInventoryController(i, svc), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (productController.ProductController, error) {
+		return productController.NewProductController(i, products), nil
+	})
+
+	engine := gin.New()
+	inventoryModule.RegisterRoutes(engine, inj)
+	productModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, db: db, products: products, orders: orders}
+}
+
+func call(t *testing.T, server http.Handler, method, path string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if out != nil {
+		_ = json.Unmarshal(rec.Body.Bytes(), &struct {
+			Data any `json:"data"`
+		}{Data: out})
+	}
+	return rec
+}
+
+func stockOf(t *testing.T, db *gorm.DB, id string) int {
+	t.Helper()
+	var p entities.Product
+	if err := db.Where("id = ?", id).Take(&p).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	return p.Stock
+}
+
+func TestEveryStockChangeIsRecorded(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+
+	product, err := env.products.Create(ctx, productDto.ProductCreateRequest{Name: "Ledger Lamp", Stock: 10, PriceCents: 2_500})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	order, err := env.orders.Create(ctx, orderDto.OrderCreateRequest{BuyerID: "buyer-1", ProductID: product.ID, Quantity: 4})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	if _, err := env.orders.Transition(ctx, order.ID, "cancelled", "changed mind"); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	adjustments := "/api/inventory/products/" + product.ID + "/adjustments"
+	var adjusted dto.StockAdjustmentResponse
+	rec := call(t, env.server, http.MethodPost, adjustments, map[string]any{"kind": "restock", "delta": 5, "actor": "warehouse-1", "reason": "delivery 42"}, &adjusted)
+	if rec.Code != http.StatusCreated || adjusted.Stock != 15 || adjusted.Movement.ID == "" {
+		t.Fatalf("restock expected 201 and stock 15, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPost, adjustments, map[string]any{"kind": "adjustment", "delta": -3, "actor": "auditor", "reason": "damaged"}, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("adjustment expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPost, adjustments, map[string]any{"kind": "adjustment", "delta": -13, "actor": "auditor", "reason": "lost"}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("adjusting below zero expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPost, adjustments, map[string]any{"kind": "restock", "delta": -1, "actor": "auditor", "reason": "typo"}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a negative restock expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPut, "/api/products/"+product.ID, map[string]any{"stock": 99}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("overwriting stock expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := stockOf(t, env.db, product.ID); got != 12 {
+		t.Fatalf("expected stock 12, got %d", got)
+	}
+
+	var list struct {
+		Items []dto.MovementResponse `json:"items"`
+	}
+	rec = call(t, env.server, http.MethodGet, "/api/inventory/products/"+product.ID+"/movements?per_page=20", nil, &list)
+	if rec.Code != http.StatusOK || len(list.Items) != 5 {
+		t.Fatalf("expected 5 movements, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Newest first
+	want := []struct {
+		kind  string
+		delta int
+	}{{"adjustment", -3}, {"restock", 5}, {"cancellation", 4}, {"order", -4}, {"initial", 10}}
+	for i, w := range want {
+		if list.Items[i].Kind != w.kind || list.Items[i].Delta != w.delta {
+			t.Fatalf("movement %d: expected %s %d, got %s %d", i, w.kind, w.delta, list.Items[i].Kind, list.Items[i].Delta)
+		}
+	}
+	if list.Items[3].Reference != order.ID || list.Items[3].Actor != "buyer-1" || list.Items[2].Reason != "changed mind" {
+		t.Fatalf("order movements should name the order, buyer and reason: %+v", list.Items[2:4])
+	}
+}
+
+func TestVerifyAndRebuildFromLedger(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+
+	product, err := env.products.Create(ctx, productDto.ProductCreateRequest{Name: "Ledger Desk", Stock: 8, PriceCents: 9_000})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if _, err := env.orders.Create(ctx, orderDto.OrderCreateRequest{BuyerID: "buyer-1", ProductID: product.ID, Quantity: 3}); err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	var report dto.StockVerifyResponse
+	if rec := call(t, env.server, http.MethodGet, "/api/inventory/verify", nil, &report); rec.Code != http.StatusOK || !report.Consistent {
+		t.Fatalf("expected a consistent ledger, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A write that bypassed the ledger
+	if err := env.db.Model(&entities.Product{}).Where("id = ?", product.ID).Update("stock", 50).Error; err != nil {
+		t.Fatalf("corrupt stock: %v", err)
+	}
+	report = dto.StockVerifyResponse{}
+	call(t, env.server, http.MethodGet, "/api/inventory/verify", nil, &report)
+	if report.Consistent || len(report.Mismatches) != 1 || report.Mismatches[0].Stock != 50 || report.Mismatches[0].LedgerStock != 5 {
+		t.Fatalf("expected the bypassed write to be reported, got %+v", report)
+	}
+	if got := stockOf(t, env.db, product.ID); got != 50 {
+		t.Fatalf("verify must not change stock, got %d", got)
+	}
+
+	report = dto.StockVerifyResponse{}
+	if rec := call(t, env.server, http.MethodPost, "/api/inventory/rebuild", nil, &report); rec.Code != http.StatusOK || !report.Consistent || !report.Mismatches[0].Repaired {
+		t.Fatalf("rebuild expected to repair the product, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := stockOf(t, env.db, product.ID); got != 5 {
+		t.Fatalf("expected stock rebuilt to 5, got %d", got)
+	}
+	report = dto.StockVerifyResponse{}
+	call(t, env.server, http.MethodGet, "/api/inventory/verify", nil, &report)
+	if !report.Consistent || len(report.Mismatches) != 0 {
+		t.Fatalf("expected a consistent ledger after the rebuild, got %+v", report)
+	}
+
+	// A warehouse level written around the ledger is caught even though the product total is right
+	if err := env.db.Model(&entities.WarehouseStock{}).Where("product_id = ?", product.ID).Update("stock", 9).Error; err != nil {
+		t.Fatalf("corrupt warehouse stock: %v", err)
+	}
+	report = dto.StockVerifyResponse{}
+	call(t, env.server, http.MethodGet, "/api/inventory/verify", nil, &report)
+	if report.Consistent || len(report.Mismatches) != 1 || len(report.Mismatches[0].Warehouses) != 1 ||
+		report.Mismatches[0].Warehouses[0].Stock != 9 || report.Mismatches[0].Warehouses[0].LedgerStock != 5 {
+		t.Fatalf("expected the warehouse level to be reported, got %+v", report)
+	}
+	report = dto.StockVerifyResponse{}
+	if rec := call(t, env.server, http.MethodPost, "/api/inventory/rebuild", nil, &report); rec.Code != http.StatusOK || !report.Consistent {
+		t.Fatalf("rebuild expected to repair the warehouse level, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var level entities.WarehouseStock
+	if err := env.db.Where("product_id = ?", product.ID).Take(&level).Error; err != nil || level.Stock != 5 {
+		t.Fatalf("expected the warehouse level rebuilt to 5, got %+v, %v", level, err)
+	}
+	if got := stockOf(t, env.db, product.ID); got != 5 {
+		t.Fatalf("expected stock to stay 5, got %d", got)
+	}
+}
@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/inventory/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+type InventoryValidation struct {
+	validate *validator.Validate
+}
+
+func NewInventoryValidation() *InventoryValidation {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &InventoryValidation{validate: validate}
+}
+
+func (v *InventoryValidation) ValidateStockAdjustmentRequest(req dto.StockAdjustmentRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if req.Kind == constants.ENUM_INVENTORY_MOVEMENT_RESTOCK && req.Delta < 0 {
+		return dto.ErrRestockMustAdd
+	}
+	return nil
+}
+
+func (v *InventoryValidation) ValidateStockTransferRequest(req dto.StockTransferRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return dto.ErrSameWarehouse
+	}
+	return nil
+}
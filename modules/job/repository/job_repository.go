@@ -4,6 +4,8 @@ import (
     "context"
 
     "github.com/xkillx/go-gin-order-settlement/database/entities"
+    pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+    "github.com/xkillx/go-gin-order-settlement/pkg/query"
     "gorm.io/gorm"
 )
 
@@ -14,7 +16,40 @@ type JobRepo interface {
     RequestCancel(ctx context.Context, jobID string) error
     IsCancelRequested(ctx context.Context, jobID string) (bool, error)
     Get(ctx context.Context, jobID string) (entities.Job, error)
+    List(ctx context.Context, q query.Request) ([]entities.Job, pkgdto.PaginationResponse, error)
     SetStatus(ctx context.Context, jobID, status string) error
+    // Finish stores a job's final status and calls fn with the updated job inside the same
+    // transaction, so rows fn writes commit or roll back together with the status.
+    Finish(ctx context.Context, jobID, status string, fn func(tx *gorm.DB, job entities.Job) error) error
+    SetError(ctx context.Context, jobID, message string) error
+    ResetForRetry(ctx context.Context, jobID string, total int64) (bool, error)
+    TransitionStatus(ctx context.Context, jobID, from, to string) (bool, error)
+    TransitionStatusIn(ctx context.Context, jobID string, from []string, to string) (bool, error)
+    CreateWithDependencies(ctx context.Context, jobs []entities.Job, deps []entities.JobDependency) error
+    ListParents(ctx context.Context, jobID string) ([]entities.Job, error)
+    ListDependents(ctx context.Context, parentID string) ([]entities.Job, error)
+    ListByWorkflow(ctx context.Context, workflowID string) ([]entities.Job, []entities.JobDependency, error)
+}
+
+// jobSchema is what the job list may be filtered and sorted by; search matches the id or type.
+var jobSchema = query.Schema{
+    Fields: map[string]query.Field{
+        "id":          {Column: "id", Type: query.String, Ops: query.Equality, Sort: true},
+        "type":        {Column: "type", Type: query.String, Ops: query.Equality, Sort: true},
+        "status":      {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+        "workflow_id": {Column: "workflow_id", Type: query.String, Ops: query.Equality},
+        "from_date":   {Column: "from_date", Type: query.Date, Ops: query.Range},
+        "to_date":     {Column: "to_date", Type: query.Date, Ops: query.Range},
+        "progress":    {Column: "progress", Type: query.Int, Ops: query.Range, Sort: true},
+        "attempts":    {Column: "attempts", Type: query.Int, Ops: query.Range},
+        "created_at":  {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+        "updated_at":  {Column: "updated_at", Type: query.Time, Ops: query.Range, Sort: true},
+    },
+    DefaultSort: "-created_at",
+    Search: func(db *gorm.DB, term string) *gorm.DB {
+        pattern := query.Contains(term)
+        return db.Where("id ILIKE ? OR type ILIKE ?", pattern, pattern)
+    },
 }
 
 type jobRepository struct {
@@ -70,8 +105,133 @@ func (r *jobRepository) Get(ctx context.Context, jobID string) (entities.Job, er
     return j, nil
 }
 
+func (r *jobRepository) List(ctx context.Context, q query.Request) ([]entities.Job, pkgdto.PaginationResponse, error) {
+    return query.Find[entities.Job](func() *gorm.DB {
+        return r.db.WithContext(ctx).Model(&entities.Job{})
+    }, q, jobSchema)
+}
+
 func (r *jobRepository) SetStatus(ctx context.Context, jobID, status string) error {
     return r.db.WithContext(ctx).Model(&entities.Job{}).
         Where("id = ?", jobID).
         Update("status", status).Error
 }
+
+func (r *jobRepository) Finish(ctx context.Context, jobID, status string, fn func(tx *gorm.DB, job entities.Job) error) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&entities.Job{}).Where("id = ?", jobID).Update("status", status).Error; err != nil {
+            return err
+        }
+        var job entities.Job
+        if err := tx.Where("id = ?", jobID).Take(&job).Error; err != nil {
+            return err
+        }
+        return fn(tx, job)
+    })
+}
+
+func (r *jobRepository) SetError(ctx context.Context, jobID, message string) error {
+    return r.db.WithContext(ctx).Model(&entities.Job{}).
+        Where("id = ?", jobID).
+        Update("error", message).Error
+}
+
+// ResetForRetry re-queues a failed or cancelled job: clears progress, error and cancel flag and
+// bumps attempts. It reports false when the job is in any other status, e.g. because a
+// concurrent retry already re-queued it.
+func (r *jobRepository) ResetForRetry(ctx context.Context, jobID string, total int64) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&entities.Job{}).
+        Where("id = ? AND status IN ?", jobID, []string{"FAILED", "CANCELLED"}).
+        Updates(map[string]interface{}{
+            "status":           "QUEUED",
+            "progress":         0,
+            "processed":        0,
+            "total":            total,
+            "cancel_requested": false,
+            "error":            "",
+            "result_path":      "",
+            "attempts":         gorm.Expr("attempts + 1"),
+        })
+    if res.Error != nil {
+        return false, res.Error
+    }
+    return res.RowsAffected > 0, nil
+}
+
+// TransitionStatus moves a job from one status to another only if it is still in the
+// expected status. It reports whether this caller won the transition.
+func (r *jobRepository) TransitionStatus(ctx context.Context, jobID, from, to string) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&entities.Job{}).
+        Where("id = ? AND status = ?", jobID, from).
+        Update("status", to)
+    if res.Error != nil {
+        return false, res.Error
+    }
+    return res.RowsAffected > 0, nil
+}
+
+// TransitionStatusIn is TransitionStatus for a job that may be in any of several statuses.
+func (r *jobRepository) TransitionStatusIn(ctx context.Context, jobID string, from []string, to string) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&entities.Job{}).
+        Where("id = ? AND status IN ?", jobID, from).
+        Update("status", to)
+    if res.Error != nil {
+        return false, res.Error
+    }
+    return res.RowsAffected > 0, nil
+}
+
+// CreateWithDependencies persists a set of jobs and their dependency edges atomically.
+func (r *jobRepository) CreateWithDependencies(ctx context.Context, jobs []entities.Job, deps []entities.JobDependency) error {
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&jobs).Error; err != nil {
+            return err
+        }
+        if len(deps) == 0 {
+            return nil
+        }
+        return tx.Create(&deps).Error
+    })
+}
+
+func (r *jobRepository) ListParents(ctx context.Context, jobID string) ([]entities.Job, error) {
+    var jobs []entities.Job
+    err := r.db.WithContext(ctx).
+        Joins("JOIN job_dependencies d ON d.parent_id = jobs.id").
+        Where("d.job_id = ?", jobID).
+        Find(&jobs).Error
+    return jobs, err
+}
+
+func (r *jobRepository) ListDependents(ctx context.Context, parentID string) ([]entities.Job, error) {
+    var jobs []entities.Job
+    err := r.db.WithContext(ctx).
+        Joins("JOIN job_dependencies d ON d.job_id = jobs.id").
+        Where("d.parent_id = ?", parentID).
+        Find(&jobs).Error
+    return jobs, err
+}
+
+func (r *jobRepository) ListByWorkflow(ctx context.Context, workflowID string) ([]entities.Job, []entities.JobDependency, error) {
+    var jobs []entities.Job
+    if err := r.db.WithContext(ctx).
+        Where("workflow_id = ?", workflowID).
+        Order("created_at ASC, id ASC").
+        Find(&jobs).Error; err != nil {
+        return nil, nil, err
+    }
+    if len(jobs) == 0 {
+        return nil, nil, gorm.ErrRecordNotFound
+    }
+    ids := make([]string, 0, len(jobs))
+    for _, j := range jobs {
+        ids = append(ids, j.ID)
+    }
+    var deps []entities.JobDependency
+    if err := r.db.WithContext(ctx).
+        Where("job_id IN ?", ids).
+        Find(&deps).Error; err != nil {
+        return nil, nil, err
+    }
+    return jobs, deps, nil
+}
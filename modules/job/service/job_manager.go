@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobrepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusWaiting    = "WAITING"
+	StatusQueued     = "QUEUED"
+	StatusRunning    = "RUNNING"
+	StatusCompleted  = "COMPLETED"
+	StatusCancelling = "CANCELLING"
+	StatusCancelled  = "CANCELLED"
+	StatusFailed     = "FAILED"
+	StatusSkipped    = "SKIPPED"
+)
+
+var (
+	ErrUnknownJobType  = errors.New("unknown job type")
+	ErrInvalidPayload  = errors.New("invalid job payload")
+	ErrJobNotRetryable = errors.New("only failed or cancelled jobs can be retried")
+	// ErrJobNotCancellable is returned when a job finished before the cancel reached it
+	ErrJobNotCancellable = errors.New("only queued, waiting or running jobs can be cancelled")
+)
+
+// Handler implements one type of background job on top of the shared jobs table.
+type Handler interface {
+	// Type is the job type accepted by POST /jobs/:type and stored on the job row.
+	Type() string
+	// Prepare validates the raw payload and fills type-specific fields on the job
+	// (date range, total) before it is persisted. Validation errors should wrap ErrInvalidPayload.
+	Prepare(ctx context.Context, payload json.RawMessage, job *entities.Job) error
+	// Run processes the job. It must return promptly once ctx is cancelled.
+	Run(ctx context.Context, job entities.Job, progress *Progress) error
+}
+
+// Listener is told about every job that finished running, with its final status and error.
+// It runs on the job's goroutine, so slow work should be queued rather than done inline.
+type Listener interface {
+	JobFinished(ctx context.Context, job entities.Job)
+}
+
+// TxListener is called inside the transaction that stores a job's final status, so whatever it
+// writes (an outbox row, say) is committed exactly when the status is. Returning an error rolls
+// both back; the transaction is then retried, and the job failed if it keeps failing. The status
+// is never stored without what the listeners write.
+type TxListener interface {
+	JobFinishedTx(ctx context.Context, tx *gorm.DB, job entities.Job) error
+}
+
+// Progress lets a running handler report progress and its result file.
+type Progress struct {
+	jobRepo jobrepo.JobRepo
+	jobID   string
+	total   int64
+}
+
+// Total returns the number of units the job is expected to process.
+func (p *Progress) Total() int64 {
+	return p.total
+}
+
+// SetTotal replaces the expected number of units, e.g. once a file has been scanned.
+func (p *Progress) SetTotal(ctx context.Context, total int64) error {
+	p.total = total
+	return p.jobRepo.UpdateProgress(ctx, p.jobID, 0, total, 0)
+}
+
+// Update records processed units and derives the percentage from the total.
+func (p *Progress) Update(ctx context.Context, processed int64) error {
+	progress := 100
+	if p.total > 0 {
+		progress = int((processed * 100) / p.total)
+		if progress > 100 {
+			progress = 100
+		}
+	}
+	return p.jobRepo.UpdateProgress(ctx, p.jobID, processed, p.total, progress)
+}
+
+// SetResultPath stores the location of the file produced by the job.
+func (p *Progress) SetResultPath(ctx context.Context, path string) error {
+	return p.jobRepo.SetResultPath(ctx, p.jobID, path)
+}
+
+// JobManager runs registered job handlers in the background and owns the shared
+// status, cancellation and retry plumbing.
+type JobManager struct {
+	jobRepo jobrepo.JobRepo
+
+	handlersMu  sync.RWMutex
+	handlers    map[string]Handler
+	listeners   []Listener
+	txListeners []TxListener
+
+	cancelMu sync.Mutex
+	cancels  map[string]context.CancelFunc
+
+	finishAttempts int
+	finishBackoff  time.Duration
+}
+
+func NewJobManager(j jobrepo.JobRepo) *JobManager {
+	return &JobManager{
+		jobRepo:        j,
+		handlers:       make(map[string]Handler),
+		cancels:        make(map[string]context.CancelFunc),
+		finishAttempts: 5,
+		finishBackoff:  200 * time.Millisecond,
+	}
+}
+
+// SetFinishRetry sets how many times a failing final status transaction is tried and the delay
+// before the second try, doubled after each one.
+func (m *JobManager) SetFinishRetry(attempts int, backoff time.Duration) {
+	m.finishAttempts = attempts
+	m.finishBackoff = backoff
+}
+
+// Register adds a handler for its job type, replacing any previous one.
+func (m *JobManager) Register(h Handler) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.handlers[h.Type()] = h
+}
+
+// Subscribe adds a listener notified when jobs finish.
+func (m *JobManager) Subscribe(l Listener) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.listeners = append(m.listeners, l)
+}
+
+// SubscribeTx adds a listener that writes in the same transaction as a job's final status.
+func (m *JobManager) SubscribeTx(l TxListener) {
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.txListeners = append(m.txListeners, l)
+}
+
+// finish stores the final status of a job together with what the tx listeners write and returns
+// the status stored. When that keeps failing the job is failed instead, again with the
+// listeners; if even that cannot be stored it keeps its current status and "" is returned.
+func (m *JobManager) finish(ctx context.Context, jobID, status string) string {
+	m.handlersMu.RLock()
+	listeners := m.txListeners
+	m.handlersMu.RUnlock()
+	if len(listeners) == 0 {
+		_ = m.jobRepo.SetStatus(ctx, jobID, status)
+		return status
+	}
+	err := m.finishTx(ctx, jobID, status, listeners)
+	if err == nil {
+		return status
+	}
+	log.Printf("job %s: storing final status %s: %v", jobID, status, err)
+	if status != StatusFailed {
+		_ = m.jobRepo.SetError(ctx, jobID, fmt.Sprintf("storing final status %s: %v", status, err))
+		if err = m.finishTx(ctx, jobID, StatusFailed, listeners); err == nil {
+			return StatusFailed
+		}
+	}
+	log.Printf("job %s: final status not stored, job left as it is: %v", jobID, err)
+	return ""
+}
+
+// finishTx runs the final status transaction, retrying it with exponential backoff.
+func (m *JobManager) finishTx(ctx context.Context, jobID, status string, listeners []TxListener) error {
+	delay := m.finishBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = m.jobRepo.Finish(ctx, jobID, status, func(tx *gorm.DB, job entities.Job) error {
+			for _, l := range listeners {
+				if err := l.JobFinishedTx(ctx, tx, job); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil || attempt >= m.finishAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (m *JobManager) notify(ctx context.Context, jobID string) {
+	m.handlersMu.RLock()
+	listeners := m.listeners
+	m.handlersMu.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+	job, err := m.jobRepo.Get(ctx, jobID)
+	if err != nil {
+		return
+	}
+	for _, l := range listeners {
+		l.JobFinished(ctx, job)
+	}
+}
+
+func (m *JobManager) handler(jobType string) (Handler, bool) {
+	m.handlersMu.RLock()
+	defer m.handlersMu.RUnlock()
+	h, ok := m.handlers[jobType]
+	return h, ok
+}
+
+// Start validates the payload for jobType, creates the job record and launches processing
+// in background. It returns immediately with the queued job.
+func (m *JobManager) Start(ctx context.Context, jobType string, payload json.RawMessage) (entities.Job, error) {
+	h, ok := m.handler(jobType)
+	if !ok {
+		return entities.Job{}, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+
+	job := entities.Job{
+		ID:      uuid.NewString(),
+		Type:    jobType,
+		Status:  StatusQueued,
+		Payload: string(payload),
+	}
+	if err := h.Prepare(ctx, payload, &job); err != nil {
+		return entities.Job{}, err
+	}
+	if err := m.jobRepo.Create(ctx, job); err != nil {
+		return entities.Job{}, err
+	}
+
+	// Detach from the request lifetime so the job outlives the HTTP call
+	go m.run(context.WithoutCancel(ctx), h, job)
+	return job, nil
+}
+
+// Retry re-queues a failed or cancelled job with its original payload.
+func (m *JobManager) Retry(ctx context.Context, jobID string) (entities.Job, error) {
+	job, err := m.jobRepo.Get(ctx, jobID)
+	if err != nil {
+		return entities.Job{}, err
+	}
+	if job.Status != StatusFailed && job.Status != StatusCancelled {
+		return entities.Job{}, ErrJobNotRetryable
+	}
+	h, ok := m.handler(job.Type)
+	if !ok {
+		return entities.Job{}, fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+	}
+
+	// Re-run preparation so totals reflect the current data
+	if err := h.Prepare(ctx, json.RawMessage(job.Payload), &job); err != nil {
+		return entities.Job{}, err
+	}
+	// The status check above is repeated in the update, so of two concurrent retries only one runs
+	reset, err := m.jobRepo.ResetForRetry(ctx, jobID, job.Total)
+	if err != nil {
+		return entities.Job{}, err
+	}
+	if !reset {
+		return entities.Job{}, ErrJobNotRetryable
+	}
+	job.Status = StatusQueued
+	job.Attempts++
+
+	// Dependents skipped because of this failure get another chance
+	m.rearmDependents(ctx, jobID)
+
+	go m.run(context.WithoutCancel(ctx), h, job)
+	return job, nil
+}
+
+// Cancel stops a running job if present. Returns true if a cancel was triggered.
+func (m *JobManager) Cancel(jobID string) bool {
+	m.cancelMu.Lock()
+	defer m.cancelMu.Unlock()
+	if c, ok := m.cancels[jobID]; ok {
+		c()
+		return true
+	}
+	return false
+}
+
+// CancelPending cancels a job that is not running (queued or waiting on parents)
+// and skips everything that depends on it. A job that has already moved on is left as it is
+// and ErrJobNotCancellable returned.
+func (m *JobManager) CancelPending(ctx context.Context, jobID string) error {
+	ok, err := m.jobRepo.TransitionStatusIn(ctx, jobID, []string{StatusQueued, StatusWaiting}, StatusCancelled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotCancellable
+	}
+	m.releaseDependents(ctx, jobID, StatusCancelled)
+	return nil
+}
+
+// MarkCancelling flags a running job whose context was just cancelled. The handler may have
+// finished meanwhile; its final status is kept and ErrJobNotCancellable returned.
+func (m *JobManager) MarkCancelling(ctx context.Context, jobID string) error {
+	ok, err := m.jobRepo.TransitionStatusIn(ctx, jobID, []string{StatusQueued, StatusRunning}, StatusCancelling)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotCancellable
+	}
+	return nil
+}
+
+func (m *JobManager) run(parentCtx context.Context, h Handler, job entities.Job) {
+	// Derive cancellable context and store cancel function
+	jobCtx, cancel := context.WithCancel(parentCtx)
+	m.cancelMu.Lock()
+	m.cancels[job.ID] = cancel
+	m.cancelMu.Unlock()
+	defer func() {
+		m.cancelMu.Lock()
+		delete(m.cancels, job.ID)
+		m.cancelMu.Unlock()
+		cancel()
+	}()
+
+	// A cancel may have landed between queueing and this goroutine registering its cancel func
+	if requested, err := m.jobRepo.IsCancelRequested(jobCtx, job.ID); err == nil && requested {
+		if final := m.finish(context.Background(), job.ID, StatusCancelled); final != "" {
+			m.releaseDependents(context.Background(), job.ID, final)
+		}
+		m.notify(context.Background(), job.ID)
+		return
+	}
+	_ = m.jobRepo.SetStatus(jobCtx, job.ID, StatusRunning)
+
+	progress := &Progress{jobRepo: m.jobRepo, jobID: job.ID, total: job.Total}
+	err := h.Run(jobCtx, job, progress)
+
+	// Status writes use a fresh context since jobCtx may already be cancelled
+	bg := context.Background()
+	final := StatusCompleted
+	switch {
+	case jobCtx.Err() != nil:
+		final = StatusCancelled
+	case err != nil:
+		final = StatusFailed
+		_ = m.jobRepo.SetError(bg, job.ID, err.Error())
+	default:
+		_ = m.jobRepo.UpdateProgress(bg, job.ID, progress.total, progress.total, 100)
+	}
+	// Dependents wait on a job whose final status could not be stored
+	if final = m.finish(bg, job.ID, final); final != "" {
+		m.releaseDependents(bg, job.ID, final)
+	}
+	m.notify(bg, job.ID)
+}
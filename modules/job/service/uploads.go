@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+// UploadDir is the utils.UploadFile folder that files posted to POST /jobs/:type are stored in.
+const UploadDir = "jobs"
+
+// UploadPath returns where an uploaded job file named name is stored.
+func UploadPath(name string) string {
+	return filepath.Join(utils.PATH, UploadDir, name)
+}
+
+// ResolveUpload validates a file_path taken from a job payload and makes sure it stays
+// inside the upload folder. Errors wrap ErrInvalidPayload.
+func ResolveUpload(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: file_path is required", ErrInvalidPayload)
+	}
+	clean := filepath.Clean(path)
+	rel, err := filepath.Rel(filepath.Join(utils.PATH, UploadDir), clean)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%w: file_path must point to an uploaded file", ErrInvalidPayload)
+	}
+	return clean, nil
+}
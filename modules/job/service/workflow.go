@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+)
+
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// WorkflowStep declares one job in a workflow. DependsOn lists the keys of steps
+// that must COMPLETE before this one starts.
+type WorkflowStep struct {
+	Key       string          `json:"key" binding:"required"`
+	Type      string          `json:"type" binding:"required"`
+	Payload   json.RawMessage `json:"payload"`
+	DependsOn []string        `json:"depends_on"`
+}
+
+// WorkflowJob is a job in a workflow together with the IDs of its parents.
+type WorkflowJob struct {
+	entities.Job
+	DependsOn []string `json:"depends_on"`
+}
+
+// WorkflowStatus is the DAG view returned by GET /workflows/:id.
+type WorkflowStatus struct {
+	WorkflowID string        `json:"workflow_id"`
+	Status     string        `json:"status"`
+	Jobs       []WorkflowJob `json:"jobs"`
+}
+
+// StartWorkflow validates the DAG, creates every job in one transaction and launches the
+// roots. Steps with parents wait in WAITING until all parents COMPLETE.
+func (m *JobManager) StartWorkflow(ctx context.Context, steps []WorkflowStep) (WorkflowStatus, error) {
+	if len(steps) == 0 {
+		return WorkflowStatus{}, fmt.Errorf("%w: at least one step is required", ErrInvalidWorkflow)
+	}
+	byKey := make(map[string]WorkflowStep, len(steps))
+	for _, st := range steps {
+		if st.Key == "" {
+			return WorkflowStatus{}, fmt.Errorf("%w: every step needs a key", ErrInvalidWorkflow)
+		}
+		if _, dup := byKey[st.Key]; dup {
+			return WorkflowStatus{}, fmt.Errorf("%w: duplicate step key %q", ErrInvalidWorkflow, st.Key)
+		}
+		byKey[st.Key] = st
+	}
+	for _, st := range steps {
+		for _, parent := range st.DependsOn {
+			if _, ok := byKey[parent]; !ok {
+				return WorkflowStatus{}, fmt.Errorf("%w: step %q depends on unknown step %q", ErrInvalidWorkflow, st.Key, parent)
+			}
+		}
+	}
+	if cycle := findCycle(steps); cycle != "" {
+		return WorkflowStatus{}, fmt.Errorf("%w: dependency cycle through step %q", ErrInvalidWorkflow, cycle)
+	}
+
+	workflowID := uuid.NewString()
+	ids := make(map[string]string, len(steps))
+	for _, st := range steps {
+		ids[st.Key] = uuid.NewString()
+	}
+
+	jobs := make([]entities.Job, 0, len(steps))
+	deps := make([]entities.JobDependency, 0)
+	handlers := make(map[string]Handler, len(steps))
+	for _, st := range steps {
+		h, ok := m.handler(st.Type)
+		if !ok {
+			return WorkflowStatus{}, fmt.Errorf("%w: %s", ErrUnknownJobType, st.Type)
+		}
+		payload := st.Payload
+		if len(payload) == 0 {
+			payload = json.RawMessage("{}")
+		}
+		job := entities.Job{
+			ID:           ids[st.Key],
+			Type:         st.Type,
+			Status:       StatusQueued,
+			Payload:      string(payload),
+			WorkflowID:   workflowID,
+			WorkflowStep: st.Key,
+		}
+		if len(st.DependsOn) > 0 {
+			job.Status = StatusWaiting
+		}
+		if err := h.Prepare(ctx, payload, &job); err != nil {
+			return WorkflowStatus{}, fmt.Errorf("step %q: %w", st.Key, err)
+		}
+		for _, parent := range st.DependsOn {
+			deps = append(deps, entities.JobDependency{JobID: job.ID, ParentID: ids[parent]})
+		}
+		jobs = append(jobs, job)
+		handlers[job.ID] = h
+	}
+
+	if err := m.jobRepo.CreateWithDependencies(ctx, jobs, deps); err != nil {
+		return WorkflowStatus{}, err
+	}
+
+	runCtx := context.WithoutCancel(ctx)
+	for _, job := range jobs {
+		if job.Status == StatusQueued {
+			go m.run(runCtx, handlers[job.ID], job)
+		}
+	}
+	return m.GetWorkflow(ctx, workflowID)
+}
+
+// GetWorkflow returns every job in the workflow, its parents and the overall status.
+func (m *JobManager) GetWorkflow(ctx context.Context, workflowID string) (WorkflowStatus, error) {
+	jobs, deps, err := m.jobRepo.ListByWorkflow(ctx, workflowID)
+	if err != nil {
+		return WorkflowStatus{}, err
+	}
+	parents := make(map[string][]string, len(jobs))
+	for _, d := range deps {
+		parents[d.JobID] = append(parents[d.JobID], d.ParentID)
+	}
+
+	out := WorkflowStatus{WorkflowID: workflowID, Jobs: make([]WorkflowJob, 0, len(jobs))}
+	statuses := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		dependsOn := parents[j.ID]
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		out.Jobs = append(out.Jobs, WorkflowJob{Job: j, DependsOn: dependsOn})
+		statuses = append(statuses, j.Status)
+	}
+	out.Status = workflowStatus(statuses)
+	return out, nil
+}
+
+// workflowStatus folds job statuses: RUNNING while anything can still make progress,
+// otherwise COMPLETED only if every job completed.
+func workflowStatus(statuses []string) string {
+	failed, cancelled := false, false
+	for _, st := range statuses {
+		switch st {
+		case StatusWaiting, StatusQueued, StatusRunning, StatusCancelling:
+			return StatusRunning
+		case StatusFailed:
+			failed = true
+		case StatusCancelled:
+			cancelled = true
+		}
+	}
+	switch {
+	case failed:
+		return StatusFailed
+	case cancelled:
+		return StatusCancelled
+	default:
+		return StatusCompleted
+	}
+}
+
+// findCycle returns the key of a step on a dependency cycle, or "" when the graph is acyclic.
+func findCycle(steps []WorkflowStep) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	parents := make(map[string][]string, len(steps))
+	for _, st := range steps {
+		parents[st.Key] = st.DependsOn
+	}
+	state := make(map[string]int, len(steps))
+	var visit func(key string) string
+	visit = func(key string) string {
+		switch state[key] {
+		case visiting:
+			return key
+		case visited:
+			return ""
+		}
+		state[key] = visiting
+		for _, p := range parents[key] {
+			if c := visit(p); c != "" {
+				return c
+			}
+		}
+		state[key] = visited
+		return ""
+	}
+	for _, st := range steps {
+		if c := visit(st.Key); c != "" {
+			return c
+		}
+	}
+	return ""
+}
+
+// releaseDependents reacts to a parent reaching a terminal status. Waiting dependents are
+// launched once all their parents completed, or skipped (recursively) if the parent did not.
+func (m *JobManager) releaseDependents(ctx context.Context, parentID, parentStatus string) {
+	dependents, err := m.jobRepo.ListDependents(ctx, parentID)
+	if err != nil {
+		return
+	}
+	for _, d := range dependents {
+		if d.Status != StatusWaiting {
+			continue
+		}
+		if parentStatus != StatusCompleted {
+			ok, err := m.jobRepo.TransitionStatus(ctx, d.ID, StatusWaiting, StatusSkipped)
+			if err != nil || !ok {
+				continue
+			}
+			_ = m.jobRepo.SetError(ctx, d.ID, fmt.Sprintf("parent job %s %s", parentID, strings.ToLower(parentStatus)))
+			m.releaseDependents(ctx, d.ID, StatusSkipped)
+			continue
+		}
+
+		parents, err := m.jobRepo.ListParents(ctx, d.ID)
+		if err != nil {
+			continue
+		}
+		ready := true
+		for _, p := range parents {
+			if p.Status != StatusCompleted {
+				ready = false
+				break
+			}
+		}
+		if !ready {
+			continue
+		}
+		// Only one finishing parent may launch the dependent
+		ok, err := m.jobRepo.TransitionStatus(ctx, d.ID, StatusWaiting, StatusQueued)
+		if err != nil || !ok {
+			continue
+		}
+		m.launchDependent(ctx, d)
+	}
+}
+
+// launchDependent re-prepares a released job so its totals reflect what its parents produced.
+func (m *JobManager) launchDependent(ctx context.Context, job entities.Job) {
+	h, ok := m.handler(job.Type)
+	if !ok {
+		_ = m.jobRepo.SetError(ctx, job.ID, fmt.Sprintf("%s: %s", ErrUnknownJobType, job.Type))
+		_ = m.jobRepo.SetStatus(ctx, job.ID, StatusFailed)
+		m.releaseDependents(ctx, job.ID, StatusFailed)
+		return
+	}
+	if err := h.Prepare(ctx, json.RawMessage(job.Payload), &job); err != nil {
+		_ = m.jobRepo.SetError(ctx, job.ID, err.Error())
+		_ = m.jobRepo.SetStatus(ctx, job.ID, StatusFailed)
+		m.releaseDependents(ctx, job.ID, StatusFailed)
+		return
+	}
+	_ = m.jobRepo.UpdateProgress(ctx, job.ID, 0, job.Total, 0)
+	job.Status = StatusQueued
+	go m.run(context.WithoutCancel(ctx), h, job)
+}
+
+// rearmDependents puts jobs skipped because of jobID back into WAITING before a retry.
+func (m *JobManager) rearmDependents(ctx context.Context, jobID string) {
+	dependents, err := m.jobRepo.ListDependents(ctx, jobID)
+	if err != nil {
+		return
+	}
+	for _, d := range dependents {
+		ok, err := m.jobRepo.TransitionStatus(ctx, d.ID, StatusSkipped, StatusWaiting)
+		if err != nil || !ok {
+			continue
+		}
+		_ = m.jobRepo.SetError(ctx, d.ID, "")
+		m.rearmDependents(ctx, d.ID)
+	}
+}
@@ -0,0 +1,595 @@
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+// memoryJobRepo is an in-memory jobrepo.JobRepo so the framework can be exercised without Postgres.
+type memoryJobRepo struct {
+	mu   sync.Mutex
+	jobs map[string]entities.Job
+	deps []entities.JobDependency
+}
+
+func newMemoryJobRepo() *memoryJobRepo {
+	return &memoryJobRepo{jobs: make(map[string]entities.Job)}
+}
+
+func (r *memoryJobRepo) update(jobID string, fn func(j *entities.Job)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[jobID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	fn(&j)
+	r.jobs[jobID] = j
+	return nil
+}
+
+func (r *memoryJobRepo) Create(_ context.Context, job entities.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.Attempts == 0 {
+		job.Attempts = 1
+	}
+	r.jobs[job.ID] = job
+	return nil
+}
+
+func (r *memoryJobRepo) UpdateProgress(_ context.Context, jobID string, processed, total int64, progress int) error {
+	return r.update(jobID, func(j *entities.Job) {
+		j.Processed, j.Total, j.Progress = processed, total, progress
+	})
+}
+
+func (r *memoryJobRepo) SetResultPath(_ context.Context, jobID, path string) error {
+	return r.update(jobID, func(j *entities.Job) { j.ResultPath = path })
+}
+
+func (r *memoryJobRepo) RequestCancel(_ context.Context, jobID string) error {
+	return r.update(jobID, func(j *entities.Job) { j.CancelRequested = true })
+}
+
+func (r *memoryJobRepo) IsCancelRequested(ctx context.Context, jobID string) (bool, error) {
+	j, err := r.Get(ctx, jobID)
+	return j.CancelRequested, err
+}
+
+func (r *memoryJobRepo) Get(_ context.Context, jobID string) (entities.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[jobID]
+	if !ok {
+		return entities.Job{}, gorm.ErrRecordNotFound
+	}
+	return j, nil
+}
+
+// List ignores the query: the job manager never lists, only the HTTP routes do.
+func (r *memoryJobRepo) List(_ context.Context, _ query.Request) ([]entities.Job, pkgdto.PaginationResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := make([]entities.Job, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, pkgdto.PaginationResponse{Page: 1, PerPage: len(jobs), MaxPage: 1, Count: int64(len(jobs))}, nil
+}
+
+func (r *memoryJobRepo) SetStatus(_ context.Context, jobID, status string) error {
+	return r.update(jobID, func(j *entities.Job) { j.Status = status })
+}
+
+// Finish stores the status only when fn succeeds, as the transaction would.
+func (r *memoryJobRepo) Finish(ctx context.Context, jobID, status string, fn func(tx *gorm.DB, job entities.Job) error) error {
+	job, err := r.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	job.Status = status
+	if err := fn(nil, job); err != nil {
+		return err
+	}
+	return r.SetStatus(ctx, jobID, status)
+}
+
+func (r *memoryJobRepo) SetError(_ context.Context, jobID, message string) error {
+	return r.update(jobID, func(j *entities.Job) { j.Error = message })
+}
+
+func (r *memoryJobRepo) ResetForRetry(_ context.Context, jobID string, total int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[jobID]
+	if !ok || (j.Status != jobservice.StatusFailed && j.Status != jobservice.StatusCancelled) {
+		return false, nil
+	}
+	j.Status = jobservice.StatusQueued
+	j.Progress, j.Processed, j.Total = 0, 0, total
+	j.CancelRequested = false
+	j.Error, j.ResultPath = "", ""
+	j.Attempts++
+	r.jobs[jobID] = j
+	return true, nil
+}
+
+func (r *memoryJobRepo) TransitionStatus(_ context.Context, jobID, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[jobID]
+	if !ok || j.Status != from {
+		return false, nil
+	}
+	j.Status = to
+	r.jobs[jobID] = j
+	return true, nil
+}
+
+func (r *memoryJobRepo) TransitionStatusIn(_ context.Context, jobID string, from []string, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[jobID]
+	if !ok || !slices.Contains(from, j.Status) {
+		return false, nil
+	}
+	j.Status = to
+	r.jobs[jobID] = j
+	return true, nil
+}
+
+func (r *memoryJobRepo) CreateWithDependencies(ctx context.Context, jobs []entities.Job, deps []entities.JobDependency) error {
+	for _, j := range jobs {
+		_ = r.Create(ctx, j)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deps = append(r.deps, deps...)
+	return nil
+}
+
+func (r *memoryJobRepo) ListParents(_ context.Context, jobID string) ([]entities.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []entities.Job
+	for _, d := range r.deps {
+		if d.JobID == jobID {
+			out = append(out, r.jobs[d.ParentID])
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryJobRepo) ListDependents(_ context.Context, parentID string) ([]entities.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []entities.Job
+	for _, d := range r.deps {
+		if d.ParentID == parentID {
+			out = append(out, r.jobs[d.JobID])
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryJobRepo) ListByWorkflow(_ context.Context, workflowID string) ([]entities.Job, []entities.JobDependency, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []entities.Job
+	ids := make(map[string]bool)
+	for _, j := range r.jobs {
+		if j.WorkflowID == workflowID {
+			jobs = append(jobs, j)
+			ids[j.ID] = true
+		}
+	}
+	if len(jobs) == 0 {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	var deps []entities.JobDependency
+	for _, d := range r.deps {
+		if ids[d.JobID] {
+			deps = append(deps, d)
+		}
+	}
+	return jobs, deps, nil
+}
+
+// countHandler counts to payload.N, failing on the first attempt when payload.FailOnce is set.
+type countHandler struct {
+	mu       sync.Mutex
+	attempts int
+	block    chan struct{}
+}
+
+type countPayload struct {
+	N        int64 `json:"n"`
+	FailOnce bool  `json:"fail_once"`
+}
+
+func (h *countHandler) Type() string { return "count" }
+
+func (h *countHandler) Prepare(_ context.Context, payload json.RawMessage, job *entities.Job) error {
+	var p countPayload
+	if err := json.Unmarshal(payload, &p); err != nil || p.N <= 0 {
+		return fmt.Errorf("%w: n must be positive", jobservice.ErrInvalidPayload)
+	}
+	job.Total = p.N
+	return nil
+}
+
+func (h *countHandler) Run(ctx context.Context, job entities.Job, progress *jobservice.Progress) error {
+	var p countPayload
+	_ = json.Unmarshal([]byte(job.Payload), &p)
+
+	h.mu.Lock()
+	h.attempts++
+	attempt := h.attempts
+	h.mu.Unlock()
+
+	if h.block != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.block:
+		}
+	}
+	if p.FailOnce && attempt == 1 {
+		return errors.New("boom")
+	}
+	for i := int64(1); i <= p.N; i++ {
+		_ = progress.Update(ctx, i)
+	}
+	return nil
+}
+
+func waitForStatus(t *testing.T, repo *memoryJobRepo, jobID, status string) entities.Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	var j entities.Job
+	for time.Now().Before(deadline) {
+		j, _ = repo.Get(context.Background(), jobID)
+		if j.Status == status {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach %s, last: %#v", jobID, status, j)
+	return j
+}
+
+func TestJobManagerRunsRegisteredHandler(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{})
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":5}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := waitForStatus(t, repo, job.ID, jobservice.StatusCompleted)
+	if done.Processed != 5 || done.Progress != 100 || done.Type != "count" {
+		t.Fatalf("unexpected completed job: %#v", done)
+	}
+}
+
+func TestJobManagerRejectsUnknownTypeAndInvalidPayload(t *testing.T) {
+	m := jobservice.NewJobManager(newMemoryJobRepo())
+	m.Register(&countHandler{})
+
+	if _, err := m.Start(context.Background(), "missing", nil); !errors.Is(err, jobservice.ErrUnknownJobType) {
+		t.Fatalf("expected ErrUnknownJobType, got %v", err)
+	}
+	if _, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":0}`)); !errors.Is(err, jobservice.ErrInvalidPayload) {
+		t.Fatalf("expected ErrInvalidPayload, got %v", err)
+	}
+}
+
+func TestJobManagerRetryFailedJob(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{})
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":3,"fail_once":true}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	failed := waitForStatus(t, repo, job.ID, jobservice.StatusFailed)
+	if failed.Error != "boom" {
+		t.Fatalf("expected error to be recorded, got %q", failed.Error)
+	}
+
+	if _, err := m.Retry(context.Background(), job.ID); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	done := waitForStatus(t, repo, job.ID, jobservice.StatusCompleted)
+	if done.Attempts != 2 || done.Error != "" {
+		t.Fatalf("unexpected retried job: %#v", done)
+	}
+	if _, err := m.Retry(context.Background(), job.ID); !errors.Is(err, jobservice.ErrJobNotRetryable) {
+		t.Fatalf("expected ErrJobNotRetryable for completed job, got %v", err)
+	}
+}
+
+func TestJobManagerConcurrentRetriesRunOnce(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	h := &countHandler{block: make(chan struct{})}
+	m.Register(h)
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":2,"fail_once":true}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	close(h.block)
+	waitForStatus(t, repo, job.ID, jobservice.StatusFailed)
+
+	const n = 8
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Retry(context.Background(), job.ID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	won := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, jobservice.ErrJobNotRetryable):
+			t.Fatalf("expected ErrJobNotRetryable for a losing retry, got %v", err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("expected exactly one retry to win, got %d", won)
+	}
+	if done := waitForStatus(t, repo, job.ID, jobservice.StatusCompleted); done.Attempts != 2 {
+		t.Fatalf("expected a single extra attempt, got %d", done.Attempts)
+	}
+}
+
+func TestJobManagerCancelKeepsFinalStatus(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{})
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":2}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitForStatus(t, repo, job.ID, jobservice.StatusCompleted)
+
+	// A cancel that arrives after the job finished must not rewrite its status
+	if err := m.CancelPending(context.Background(), job.ID); !errors.Is(err, jobservice.ErrJobNotCancellable) {
+		t.Fatalf("expected ErrJobNotCancellable from CancelPending, got %v", err)
+	}
+	if err := m.MarkCancelling(context.Background(), job.ID); !errors.Is(err, jobservice.ErrJobNotCancellable) {
+		t.Fatalf("expected ErrJobNotCancellable from MarkCancelling, got %v", err)
+	}
+	if j, _ := repo.Get(context.Background(), job.ID); j.Status != jobservice.StatusCompleted {
+		t.Fatalf("expected the job to stay COMPLETED, got %s", j.Status)
+	}
+
+	waiting := entities.Job{ID: "waiting-job", Type: "count", Status: jobservice.StatusWaiting}
+	_ = repo.Create(context.Background(), waiting)
+	if err := m.CancelPending(context.Background(), waiting.ID); err != nil {
+		t.Fatalf("cancel a waiting job: %v", err)
+	}
+	if j, _ := repo.Get(context.Background(), waiting.ID); j.Status != jobservice.StatusCancelled {
+		t.Fatalf("expected the waiting job CANCELLED, got %s", j.Status)
+	}
+}
+
+// chanListener forwards finished jobs to a channel.
+type chanListener chan entities.Job
+
+func (l chanListener) JobFinished(_ context.Context, job entities.Job) { l <- job }
+
+func TestJobManagerNotifiesListenersWithFinalStatus(t *testing.T) {
+	m := jobservice.NewJobManager(newMemoryJobRepo())
+	m.Register(&countHandler{})
+	finished := make(chanListener, 2)
+	m.Subscribe(finished)
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":2,"fail_once":true}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	select {
+	case got := <-finished:
+		if got.ID != job.ID || got.Status != jobservice.StatusFailed || got.Error != "boom" {
+			t.Fatalf("expected failed job with its error, got %#v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener was not notified of the failure")
+	}
+
+	if _, err := m.Retry(context.Background(), job.ID); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	select {
+	case got := <-finished:
+		if got.Status != jobservice.StatusCompleted || got.Processed != 2 {
+			t.Fatalf("expected completed job, got %#v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener was not notified of the completion")
+	}
+}
+
+// txListener records the jobs it saw inside the finishing transaction. It fails the first
+// failures calls, and every call for failStatus.
+type txListener struct {
+	mu         sync.Mutex
+	seen       []entities.Job
+	failures   int
+	failStatus string
+}
+
+func (l *txListener) JobFinishedTx(_ context.Context, _ *gorm.DB, job entities.Job) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seen = append(l.seen, job)
+	if l.failures > 0 || job.Status == l.failStatus {
+		l.failures--
+		return errors.New("outbox unavailable")
+	}
+	return nil
+}
+
+func TestJobManagerRetriesFailingTxListener(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.SetFinishRetry(5, time.Millisecond)
+	m.Register(&countHandler{})
+	l := &txListener{failures: 2}
+	m.SubscribeTx(l)
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":3}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := waitForStatus(t, repo, job.ID, jobservice.StatusCompleted)
+	if done.Processed != 3 {
+		t.Fatalf("expected 3 processed, got %#v", done)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.seen) != 3 || l.seen[2].Status != jobservice.StatusCompleted {
+		t.Fatalf("expected the completion to be retried until the listener took it, got %#v", l.seen)
+	}
+}
+
+func TestJobManagerFailsJobWhenTxListenerKeepsFailing(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.SetFinishRetry(3, time.Millisecond)
+	m.Register(&countHandler{})
+	l := &txListener{failStatus: jobservice.StatusCompleted}
+	m.SubscribeTx(l)
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":3}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	// The completion is never stored without its event; the job is failed, with one
+	failed := waitForStatus(t, repo, job.ID, jobservice.StatusFailed)
+	if failed.Error == "" {
+		t.Fatalf("expected the reason to be recorded, got %#v", failed)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.seen) != 4 || l.seen[3].Status != jobservice.StatusFailed {
+		t.Fatalf("expected 3 tries to complete and then the failure, got %#v", l.seen)
+	}
+}
+
+func TestJobManagerCancel(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{block: make(chan struct{})})
+
+	job, err := m.Start(context.Background(), "count", json.RawMessage(`{"n":1}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitForStatus(t, repo, job.ID, jobservice.StatusRunning)
+	if !m.Cancel(job.ID) {
+		t.Fatalf("expected running job to be cancellable")
+	}
+	waitForStatus(t, repo, job.ID, jobservice.StatusCancelled)
+}
+
+func workflowJobID(t *testing.T, wf jobservice.WorkflowStatus, key string) string {
+	t.Helper()
+	for _, j := range wf.Jobs {
+		if j.WorkflowStep == key {
+			return j.ID
+		}
+	}
+	t.Fatalf("workflow has no step %q", key)
+	return ""
+}
+
+func TestWorkflowRunsStepsAfterParentsComplete(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{})
+
+	wf, err := m.StartWorkflow(context.Background(), []jobservice.WorkflowStep{
+		{Key: "settle", Type: "count", Payload: json.RawMessage(`{"n":2}`)},
+		{Key: "export", Type: "count", Payload: json.RawMessage(`{"n":2}`), DependsOn: []string{"settle"}},
+		{Key: "payout", Type: "count", Payload: json.RawMessage(`{"n":2}`), DependsOn: []string{"export"}},
+	})
+	if err != nil {
+		t.Fatalf("start workflow: %v", err)
+	}
+	waitForStatus(t, repo, workflowJobID(t, wf, "payout"), jobservice.StatusCompleted)
+
+	got, err := m.GetWorkflow(context.Background(), wf.WorkflowID)
+	if err != nil {
+		t.Fatalf("get workflow: %v", err)
+	}
+	if got.Status != jobservice.StatusCompleted {
+		t.Fatalf("expected workflow COMPLETED, got %s", got.Status)
+	}
+}
+
+func TestWorkflowSkipsDependentsOfFailedStep(t *testing.T) {
+	repo := newMemoryJobRepo()
+	m := jobservice.NewJobManager(repo)
+	m.Register(&countHandler{})
+
+	wf, err := m.StartWorkflow(context.Background(), []jobservice.WorkflowStep{
+		{Key: "settle", Type: "count", Payload: json.RawMessage(`{"n":2,"fail_once":true}`)},
+		{Key: "export", Type: "count", Payload: json.RawMessage(`{"n":2}`), DependsOn: []string{"settle"}},
+		{Key: "payout", Type: "count", Payload: json.RawMessage(`{"n":2}`), DependsOn: []string{"export"}},
+	})
+	if err != nil {
+		t.Fatalf("start workflow: %v", err)
+	}
+	waitForStatus(t, repo, workflowJobID(t, wf, "payout"), jobservice.StatusSkipped)
+	got, _ := m.GetWorkflow(context.Background(), wf.WorkflowID)
+	if got.Status != jobservice.StatusFailed {
+		t.Fatalf("expected workflow FAILED, got %s", got.Status)
+	}
+
+	// Retrying the failed root re-arms and eventually completes the whole chain
+	if _, err := m.Retry(context.Background(), workflowJobID(t, wf, "settle")); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	waitForStatus(t, repo, workflowJobID(t, wf, "payout"), jobservice.StatusCompleted)
+}
+
+func TestWorkflowRejectsCycles(t *testing.T) {
+	m := jobservice.NewJobManager(newMemoryJobRepo())
+	m.Register(&countHandler{})
+
+	_, err := m.StartWorkflow(context.Background(), []jobservice.WorkflowStep{
+		{Key: "a", Type: "count", Payload: json.RawMessage(`{"n":1}`), DependsOn: []string{"b"}},
+		{Key: "b", Type: "count", Payload: json.RawMessage(`{"n":1}`), DependsOn: []string{"a"}},
+	})
+	if !errors.Is(err, jobservice.ErrInvalidWorkflow) {
+		t.Fatalf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
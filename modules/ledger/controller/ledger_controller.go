@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	LedgerController interface {
+		CreateEntry(ctx *gin.Context)
+		GetEntry(ctx *gin.Context)
+		ListEntries(ctx *gin.Context)
+		ListAccounts(ctx *gin.Context)
+		GetAccount(ctx *gin.Context)
+		MerchantBalance(ctx *gin.Context)
+	}
+
+	ledgerController struct {
+		service  service.LedgerService
+		validate *validation.LedgerValidation
+	}
+)
+
+func NewLedgerController(_ *do.Injector, s service.LedgerService) LedgerController {
+	return &ledgerController{
+		service:  s,
+		validate: validation.NewLedgerValidation(),
+	}
+}
+
+func (c *ledgerController) CreateEntry(ctx *gin.Context) {
+	var req dto.JournalEntryCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+	if err := c.validate.ValidateJournalEntryCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_ENTRY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, created, err := c.service.CreateEntry(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ENTRY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	if !created {
+		res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REPLAY_ENTRY, result)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ENTRY, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *ledgerController) GetEntry(ctx *gin.Context) {
+	result, err := c.service.GetEntry(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ENTRY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ENTRY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ledgerController) ListEntries(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var req dto.JournalEntryListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.ListEntries(ctx.Request.Context(), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_ENTRY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_ENTRY, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ledgerController) ListAccounts(ctx *gin.Context) {
+	var req dto.BalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	items, err := c.service.ListAccounts(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_ACCOUNT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_ACCOUNT, items)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ledgerController) GetAccount(ctx *gin.Context) {
+	var req dto.BalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	result, err := c.service.GetAccount(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ACCOUNT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ACCOUNT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ledgerController) MerchantBalance(ctx *gin.Context) {
+	var req dto.BalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	result, err := c.service.MerchantBalance(ctx.Request.Context(), ctx.Param("merchant_id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_BALANCE, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_BALANCE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrEntryNotFound), errors.Is(err, dto.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrEntryExternalRefInUse):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrInvalidAsOf), errors.Is(err, dto.ErrInvalidSettlementDate), errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,119 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY = "failed get data from body"
+	MESSAGE_FAILED_PROSES_REQUEST     = "failed proses request"
+	MESSAGE_FAILED_VALIDATION_ENTRY   = "Validation failed"
+	MESSAGE_FAILED_CREATE_ENTRY       = "failed create journal entry"
+	MESSAGE_FAILED_GET_ENTRY          = "failed get journal entry"
+	MESSAGE_FAILED_GET_LIST_ENTRY     = "failed get list journal entry"
+	MESSAGE_FAILED_GET_ACCOUNT        = "failed get ledger account"
+	MESSAGE_FAILED_GET_LIST_ACCOUNT   = "failed get list ledger account"
+	MESSAGE_FAILED_GET_BALANCE        = "failed get merchant balance"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_ENTRY     = "success create journal entry"
+	MESSAGE_SUCCESS_REPLAY_ENTRY     = "journal entry already exists"
+	MESSAGE_SUCCESS_GET_ENTRY        = "success get journal entry"
+	MESSAGE_SUCCESS_GET_LIST_ENTRY   = "success get list journal entry"
+	MESSAGE_SUCCESS_GET_ACCOUNT      = "success get ledger account"
+	MESSAGE_SUCCESS_GET_LIST_ACCOUNT = "success get list ledger account"
+	MESSAGE_SUCCESS_GET_BALANCE      = "success get merchant balance"
+)
+
+var (
+	ErrEntryNotFound         = errors.New("journal entry not found")
+	ErrAccountNotFound       = errors.New("ledger account not found")
+	ErrUnknownAccountType    = errors.New("unknown ledger account type")
+	ErrMerchantRequired      = errors.New("merchant_id is required for merchant accounts")
+	ErrMerchantNotAllowed    = errors.New("merchant_id is not allowed for platform accounts")
+	ErrLineOneSided          = errors.New("each line must have exactly one positive debit_cents or credit_cents")
+	ErrEntryUnbalanced       = errors.New("total debits must equal total credits")
+	ErrEntryExternalRefInUse = errors.New("external_ref already used by a different journal entry")
+	ErrEffectiveAtInFuture   = errors.New("effective_at must not be in the future")
+	ErrSettlementUnbalanced  = errors.New("settlement amounts do not balance")
+	ErrTooFewLines           = errors.New("a journal entry needs at least two lines")
+	ErrInvalidSettlementDate = errors.New("settlement_date must be YYYY-MM-DD")
+	ErrInvalidAsOf           = errors.New("as_of must be an RFC3339 timestamp")
+)
+
+type (
+	JournalLineRequest struct {
+		AccountType string `json:"account_type" binding:"required"`
+		MerchantID  string `json:"merchant_id"`
+		DebitCents  int64  `json:"debit_cents"`
+		CreditCents int64  `json:"credit_cents"`
+	}
+
+	// JournalEntryCreateRequest posts a manual entry, e.g. moving funds into a merchant's reserve.
+	// ExternalRef makes the call idempotent.
+	JournalEntryCreateRequest struct {
+		ExternalRef string               `json:"external_ref" binding:"required,min=1,max=255"`
+		Description string               `json:"description" binding:"required"`
+		EffectiveAt *time.Time           `json:"effective_at"`
+		Lines       []JournalLineRequest `json:"lines" binding:"required"`
+	}
+
+	JournalEntryListRequest struct {
+		MerchantID     string `form:"merchant_id"`
+		Source         string `form:"source"`
+		SourceRef      string `form:"source_ref"`
+		SettlementDate string `form:"settlement_date"`
+	}
+
+	// BalanceRequest selects the point in time balances are computed at; empty means now.
+	BalanceRequest struct {
+		AsOf       string `form:"as_of"`
+		MerchantID string `form:"merchant_id"`
+	}
+
+	JournalLineResponse struct {
+		AccountID   string `json:"account_id"`
+		AccountCode string `json:"account_code"`
+		AccountType string `json:"account_type"`
+		MerchantID  string `json:"merchant_id,omitempty"`
+		DebitCents  int64  `json:"debit_cents"`
+		CreditCents int64  `json:"credit_cents"`
+	}
+
+	JournalEntryResponse struct {
+		ID             string                `json:"id"`
+		Source         string                `json:"source"`
+		SourceRef      string                `json:"source_ref,omitempty"`
+		ExternalRef    string                `json:"external_ref,omitempty"`
+		MerchantID     string                `json:"merchant_id,omitempty"`
+		SettlementDate string                `json:"settlement_date,omitempty"`
+		Description    string                `json:"description"`
+		EffectiveAt    time.Time             `json:"effective_at"`
+		PostedAt       time.Time             `json:"posted_at"`
+		Lines          []JournalLineResponse `json:"lines"`
+	}
+
+	// AccountResponse carries an account with its totals as of AsOf. BalanceCents is signed
+	// towards the account's normal side.
+	AccountResponse struct {
+		ID            string    `json:"id"`
+		Code          string    `json:"code"`
+		Type          string    `json:"type"`
+		MerchantID    string    `json:"merchant_id,omitempty"`
+		NormalBalance string    `json:"normal_balance"`
+		DebitCents    int64     `json:"debit_cents"`
+		CreditCents   int64     `json:"credit_cents"`
+		BalanceCents  int64     `json:"balance_cents"`
+		AsOf          time.Time `json:"as_of"`
+	}
+
+	// MerchantBalanceResponse is what the platform owes a merchant as of AsOf.
+	MerchantBalanceResponse struct {
+		MerchantID   string    `json:"merchant_id"`
+		AsOf         time.Time `json:"as_of"`
+		PayableCents int64     `json:"payable_cents"`
+		ReserveCents int64     `json:"reserve_cents"`
+	}
+)
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// settlementPostingLock is the advisory lock key serializing settlement postings, so two
+// jobs settling the same days cannot both post the same delta.
+const settlementPostingLock = 7_340_034
+
+type LedgerRepository interface {
+	EnsureAccounts(ctx context.Context, tx *gorm.DB, accounts []entities.LedgerAccount) (map[string]entities.LedgerAccount, error)
+	ListAccounts(ctx context.Context, tx *gorm.DB, merchantID string) ([]entities.LedgerAccount, error)
+	FindAccountByID(ctx context.Context, tx *gorm.DB, id string) (entities.LedgerAccount, error)
+	Totals(ctx context.Context, tx *gorm.DB, accountIDs []uuid.UUID, asOf time.Time) (map[uuid.UUID]AccountTotal, error)
+
+	LockSettlementPosting(ctx context.Context, tx *gorm.DB) error
+	SettlementPostedTotals(ctx context.Context, tx *gorm.DB, merchantIDs []string, dates []time.Time) ([]PostedTotal, error)
+	SettlementDaysPostedBy(ctx context.Context, tx *gorm.DB, jobID string, merchantIDs []string, dates []time.Time) ([]SettlementDay, error)
+	CreateEntries(ctx context.Context, tx *gorm.DB, entries []entities.JournalEntry) error
+	FindEntryByID(ctx context.Context, tx *gorm.DB, id string) (entities.JournalEntry, error)
+	FindEntryByExternalRef(ctx context.Context, tx *gorm.DB, ref string) (entities.JournalEntry, error)
+	ListEntries(ctx context.Context, tx *gorm.DB, filter EntryFilter, q query.Request) ([]entities.JournalEntry, pkgdto.PaginationResponse, error)
+}
+
+// AccountTotal sums the debits and credits posted to one account.
+type AccountTotal struct {
+	AccountID   uuid.UUID
+	DebitCents  int64
+	CreditCents int64
+}
+
+// PostedTotal is the net (debit - credit) already posted to one account type by the
+// settlement entries of a (merchant_id, settlement_date).
+type PostedTotal struct {
+	MerchantID     string
+	SettlementDate time.Time
+	AccountType    string
+	NetCents       int64
+}
+
+// SettlementDay is a (merchant_id, settlement_date) some settlement entry was posted for.
+type SettlementDay struct {
+	MerchantID     string
+	SettlementDate time.Time
+}
+
+type EntryFilter struct {
+	MerchantID     string
+	Source         string
+	SourceRef      string
+	SettlementDate *time.Time
+}
+
+// entrySchema is what the journal may be filtered and sorted by, in posting order by default;
+// search matches part of the description or source reference.
+var entrySchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":              {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"source":          {Column: "source", Type: query.String, Ops: query.Equality},
+		"source_ref":      {Column: "source_ref", Type: query.String, Ops: query.Text},
+		"merchant_id":     {Column: "merchant_id", Type: query.String, Ops: query.Text},
+		"settlement_date": {Column: "settlement_date", Type: query.Date, Ops: query.Range},
+		"effective_at":    {Column: "effective_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"posted_at":       {Column: "posted_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "posted_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		pattern := query.Contains(term)
+		return db.Where("description ILIKE ? OR source_ref ILIKE ?", pattern, pattern)
+	},
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+// EnsureAccounts creates any missing accounts and returns all of them keyed by code.
+func (r *ledgerRepository) EnsureAccounts(ctx context.Context, tx *gorm.DB, accounts []entities.LedgerAccount) (map[string]entities.LedgerAccount, error) {
+	db := r.getDB(tx)
+	if len(accounts) == 0 {
+		return map[string]entities.LedgerAccount{}, nil
+	}
+	if err := db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(&accounts).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, len(accounts))
+	for i, a := range accounts {
+		codes[i] = a.Code
+	}
+	var found []entities.LedgerAccount
+	if err := db.WithContext(ctx).Where("code IN ?", codes).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]entities.LedgerAccount, len(found))
+	for _, a := range found {
+		byCode[a.Code] = a
+	}
+	return byCode, nil
+}
+
+func (r *ledgerRepository) ListAccounts(ctx context.Context, tx *gorm.DB, merchantID string) ([]entities.LedgerAccount, error) {
+	db := r.getDB(tx)
+	var accounts []entities.LedgerAccount
+	q := db.WithContext(ctx).Model(&entities.LedgerAccount{})
+	if merchantID != "" {
+		q = q.Where("merchant_id = ?", merchantID)
+	}
+	if err := q.Order("code ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *ledgerRepository) FindAccountByID(ctx context.Context, tx *gorm.DB, id string) (entities.LedgerAccount, error) {
+	db := r.getDB(tx)
+	var account entities.LedgerAccount
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&account).Error; err != nil {
+		return entities.LedgerAccount{}, err
+	}
+	return account, nil
+}
+
+// Totals sums the lines of entries effective at or before asOf for each account.
+func (r *ledgerRepository) Totals(ctx context.Context, tx *gorm.DB, accountIDs []uuid.UUID, asOf time.Time) (map[uuid.UUID]AccountTotal, error) {
+	db := r.getDB(tx)
+	totals := make(map[uuid.UUID]AccountTotal, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return totals, nil
+	}
+	var rows []AccountTotal
+	if err := db.WithContext(ctx).
+		Table("journal_lines AS l").
+		Select("l.account_id, COALESCE(SUM(l.debit_cents), 0) AS debit_cents, COALESCE(SUM(l.credit_cents), 0) AS credit_cents").
+		Joins("JOIN journal_entries e ON e.id = l.entry_id").
+		Where("l.account_id IN ? AND e.effective_at <= ?", accountIDs, asOf).
+		Group("l.account_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		totals[row.AccountID] = row
+	}
+	return totals, nil
+}
+
+// LockSettlementPosting takes a transaction-scoped advisory lock released on commit or rollback.
+func (r *ledgerRepository) LockSettlementPosting(ctx context.Context, tx *gorm.DB) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", settlementPostingLock).Error
+}
+
+func (r *ledgerRepository) SettlementPostedTotals(ctx context.Context, tx *gorm.DB, merchantIDs []string, dates []time.Time) ([]PostedTotal, error) {
+	db := r.getDB(tx)
+	var totals []PostedTotal
+	if len(merchantIDs) == 0 || len(dates) == 0 {
+		return totals, nil
+	}
+	err := db.WithContext(ctx).
+		Table("journal_lines AS l").
+		Select("e.merchant_id, e.settlement_date, a.type AS account_type, SUM(l.debit_cents - l.credit_cents) AS net_cents").
+		Joins("JOIN journal_entries e ON e.id = l.entry_id").
+		Joins("JOIN ledger_accounts a ON a.id = l.account_id").
+		Where("e.source = ? AND e.merchant_id IN ? AND e.settlement_date IN ?", constants.ENUM_JOURNAL_SOURCE_SETTLEMENT, merchantIDs, dates).
+		Group("e.merchant_id, e.settlement_date, a.type").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// SettlementDaysPostedBy returns the (merchant_id, settlement_date) pairs the settlement job jobID
+// already posted entries for.
+func (r *ledgerRepository) SettlementDaysPostedBy(ctx context.Context, tx *gorm.DB, jobID string, merchantIDs []string, dates []time.Time) ([]SettlementDay, error) {
+	db := r.getDB(tx)
+	var days []SettlementDay
+	if len(merchantIDs) == 0 || len(dates) == 0 {
+		return days, nil
+	}
+	err := db.WithContext(ctx).
+		Model(&entities.JournalEntry{}).
+		Distinct("merchant_id", "settlement_date").
+		Where("source = ? AND source_ref = ? AND merchant_id IN ? AND settlement_date IN ?", constants.ENUM_JOURNAL_SOURCE_SETTLEMENT, jobID, merchantIDs, dates).
+		Scan(&days).Error
+	return days, err
+}
+
+// CreateEntries inserts entries and then their lines. Lines must already reference
+// existing accounts by AccountID.
+func (r *ledgerRepository) CreateEntries(ctx context.Context, tx *gorm.DB, entries []entities.JournalEntry) error {
+	db := r.getDB(tx)
+	if len(entries) == 0 {
+		return nil
+	}
+	lines := make([]entities.JournalLine, 0, len(entries)*4)
+	for i := range entries {
+		if entries[i].ID == uuid.Nil {
+			entries[i].ID = uuid.New()
+		}
+		for _, l := range entries[i].Lines {
+			l.EntryID = entries[i].ID
+			lines = append(lines, l)
+		}
+	}
+	if err := db.WithContext(ctx).Omit(clause.Associations).CreateInBatches(&entries, 500).Error; err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Omit(clause.Associations).CreateInBatches(&lines, 1000).Error
+}
+
+func (r *ledgerRepository) FindEntryByID(ctx context.Context, tx *gorm.DB, id string) (entities.JournalEntry, error) {
+	db := r.getDB(tx)
+	var entry entities.JournalEntry
+	if err := db.WithContext(ctx).Preload("Lines.Account").Where("id = ?", id).Take(&entry).Error; err != nil {
+		return entities.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *ledgerRepository) FindEntryByExternalRef(ctx context.Context, tx *gorm.DB, ref string) (entities.JournalEntry, error) {
+	db := r.getDB(tx)
+	var entry entities.JournalEntry
+	if err := db.WithContext(ctx).Preload("Lines.Account").Where("external_ref = ?", ref).Take(&entry).Error; err != nil {
+		return entities.JournalEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *ledgerRepository) ListEntries(ctx context.Context, tx *gorm.DB, filter EntryFilter, q query.Request) ([]entities.JournalEntry, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	entries := func() *gorm.DB {
+		e := db.WithContext(ctx).Model(&entities.JournalEntry{})
+		if filter.MerchantID != "" {
+			e = e.Where("merchant_id = ?", filter.MerchantID)
+		}
+		if filter.Source != "" {
+			e = e.Where("source = ?", filter.Source)
+		}
+		if filter.SourceRef != "" {
+			e = e.Where("source_ref = ?", filter.SourceRef)
+		}
+		if filter.SettlementDate != nil {
+			e = e.Where("settlement_date = ?", *filter.SettlementDate)
+		}
+		return e
+	}
+	return query.Find[entities.JournalEntry](entries, q, entrySchema, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Lines.Account")
+	})
+}
@@ -0,0 +1,22 @@
+package ledger
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/controller"
+)
+
+// RegisterRoutes exposes the ledger. Journal entries can be created but never changed.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.LedgerController](injector)
+
+	r := server.Group("/api/ledger")
+	{
+		r.GET("/accounts", ctrl.ListAccounts)
+		r.GET("/accounts/:id", ctrl.GetAccount)
+		r.GET("/entries", ctrl.ListEntries)
+		r.GET("/entries/:id", ctrl.GetEntry)
+		r.POST("/entries", ctrl.CreateEntry)
+		r.GET("/merchants/:merchant_id/balance", ctrl.MerchantBalance)
+	}
+}
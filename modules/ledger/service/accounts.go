@@ -0,0 +1,41 @@
+package service
+
+import (
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// accountFor describes the account of the given type, scoped to merchantID for merchant accounts.
+// Clearing is the only asset account; the rest are liabilities or revenue and carry credit balances.
+func accountFor(accountType, merchantID string) entities.LedgerAccount {
+	normal := constants.ENUM_LEDGER_NORMAL_CREDIT
+	if accountType == constants.ENUM_LEDGER_ACCOUNT_CLEARING {
+		normal = constants.ENUM_LEDGER_NORMAL_DEBIT
+	}
+	code := accountType
+	if merchantID != "" {
+		code += ":" + merchantID
+	}
+	return entities.LedgerAccount{
+		Code:          code,
+		Type:          accountType,
+		MerchantID:    merchantID,
+		NormalBalance: normal,
+	}
+}
+
+// balanceOf signs the account's totals towards its normal side.
+func balanceOf(a entities.LedgerAccount, debit, credit int64) int64 {
+	if a.NormalBalance == constants.ENUM_LEDGER_NORMAL_DEBIT {
+		return debit - credit
+	}
+	return credit - debit
+}
+
+// signedLine turns a signed amount into a one-sided line: positive debits, negative credits.
+func signedLine(account entities.LedgerAccount, amount int64) entities.JournalLine {
+	if amount >= 0 {
+		return entities.JournalLine{AccountID: account.ID, DebitCents: amount}
+	}
+	return entities.JournalLine{AccountID: account.ID, CreditCents: -amount}
+}
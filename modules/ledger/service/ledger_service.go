@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type LedgerService interface {
+	PostSettlements(ctx context.Context, tx *gorm.DB, jobID string, settlements []entities.Settlement) error
+	CreateEntry(ctx context.Context, req dto.JournalEntryCreateRequest) (dto.JournalEntryResponse, bool, error)
+	GetEntry(ctx context.Context, id string) (dto.JournalEntryResponse, error)
+	ListEntries(ctx context.Context, req dto.JournalEntryListRequest, q query.Request) ([]dto.JournalEntryResponse, pkgdto.PaginationResponse, error)
+	ListAccounts(ctx context.Context, req dto.BalanceRequest) ([]dto.AccountResponse, error)
+	GetAccount(ctx context.Context, id string, req dto.BalanceRequest) (dto.AccountResponse, error)
+	MerchantBalance(ctx context.Context, merchantID string, req dto.BalanceRequest) (dto.MerchantBalanceResponse, error)
+}
+
+type ledgerService struct {
+	repo repository.LedgerRepository
+	db   *gorm.DB
+}
+
+func NewLedgerService(repo repository.LedgerRepository, db *gorm.DB) LedgerService {
+	return &ledgerService{repo: repo, db: db}
+}
+
+// CreateEntry posts a balanced manual entry. A replay of the same external_ref with the same
+// lines returns the recorded entry; different lines are rejected.
+func (s *ledgerService) CreateEntry(ctx context.Context, req dto.JournalEntryCreateRequest) (dto.JournalEntryResponse, bool, error) {
+	var (
+		result  entities.JournalEntry
+		created bool
+	)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.repo.FindEntryByExternalRef(ctx, tx, req.ExternalRef)
+		switch {
+		case err == nil:
+			if linesSignature(existing) != requestSignature(req) {
+				return dto.ErrEntryExternalRefInUse
+			}
+			result = existing
+			return nil
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		specs := make([]entities.LedgerAccount, 0, len(req.Lines))
+		merchants := make(map[string]struct{})
+		for _, l := range req.Lines {
+			specs = append(specs, accountFor(l.AccountType, l.MerchantID))
+			if l.MerchantID != "" {
+				merchants[l.MerchantID] = struct{}{}
+			}
+		}
+		accounts, err := s.repo.EnsureAccounts(ctx, tx, specs)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		effectiveAt := now
+		if req.EffectiveAt != nil {
+			effectiveAt = req.EffectiveAt.UTC()
+		}
+		ref := req.ExternalRef
+		entry := entities.JournalEntry{
+			Source:      constants.ENUM_JOURNAL_SOURCE_MANUAL,
+			ExternalRef: &ref,
+			Description: req.Description,
+			EffectiveAt: effectiveAt,
+			PostedAt:    now,
+		}
+		// Entries touching a single merchant are listed under that merchant
+		if len(merchants) == 1 {
+			for m := range merchants {
+				entry.MerchantID = m
+			}
+		}
+		for i, l := range req.Lines {
+			account := accounts[specs[i].Code]
+			entry.Lines = append(entry.Lines, entities.JournalLine{
+				AccountID:   account.ID,
+				DebitCents:  l.DebitCents,
+				CreditCents: l.CreditCents,
+			})
+		}
+		entries := []entities.JournalEntry{entry}
+		if err := s.repo.CreateEntries(ctx, tx, entries); err != nil {
+			return err
+		}
+		result, err = s.repo.FindEntryByID(ctx, tx, entries[0].ID.String())
+		if err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return dto.JournalEntryResponse{}, false, err
+	}
+	return toEntryResponse(result), created, nil
+}
+
+func (s *ledgerService) GetEntry(ctx context.Context, id string) (dto.JournalEntryResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.JournalEntryResponse{}, dto.ErrEntryNotFound
+	}
+	entry, err := s.repo.FindEntryByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.JournalEntryResponse{}, dto.ErrEntryNotFound
+		}
+		return dto.JournalEntryResponse{}, err
+	}
+	return toEntryResponse(entry), nil
+}
+
+func (s *ledgerService) ListEntries(ctx context.Context, req dto.JournalEntryListRequest, q query.Request) ([]dto.JournalEntryResponse, pkgdto.PaginationResponse, error) {
+	filter := repository.EntryFilter{
+		MerchantID: req.MerchantID,
+		Source:     req.Source,
+		SourceRef:  req.SourceRef,
+	}
+	if req.SettlementDate != "" {
+		day, err := time.Parse("2006-01-02", req.SettlementDate)
+		if err != nil {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrInvalidSettlementDate
+		}
+		filter.SettlementDate = &day
+	}
+	entries, meta, err := s.repo.ListEntries(ctx, s.db, filter, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	items := make([]dto.JournalEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, toEntryResponse(e))
+	}
+	return items, meta, nil
+}
+
+func (s *ledgerService) ListAccounts(ctx context.Context, req dto.BalanceRequest) ([]dto.AccountResponse, error) {
+	asOf, err := parseAsOf(req.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.repo.ListAccounts(ctx, s.db, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withBalances(ctx, accounts, asOf)
+}
+
+func (s *ledgerService) GetAccount(ctx context.Context, id string, req dto.BalanceRequest) (dto.AccountResponse, error) {
+	asOf, err := parseAsOf(req.AsOf)
+	if err != nil {
+		return dto.AccountResponse{}, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.AccountResponse{}, dto.ErrAccountNotFound
+	}
+	account, err := s.repo.FindAccountByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.AccountResponse{}, dto.ErrAccountNotFound
+		}
+		return dto.AccountResponse{}, err
+	}
+	resp, err := s.withBalances(ctx, []entities.LedgerAccount{account}, asOf)
+	if err != nil {
+		return dto.AccountResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// MerchantBalance reports a merchant's payable and reserve balances as of a timestamp. Accounts
+// that were never posted to count as zero.
+func (s *ledgerService) MerchantBalance(ctx context.Context, merchantID string, req dto.BalanceRequest) (dto.MerchantBalanceResponse, error) {
+	asOf, err := parseAsOf(req.AsOf)
+	if err != nil {
+		return dto.MerchantBalanceResponse{}, err
+	}
+	accounts, err := s.repo.ListAccounts(ctx, s.db, merchantID)
+	if err != nil {
+		return dto.MerchantBalanceResponse{}, err
+	}
+	balances, err := s.withBalances(ctx, accounts, asOf)
+	if err != nil {
+		return dto.MerchantBalanceResponse{}, err
+	}
+	resp := dto.MerchantBalanceResponse{MerchantID: merchantID, AsOf: asOf}
+	for _, b := range balances {
+		switch b.Type {
+		case constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE:
+			resp.PayableCents = b.BalanceCents
+		case constants.ENUM_LEDGER_ACCOUNT_RESERVE:
+			resp.ReserveCents = b.BalanceCents
+		}
+	}
+	return resp, nil
+}
+
+func (s *ledgerService) withBalances(ctx context.Context, accounts []entities.LedgerAccount, asOf time.Time) ([]dto.AccountResponse, error) {
+	ids := make([]uuid.UUID, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.ID
+	}
+	totals, err := s.repo.Totals(ctx, s.db, ids, asOf)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.AccountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		t := totals[a.ID]
+		resp = append(resp, dto.AccountResponse{
+			ID:            a.ID.String(),
+			Code:          a.Code,
+			Type:          a.Type,
+			MerchantID:    a.MerchantID,
+			NormalBalance: a.NormalBalance,
+			DebitCents:    t.DebitCents,
+			CreditCents:   t.CreditCents,
+			BalanceCents:  balanceOf(a, t.DebitCents, t.CreditCents),
+			AsOf:          asOf,
+		})
+	}
+	return resp, nil
+}
+
+func parseAsOf(v string) (time.Time, error) {
+	if v == "" {
+		return time.Now().UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, dto.ErrInvalidAsOf
+	}
+	return t.UTC(), nil
+}
+
+// linesSignature and requestSignature render lines order-independently so a replayed request
+// can be compared with the recorded entry.
+func linesSignature(e entities.JournalEntry) string {
+	parts := make([]string, 0, len(e.Lines))
+	for _, l := range e.Lines {
+		parts = append(parts, lineKey(l.Account.Code, l.DebitCents, l.CreditCents))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+func requestSignature(req dto.JournalEntryCreateRequest) string {
+	parts := make([]string, 0, len(req.Lines))
+	for _, l := range req.Lines {
+		parts = append(parts, lineKey(accountFor(l.AccountType, l.MerchantID).Code, l.DebitCents, l.CreditCents))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+func lineKey(code string, debit, credit int64) string {
+	return code + "/" + strconv.FormatInt(debit, 10) + "/" + strconv.FormatInt(credit, 10)
+}
+
+func toEntryResponse(e entities.JournalEntry) dto.JournalEntryResponse {
+	resp := dto.JournalEntryResponse{
+		ID:          e.ID.String(),
+		Source:      e.Source,
+		SourceRef:   e.SourceRef,
+		MerchantID:  e.MerchantID,
+		Description: e.Description,
+		EffectiveAt: e.EffectiveAt,
+		PostedAt:    e.PostedAt,
+		Lines:       make([]dto.JournalLineResponse, 0, len(e.Lines)),
+	}
+	if e.ExternalRef != nil {
+		resp.ExternalRef = *e.ExternalRef
+	}
+	if e.SettlementDate != nil {
+		resp.SettlementDate = e.SettlementDate.Format("2006-01-02")
+	}
+	for _, l := range e.Lines {
+		resp.Lines = append(resp.Lines, dto.JournalLineResponse{
+			AccountID:   l.AccountID.String(),
+			AccountCode: l.Account.Code,
+			AccountType: l.Account.Type,
+			MerchantID:  l.Account.MerchantID,
+			DebitCents:  l.DebitCents,
+			CreditCents: l.CreditCents,
+		})
+	}
+	return resp
+}
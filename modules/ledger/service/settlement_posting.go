@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// settlementAccountTypes fixes the line order of settlement entries.
+var settlementAccountTypes = []string{
+	constants.ENUM_LEDGER_ACCOUNT_CLEARING,
+	constants.ENUM_LEDGER_ACCOUNT_FEE_REVENUE,
+	constants.ENUM_LEDGER_ACCOUNT_REFUNDS,
+	constants.ENUM_LEDGER_ACCOUNT_CHARGEBACKS,
+	constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE,
+}
+
+// settlementAmounts is the signed (debit positive) amount a settlement row puts on each account:
+// gross is received into clearing, the fee is earned, refunds and chargebacks become owed to the
+// processor, and the net is owed to the merchant (debited when negative).
+func settlementAmounts(s entities.Settlement) map[string]int64 {
+	return map[string]int64{
+		constants.ENUM_LEDGER_ACCOUNT_CLEARING:         s.GrossCents,
+		constants.ENUM_LEDGER_ACCOUNT_FEE_REVENUE:      -s.FeeCents,
+		constants.ENUM_LEDGER_ACCOUNT_REFUNDS:          -s.RefundCents,
+		constants.ENUM_LEDGER_ACCOUNT_CHARGEBACKS:      -s.ChargebackCents,
+		constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE: -s.NetCents,
+	}
+}
+
+func settlementKey(merchantID string, day time.Time) string {
+	return merchantID + "|" + day.UTC().Format("2006-01-02")
+}
+
+// PostSettlements records final settlement rows in the ledger. Entries are never rewritten: each row
+// posts only the difference to what earlier settlement entries for the same (merchant_id, date)
+// already recorded, so re-running a job posts nothing unless the underlying data changed. Rows the
+// job jobID already posted for are skipped, so a retried job never posts the same day twice.
+// When tx is given the entries commit or roll back together with the caller's settlement rows.
+// Entries become effective at the close of their settlement day.
+func (s *ledgerService) PostSettlements(ctx context.Context, tx *gorm.DB, jobID string, settlements []entities.Settlement) error {
+	if len(settlements) == 0 {
+		return nil
+	}
+	if tx == nil {
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.postSettlements(ctx, tx, jobID, settlements)
+		})
+	}
+	return s.postSettlements(ctx, tx, jobID, settlements)
+}
+
+func (s *ledgerService) postSettlements(ctx context.Context, tx *gorm.DB, jobID string, settlements []entities.Settlement) error {
+	if err := s.repo.LockSettlementPosting(ctx, tx); err != nil {
+		return err
+	}
+
+	merchantSet := make(map[string]struct{})
+	daySet := make(map[time.Time]struct{})
+	for _, st := range settlements {
+		merchantSet[st.MerchantID] = struct{}{}
+		daySet[st.Date.UTC()] = struct{}{}
+	}
+	merchantIDs := make([]string, 0, len(merchantSet))
+	specs := []entities.LedgerAccount{
+		accountFor(constants.ENUM_LEDGER_ACCOUNT_CLEARING, ""),
+		accountFor(constants.ENUM_LEDGER_ACCOUNT_FEE_REVENUE, ""),
+		accountFor(constants.ENUM_LEDGER_ACCOUNT_REFUNDS, ""),
+		accountFor(constants.ENUM_LEDGER_ACCOUNT_CHARGEBACKS, ""),
+	}
+	for m := range merchantSet {
+		merchantIDs = append(merchantIDs, m)
+		specs = append(specs, accountFor(constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE, m))
+	}
+	days := make([]time.Time, 0, len(daySet))
+	for d := range daySet {
+		days = append(days, d)
+	}
+
+	accounts, err := s.repo.EnsureAccounts(ctx, tx, specs)
+	if err != nil {
+		return err
+	}
+	done, err := s.repo.SettlementDaysPostedBy(ctx, tx, jobID, merchantIDs, days)
+	if err != nil {
+		return err
+	}
+	already := make(map[string]struct{}, len(done))
+	for _, d := range done {
+		already[settlementKey(d.MerchantID, d.SettlementDate)] = struct{}{}
+	}
+	posted, err := s.repo.SettlementPostedTotals(ctx, tx, merchantIDs, days)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]map[string]int64, len(posted))
+	for _, p := range posted {
+		key := settlementKey(p.MerchantID, p.SettlementDate)
+		if previous[key] == nil {
+			previous[key] = make(map[string]int64)
+		}
+		previous[key][p.AccountType] = p.NetCents
+	}
+
+	now := time.Now().UTC()
+	entries := make([]entities.JournalEntry, 0, len(settlements))
+	for _, st := range settlements {
+		day := st.Date.UTC()
+		key := settlementKey(st.MerchantID, day)
+		if _, ok := already[key]; ok {
+			continue
+		}
+		want := settlementAmounts(st)
+		prev, corrected := previous[key]
+
+		lines := make([]entities.JournalLine, 0, len(settlementAccountTypes))
+		var sum int64
+		for _, typ := range settlementAccountTypes {
+			delta := want[typ] - prev[typ]
+			if delta == 0 {
+				continue
+			}
+			merchantID := ""
+			if typ == constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE {
+				merchantID = st.MerchantID
+			}
+			lines = append(lines, signedLine(accounts[accountFor(typ, merchantID).Code], delta))
+			sum += delta
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if sum != 0 {
+			return fmt.Errorf("%w: %s off by %d", dto.ErrSettlementUnbalanced, key, sum)
+		}
+
+		description := "settlement " + day.Format("2006-01-02")
+		if corrected {
+			description = "settlement correction " + day.Format("2006-01-02")
+		}
+		d := day
+		entries = append(entries, entities.JournalEntry{
+			Source:         constants.ENUM_JOURNAL_SOURCE_SETTLEMENT,
+			SourceRef:      jobID,
+			MerchantID:     st.MerchantID,
+			SettlementDate: &d,
+			Description:    description,
+			EffectiveAt:    day.AddDate(0, 0, 1),
+			PostedAt:       now,
+			Lines:          lines,
+		})
+	}
+	return s.repo.CreateEntries(ctx, tx, entries)
+}
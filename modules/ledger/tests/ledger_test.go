@@ -0,0 +1,317 @@
+package ledger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerModule "github.com/xkillx/go-gin-order-settlement/modules/ledger"
+	ledgerController "github.com/xkillx/go-gin-order-settlement/modules/ledger/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/dto"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	settlement "github.com/xkillx/go-gin-order-settlement/modules/settlement"
+	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+	t.Cleanup(func() { _ = os.RemoveAll("/tmp/settlements") })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"journal_lines", "journal_entries", "ledger_accounts", "settlements", "jobs", "transaction_adjustments", "transactions"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	ledger := ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db)
+	jobManager := settlementService.NewJobManager(
+		transactionRepo.NewTransactionRepository(db),
+		settlementRepo.NewSettlementRepository(db),
+		ledger,
+		jobRepo.NewJobRepository(db),
+		db,
+	)
+
+	inj := do.New()
+	do.ProvideNamed(inj, constants.DB, func(i *do.Injector) (*gorm.DB, error) { return db, nil })
+	do.Provide(inj, func(i *do.Injector) (*settlementService.JobManager, error) { return jobManager, nil })
+	do.Provide(inj, func(i *do.Injector) (ledgerController.LedgerController, error) {
+		return ledgerController.NewLedgerController(i, ledger), nil
+	})
+
+	engine := gin.New()
+	settlement.RegisterRoutes(engine, inj)
+	ledgerModule.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+func runSettlement(t *testing.T, server *gin.Engine, from, to string) {
+	t.Helper()
+	b, _ := json.Marshal(map[string]string{"from": from, "to": to})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var created map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	jobID, _ := created["job_id"].(string)
+	if jobID == "" {
+		t.Fatalf("start settlement: %d %s", rec.Code, rec.Body.String())
+	}
+
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		grec := httptest.NewRecorder()
+		server.ServeHTTP(grec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		_ = json.Unmarshal(grec.Body.Bytes(), &last)
+		switch last["status"] {
+		case "COMPLETED":
+			return
+		case "FAILED", "CANCELLED":
+			t.Fatalf("settlement job ended %v: %#v", last["status"], last)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("settlement job did not finish: %#v", last)
+}
+
+func merchantBalance(t *testing.T, server *gin.Engine, merchantID, asOf string) dto.MerchantBalanceResponse {
+	t.Helper()
+	url := "/api/ledger/merchants/" + merchantID + "/balance"
+	if asOf != "" {
+		url += "?as_of=" + asOf
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("balance expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data dto.MerchantBalanceResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Data
+}
+
+func listEntries(t *testing.T, server *gin.Engine, query string) []dto.JournalEntryResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ledger/entries?per_page=100&"+query, nil))
+	var resp struct {
+		Data struct {
+			Items []dto.JournalEntryResponse `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Data.Items
+}
+
+func TestSettlementPostsBalancedEntries(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	day := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	txs := []entities.Transaction{
+		{ExternalRef: "l-1", MerchantID: "m-ledger", AmountCents: 10_000, FeeCents: 300, Status: "paid", PaidAt: day},
+		{ExternalRef: "l-2", MerchantID: "m-ledger", AmountCents: 5_000, FeeCents: 150, Status: "paid", PaidAt: day.Add(time.Hour)},
+	}
+	if err := db.Create(&txs).Error; err != nil {
+		t.Fatalf("seed transactions: %v", err)
+	}
+	refund := entities.TransactionAdjustment{
+		TransactionID: txs[0].ID, Type: constants.ENUM_ADJUSTMENT_TYPE_REFUND, ExternalRef: "l-rf-1",
+		MerchantID: "m-ledger", AmountCents: 2_000, IssuedAt: day.Add(2 * time.Hour),
+	}
+	if err := db.Omit("Transaction").Create(&refund).Error; err != nil {
+		t.Fatalf("seed refund: %v", err)
+	}
+
+	runSettlement(t, server, "2024-06-01", "2024-06-05")
+
+	entries := listEntries(t, server, "merchant_id=m-ledger&settlement_date=2024-06-03")
+	if len(entries) != 1 {
+		t.Fatalf("expected one settlement entry, got %#v", entries)
+	}
+	var debits, credits int64
+	for _, l := range entries[0].Lines {
+		debits += l.DebitCents
+		credits += l.CreditCents
+	}
+	if debits != credits || debits != 15_000 {
+		t.Fatalf("expected balanced entry of 15000, got debits %d credits %d", debits, credits)
+	}
+
+	// The day only counts once it has closed
+	if b := merchantBalance(t, server, "m-ledger", "2024-06-03T23:00:00Z"); b.PayableCents != 0 {
+		t.Fatalf("expected no balance before day close, got %#v", b)
+	}
+	// 15000 gross - 450 fee - 2000 refund
+	if b := merchantBalance(t, server, "m-ledger", "2024-06-04T00:00:00Z"); b.PayableCents != 12_550 {
+		t.Fatalf("expected payable 12550, got %#v", b)
+	}
+
+	// Re-running over unchanged data posts nothing new
+	runSettlement(t, server, "2024-06-01", "2024-06-05")
+	if entries := listEntries(t, server, "merchant_id=m-ledger"); len(entries) != 1 {
+		t.Fatalf("expected re-run to post nothing, got %d entries", len(entries))
+	}
+
+	// A late transaction is posted as a correction for the difference only
+	late := entities.Transaction{ExternalRef: "l-3", MerchantID: "m-ledger", AmountCents: 1_000, FeeCents: 30, Status: "paid", PaidAt: day.Add(3 * time.Hour)}
+	if err := db.Create(&late).Error; err != nil {
+		t.Fatalf("seed late transaction: %v", err)
+	}
+	runSettlement(t, server, "2024-06-01", "2024-06-05")
+	entries = listEntries(t, server, "merchant_id=m-ledger")
+	if len(entries) != 2 {
+		t.Fatalf("expected a correction entry, got %#v", entries)
+	}
+	for _, l := range entries[1].Lines {
+		if l.AccountType == constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE && l.CreditCents != 970 {
+			t.Fatalf("expected correction crediting 970 to the merchant, got %#v", entries[1])
+		}
+	}
+	if b := merchantBalance(t, server, "m-ledger", ""); b.PayableCents != 13_520 {
+		t.Fatalf("expected payable 13520, got %#v", b)
+	}
+
+	// Posted entries cannot be changed
+	var stored entities.JournalEntry
+	if err := db.First(&stored).Error; err != nil {
+		t.Fatalf("load entry: %v", err)
+	}
+	if err := db.Model(&stored).Update("description", "edited").Error; !errors.Is(err, entities.ErrJournalImmutable) {
+		t.Fatalf("expected immutable entry, got %v", err)
+	}
+}
+
+func TestManualEntryMovesFundsToReserve(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	post := func(body map[string]any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/ledger/entries", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	entry := func(amount int64) map[string]any {
+		return map[string]any{
+			"external_ref": "reserve-1",
+			"description":  "rolling reserve",
+			"lines": []map[string]any{
+				{"account_type": "merchant_payable", "merchant_id": "m-res", "debit_cents": amount},
+				{"account_type": "reserve", "merchant_id": "m-res", "credit_cents": amount},
+			},
+		}
+	}
+
+	if rec := post(entry(500)); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(entry(500)); rec.Code != http.StatusOK {
+		t.Fatalf("expected replay 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(entry(700)); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a different entry, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	unbalanced := entry(500)
+	unbalanced["external_ref"] = "reserve-2"
+	unbalanced["lines"].([]map[string]any)[1]["credit_cents"] = 400
+	if rec := post(unbalanced); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unbalanced entry, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	b := merchantBalance(t, server, "m-res", "")
+	if b.PayableCents != -500 || b.ReserveCents != 500 {
+		t.Fatalf("expected 500 moved to reserve, got %#v", b)
+	}
+}
+
+func TestSettlementPostingIsKeyedByJob(t *testing.T) {
+	_, db := setupTestServer(t)
+	ctx := context.Background()
+	ledger := ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db)
+
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	row := entities.Settlement{MerchantID: "m-retry", Date: day, GrossCents: 1_000, FeeCents: 30, NetCents: 970, TxnCount: 1}
+	if err := ledger.PostSettlements(ctx, nil, "job-1", []entities.Settlement{row}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	// A retry of the same job posts nothing, even if it aggregated a different total
+	row.GrossCents, row.NetCents = 2_000, 1_970
+	if err := ledger.PostSettlements(ctx, nil, "job-1", []entities.Settlement{row}); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	var count int64
+	db.Model(&entities.JournalEntry{}).Where("merchant_id = ?", "m-retry").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected a retry to post nothing, got %d entries", count)
+	}
+
+	// A rolled back transaction leaves no entries behind
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if err := ledger.PostSettlements(ctx, tx, "job-2", []entities.Settlement{row}); err != nil {
+			t.Fatalf("post in tx: %v", err)
+		}
+		return errors.New("rollback")
+	})
+	db.Model(&entities.JournalEntry{}).Where("merchant_id = ?", "m-retry").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected the rolled back posting to leave nothing, got %d entries", count)
+	}
+}
+
+func TestSettlementPostsOnceAcrossFlushes(t *testing.T) {
+	prev := os.Getenv("BATCH_SIZE")
+	os.Setenv("BATCH_SIZE", "1")
+	t.Cleanup(func() { os.Setenv("BATCH_SIZE", prev) })
+	server, db := setupTestServer(t)
+
+	day := time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)
+	txs := make([]entities.Transaction, 0, 120)
+	for i := 0; i < 120; i++ {
+		txs = append(txs, entities.Transaction{ExternalRef: "flush-" + strconv.Itoa(i), MerchantID: "m-flush", AmountCents: 100, FeeCents: 1, Status: "paid", PaidAt: day.Add(time.Duration(i) * time.Second)})
+	}
+	if err := db.Create(&txs).Error; err != nil {
+		t.Fatalf("seed transactions: %v", err)
+	}
+
+	// With a batch per transaction the job flushes several times; only the final totals are posted
+	runSettlement(t, server, "2024-08-01", "2024-08-02")
+	entries := listEntries(t, server, "merchant_id=m-flush")
+	if len(entries) != 1 {
+		t.Fatalf("expected one settlement entry, got %d", len(entries))
+	}
+	if b := merchantBalance(t, server, "m-flush", ""); b.PayableCents != 11_880 {
+		t.Fatalf("expected payable 11880, got %#v", b)
+	}
+}
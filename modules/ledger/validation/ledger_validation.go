@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/ledger/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// accountScopes maps each account type to whether it is kept per merchant.
+var accountScopes = map[string]bool{
+	constants.ENUM_LEDGER_ACCOUNT_CLEARING:         false,
+	constants.ENUM_LEDGER_ACCOUNT_FEE_REVENUE:      false,
+	constants.ENUM_LEDGER_ACCOUNT_REFUNDS:          false,
+	constants.ENUM_LEDGER_ACCOUNT_CHARGEBACKS:      false,
+	constants.ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE: true,
+	constants.ENUM_LEDGER_ACCOUNT_RESERVE:          true,
+}
+
+type LedgerValidation struct {
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+func NewLedgerValidation() *LedgerValidation {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &LedgerValidation{validate: validate, now: time.Now}
+}
+
+func (v *LedgerValidation) ValidateJournalEntryCreateRequest(req dto.JournalEntryCreateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if len(req.Lines) < 2 {
+		return dto.ErrTooFewLines
+	}
+	if req.EffectiveAt != nil && req.EffectiveAt.After(v.now()) {
+		return dto.ErrEffectiveAtInFuture
+	}
+	var debits, credits int64
+	for i, l := range req.Lines {
+		perMerchant, ok := accountScopes[l.AccountType]
+		if !ok {
+			return fmt.Errorf("line %d: %w: %q", i, dto.ErrUnknownAccountType, l.AccountType)
+		}
+		if perMerchant && l.MerchantID == "" {
+			return fmt.Errorf("line %d: %w", i, dto.ErrMerchantRequired)
+		}
+		if !perMerchant && l.MerchantID != "" {
+			return fmt.Errorf("line %d: %w", i, dto.ErrMerchantNotAllowed)
+		}
+		if l.DebitCents < 0 || l.CreditCents < 0 || (l.DebitCents > 0) == (l.CreditCents > 0) {
+			return fmt.Errorf("line %d: %w", i, dto.ErrLineOneSided)
+		}
+		debits += l.DebitCents
+		credits += l.CreditCents
+	}
+	if debits != credits {
+		return fmt.Errorf("%w (debits %d, credits %d)", dto.ErrEntryUnbalanced, debits, credits)
+	}
+	return nil
+}
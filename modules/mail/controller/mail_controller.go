@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	MailController interface {
+		List(ctx *gin.Context)
+		Get(ctx *gin.Context)
+		Retry(ctx *gin.Context)
+	}
+
+	mailController struct {
+		service service.MailService
+	}
+)
+
+func NewMailController(_ *do.Injector, s service.MailService) MailController {
+	return &mailController{service: s}
+}
+
+func (c *mailController) List(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var req dto.MailListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.List(ctx.Request.Context(), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_MAIL, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_MAIL, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *mailController) Get(ctx *gin.Context) {
+	result, err := c.service.Get(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_MAIL, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_MAIL, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *mailController) Retry(ctx *gin.Context) {
+	result, err := c.service.Retry(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_RETRY_MAIL, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RETRY_MAIL, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrMailNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrMailNotRetryable):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrUnknownMailStatus), errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
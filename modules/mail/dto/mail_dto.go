@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_MAIL       = "failed get mail"
+	MESSAGE_FAILED_GET_LIST_MAIL  = "failed get list mail"
+	MESSAGE_FAILED_RETRY_MAIL     = "failed retry mail"
+	MESSAGE_FAILED_PROSES_REQUEST = "failed proses request"
+
+	// Success
+	MESSAGE_SUCCESS_GET_MAIL      = "success get mail"
+	MESSAGE_SUCCESS_GET_LIST_MAIL = "success get list mail"
+	MESSAGE_SUCCESS_RETRY_MAIL    = "success retry mail"
+)
+
+var (
+	ErrMailNotFound      = errors.New("mail not found")
+	ErrMailNotRetryable  = errors.New("only failed mails can be retried")
+	ErrUnknownMailStatus = errors.New("unknown mail status")
+	ErrNoRecipient       = errors.New("mail has no recipient")
+)
+
+type (
+	MailListRequest struct {
+		Status   string `form:"status"`
+		Template string `form:"template"`
+	}
+
+	MailResponse struct {
+		ID            string     `json:"id"`
+		Template      string     `json:"template"`
+		ToEmail       string     `json:"to_email"`
+		Subject       string     `json:"subject"`
+		Status        string     `json:"status"`
+		Attempts      int        `json:"attempts"`
+		MaxAttempts   int        `json:"max_attempts"`
+		NextAttemptAt time.Time  `json:"next_attempt_at"`
+		LastError     string     `json:"last_error,omitempty"`
+		SentAt        *time.Time `json:"sent_at"`
+		Attachments   []string   `json:"attachments"`
+		CreatedAt     time.Time  `json:"created_at"`
+	}
+)
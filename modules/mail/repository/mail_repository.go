@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MailRepository interface {
+	// Create inserts a message. A message whose DedupeKey is already taken is not inserted again.
+	Create(ctx context.Context, tx *gorm.DB, m entities.MailMessage) (entities.MailMessage, error)
+	FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.MailMessage, error)
+	List(ctx context.Context, tx *gorm.DB, status, template string, q query.Request) ([]entities.MailMessage, pkgdto.PaginationResponse, error)
+	// ClaimDue leases up to limit due messages until leaseUntil and counts the attempt. Messages
+	// whose lease ran out (a dispatcher died mid-send) are claimed again.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.MailMessage, error)
+	MarkSent(ctx context.Context, id string, at time.Time) error
+	MarkRetry(ctx context.Context, id string, next time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, id string, lastErr string) error
+	Requeue(ctx context.Context, tx *gorm.DB, id string, at time.Time) (bool, error)
+}
+
+// mailSchema is what the mail list may be filtered and sorted by; search matches part of the
+// recipient or subject.
+var mailSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":              {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"template":        {Column: "template", Type: query.String, Ops: query.Equality},
+		"to_email":        {Column: "to_email", Type: query.String, Ops: query.Text},
+		"status":          {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"attempts":        {Column: "attempts", Type: query.Int, Ops: query.Range, Sort: true},
+		"next_attempt_at": {Column: "next_attempt_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"created_at":      {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-created_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		pattern := query.Contains(term)
+		return db.Where("to_email ILIKE ? OR subject ILIKE ?", pattern, pattern)
+	},
+}
+
+type mailRepository struct {
+	db *gorm.DB
+}
+
+func NewMailRepository(db *gorm.DB) MailRepository {
+	return &mailRepository{db: db}
+}
+
+func (r *mailRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *mailRepository) Create(ctx context.Context, tx *gorm.DB, m entities.MailMessage) (entities.MailMessage, error) {
+	db := r.getDB(tx).WithContext(ctx)
+	if m.DedupeKey != nil {
+		db = db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dedupe_key"}}, DoNothing: true})
+	}
+	if err := db.Create(&m).Error; err != nil {
+		return entities.MailMessage{}, err
+	}
+	return m, nil
+}
+
+func (r *mailRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.MailMessage, error) {
+	db := r.getDB(tx)
+	var m entities.MailMessage
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&m).Error; err != nil {
+		return entities.MailMessage{}, err
+	}
+	return m, nil
+}
+
+func (r *mailRepository) List(ctx context.Context, tx *gorm.DB, status, template string, q query.Request) ([]entities.MailMessage, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	messages := func() *gorm.DB {
+		m := db.WithContext(ctx).Model(&entities.MailMessage{})
+		if status != "" {
+			m = m.Where("status = ?", status)
+		}
+		if template != "" {
+			m = m.Where("template = ?", template)
+		}
+		return m
+	}
+	return query.Find[entities.MailMessage](messages, q, mailSchema)
+}
+
+func (r *mailRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.MailMessage, error) {
+	var claimed []entities.MailMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several dispatchers share the outbox without sending a message twice
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?",
+				[]string{constants.ENUM_MAIL_STATUS_PENDING, constants.ENUM_MAIL_STATUS_SENDING}, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]string, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID.String()
+			claimed[i].Status = constants.ENUM_MAIL_STATUS_SENDING
+			claimed[i].Attempts++
+			claimed[i].NextAttemptAt = leaseUntil
+		}
+		return tx.Model(&entities.MailMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":          constants.ENUM_MAIL_STATUS_SENDING,
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": leaseUntil,
+				"updated_at":      now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *mailRepository) MarkSent(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.MailMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     constants.ENUM_MAIL_STATUS_SENT,
+			"sent_at":    at,
+			"last_error": "",
+			"updated_at": at,
+		}).Error
+}
+
+func (r *mailRepository) MarkRetry(ctx context.Context, id string, next time.Time, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.MailMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          constants.ENUM_MAIL_STATUS_PENDING,
+			"next_attempt_at": next,
+			"last_error":      lastErr,
+			"updated_at":      time.Now().UTC(),
+		}).Error
+}
+
+func (r *mailRepository) MarkFailed(ctx context.Context, id string, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.MailMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     constants.ENUM_MAIL_STATUS_FAILED,
+			"last_error": lastErr,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// Requeue gives a failed message a fresh set of attempts. It reports whether the message was failed.
+func (r *mailRepository) Requeue(ctx context.Context, tx *gorm.DB, id string, at time.Time) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).
+		Model(&entities.MailMessage{}).
+		Where("id = ? AND status = ?", id, constants.ENUM_MAIL_STATUS_FAILED).
+		Updates(map[string]any{
+			"status":          constants.ENUM_MAIL_STATUS_PENDING,
+			"attempts":        0,
+			"next_attempt_at": at,
+			"updated_at":      at,
+		})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,19 @@
+package mail
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/controller"
+)
+
+// RegisterRoutes exposes the mail outbox so operators can see what was sent and retry failures.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.MailController](injector)
+
+	r := server.Group("/api/mail/outbox")
+	{
+		r.GET("", ctrl.List)
+		r.GET("/:id", ctrl.Get)
+		r.POST("/:id/retry", ctrl.Retry)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+// Dispatcher drains the outbox: it claims due messages, sends them and reschedules failures with
+// exponential backoff until a message runs out of attempts.
+type Dispatcher struct {
+	repo      repository.MailRepository
+	transport Transport
+
+	interval   time.Duration
+	batch      int
+	lease      time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func NewDispatcher(repo repository.MailRepository, transport Transport, interval, backoff time.Duration) *Dispatcher {
+	return &Dispatcher{
+		repo:       repo,
+		transport:  transport,
+		interval:   interval,
+		batch:      20,
+		lease:      5 * time.Minute,
+		backoff:    backoff,
+		maxBackoff: time.Hour,
+	}
+}
+
+// Run dispatches every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	utils.RunEvery(ctx, d.interval, "mail dispatcher", func(ctx context.Context) error {
+		_, err := d.DispatchOnce(ctx)
+		return err
+	})
+}
+
+// DispatchOnce sends every message due now and returns how many were sent.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		now := time.Now().UTC()
+		batch, err := d.repo.ClaimDue(ctx, now, now.Add(d.lease), d.batch)
+		if err != nil {
+			return sent, err
+		}
+		if len(batch) == 0 {
+			return sent, nil
+		}
+		for _, m := range batch {
+			id := m.ID.String()
+			atts, err := decodeAttachments(m.Attachments)
+			if err == nil {
+				err = d.transport.Send(ctx, Message{To: m.ToEmail, Subject: m.Subject, Body: m.Body, Attachments: atts})
+			}
+			switch {
+			case err == nil:
+				if err := d.repo.MarkSent(ctx, id, time.Now().UTC()); err != nil {
+					return sent, err
+				}
+				sent++
+			case m.Attempts >= m.MaxAttempts:
+				if err := d.repo.MarkFailed(ctx, id, err.Error()); err != nil {
+					return sent, err
+				}
+			default:
+				if err := d.repo.MarkRetry(ctx, id, time.Now().UTC().Add(utils.Backoff(d.backoff, d.maxBackoff, m.Attempts)), err.Error()); err != nil {
+					return sent, err
+				}
+			}
+		}
+		if len(batch) < d.batch {
+			return sent, nil
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	eventservice "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// JobNotifier mails the operators in JOB_NOTIFY_EMAILS when a job completes, fails or is
+// cancelled. It subscribes to the job events on the event bus.
+type JobNotifier struct {
+	mail       MailService
+	recipients []string
+}
+
+func NewJobNotifier(mail MailService, recipients []string) *JobNotifier {
+	return &JobNotifier{mail: mail, recipients: recipients}
+}
+
+// JobNotifyRecipients reads the comma separated JOB_NOTIFY_EMAILS.
+func JobNotifyRecipients() []string {
+	var out []string
+	for _, e := range strings.Split(os.Getenv("JOB_NOTIFY_EMAILS"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Subscribe registers the notifier for the job events it mails about.
+func (n *JobNotifier) Subscribe(bus *eventservice.Bus) {
+	for _, t := range []string{constants.ENUM_EVENT_JOB_COMPLETED, constants.ENUM_EVENT_JOB_FAILED, constants.ENUM_EVENT_JOB_CANCELLED} {
+		bus.Subscribe(t, "mail.job_notifier", n.HandleEvent)
+	}
+}
+
+func (n *JobNotifier) HandleEvent(ctx context.Context, ev eventservice.Event) error {
+	var job eventservice.JobEvent
+	if err := ev.Decode(&job); err != nil {
+		return err
+	}
+	var tmpl Template
+	if ev.Type == constants.ENUM_EVENT_JOB_COMPLETED {
+		tmpl = NewJobCompletedMail(job)
+	} else {
+		tmpl = NewJobFailedMail(job)
+	}
+	for _, to := range n.recipients {
+		// Keyed by event so a redelivered event does not mail anyone twice
+		if err := n.mail.EnqueueOnce(ctx, nil, ev.ID+":"+to, to, tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
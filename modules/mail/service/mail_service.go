@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/mail/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxAttempts is how often the dispatcher tries a message before marking it failed.
+const DefaultMaxAttempts = 5
+
+var knownMailStatuses = map[string]struct{}{
+	constants.ENUM_MAIL_STATUS_PENDING: {},
+	constants.ENUM_MAIL_STATUS_SENDING: {},
+	constants.ENUM_MAIL_STATUS_SENT:    {},
+	constants.ENUM_MAIL_STATUS_FAILED:  {},
+}
+
+type MailService interface {
+	// Enqueue renders tmpl and stores it in the outbox inside tx, so the mail is only sent when
+	// the caller's transaction commits. The dispatcher picks it up on its next tick.
+	Enqueue(ctx context.Context, tx *gorm.DB, toEmail string, tmpl Template, attachments ...utils.Attachment) (entities.MailMessage, error)
+	// EnqueueOnce is Enqueue for callers that may run more than once for the same mail: a
+	// second call with the same key is a no-op.
+	EnqueueOnce(ctx context.Context, tx *gorm.DB, key, toEmail string, tmpl Template, attachments ...utils.Attachment) error
+	// SendMail sends right away through the transport, bypassing the outbox. It matches the
+	// signature of utils.SendMail for callers that track delivery themselves.
+	SendMail(toEmail, subject, body string, attachments ...utils.Attachment) error
+	List(ctx context.Context, req dto.MailListRequest, q query.Request) ([]dto.MailResponse, pkgdto.PaginationResponse, error)
+	Get(ctx context.Context, id string) (dto.MailResponse, error)
+	// Retry puts a failed message back in the outbox with a fresh set of attempts.
+	Retry(ctx context.Context, id string) (dto.MailResponse, error)
+}
+
+type mailService struct {
+	repo        repository.MailRepository
+	transport   Transport
+	db          *gorm.DB
+	maxAttempts int
+}
+
+func NewMailService(repo repository.MailRepository, transport Transport, db *gorm.DB, maxAttempts int) MailService {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &mailService{repo: repo, transport: transport, db: db, maxAttempts: maxAttempts}
+}
+
+func (s *mailService) Enqueue(ctx context.Context, tx *gorm.DB, toEmail string, tmpl Template, attachments ...utils.Attachment) (entities.MailMessage, error) {
+	return s.enqueue(ctx, tx, nil, toEmail, tmpl, attachments...)
+}
+
+func (s *mailService) EnqueueOnce(ctx context.Context, tx *gorm.DB, key, toEmail string, tmpl Template, attachments ...utils.Attachment) error {
+	_, err := s.enqueue(ctx, tx, &key, toEmail, tmpl, attachments...)
+	return err
+}
+
+func (s *mailService) enqueue(ctx context.Context, tx *gorm.DB, key *string, toEmail string, tmpl Template, attachments ...utils.Attachment) (entities.MailMessage, error) {
+	toEmail = strings.TrimSpace(toEmail)
+	if toEmail == "" {
+		return entities.MailMessage{}, dto.ErrNoRecipient
+	}
+	body, err := utils.RenderEmailTemplate(tmpl.TemplateName(), tmpl)
+	if err != nil {
+		return entities.MailMessage{}, err
+	}
+	var encoded string
+	if len(attachments) > 0 {
+		raw, err := json.Marshal(attachments)
+		if err != nil {
+			return entities.MailMessage{}, err
+		}
+		encoded = string(raw)
+	}
+	if tx == nil {
+		tx = s.db
+	}
+	return s.repo.Create(ctx, tx, entities.MailMessage{
+		Template:      strings.TrimSuffix(tmpl.TemplateName(), ".html"),
+		ToEmail:       toEmail,
+		Subject:       tmpl.Subject(),
+		Body:          body,
+		Attachments:   encoded,
+		Status:        constants.ENUM_MAIL_STATUS_PENDING,
+		MaxAttempts:   s.maxAttempts,
+		NextAttemptAt: time.Now().UTC(),
+		DedupeKey:     key,
+	})
+}
+
+func (s *mailService) SendMail(toEmail, subject, body string, attachments ...utils.Attachment) error {
+	return s.transport.Send(context.Background(), Message{To: toEmail, Subject: subject, Body: body, Attachments: attachments})
+}
+
+func (s *mailService) List(ctx context.Context, req dto.MailListRequest, q query.Request) ([]dto.MailResponse, pkgdto.PaginationResponse, error) {
+	if req.Status != "" {
+		if _, ok := knownMailStatuses[req.Status]; !ok {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownMailStatus
+		}
+	}
+	items, meta, err := s.repo.List(ctx, s.db, req.Status, req.Template, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	out := make([]dto.MailResponse, len(items))
+	for i := range items {
+		out[i] = toMailResponse(items[i])
+	}
+	return out, meta, nil
+}
+
+func (s *mailService) Get(ctx context.Context, id string) (dto.MailResponse, error) {
+	m, err := s.find(ctx, id)
+	if err != nil {
+		return dto.MailResponse{}, err
+	}
+	return toMailResponse(m), nil
+}
+
+func (s *mailService) Retry(ctx context.Context, id string) (dto.MailResponse, error) {
+	if _, err := s.find(ctx, id); err != nil {
+		return dto.MailResponse{}, err
+	}
+	ok, err := s.repo.Requeue(ctx, s.db, id, time.Now().UTC())
+	if err != nil {
+		return dto.MailResponse{}, err
+	}
+	if !ok {
+		return dto.MailResponse{}, dto.ErrMailNotRetryable
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *mailService) find(ctx context.Context, id string) (entities.MailMessage, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.MailMessage{}, dto.ErrMailNotFound
+	}
+	m, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.MailMessage{}, dto.ErrMailNotFound
+		}
+		return entities.MailMessage{}, err
+	}
+	return m, nil
+}
+
+func decodeAttachments(raw string) ([]utils.Attachment, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var out []utils.Attachment
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func toMailResponse(m entities.MailMessage) dto.MailResponse {
+	names := []string{}
+	if atts, err := decodeAttachments(m.Attachments); err == nil {
+		for _, a := range atts {
+			names = append(names, a.Filename)
+		}
+	}
+	return dto.MailResponse{
+		ID:            m.ID.String(),
+		Template:      m.Template,
+		ToEmail:       m.ToEmail,
+		Subject:       m.Subject,
+		Status:        m.Status,
+		Attempts:      m.Attempts,
+		MaxAttempts:   m.MaxAttempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+		SentAt:        m.SentAt,
+		Attachments:   names,
+		CreatedAt:     m.CreatedAt,
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	eventservice "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+)
+
+// Template is a typed mail. TemplateName names its file in pkg/utils/email-template/ and the
+// value itself is the data the file is executed with.
+type Template interface {
+	TemplateName() string
+	Subject() string
+}
+
+// JobCompletedMail tells operators a background job finished.
+type JobCompletedMail struct {
+	JobID      string
+	Type       string
+	Processed  int64
+	Total      int64
+	ResultPath string
+	StartedAt  string
+	FinishedAt string
+}
+
+func (JobCompletedMail) TemplateName() string { return "job_completed.html" }
+
+func (m JobCompletedMail) Subject() string {
+	return fmt.Sprintf("Job %s completed", m.Type)
+}
+
+// JobFailedMail tells operators a background job failed or was cancelled.
+type JobFailedMail struct {
+	JobID      string
+	Type       string
+	Status     string
+	Error      string
+	Attempts   int
+	Processed  int64
+	Total      int64
+	StartedAt  string
+	FinishedAt string
+}
+
+func (JobFailedMail) TemplateName() string { return "job_failed.html" }
+
+func (m JobFailedMail) Subject() string {
+	return fmt.Sprintf("Job %s %s", m.Type, m.Status)
+}
+
+func NewJobCompletedMail(ev eventservice.JobEvent) JobCompletedMail {
+	return JobCompletedMail{
+		JobID:      ev.JobID,
+		Type:       ev.Type,
+		Processed:  ev.Processed,
+		Total:      ev.Total,
+		ResultPath: ev.ResultPath,
+		StartedAt:  ev.StartedAt.UTC().Format(time.RFC3339),
+		FinishedAt: ev.FinishedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func NewJobFailedMail(ev eventservice.JobEvent) JobFailedMail {
+	return JobFailedMail{
+		JobID:      ev.JobID,
+		Type:       ev.Type,
+		Status:     ev.Status,
+		Error:      ev.Error,
+		Attempts:   ev.Attempts,
+		Processed:  ev.Processed,
+		Total:      ev.Total,
+		StartedAt:  ev.StartedAt.UTC().Format(time.RFC3339),
+		FinishedAt: ev.FinishedAt.UTC().Format(time.RFC3339),
+	}
+}
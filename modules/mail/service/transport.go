@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gopkg.in/gomail.v2"
+)
+
+// Message is a rendered mail handed to a Transport.
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []utils.Attachment
+}
+
+// Transport delivers one message. SMTPTransport is used in production; FileTransport is a local
+// stand-in that writes .eml files and MemoryTransport keeps messages for tests.
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPTransport sends over SMTP. The settings are read from .env on the first send and kept;
+// a failed read is tried again on the next send.
+type SMTPTransport struct {
+	mu  sync.Mutex
+	cfg *config.EmailConfig
+}
+
+func NewSMTPTransport(cfg *config.EmailConfig) *SMTPTransport {
+	return &SMTPTransport{cfg: cfg}
+}
+
+func (t *SMTPTransport) config() (*config.EmailConfig, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cfg == nil {
+		cfg, err := config.NewEmailConfig()
+		if err != nil {
+			return nil, err
+		}
+		t.cfg = cfg
+	}
+	return t.cfg, nil
+}
+
+func (t *SMTPTransport) Send(_ context.Context, msg Message) error {
+	cfg, err := t.config()
+	if err != nil {
+		return err
+	}
+	m := utils.NewMailMessage(cfg.AuthEmail, msg.To, msg.Subject, msg.Body, msg.Attachments...)
+	return gomail.NewDialer(cfg.Host, cfg.Port, cfg.AuthEmail, cfg.AuthPassword).DialAndSend(m)
+}
+
+// FileTransport writes every message as an .eml file into dir, which local mail clients open.
+type FileTransport struct {
+	dir  string
+	from string
+}
+
+func NewFileTransport(dir, from string) *FileTransport {
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	return &FileTransport{dir: dir, from: from}
+}
+
+func (t *FileTransport) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitizeFilename(msg.To))
+	f, err := os.Create(filepath.Join(t.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = utils.NewMailMessage(t.from, msg.To, msg.Subject, msg.Body, msg.Attachments...).WriteTo(f)
+	return err
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// MemoryTransport records messages instead of sending them.
+type MemoryTransport struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(_ context.Context, msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+// Sent returns a copy of the messages sent so far.
+func (t *MemoryTransport) Sent() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.sent...)
+}
+
+// NewTransportFromEnv picks the transport named by MAIL_TRANSPORT: smtp (default), file or memory.
+// The file transport writes into MAIL_FILE_DIR, /tmp/mail by default.
+func NewTransportFromEnv() (Transport, error) {
+	switch strings.ToLower(os.Getenv("MAIL_TRANSPORT")) {
+	case "", "smtp":
+		return NewSMTPTransport(nil), nil
+	case "file":
+		dir := os.Getenv("MAIL_FILE_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "mail")
+		}
+		return NewFileTransport(dir, os.Getenv("SMTP_AUTH_EMAIL")), nil
+	case "memory":
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q", os.Getenv("MAIL_TRANSPORT"))
+	}
+}
@@ -0,0 +1,243 @@
+package mail_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	eventservice "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	mailModule "github.com/xkillx/go-gin-order-settlement/modules/mail"
+	mailController "github.com/xkillx/go-gin-order-settlement/modules/mail/controller"
+	mailRepo "github.com/xkillx/go-gin-order-settlement/modules/mail/repository"
+	mailService "github.com/xkillx/go-gin-order-settlement/modules/mail/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// flakyTransport fails while down is set and records what it delivered.
+type flakyTransport struct {
+	mu   sync.Mutex
+	down bool
+	sent []mailService.Message
+}
+
+func (t *flakyTransport) Send(_ context.Context, msg mailService.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.down {
+		return errors.New("smtp: connection refused")
+	}
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+func (t *flakyTransport) setDown(down bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.down = down
+}
+
+func (t *flakyTransport) sentTo(to string) []mailService.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []mailService.Message
+	for _, m := range t.sent {
+		if m.To == to {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+type testEnv struct {
+	server     *gin.Engine
+	db         *gorm.DB
+	service    mailService.MailService
+	dispatcher *mailService.Dispatcher
+}
+
+func setupTestServer(t *testing.T, transport mailService.Transport, maxAttempts int) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if err := db.Exec("DELETE FROM mail_outbox").Error; err != nil {
+		t.Fatalf("failed to truncate mail_outbox: %v", err)
+	}
+
+	repo := mailRepo.NewMailRepository(db)
+	svc := mailService.NewMailService(repo, transport, db, maxAttempts)
+	// No backoff so every DispatchOnce retries right away
+	dispatcher := mailService.NewDispatcher(repo, transport, time.Hour, 0)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (mailController.MailController, error) {
+		return mailController.NewMailController(i, svc), nil
+	})
+	engine := gin.New()
+	mailModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, db: db, service: svc, dispatcher: dispatcher}
+}
+
+func getMail(t *testing.T, server *gin.Engine, id string) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/mail/outbox/"+id, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get mail expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Data
+}
+
+func failedJob() eventservice.JobEvent {
+	return eventservice.JobEvent{
+		JobID:    "mail-test-job",
+		Type:     "settlement",
+		Status:   jobservice.StatusFailed,
+		Error:    "settlement: database unavailable",
+		Attempts: 2,
+		Total:    10,
+	}
+}
+
+func jobEvent(t *testing.T, id, eventType string, job eventservice.JobEvent) eventservice.Event {
+	t.Helper()
+	raw, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return eventservice.Event{ID: id, Type: eventType, Payload: raw, OccurredAt: time.Now()}
+}
+
+func TestOutboxRetriesUntilMaxAttemptsAndCanBeRequeued(t *testing.T) {
+	transport := &flakyTransport{down: true}
+	env := setupTestServer(t, transport, 3)
+	ctx := context.Background()
+
+	msg, err := env.service.Enqueue(ctx, nil, "ops@example.com", mailService.NewJobFailedMail(failedJob()),
+		utils.Attachment{Filename: "report.csv", ContentType: "text/csv", Data: []byte("a,b\n")})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := env.dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+	}
+	got := getMail(t, env.server, msg.ID.String())
+	if got["status"] != "failed" || got["attempts"].(float64) != 3 {
+		t.Fatalf("expected failed after 3 attempts, got %#v", got)
+	}
+	if !strings.Contains(got["last_error"].(string), "connection refused") {
+		t.Fatalf("expected the transport error to be kept, got %#v", got["last_error"])
+	}
+
+	transport.setDown(false)
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/mail/outbox/"+msg.ID.String()+"/retry", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("retry expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if sent, err := env.dispatcher.DispatchOnce(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected the requeued mail to be sent, got %d, %v", sent, err)
+	}
+	if got := getMail(t, env.server, msg.ID.String()); got["status"] != "sent" || got["sent_at"] == nil {
+		t.Fatalf("expected sent, got %#v", got)
+	}
+	delivered := transport.sentTo("ops@example.com")
+	if len(delivered) != 1 || len(delivered[0].Attachments) != 1 || string(delivered[0].Attachments[0].Data) != "a,b\n" {
+		t.Fatalf("expected one delivery with its attachment, got %#v", delivered)
+	}
+	if !strings.Contains(delivered[0].Body, "database unavailable") || delivered[0].Subject != "Job settlement FAILED" {
+		t.Fatalf("expected the job_failed template, got %q: %s", delivered[0].Subject, delivered[0].Body)
+	}
+
+	// A sent mail cannot be retried
+	rec = httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/mail/outbox/"+msg.ID.String()+"/retry", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("retry of a sent mail expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestJobNotifierQueuesMailOncePerRecipient(t *testing.T) {
+	transport := mailService.NewMemoryTransport()
+	env := setupTestServer(t, transport, 3)
+	bus := eventservice.NewBus()
+	mailService.NewJobNotifier(env.service, []string{"a@example.com", "b@example.com"}).Subscribe(bus)
+
+	completed := failedJob()
+	completed.Status = jobservice.StatusCompleted
+	completed.Processed = 10
+	ev := jobEvent(t, "6f1c7a40-8a43-4c1e-9a39-2f4f1f7e0d11", "job.completed", completed)
+	// The relay delivers at least once; the second publish must not queue more mail
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(context.Background(), ev); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	// Events the notifier did not subscribe to are ignored
+	if err := bus.Publish(context.Background(), jobEvent(t, "0b7f0c4e-31a8-4d8e-a1c2-5a1f0c9e2b33", "order.created", completed)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/mail/outbox?status=pending&template=job_completed", nil))
+	var list struct {
+		Data struct {
+			Items []map[string]any `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data.Items) != 2 {
+		t.Fatalf("expected two pending job_completed mails, got %s", rec.Body.String())
+	}
+
+	if sent, err := env.dispatcher.DispatchOnce(context.Background()); err != nil || sent != 2 {
+		t.Fatalf("expected 2 mails sent, got %d, %v", sent, err)
+	}
+	for _, m := range transport.Sent() {
+		if m.Subject != "Job settlement completed" || !strings.Contains(m.Body, "10 / 10") {
+			t.Fatalf("unexpected mail %q: %s", m.Subject, m.Body)
+		}
+	}
+}
+
+func TestFileTransportWritesEML(t *testing.T) {
+	dir := t.TempDir()
+	transport := mailService.NewFileTransport(dir, "no-reply@example.com")
+	if err := transport.Send(context.Background(), mailService.Message{To: "ops@example.com", Subject: "Hello", Body: "<p>hi</p>"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+	raw, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(raw), "Subject: Hello") || !strings.Contains(string(raw), "To: ops@example.com") {
+		t.Fatalf("unexpected eml:\n%s", raw)
+	}
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	MerchantController interface {
+		Create(ctx *gin.Context)
+		GetByID(ctx *gin.Context)
+		List(ctx *gin.Context)
+		Update(ctx *gin.Context)
+		Delete(ctx *gin.Context)
+		CreateAPIKey(ctx *gin.Context)
+		ListAPIKeys(ctx *gin.Context)
+		RevokeAPIKey(ctx *gin.Context)
+	}
+
+	merchantController struct {
+		service   service.MerchantService
+		validator *validation.MerchantValidation
+	}
+)
+
+func NewMerchantController(_ *do.Injector, s service.MerchantService) MerchantController {
+	return &merchantController{
+		service:   s,
+		validator: validation.NewMerchantValidation(),
+	}
+}
+
+func (c *merchantController) Create(ctx *gin.Context) {
+	var req dto.MerchantCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateMerchantCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_MERCHANT, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_MERCHANT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_MERCHANT, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *merchantController) GetByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+	result, err := c.service.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_MERCHANT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_MERCHANT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *merchantController) List(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.List(ctx.Request.Context(), ctx.Query("status"), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_MERCHANT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_MERCHANT, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *merchantController) Update(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var req dto.MerchantUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateMerchantUpdateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_MERCHANT, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Update(ctx.Request.Context(), id, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_MERCHANT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_MERCHANT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *merchantController) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_MERCHANT, err.Error(), nil)
+		ctx.AbortWithStatusJSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_MERCHANT, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *merchantController) CreateAPIKey(ctx *gin.Context) {
+	result, err := c.service.CreateAPIKey(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_API_KEY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_API_KEY, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *merchantController) ListAPIKeys(ctx *gin.Context) {
+	result, err := c.service.ListAPIKeys(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_API_KEYS, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_API_KEYS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *merchantController) RevokeAPIKey(ctx *gin.Context) {
+	if err := c.service.RevokeAPIKey(ctx.Request.Context(), ctx.Param("id"), ctx.Param("key_id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REVOKE_API_KEY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_API_KEY, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrMerchantNotFound), errors.Is(err, dto.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrMerchantExists), errors.Is(err, dto.ErrMerchantInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	// StatementController serves merchant-facing settlement statements. Routes must sit behind
+	// middlewares.MerchantAuthentication, which sets the authenticated merchant.
+	StatementController interface {
+		Settlements(ctx *gin.Context)
+		SettlementDay(ctx *gin.Context)
+	}
+
+	statementController struct {
+		service service.StatementService
+	}
+)
+
+func NewStatementController(_ *do.Injector, s service.StatementService) StatementController {
+	return &statementController{service: s}
+}
+
+func (c *statementController) Settlements(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	var req dto.StatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Settlements(ctx.Request.Context(), ctx.GetString(constants.CTX_MERCHANT_ID), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SETTLEMENTS, err.Error(), nil)
+		ctx.JSON(statementErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SETTLEMENTS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *statementController) SettlementDay(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.SettlementDay(ctx.Request.Context(), ctx.GetString(constants.CTX_MERCHANT_ID), ctx.Param("date"), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SETTLEMENT_DAY, err.Error(), nil)
+		ctx.JSON(statementErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SETTLEMENT_DAY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func statementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrInvalidDate), errors.Is(err, dto.ErrInvalidDateRange), errors.Is(err, dto.ErrDateRangeTooLong),
+		errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,180 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY  = "failed get data from body"
+	MESSAGE_FAILED_CREATE_MERCHANT     = "failed create merchant"
+	MESSAGE_FAILED_GET_MERCHANT        = "failed get merchant"
+	MESSAGE_FAILED_GET_LIST_MERCHANT   = "failed get list merchant"
+	MESSAGE_FAILED_UPDATE_MERCHANT     = "failed update merchant"
+	MESSAGE_FAILED_DELETE_MERCHANT     = "failed delete merchant"
+	MESSAGE_FAILED_PROSES_REQUEST      = "failed proses request"
+	MESSAGE_FAILED_VALIDATION_MERCHANT = "Validation failed"
+	MESSAGE_FAILED_CREATE_API_KEY      = "failed create api key"
+	MESSAGE_FAILED_GET_API_KEYS        = "failed get api keys"
+	MESSAGE_FAILED_REVOKE_API_KEY      = "failed revoke api key"
+	MESSAGE_FAILED_GET_SETTLEMENTS     = "failed get settlements"
+	MESSAGE_FAILED_GET_SETTLEMENT_DAY  = "failed get settlement transactions"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_MERCHANT    = "success create merchant"
+	MESSAGE_SUCCESS_GET_MERCHANT       = "success get merchant"
+	MESSAGE_SUCCESS_GET_LIST_MERCHANT  = "success get list merchant"
+	MESSAGE_SUCCESS_UPDATE_MERCHANT    = "success update merchant"
+	MESSAGE_SUCCESS_DELETE_MERCHANT    = "success delete merchant"
+	MESSAGE_SUCCESS_CREATE_API_KEY     = "success create api key"
+	MESSAGE_SUCCESS_GET_API_KEYS       = "success get api keys"
+	MESSAGE_SUCCESS_REVOKE_API_KEY     = "success revoke api key"
+	MESSAGE_SUCCESS_GET_SETTLEMENTS    = "success get settlements"
+	MESSAGE_SUCCESS_GET_SETTLEMENT_DAY = "success get settlement transactions"
+
+	// MaxStatementDays caps the range of a settlement statement request.
+	MaxStatementDays = 366
+)
+
+var (
+	ErrMerchantNotFound    = errors.New("merchant not found")
+	ErrMerchantExists      = errors.New("merchant id already exists")
+	ErrMerchantInUse       = errors.New("merchant has transactions and cannot be deleted")
+	ErrFailedEncryptPayout = errors.New("failed to encrypt payout bank details")
+	ErrFailedDecryptPayout = errors.New("failed to decrypt payout bank details")
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidDate         = errors.New("dates must be formatted as YYYY-MM-DD")
+	ErrInvalidDateRange    = errors.New("'to' must be on or after 'from'")
+	ErrDateRangeTooLong    = errors.New("date range must not exceed 366 days")
+)
+
+type (
+	// PayoutBankDetails is the plaintext form of a merchant's payout account.
+	PayoutBankDetails struct {
+		BankName      string `json:"bank_name" binding:"required"`
+		AccountHolder string `json:"account_holder" binding:"required"`
+		AccountNumber string `json:"account_number" binding:"required,min=4,max=34"`
+		RoutingCode   string `json:"routing_code" binding:"omitempty,max=34"`
+	}
+
+	MerchantCreateRequest struct {
+		// ID is the merchant_id used on transactions; generated when empty
+		ID                 string             `json:"id" binding:"omitempty,min=1,max=64"`
+		LegalName          string             `json:"legal_name" binding:"required,min=2"`
+		Status             string             `json:"status" binding:"omitempty,oneof=active suspended"`
+		SettlementSchedule string             `json:"settlement_schedule" binding:"omitempty,oneof=daily weekly monthly"`
+		ContactEmail       string             `json:"contact_email" binding:"required,email"`
+		PayoutBank         *PayoutBankDetails `json:"payout_bank"`
+	}
+
+	MerchantUpdateRequest struct {
+		LegalName          string             `json:"legal_name" binding:"omitempty,min=2"`
+		Status             string             `json:"status" binding:"omitempty,oneof=active suspended"`
+		SettlementSchedule string             `json:"settlement_schedule" binding:"omitempty,oneof=daily weekly monthly"`
+		ContactEmail       string             `json:"contact_email" binding:"omitempty,email"`
+		PayoutBank         *PayoutBankDetails `json:"payout_bank"`
+	}
+
+	// PayoutBankResponse shows payout details with the account number masked.
+	PayoutBankResponse struct {
+		BankName      string `json:"bank_name"`
+		AccountHolder string `json:"account_holder"`
+		AccountNumber string `json:"account_number"`
+		RoutingCode   string `json:"routing_code,omitempty"`
+	}
+
+	MerchantResponse struct {
+		ID                 string              `json:"id"`
+		LegalName          string              `json:"legal_name"`
+		Status             string              `json:"status"`
+		SettlementSchedule string              `json:"settlement_schedule"`
+		ContactEmail       string              `json:"contact_email"`
+		PayoutBank         *PayoutBankResponse `json:"payout_bank,omitempty"`
+		// Error is set on list rows whose payout details could not be decrypted
+		Error string `json:"error,omitempty"`
+	}
+
+	// APIKeyResponse describes a key without its secret.
+	APIKeyResponse struct {
+		ID         string     `json:"id"`
+		Prefix     string     `json:"prefix"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	// APIKeyCreateResponse carries the plaintext key; it is only returned once.
+	APIKeyCreateResponse struct {
+		APIKeyResponse
+		Key string `json:"key"`
+	}
+
+	// StatementRequest selects settlement days; both dates are inclusive YYYY-MM-DD and default
+	// to the last 30 days.
+	StatementRequest struct {
+		From string `form:"from"`
+		To   string `form:"to"`
+	}
+
+	SettlementRowResponse struct {
+		Date            string `json:"date"`
+		GrossCents      int64  `json:"gross_cents"`
+		FeeCents        int64  `json:"fee_cents"`
+		RefundCents     int64  `json:"refund_cents"`
+		ChargebackCents int64  `json:"chargeback_cents"`
+		NetCents        int64  `json:"net_cents"`
+		TxnCount        int64  `json:"txn_count"`
+	}
+
+	// SettlementTotalsResponse sums every day in the requested range, not only the current page.
+	SettlementTotalsResponse struct {
+		Days            int64 `json:"days"`
+		GrossCents      int64 `json:"gross_cents"`
+		FeeCents        int64 `json:"fee_cents"`
+		RefundCents     int64 `json:"refund_cents"`
+		ChargebackCents int64 `json:"chargeback_cents"`
+		NetCents        int64 `json:"net_cents"`
+		TxnCount        int64 `json:"txn_count"`
+	}
+
+	StatementResponse struct {
+		MerchantID string                    `json:"merchant_id"`
+		From       string                    `json:"from"`
+		To         string                    `json:"to"`
+		Items      []SettlementRowResponse   `json:"items"`
+		Totals     SettlementTotalsResponse  `json:"totals"`
+		Pagination pkgdto.PaginationResponse `json:"pagination"`
+	}
+
+	StatementTransactionResponse struct {
+		ID          string    `json:"id"`
+		ExternalRef string    `json:"external_ref"`
+		AmountCents int64     `json:"amount_cents"`
+		FeeCents    int64     `json:"fee_cents"`
+		Status      string    `json:"status"`
+		PaidAt      time.Time `json:"paid_at"`
+	}
+
+	StatementAdjustmentResponse struct {
+		ID            string    `json:"id"`
+		TransactionID string    `json:"transaction_id"`
+		Type          string    `json:"type"`
+		ExternalRef   string    `json:"external_ref"`
+		AmountCents   int64     `json:"amount_cents"`
+		IssuedAt      time.Time `json:"issued_at"`
+	}
+
+	// SettlementDayResponse is the drill-down of one settlement day. Settlement is nil when the
+	// day has not been settled yet.
+	SettlementDayResponse struct {
+		MerchantID   string                         `json:"merchant_id"`
+		Date         string                         `json:"date"`
+		Settlement   *SettlementRowResponse         `json:"settlement"`
+		Transactions []StatementTransactionResponse `json:"transactions"`
+		Adjustments  []StatementAdjustmentResponse  `json:"adjustments"`
+		Pagination   pkgdto.PaginationResponse      `json:"pagination"`
+	}
+)
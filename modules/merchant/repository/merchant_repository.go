@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	MerchantRepository interface {
+		Create(ctx context.Context, tx *gorm.DB, m entities.Merchant) (entities.Merchant, error)
+		FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Merchant, error)
+		List(ctx context.Context, tx *gorm.DB, status string, q query.Request) ([]entities.Merchant, pkgdto.PaginationResponse, error)
+		Update(ctx context.Context, tx *gorm.DB, m entities.Merchant) (entities.Merchant, error)
+		Delete(ctx context.Context, tx *gorm.DB, id string) error
+		HasTransactions(ctx context.Context, tx *gorm.DB, id string) (bool, error)
+
+		CreateAPIKey(ctx context.Context, tx *gorm.DB, k entities.MerchantAPIKey) (entities.MerchantAPIKey, error)
+		ListAPIKeys(ctx context.Context, tx *gorm.DB, merchantID string) ([]entities.MerchantAPIKey, error)
+		RevokeAPIKey(ctx context.Context, tx *gorm.DB, merchantID, keyID string) (bool, error)
+		FindActiveAPIKey(ctx context.Context, tx *gorm.DB, keyHash string) (entities.MerchantAPIKey, error)
+		TouchAPIKey(ctx context.Context, tx *gorm.DB, keyID string, at time.Time) error
+	}
+
+	merchantRepository struct {
+		db *gorm.DB
+	}
+)
+
+// merchantSchema is what the merchant list may be filtered and sorted by; search matches part of
+// the id or legal name.
+var merchantSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":                  {Column: "id", Type: query.String, Ops: query.Text, Sort: true},
+		"legal_name":          {Column: "legal_name", Type: query.String, Ops: query.Text, Sort: true},
+		"status":              {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"settlement_schedule": {Column: "settlement_schedule", Type: query.String, Ops: query.Equality},
+		"contact_email":       {Column: "contact_email", Type: query.String, Ops: query.Text},
+		"created_at":          {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"updated_at":          {Column: "updated_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-created_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		pattern := query.Contains(term)
+		return db.Where("id ILIKE ? OR legal_name ILIKE ?", pattern, pattern)
+	},
+}
+
+func NewMerchantRepository(db *gorm.DB) MerchantRepository {
+	return &merchantRepository{db: db}
+}
+
+func (r *merchantRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *merchantRepository) Create(ctx context.Context, tx *gorm.DB, m entities.Merchant) (entities.Merchant, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&m).Error; err != nil {
+		return entities.Merchant{}, err
+	}
+	return m, nil
+}
+
+func (r *merchantRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Merchant, error) {
+	db := r.getDB(tx)
+	var m entities.Merchant
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&m).Error; err != nil {
+		return entities.Merchant{}, err
+	}
+	return m, nil
+}
+
+func (r *merchantRepository) List(ctx context.Context, tx *gorm.DB, status string, q query.Request) ([]entities.Merchant, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	merchants := func() *gorm.DB {
+		m := db.WithContext(ctx).Model(&entities.Merchant{})
+		if status != "" {
+			m = m.Where("status = ?", status)
+		}
+		return m
+	}
+	return query.Find[entities.Merchant](merchants, q, merchantSchema)
+}
+
+func (r *merchantRepository) Update(ctx context.Context, tx *gorm.DB, m entities.Merchant) (entities.Merchant, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Clauses(clause.Returning{}).Updates(&m).Error; err != nil {
+		return entities.Merchant{}, err
+	}
+	return m, nil
+}
+
+func (r *merchantRepository) Delete(ctx context.Context, tx *gorm.DB, id string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Delete(&entities.Merchant{}, "id = ?", id).Error
+}
+
+func (r *merchantRepository) HasTransactions(ctx context.Context, tx *gorm.DB, id string) (bool, error) {
+	db := r.getDB(tx)
+	var exists bool
+	err := db.WithContext(ctx).
+		Raw("SELECT EXISTS (SELECT 1 FROM transactions WHERE merchant_id = ?)", id).
+		Scan(&exists).Error
+	return exists, err
+}
+
+func (r *merchantRepository) CreateAPIKey(ctx context.Context, tx *gorm.DB, k entities.MerchantAPIKey) (entities.MerchantAPIKey, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&k).Error; err != nil {
+		return entities.MerchantAPIKey{}, err
+	}
+	return k, nil
+}
+
+func (r *merchantRepository) ListAPIKeys(ctx context.Context, tx *gorm.DB, merchantID string) ([]entities.MerchantAPIKey, error) {
+	db := r.getDB(tx)
+	var keys []entities.MerchantAPIKey
+	if err := db.WithContext(ctx).
+		Where("merchant_id = ?", merchantID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey reports whether an active key of the merchant was revoked.
+func (r *merchantRepository) RevokeAPIKey(ctx context.Context, tx *gorm.DB, merchantID, keyID string) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).
+		Model(&entities.MerchantAPIKey{}).
+		Where("id = ? AND merchant_id = ? AND revoked_at IS NULL", keyID, merchantID).
+		Update("revoked_at", time.Now().UTC())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *merchantRepository) FindActiveAPIKey(ctx context.Context, tx *gorm.DB, keyHash string) (entities.MerchantAPIKey, error) {
+	db := r.getDB(tx)
+	var k entities.MerchantAPIKey
+	if err := db.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", keyHash).
+		Take(&k).Error; err != nil {
+		return entities.MerchantAPIKey{}, err
+	}
+	return k, nil
+}
+
+func (r *merchantRepository) TouchAPIKey(ctx context.Context, tx *gorm.DB, keyID string, at time.Time) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).
+		Model(&entities.MerchantAPIKey{}).
+		Where("id = ?", keyID).
+		UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type (
+	// StatementRepository reads a merchant's settlements and the rows behind them.
+	StatementRepository interface {
+		ListSettlements(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time, q query.Request) ([]entities.Settlement, pkgdto.PaginationResponse, error)
+		SettlementTotals(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time) (SettlementTotals, error)
+		FindSettlement(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time) (entities.Settlement, error)
+		ListDayTransactions(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time, q query.Request) ([]entities.Transaction, pkgdto.PaginationResponse, error)
+		ListDayAdjustments(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time) ([]entities.TransactionAdjustment, error)
+	}
+
+	// SettlementTotals sums settlement rows over a date range.
+	SettlementTotals struct {
+		Days            int64
+		GrossCents      int64
+		FeeCents        int64
+		RefundCents     int64
+		ChargebackCents int64
+		NetCents        int64
+		TxnCount        int64
+	}
+
+	statementRepository struct {
+		db *gorm.DB
+	}
+)
+
+// Statement lists are a single merchant's, so its settlement days are keyed by date alone.
+var (
+	statementSettlementSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"date":             {Column: "date", Type: query.Date, Ops: query.Range, Sort: true},
+			"gross_cents":      {Column: "gross_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"fee_cents":        {Column: "fee_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"refund_cents":     {Column: "refund_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"chargeback_cents": {Column: "chargeback_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"net_cents":        {Column: "net_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"txn_count":        {Column: "txn_count", Type: query.Int, Ops: query.Range, Sort: true},
+		},
+		Keys:        []string{"date"},
+		DefaultSort: "-date",
+	}
+
+	statementTransactionSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":           {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"external_ref": {Column: "external_ref", Type: query.String, Ops: query.Text},
+			"amount_cents": {Column: "amount_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"fee_cents":    {Column: "fee_cents", Type: query.Int, Ops: query.Range, Sort: true},
+			"status":       {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+			"paid_at":      {Column: "paid_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "paid_at",
+		Search: func(db *gorm.DB, term string) *gorm.DB {
+			return db.Where("external_ref ILIKE ?", query.Contains(term))
+		},
+	}
+)
+
+func NewStatementRepository(db *gorm.DB) StatementRepository {
+	return &statementRepository{db: db}
+}
+
+func (r *statementRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+// settlementRange selects a merchant's settlement days in [from, to).
+func settlementRange(db *gorm.DB, merchantID string, from, to time.Time) *gorm.DB {
+	return db.Model(&entities.Settlement{}).
+		Where("merchant_id = ? AND date >= ? AND date < ?", merchantID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+func (r *statementRepository) ListSettlements(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time, q query.Request) ([]entities.Settlement, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx).WithContext(ctx)
+	return query.Find[entities.Settlement](func() *gorm.DB {
+		return settlementRange(db, merchantID, from, to)
+	}, q, statementSettlementSchema)
+}
+
+func (r *statementRepository) SettlementTotals(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time) (SettlementTotals, error) {
+	db := r.getDB(tx).WithContext(ctx)
+	var totals SettlementTotals
+	err := settlementRange(db, merchantID, from, to).
+		Select(`COUNT(*) AS days,
+			COALESCE(SUM(gross_cents), 0) AS gross_cents,
+			COALESCE(SUM(fee_cents), 0) AS fee_cents,
+			COALESCE(SUM(refund_cents), 0) AS refund_cents,
+			COALESCE(SUM(chargeback_cents), 0) AS chargeback_cents,
+			COALESCE(SUM(net_cents), 0) AS net_cents,
+			COALESCE(SUM(txn_count), 0) AS txn_count`).
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *statementRepository) FindSettlement(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time) (entities.Settlement, error) {
+	db := r.getDB(tx)
+	var s entities.Settlement
+	if err := db.WithContext(ctx).
+		Where("merchant_id = ? AND date = ?", merchantID, day.Format("2006-01-02")).
+		Take(&s).Error; err != nil {
+		return entities.Settlement{}, err
+	}
+	return s, nil
+}
+
+// ListDayTransactions returns the settleable transactions paid on the UTC day, the same rows the settlement job sums.
+func (r *statementRepository) ListDayTransactions(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time, q query.Request) ([]entities.Transaction, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx).WithContext(ctx)
+	return query.Find[entities.Transaction](func() *gorm.DB {
+		return db.Model(&entities.Transaction{}).
+			Scopes(entities.SettleableTransactions).
+			Where("merchant_id = ? AND paid_at >= ? AND paid_at < ?", merchantID, day, day.AddDate(0, 0, 1))
+	}, q, statementTransactionSchema)
+}
+
+// ListDayAdjustments returns the refunds and chargebacks issued on the UTC day.
+func (r *statementRepository) ListDayAdjustments(ctx context.Context, tx *gorm.DB, merchantID string, day time.Time) ([]entities.TransactionAdjustment, error) {
+	db := r.getDB(tx)
+	var items []entities.TransactionAdjustment
+	if err := db.WithContext(ctx).
+		Where("merchant_id = ? AND issued_at >= ? AND issued_at < ?", merchantID, day, day.AddDate(0, 0, 1)).
+		Order("issued_at ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,48 @@
+package merchant
+
+import (
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/middlewares"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/controller"
+)
+
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.MerchantController](injector)
+	statements := do.MustInvoke[controller.StatementController](injector)
+	auth := do.MustInvoke[middlewares.MerchantAuthenticator](injector)
+
+	admin := middlewares.AdminAuthentication(os.Getenv("ADMIN_API_TOKEN"))
+
+	r := server.Group("/api/merchants")
+	{
+		r.GET("", ctrl.List)
+		r.GET("/:id", ctrl.GetByID)
+	}
+
+	// Writes change payout details and settlement status, so like API keys they are operator
+	// actions guarded by ADMIN_API_TOKEN
+	w := server.Group("/api/merchants", admin)
+	{
+		w.POST("", ctrl.Create)
+		w.PUT("/:id", ctrl.Update)
+		w.DELETE("/:id", ctrl.Delete)
+	}
+
+	// Minting and revoking API keys is an operator action, guarded by ADMIN_API_TOKEN
+	a := server.Group("/api/merchants/:id/api-keys", admin)
+	{
+		a.GET("", ctrl.ListAPIKeys)
+		a.POST("", ctrl.CreateAPIKey)
+		a.DELETE("/:key_id", ctrl.RevokeAPIKey)
+	}
+
+	// Merchant-facing statements are authenticated with the merchant's own API key
+	m := server.Group("/api/merchants/:id", middlewares.MerchantAuthentication(auth))
+	{
+		m.GET("/settlements", statements.Settlements)
+		m.GET("/settlements/:date/transactions", statements.SettlementDay)
+	}
+}
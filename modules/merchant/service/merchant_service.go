@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/middlewares"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type MerchantService interface {
+	Create(ctx context.Context, req dto.MerchantCreateRequest) (dto.MerchantResponse, error)
+	GetByID(ctx context.Context, id string) (dto.MerchantResponse, error)
+	List(ctx context.Context, status string, q query.Request) ([]dto.MerchantResponse, pkgdto.PaginationResponse, error)
+	Update(ctx context.Context, id string, req dto.MerchantUpdateRequest) (dto.MerchantResponse, error)
+	Delete(ctx context.Context, id string) error
+
+	CreateAPIKey(ctx context.Context, merchantID string) (dto.APIKeyCreateResponse, error)
+	ListAPIKeys(ctx context.Context, merchantID string) ([]dto.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, merchantID, keyID string) error
+	// AuthenticateAPIKey resolves a plaintext key to the merchant it belongs to.
+	AuthenticateAPIKey(ctx context.Context, key string) (string, error)
+}
+
+// apiKeyPrefix marks merchant keys so they are recognisable in logs and secret scanners.
+const apiKeyPrefix = "mk_"
+
+type merchantService struct {
+	repo repository.MerchantRepository
+	db   *gorm.DB
+}
+
+func NewMerchantService(repo repository.MerchantRepository, db *gorm.DB) MerchantService {
+	return &merchantService{repo: repo, db: db}
+}
+
+func (s *merchantService) Create(ctx context.Context, req dto.MerchantCreateRequest) (dto.MerchantResponse, error) {
+	m := entities.Merchant{
+		ID:                 req.ID,
+		LegalName:          req.LegalName,
+		Status:             req.Status,
+		SettlementSchedule: req.SettlementSchedule,
+		ContactEmail:       req.ContactEmail,
+	}
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.Status == "" {
+		m.Status = constants.ENUM_MERCHANT_STATUS_ACTIVE
+	}
+	if m.SettlementSchedule == "" {
+		m.SettlementSchedule = constants.ENUM_SETTLEMENT_SCHEDULE_DAILY
+	}
+	if req.PayoutBank != nil {
+		enc, err := encryptPayout(*req.PayoutBank)
+		if err != nil {
+			return dto.MerchantResponse{}, err
+		}
+		m.PayoutBankEncrypted = enc
+	}
+
+	if _, err := s.repo.FindByID(ctx, s.db, m.ID); err == nil {
+		return dto.MerchantResponse{}, dto.ErrMerchantExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.MerchantResponse{}, err
+	}
+	created, err := s.repo.Create(ctx, s.db, m)
+	if err != nil {
+		return dto.MerchantResponse{}, err
+	}
+	return toResponse(created)
+}
+
+func (s *merchantService) GetByID(ctx context.Context, id string) (dto.MerchantResponse, error) {
+	m, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.MerchantResponse{}, dto.ErrMerchantNotFound
+		}
+		return dto.MerchantResponse{}, err
+	}
+	return toResponse(m)
+}
+
+func (s *merchantService) List(ctx context.Context, status string, q query.Request) ([]dto.MerchantResponse, pkgdto.PaginationResponse, error) {
+	items, meta, err := s.repo.List(ctx, s.db, status, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.MerchantResponse, 0, len(items))
+	for _, it := range items {
+		r, err := toResponse(it)
+		if err != nil {
+			// One unreadable row must not hide the rest of the page
+			r = dto.MerchantResponse{
+				ID:                 it.ID,
+				LegalName:          it.LegalName,
+				Status:             it.Status,
+				SettlementSchedule: it.SettlementSchedule,
+				ContactEmail:       it.ContactEmail,
+				Error:              err.Error(),
+			}
+		}
+		resp = append(resp, r)
+	}
+	return resp, meta, nil
+}
+
+func (s *merchantService) Update(ctx context.Context, id string, req dto.MerchantUpdateRequest) (dto.MerchantResponse, error) {
+	m, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.MerchantResponse{}, dto.ErrMerchantNotFound
+		}
+		return dto.MerchantResponse{}, err
+	}
+	if req.LegalName != "" {
+		m.LegalName = req.LegalName
+	}
+	if req.Status != "" {
+		m.Status = req.Status
+	}
+	if req.SettlementSchedule != "" {
+		m.SettlementSchedule = req.SettlementSchedule
+	}
+	if req.ContactEmail != "" {
+		m.ContactEmail = req.ContactEmail
+	}
+	if req.PayoutBank != nil {
+		enc, err := encryptPayout(*req.PayoutBank)
+		if err != nil {
+			return dto.MerchantResponse{}, err
+		}
+		m.PayoutBankEncrypted = enc
+	}
+	updated, err := s.repo.Update(ctx, s.db, m)
+	if err != nil {
+		return dto.MerchantResponse{}, err
+	}
+	return toResponse(updated)
+}
+
+// Delete removes a merchant that never transacted; merchants with history are suspended instead.
+func (s *merchantService) Delete(ctx context.Context, id string) error {
+	if _, err := s.repo.FindByID(ctx, s.db, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrMerchantNotFound
+		}
+		return err
+	}
+	used, err := s.repo.HasTransactions(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if used {
+		return dto.ErrMerchantInUse
+	}
+	return s.repo.Delete(ctx, s.db, id)
+}
+
+// CreateAPIKey issues a new key for the merchant. Earlier keys stay valid until revoked so
+// merchants can rotate without downtime.
+func (s *merchantService) CreateAPIKey(ctx context.Context, merchantID string) (dto.APIKeyCreateResponse, error) {
+	if _, err := s.repo.FindByID(ctx, s.db, merchantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.APIKeyCreateResponse{}, dto.ErrMerchantNotFound
+		}
+		return dto.APIKeyCreateResponse{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return dto.APIKeyCreateResponse{}, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	created, err := s.repo.CreateAPIKey(ctx, s.db, entities.MerchantAPIKey{
+		MerchantID: merchantID,
+		Prefix:     key[:len(apiKeyPrefix)+8],
+		KeyHash:    hashAPIKey(key),
+	})
+	if err != nil {
+		return dto.APIKeyCreateResponse{}, err
+	}
+	return dto.APIKeyCreateResponse{APIKeyResponse: toAPIKeyResponse(created), Key: key}, nil
+}
+
+func (s *merchantService) ListAPIKeys(ctx context.Context, merchantID string) ([]dto.APIKeyResponse, error) {
+	if _, err := s.repo.FindByID(ctx, s.db, merchantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	keys, err := s.repo.ListAPIKeys(ctx, s.db, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, toAPIKeyResponse(k))
+	}
+	return resp, nil
+}
+
+func (s *merchantService) RevokeAPIKey(ctx context.Context, merchantID, keyID string) error {
+	if _, err := uuid.Parse(keyID); err != nil {
+		return dto.ErrAPIKeyNotFound
+	}
+	revoked, err := s.repo.RevokeAPIKey(ctx, s.db, merchantID, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return dto.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *merchantService) AuthenticateAPIKey(ctx context.Context, key string) (string, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", middlewares.ErrInvalidAPIKey
+	}
+	k, err := s.repo.FindActiveAPIKey(ctx, s.db, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", middlewares.ErrInvalidAPIKey
+		}
+		return "", err
+	}
+	// Usage tracking is best effort and must not fail the request
+	_ = s.repo.TouchAPIKey(ctx, s.db, k.ID.String(), time.Now().UTC())
+	return k.MerchantID, nil
+}
+
+// hashAPIKey stores keys as SHA-256; they carry 256 bits of entropy so a slow hash adds nothing.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPIKeyResponse(k entities.MerchantAPIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         k.ID.String(),
+		Prefix:     k.Prefix,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func encryptPayout(details dto.PayoutBankDetails) (string, error) {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return "", err
+	}
+	enc, err := utils.AESEncrypt(string(raw))
+	if err != nil {
+		return "", dto.ErrFailedEncryptPayout
+	}
+	return enc, nil
+}
+
+func toResponse(m entities.Merchant) (dto.MerchantResponse, error) {
+	resp := dto.MerchantResponse{
+		ID:                 m.ID,
+		LegalName:          m.LegalName,
+		Status:             m.Status,
+		SettlementSchedule: m.SettlementSchedule,
+		ContactEmail:       m.ContactEmail,
+	}
+	if m.PayoutBankEncrypted == "" {
+		return resp, nil
+	}
+	plain, err := utils.AESDecrypt(m.PayoutBankEncrypted)
+	if err != nil {
+		return dto.MerchantResponse{}, dto.ErrFailedDecryptPayout
+	}
+	var details dto.PayoutBankDetails
+	if err := json.Unmarshal([]byte(plain), &details); err != nil {
+		return dto.MerchantResponse{}, dto.ErrFailedDecryptPayout
+	}
+	resp.PayoutBank = &dto.PayoutBankResponse{
+		BankName:      details.BankName,
+		AccountHolder: details.AccountHolder,
+		AccountNumber: maskAccountNumber(details.AccountNumber),
+		RoutingCode:   details.RoutingCode,
+	}
+	return resp, nil
+}
+
+// maskAccountNumber keeps only the last four digits.
+func maskAccountNumber(n string) string {
+	if len(n) <= 4 {
+		return n
+	}
+	return strings.Repeat("*", len(n)-4) + n[len(n)-4:]
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// defaultStatementDays is the window used when the request has no 'from'
+	defaultStatementDays = 30
+)
+
+// StatementService serves a merchant's settlement statements. Callers are responsible for
+// scoping merchantID to the authenticated merchant.
+type StatementService interface {
+	Settlements(ctx context.Context, merchantID string, req dto.StatementRequest, q query.Request) (dto.StatementResponse, error)
+	SettlementDay(ctx context.Context, merchantID, date string, q query.Request) (dto.SettlementDayResponse, error)
+}
+
+type statementService struct {
+	repo repository.StatementRepository
+	db   *gorm.DB
+}
+
+func NewStatementService(repo repository.StatementRepository, db *gorm.DB) StatementService {
+	return &statementService{repo: repo, db: db}
+}
+
+func (s *statementService) Settlements(ctx context.Context, merchantID string, req dto.StatementRequest, q query.Request) (dto.StatementResponse, error) {
+	from, to, err := statementRange(req, time.Now().UTC())
+	if err != nil {
+		return dto.StatementResponse{}, err
+	}
+	// 'to' is inclusive, the repository takes [from, to)
+	end := to.AddDate(0, 0, 1)
+
+	items, meta, err := s.repo.ListSettlements(ctx, s.db, merchantID, from, end, q)
+	if err != nil {
+		return dto.StatementResponse{}, err
+	}
+	totals, err := s.repo.SettlementTotals(ctx, s.db, merchantID, from, end)
+	if err != nil {
+		return dto.StatementResponse{}, err
+	}
+
+	rows := make([]dto.SettlementRowResponse, 0, len(items))
+	for _, it := range items {
+		rows = append(rows, toSettlementRow(it))
+	}
+	return dto.StatementResponse{
+		MerchantID: merchantID,
+		From:       from.Format(dateLayout),
+		To:         to.Format(dateLayout),
+		Items:      rows,
+		Totals: dto.SettlementTotalsResponse{
+			Days:            totals.Days,
+			GrossCents:      totals.GrossCents,
+			FeeCents:        totals.FeeCents,
+			RefundCents:     totals.RefundCents,
+			ChargebackCents: totals.ChargebackCents,
+			NetCents:        totals.NetCents,
+			TxnCount:        totals.TxnCount,
+		},
+		Pagination: meta,
+	}, nil
+}
+
+func (s *statementService) SettlementDay(ctx context.Context, merchantID, date string, q query.Request) (dto.SettlementDayResponse, error) {
+	day, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return dto.SettlementDayResponse{}, dto.ErrInvalidDate
+	}
+
+	resp := dto.SettlementDayResponse{MerchantID: merchantID, Date: day.Format(dateLayout)}
+	settlement, err := s.repo.FindSettlement(ctx, s.db, merchantID, day)
+	switch {
+	case err == nil:
+		row := toSettlementRow(settlement)
+		resp.Settlement = &row
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return dto.SettlementDayResponse{}, err
+	}
+
+	txs, meta, err := s.repo.ListDayTransactions(ctx, s.db, merchantID, day, q)
+	if err != nil {
+		return dto.SettlementDayResponse{}, err
+	}
+	adjustments, err := s.repo.ListDayAdjustments(ctx, s.db, merchantID, day)
+	if err != nil {
+		return dto.SettlementDayResponse{}, err
+	}
+
+	resp.Transactions = make([]dto.StatementTransactionResponse, 0, len(txs))
+	for _, t := range txs {
+		resp.Transactions = append(resp.Transactions, dto.StatementTransactionResponse{
+			ID:          t.ID.String(),
+			ExternalRef: t.ExternalRef,
+			AmountCents: t.AmountCents,
+			FeeCents:    t.FeeCents,
+			Status:      t.Status,
+			PaidAt:      t.PaidAt,
+		})
+	}
+	resp.Adjustments = make([]dto.StatementAdjustmentResponse, 0, len(adjustments))
+	for _, a := range adjustments {
+		resp.Adjustments = append(resp.Adjustments, dto.StatementAdjustmentResponse{
+			ID:            a.ID.String(),
+			TransactionID: a.TransactionID.String(),
+			Type:          a.Type,
+			ExternalRef:   a.ExternalRef,
+			AmountCents:   a.AmountCents,
+			IssuedAt:      a.IssuedAt,
+		})
+	}
+	resp.Pagination = meta
+	return resp, nil
+}
+
+// statementRange parses the inclusive request dates, defaulting to the 30 days ending today.
+func statementRange(req dto.StatementRequest, now time.Time) (time.Time, time.Time, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if req.To != "" {
+		t, err := time.Parse(dateLayout, req.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, dto.ErrInvalidDate
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(defaultStatementDays - 1))
+	if req.From != "" {
+		f, err := time.Parse(dateLayout, req.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, dto.ErrInvalidDate
+		}
+		from = f
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, dto.ErrInvalidDateRange
+	}
+	if to.Sub(from) >= dto.MaxStatementDays*24*time.Hour {
+		return time.Time{}, time.Time{}, dto.ErrDateRangeTooLong
+	}
+	return from, to, nil
+}
+
+func toSettlementRow(s entities.Settlement) dto.SettlementRowResponse {
+	return dto.SettlementRowResponse{
+		Date:            s.Date.Format(dateLayout),
+		GrossCents:      s.GrossCents,
+		FeeCents:        s.FeeCents,
+		RefundCents:     s.RefundCents,
+		ChargebackCents: s.ChargebackCents,
+		NetCents:        s.NetCents,
+		TxnCount:        s.TxnCount,
+	}
+}
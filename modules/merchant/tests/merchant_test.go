@@ -0,0 +1,222 @@
+package merchant_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/middlewares"
+	merchantModule "github.com/xkillx/go-gin-order-settlement/modules/merchant"
+	merchantController "github.com/xkillx/go-gin-order-settlement/modules/merchant/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	merchantRepo "github.com/xkillx/go-gin-order-settlement/modules/merchant/repository"
+	merchantService "github.com/xkillx/go-gin-order-settlement/modules/merchant/service"
+	"gorm.io/gorm"
+)
+
+const adminToken = "test-admin-token"
+
+func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	t.Setenv("ADMIN_API_TOKEN", adminToken)
+	t.Setenv("AES_KEY", "8e71bbce7451ba2835de5aea73e4f3f96821455240823d2fd8174975b8321bfc")
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := db.AutoMigrate(&entities.Merchant{}, &entities.MerchantAPIKey{}, &entities.Transaction{},
+		&entities.TransactionAdjustment{}, &entities.Settlement{}); err != nil {
+		t.Fatalf("automigrate failed: %v", err)
+	}
+	for _, table := range []string{"merchant_api_keys", "settlements", "transaction_adjustments", "transactions"} {
+		if err := db.Exec("DELETE FROM " + table + " WHERE merchant_id LIKE 'mt-%'").Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+	if err := db.Exec("DELETE FROM merchants WHERE id LIKE 'mt-%'").Error; err != nil {
+		t.Fatalf("failed to truncate merchants: %v", err)
+	}
+
+	svc := merchantService.NewMerchantService(merchantRepo.NewMerchantRepository(db), db)
+	statements := merchantService.NewStatementService(merchantRepo.NewStatementRepository(db), db)
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (merchantController.MerchantController, error) {
+		return merchantController.NewMerchantController(i, svc), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (merchantController.StatementController, error) {
+		return merchantController.NewStatementController(i, statements), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (middlewares.MerchantAuthenticator, error) {
+		return svc, nil
+	})
+
+	engine := gin.New()
+	merchantModule.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+func send(t *testing.T, server *gin.Engine, method, path string, body any) (*httptest.ResponseRecorder, dto.MerchantResponse) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Token", adminToken)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var resp struct {
+		Data dto.MerchantResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp.Data
+}
+
+func TestMerchantPayoutDetailsAreEncrypted(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	rec, created := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"id":            "mt-acme",
+		"legal_name":    "Acme Trading Ltd",
+		"contact_email": "finance@acme.test",
+		"payout_bank": map[string]any{
+			"bank_name":      "First Bank",
+			"account_holder": "Acme Trading Ltd",
+			"account_number": "12345678901",
+		},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if created.Status != "active" || created.SettlementSchedule != "daily" {
+		t.Fatalf("expected active daily defaults, got %#v", created)
+	}
+	if created.PayoutBank == nil || created.PayoutBank.AccountNumber != "*******8901" {
+		t.Fatalf("expected masked account number, got %#v", created.PayoutBank)
+	}
+
+	var stored entities.Merchant
+	if err := db.Where("id = ?", "mt-acme").Take(&stored).Error; err != nil {
+		t.Fatalf("load merchant: %v", err)
+	}
+	if stored.PayoutBankEncrypted == "" || strings.Contains(stored.PayoutBankEncrypted, "12345678901") {
+		t.Fatalf("expected payout details to be stored encrypted, got %q", stored.PayoutBankEncrypted)
+	}
+
+	// A row that no longer decrypts is flagged on its own; the rest of the page still lists
+	if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"id": "mt-broken", "legal_name": "Broken Ltd", "contact_email": "finance@broken.test",
+		"payout_bank": map[string]any{"bank_name": "First Bank", "account_holder": "Broken Ltd", "account_number": "99990000"},
+	}); rec.Code != http.StatusCreated {
+		t.Fatalf("create expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := db.Model(&entities.Merchant{}).Where("id = ?", "mt-broken").Update("payout_bank_encrypted", "00ff").Error; err != nil {
+		t.Fatalf("corrupt payout details: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/merchants?per_page=100", nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var list struct {
+		Data struct {
+			Items []dto.MerchantResponse `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	got := map[string]dto.MerchantResponse{}
+	for _, m := range list.Data.Items {
+		got[m.ID] = m
+	}
+	if rec.Code != http.StatusOK || got["mt-acme"].PayoutBank == nil || got["mt-acme"].Error != "" {
+		t.Fatalf("list expected 200 with mt-acme intact, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if broken, ok := got["mt-broken"]; !ok || broken.PayoutBank != nil || broken.Error == "" {
+		t.Fatalf("expected mt-broken listed with an error, got %+v", broken)
+	}
+
+	if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"id": "mt-acme", "legal_name": "Acme Again", "contact_email": "a@acme.test",
+	}); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate id expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"legal_name": "No Email", "contact_email": "not-an-email",
+	}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid email expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMerchantSuspendAndDelete(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	for _, id := range []string{"mt-busy", "mt-idle"} {
+		if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+			"id": id, "legal_name": "Merchant " + id, "contact_email": id + "@example.com",
+		}); rec.Code != http.StatusCreated {
+			t.Fatalf("create %s expected 201, got %d: %s", id, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec, updated := send(t, server, http.MethodPut, "/api/merchants/mt-busy", map[string]any{"status": "suspended"})
+	if rec.Code != http.StatusOK || updated.Status != "suspended" {
+		t.Fatalf("suspend expected 200 suspended, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tx := entities.Transaction{ExternalRef: "mt-busy-1", MerchantID: "mt-busy", AmountCents: 100, Status: "paid", PaidAt: time.Now().UTC()}
+	if err := db.Create(&tx).Error; err != nil {
+		t.Fatalf("seed transaction: %v", err)
+	}
+	if rec, _ := send(t, server, http.MethodDelete, "/api/merchants/mt-busy", nil); rec.Code != http.StatusConflict {
+		t.Fatalf("delete with transactions expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := send(t, server, http.MethodDelete, "/api/merchants/mt-idle", nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := send(t, server, http.MethodGet, "/api/merchants/mt-idle", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("deleted merchant expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMerchantWritesRequireAdminToken(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"id": "mt-guarded", "legal_name": "Guarded Ltd", "contact_email": "finance@guarded.test",
+	}); rec.Code != http.StatusCreated {
+		t.Fatalf("create expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, tt := range []struct {
+		method, path, token string
+		body                any
+	}{
+		{http.MethodPost, "/api/merchants", "", map[string]any{"id": "mt-anon", "legal_name": "Anon", "contact_email": "a@anon.test"}},
+		{http.MethodPut, "/api/merchants/mt-guarded", "", map[string]any{"status": "suspended"}},
+		{http.MethodPut, "/api/merchants/mt-guarded", "wrong-token", map[string]any{
+			"payout_bank": map[string]any{"bank_name": "Other Bank", "account_holder": "Mallory", "account_number": "55550000"},
+		}},
+		{http.MethodDelete, "/api/merchants/mt-guarded", "", nil},
+	} {
+		b, _ := json.Marshal(tt.body)
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.token != "" {
+			req.Header.Set("X-Admin-Token", tt.token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s without the admin token expected 401, got %d: %s", tt.method, tt.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec, got := send(t, server, http.MethodGet, "/api/merchants/mt-guarded", nil); rec.Code != http.StatusOK || got.Status != "active" {
+		t.Fatalf("the merchant should be unchanged, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
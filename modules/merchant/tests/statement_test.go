@@ -0,0 +1,149 @@
+package merchant_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+	"gorm.io/gorm"
+)
+
+// issueKey registers a merchant and returns a fresh API key for it.
+func issueKey(t *testing.T, server *gin.Engine, id string) string {
+	t.Helper()
+	if rec, _ := send(t, server, http.MethodPost, "/api/merchants", map[string]any{
+		"id": id, "legal_name": "Merchant " + id, "contact_email": id + "@example.com",
+	}); rec.Code != http.StatusCreated {
+		t.Fatalf("create %s expected 201, got %d: %s", id, rec.Code, rec.Body.String())
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/merchants/"+id+"/api-keys", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("create api key without the admin token expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/merchants/"+id+"/api-keys", nil)
+	req.Header.Set("X-Admin-Token", adminToken)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var resp struct {
+		Data dto.APIKeyCreateResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusCreated || resp.Data.Key == "" {
+		t.Fatalf("create api key expected 201 with key, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return resp.Data.Key
+}
+
+func getAs(server *gin.Engine, key, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func seedSettlementDays(t *testing.T, db *gorm.DB, merchantID string, days ...time.Time) {
+	t.Helper()
+	for i, day := range days {
+		s := entities.Settlement{MerchantID: merchantID, Date: day, GrossCents: 1_000, FeeCents: 30, NetCents: 970, TxnCount: 1}
+		if err := db.Create(&s).Error; err != nil {
+			t.Fatalf("seed settlement: %v", err)
+		}
+		tx := entities.Transaction{
+			ExternalRef: merchantID + "-" + day.Format("20060102") + "-" + string(rune('a'+i)),
+			MerchantID:  merchantID, AmountCents: 1_000, FeeCents: 30, Status: "paid", PaidAt: day.Add(10 * time.Hour),
+		}
+		if err := db.Create(&tx).Error; err != nil {
+			t.Fatalf("seed transaction: %v", err)
+		}
+		// A failed attempt on the same day, which was never settled and must not be listed
+		failed := entities.Transaction{
+			ExternalRef: tx.ExternalRef + "-failed",
+			MerchantID:  merchantID, AmountCents: 4_000, FeeCents: 120, Status: "failed", PaidAt: day.Add(11 * time.Hour),
+		}
+		if err := db.Create(&failed).Error; err != nil {
+			t.Fatalf("seed transaction: %v", err)
+		}
+	}
+}
+
+func TestMerchantStatementsAreScopedToTheirKey(t *testing.T) {
+	server, db := setupTestServer(t)
+	key := issueKey(t, server, "mt-alpha")
+	otherKey := issueKey(t, server, "mt-beta")
+
+	d1 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	seedSettlementDays(t, db, "mt-alpha", d1, d2)
+	seedSettlementDays(t, db, "mt-beta", d1)
+
+	path := "/api/merchants/mt-alpha/settlements?from=2024-03-01&to=2024-03-02&per_page=1"
+	if rec := getAs(server, "", path); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing key expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := getAs(server, "mk_not-a-key", path); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown key expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := getAs(server, otherKey, path); rec.Code != http.StatusForbidden {
+		t.Fatalf("another merchant's key expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := getAs(server, key, path)
+	var resp struct {
+		Data dto.StatementResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("statement expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Data.Items) != 1 || resp.Data.Items[0].Date != "2024-03-02" || resp.Data.Pagination.Count != 2 {
+		t.Fatalf("expected newest day on a page of 1 out of 2, got %#v", resp.Data)
+	}
+	if resp.Data.Totals.Days != 2 || resp.Data.Totals.NetCents != 1_940 || resp.Data.Totals.TxnCount != 2 {
+		t.Fatalf("totals should cover the whole range, got %#v", resp.Data.Totals)
+	}
+
+	rec = getAs(server, key, "/api/merchants/mt-alpha/settlements/2024-03-01/transactions")
+	var day struct {
+		Data dto.SettlementDayResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &day)
+	if rec.Code != http.StatusOK || day.Data.Settlement == nil || len(day.Data.Transactions) != 1 {
+		t.Fatalf("drill-down expected the day's settlement and transaction, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := getAs(server, key, "/api/merchants/mt-alpha/settlements/2024-13-01/transactions"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid date expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRevokedMerchantKeyIsRejected(t *testing.T) {
+	server, _ := setupTestServer(t)
+	key := issueKey(t, server, "mt-gamma")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/merchants/mt-gamma/api-keys", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var list struct {
+		Data []dto.APIKeyResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data) != 1 {
+		t.Fatalf("expected one key, got %s", rec.Body.String())
+	}
+
+	if rec, _ := send(t, server, http.MethodDelete, "/api/merchants/mt-gamma/api-keys/"+list.Data[0].ID, nil); rec.Code != http.StatusOK {
+		t.Fatalf("revoke expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := getAs(server, key, "/api/merchants/mt-gamma/settlements"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked key expected 401, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
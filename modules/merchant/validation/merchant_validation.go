@@ -0,0 +1,25 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/merchant/dto"
+)
+
+type MerchantValidation struct {
+	validate *validator.Validate
+}
+
+func NewMerchantValidation() *MerchantValidation {
+	// Reuse the gin binding tags so nested payout details get the same checks
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &MerchantValidation{validate: validate}
+}
+
+func (v *MerchantValidation) ValidateMerchantCreateRequest(req dto.MerchantCreateRequest) error {
+	return v.validate.Struct(req)
+}
+
+func (v *MerchantValidation) ValidateMerchantUpdateRequest(req dto.MerchantUpdateRequest) error {
+	return v.validate.Struct(req)
+}
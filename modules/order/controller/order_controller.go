@@ -7,7 +7,8 @@ import (
     "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
     "github.com/xkillx/go-gin-order-settlement/modules/order/service"
     "github.com/xkillx/go-gin-order-settlement/modules/order/validation"
-    pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+    "github.com/xkillx/go-gin-order-settlement/pkg/constants"
+    "github.com/xkillx/go-gin-order-settlement/pkg/query"
     "github.com/xkillx/go-gin-order-settlement/pkg/utils"
     "github.com/gin-gonic/gin"
     "github.com/samber/do"
@@ -19,6 +20,12 @@ type (
 		GetByID(ctx *gin.Context)
 		List(ctx *gin.Context)
 		Delete(ctx *gin.Context)
+		Pay(ctx *gin.Context)
+		Fulfill(ctx *gin.Context)
+		Complete(ctx *gin.Context)
+		Cancel(ctx *gin.Context)
+		Refund(ctx *gin.Context)
+		History(ctx *gin.Context)
 	}
 
 	orderController struct {
@@ -41,6 +48,7 @@ func (c *orderController) Create(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
+	req.IdempotencyKey = ctx.GetHeader(dto.IdempotencyKeyHeader)
 
 	if err := c.validate.ValidateOrderCreateRequest(req); err != nil {
 		res := utils.BuildResponseFailed("Validation failed", err.Error(), nil)
@@ -50,12 +58,22 @@ func (c *orderController) Create(ctx *gin.Context) {
 
 	result, err := c.service.Create(ctx.Request.Context(), req)
 	if err != nil {
-		// Conflict when insufficient stock
-		if errors.Is(err, dto.ErrInsufficientStock) {
+		// Conflict when insufficient stock, in total or at the warehouses the rule allows, or the product is archived
+		if errors.Is(err, dto.ErrInsufficientStock) || errors.Is(err, dto.ErrAllocationFailed) || errors.Is(err, dto.ErrProductArchived) {
 			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
 			ctx.JSON(http.StatusConflict, res)
 			return
 		}
+		if errors.Is(err, dto.ErrProductNotFound) {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+		if errors.Is(err, dto.ErrIdempotencyKeyReused) {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
+			ctx.JSON(http.StatusUnprocessableEntity, res)
+			return
+		}
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
 		return
@@ -79,15 +97,26 @@ func (c *orderController) GetByID(ctx *gin.Context) {
 }
 
 func (c *orderController) List(ctx *gin.Context) {
-	var p pkgdto.PaginationRequest
-	if err := ctx.ShouldBindQuery(&p); err != nil {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	var filter dto.OrderListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	if err := c.validate.ValidateOrderListRequest(filter); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
-	p.Default()
 
-	items, meta, err := c.service.List(ctx.Request.Context(), p)
+	items, meta, err := c.service.List(ctx.Request.Context(), filter, q)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_ORDER, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
@@ -106,10 +135,75 @@ func (c *orderController) Delete(ctx *gin.Context) {
 	id := ctx.Param("id")
 	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_ORDER, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		ctx.AbortWithStatusJSON(transitionErrorStatus(err), res)
 		return
 	}
 
 	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_ORDER, nil)
 	ctx.JSON(http.StatusOK, res)
 }
+
+func (c *orderController) Pay(ctx *gin.Context) {
+	c.transition(ctx, constants.ENUM_ORDER_STATUS_PAID)
+}
+
+func (c *orderController) Fulfill(ctx *gin.Context) {
+	c.transition(ctx, constants.ENUM_ORDER_STATUS_FULFILLED)
+}
+
+func (c *orderController) Complete(ctx *gin.Context) {
+	c.transition(ctx, constants.ENUM_ORDER_STATUS_COMPLETED)
+}
+
+func (c *orderController) Cancel(ctx *gin.Context) {
+	c.transition(ctx, constants.ENUM_ORDER_STATUS_CANCELLED)
+}
+
+func (c *orderController) Refund(ctx *gin.Context) {
+	c.transition(ctx, constants.ENUM_ORDER_STATUS_REFUNDED)
+}
+
+// transition applies one status action; the reason body is optional.
+func (c *orderController) transition(ctx *gin.Context, status string) {
+	var req dto.OrderTransitionRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBind(&req); err != nil {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+	}
+
+	result, err := c.service.Transition(ctx.Request.Context(), ctx.Param("id"), status, req.Reason)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_ORDER, err.Error(), nil)
+		ctx.JSON(transitionErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_ORDER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *orderController) History(ctx *gin.Context) {
+	result, err := c.service.History(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ORDER_HISTORY, err.Error(), nil)
+		ctx.JSON(transitionErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ORDER_HISTORY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func transitionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrInvalidTransition), errors.Is(err, dto.ErrOrderNotDeletable):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 const (
 	// Failed
@@ -9,31 +12,133 @@ const (
 	MESSAGE_FAILED_GET_ORDER          = "failed get order"
 	MESSAGE_FAILED_GET_LIST_ORDER     = "failed get list order"
 	MESSAGE_FAILED_DELETE_ORDER       = "failed delete order"
+	MESSAGE_FAILED_UPDATE_ORDER       = "failed update order status"
+	MESSAGE_FAILED_GET_ORDER_HISTORY  = "failed get order history"
 	MESSAGE_FAILED_PROSES_REQUEST     = "failed proses request"
 
 	// Success
-	MESSAGE_SUCCESS_CREATE_ORDER   = "success create order"
-	MESSAGE_SUCCESS_GET_ORDER      = "success get order"
-	MESSAGE_SUCCESS_GET_LIST_ORDER = "success get list order"
-	MESSAGE_SUCCESS_DELETE_ORDER   = "success delete order"
+	MESSAGE_SUCCESS_CREATE_ORDER      = "success create order"
+	MESSAGE_SUCCESS_GET_ORDER         = "success get order"
+	MESSAGE_SUCCESS_GET_LIST_ORDER    = "success get list order"
+	MESSAGE_SUCCESS_DELETE_ORDER      = "success delete order"
+	MESSAGE_SUCCESS_UPDATE_ORDER      = "success update order status"
+	MESSAGE_SUCCESS_GET_ORDER_HISTORY = "success get order history"
+)
+
+// Order creation is safe to retry when the client sends an Idempotency-Key header
+const (
+	IdempotencyKeyHeader    = "Idempotency-Key"
+	MaxIdempotencyKeyLength = 255
 )
 
 var (
 	ErrOrderNotFound      = errors.New("order not found")
 	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrProductNotFound    = errors.New("product not found")
+	ErrProductArchived    = errors.New("product is archived and can no longer be ordered")
+	ErrOrderItemsRequired = errors.New("order needs items, or product_id and quantity for a single item")
+	ErrTooManyOrderItems  = errors.New("order has more than 100 distinct products")
+	ErrInvalidQuantity    = errors.New("quantity must be at least 1")
+	ErrMixedCurrency      = errors.New("all order items must be priced in the same currency")
+	ErrInvalidTransition  = errors.New("order cannot move to that status from its current one")
+	ErrOrderNotDeletable  = errors.New("only pending, cancelled or refunded orders can be deleted")
+	ErrShipToRequired     = errors.New("nearest allocation needs a ship_to location")
+	// The stock is there, but not in a way the allocation rule accepts, e.g. no single warehouse
+	// holds the whole line
+	ErrAllocationFailed = errors.New("no warehouse allocation satisfies the rule")
+	// A retry must repeat the original request; a reused key with another body is rejected
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyTooLong = errors.New("idempotency key must be at most 255 characters")
+	ErrInvalidDateRange      = errors.New("from must not be after to")
 )
 
+// MaxOrderItems caps the lines of one order, and so the rows it locks.
+const MaxOrderItems = 100
+
 type (
-	OrderCreateRequest struct {
+	OrderItemRequest struct {
 		ProductID string `json:"product_id" form:"product_id" binding:"required,uuid4"`
-		BuyerID   string `json:"buyer_id" form:"buyer_id" binding:"required,min=1"`
 		Quantity  int    `json:"quantity" form:"quantity" binding:"required,min=1"`
 	}
 
+	Location struct {
+		Latitude  float64 `json:"latitude" form:"latitude" binding:"min=-90,max=90"`
+		Longitude float64 `json:"longitude" form:"longitude" binding:"min=-180,max=180"`
+	}
+
+	// Shipping picks the warehouses each line ships from. Allocation defaults to the
+	// server's ORDER_ALLOCATION_RULE; nearest needs ShipTo.
+	Shipping struct {
+		Allocation string    `json:"allocation" form:"allocation" binding:"omitempty,oneof=nearest most_stock split"`
+		ShipTo     *Location `json:"ship_to" form:"ship_to" binding:"omitempty"`
+	}
+
+	// OrderCreateRequest takes the order lines in Items. ProductID and Quantity are the
+	// single-line form clients used before orders had several items.
+	OrderCreateRequest struct {
+		BuyerID   string             `json:"buyer_id" form:"buyer_id" binding:"required,min=1"`
+		Items     []OrderItemRequest `json:"items" form:"items" binding:"omitempty,max=100,dive"`
+		ProductID string             `json:"product_id" form:"product_id" binding:"omitempty,uuid4"`
+		Quantity  int                `json:"quantity" form:"quantity" binding:"omitempty,min=1"`
+		Shipping
+		// IdempotencyKey comes from the Idempotency-Key header, not the body
+		IdempotencyKey string `json:"-" form:"-"`
+	}
+
+	// OrderListRequest is the order list's shorthand filters, alongside the shared list
+	// parameters. From and To are inclusive days of created_at.
+	OrderListRequest struct {
+		BuyerID   string    `form:"buyer_id" binding:"omitempty,max=255"`
+		ProductID string    `form:"product_id" binding:"omitempty,uuid"`
+		Status    string    `form:"status" binding:"omitempty,oneof=pending paid fulfilled completed cancelled refunded"`
+		From      time.Time `form:"from" time_format:"2006-01-02"`
+		To        time.Time `form:"to" time_format:"2006-01-02"`
+	}
+
+	// OrderTransitionRequest is the optional body of the status actions
+	OrderTransitionRequest struct {
+		Reason string `json:"reason" form:"reason" binding:"omitempty,max=500"`
+	}
+
+	OrderTransitionResponse struct {
+		FromStatus string    `json:"from_status"`
+		ToStatus   string    `json:"to_status"`
+		Reason     string    `json:"reason,omitempty"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	OrderAllocationResponse struct {
+		WarehouseID string `json:"warehouse_id"`
+		Quantity    int    `json:"quantity"`
+	}
+
+	OrderItemResponse struct {
+		ProductID      string                    `json:"product_id"`
+		ProductName    string                    `json:"product_name"`
+		Quantity       int                       `json:"quantity"`
+		UnitPriceCents int64                     `json:"unit_price_cents"`
+		Currency       string                    `json:"currency"`
+		LineTotalCents int64                     `json:"line_total_cents"`
+		Allocations    []OrderAllocationResponse `json:"allocations"`
+	}
+
 	OrderResponse struct {
-		ID        string `json:"id"`
-		ProductID string `json:"product_id"`
-		BuyerID   string `json:"buyer_id"`
-		Quantity  int    `json:"quantity"`
+		ID            string              `json:"id"`
+		BuyerID       string              `json:"buyer_id"`
+		Status        string              `json:"status"`
+		Currency      string              `json:"currency"`
+		SubtotalCents int64               `json:"subtotal_cents"`
+		TaxCents      int64               `json:"tax_cents"`
+		TotalCents    int64               `json:"total_cents"`
+		Items         []OrderItemResponse `json:"items"`
+		CreatedAt     time.Time           `json:"created_at"`
 	}
 )
+
+// Lines returns the requested order lines, turning the single-line form into one item.
+func (r OrderCreateRequest) Lines() []OrderItemRequest {
+	if len(r.Items) == 0 && r.ProductID != "" {
+		return []OrderItemRequest{{ProductID: r.ProductID, Quantity: r.Quantity}}
+	}
+	return r.Items
+}
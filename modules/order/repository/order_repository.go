@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -12,15 +16,58 @@ type (
 	OrderRepository interface {
 		Create(ctx context.Context, tx *gorm.DB, o entities.Order) (entities.Order, error)
 		FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Order, error)
-		List(ctx context.Context, tx *gorm.DB, limit, offset int) ([]entities.Order, int64, error)
+		List(ctx context.Context, tx *gorm.DB, filter OrderFilter, q query.Request) ([]entities.Order, pkgdto.PaginationResponse, error)
 		Delete(ctx context.Context, tx *gorm.DB, id string) error
+		FindForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Order, error)
+		UpdateStatus(ctx context.Context, tx *gorm.DB, id, status string) error
+		AddTransition(ctx context.Context, tx *gorm.DB, t entities.OrderTransition) error
+		ListTransitions(ctx context.Context, tx *gorm.DB, orderID string) ([]entities.OrderTransition, error)
+		// ClaimIdempotencyKey inserts k unless its buyer already has its key, reporting whether it
+		// did. A claim racing an uncommitted one waits for that transaction to finish.
+		ClaimIdempotencyKey(ctx context.Context, tx *gorm.DB, k entities.OrderIdempotencyKey) (bool, error)
+		FindIdempotencyKey(ctx context.Context, tx *gorm.DB, buyerID, key string) (entities.OrderIdempotencyKey, error)
+		// SaveIdempotencyResponse stores the created order and its response on a claimed key.
+		SaveIdempotencyResponse(ctx context.Context, tx *gorm.DB, buyerID, key string, orderID uuid.UUID, response string) error
+		// DeleteIdempotencyKeysBefore deletes up to limit keys created before cutoff, skipping keys
+		// a running claim holds, and returns how many it deleted.
+		DeleteIdempotencyKeysBefore(ctx context.Context, tx *gorm.DB, cutoff time.Time, limit int) (int, error)
 	}
 
 	orderRepository struct {
 		db *gorm.DB
 	}
+
+	// OrderFilter is the order list's own parameters, applied on top of the shared filters; zero
+	// values are ignored. CreatedFrom is inclusive, CreatedTo exclusive.
+	OrderFilter struct {
+		BuyerID     string
+		ProductID   string
+		Status      string
+		CreatedFrom time.Time
+		CreatedTo   time.Time
+	}
 )
 
+// orderSchema is what the order list may be filtered and sorted by. search is a full-text match
+// on the name of any ordered product.
+var orderSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":          {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"buyer_id":    {Column: "buyer_id", Type: query.String, Ops: query.Text},
+		"status":      {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"currency":    {Column: "currency", Type: query.String, Ops: query.Equality},
+		"total_cents": {Column: "total_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"created_at":  {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"updated_at":  {Column: "updated_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-created_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		// Same expression as idx_products_name_search, so the index is used
+		return db.Where(`EXISTS (SELECT 1 FROM order_items i JOIN products p ON p.id = i.product_id
+			WHERE i.order_id = orders.id AND to_tsvector('simple', p.name) @@ plainto_tsquery('simple', ?))`, term)
+	},
+}
+
 func NewOrderRepository(db *gorm.DB) OrderRepository {
 	return &orderRepository{db: db}
 }
@@ -43,32 +90,111 @@ func (r *orderRepository) Create(ctx context.Context, tx *gorm.DB, o entities.Or
 func (r *orderRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Order, error) {
 	db := r.getDB(tx)
 	var o entities.Order
-	if err := db.WithContext(ctx).Preload("Product").Where("id = ?", id).Take(&o).Error; err != nil {
+	if err := db.WithContext(ctx).Preload("Items", orderItemsByLine).Preload("Items.Allocations").Where("id = ?", id).Take(&o).Error; err != nil {
 		return entities.Order{}, err
 	}
 	return o, nil
 }
 
-func (r *orderRepository) List(ctx context.Context, tx *gorm.DB, limit, offset int) ([]entities.Order, int64, error) {
+func (r *orderRepository) List(ctx context.Context, tx *gorm.DB, filter OrderFilter, q query.Request) ([]entities.Order, pkgdto.PaginationResponse, error) {
 	db := r.getDB(tx)
-	var (
-		items []entities.Order
-		total int64
-	)
-	if err := db.WithContext(ctx).Model(&entities.Order{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	orders := func() *gorm.DB {
+		return applyOrderFilter(db.WithContext(ctx).Model(&entities.Order{}), filter)
+	}
+	return query.Find[entities.Order](orders, q, orderSchema, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Items", orderItemsByLine).Preload("Items.Allocations")
+	})
+}
+
+func applyOrderFilter(db *gorm.DB, filter OrderFilter) *gorm.DB {
+	if filter.BuyerID != "" {
+		db = db.Where("buyer_id = ?", filter.BuyerID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", filter.CreatedFrom)
 	}
-	if err := db.WithContext(ctx).Model(&entities.Order{}).
-		Preload("Product").
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).
-		Find(&items).Error; err != nil {
-		return nil, 0, err
+	if !filter.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", filter.CreatedTo)
 	}
-	return items, total, nil
+	if filter.ProductID != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = orders.id AND i.product_id = ?)", filter.ProductID)
+	}
+	return db
+}
+
+func orderItemsByLine(db *gorm.DB) *gorm.DB {
+	return db.Order("line")
 }
 
 func (r *orderRepository) Delete(ctx context.Context, tx *gorm.DB, id string) error {
 	db := r.getDB(tx)
 	return db.WithContext(ctx).Clauses(clause.Returning{}).Delete(&entities.Order{}, "id = ?", id).Error
 }
+
+// FindForUpdate loads an order with its items and row-locks the order, so concurrent status
+// changes of the same order are applied one after the other.
+func (r *orderRepository) FindForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Order, error) {
+	db := r.getDB(tx)
+	var o entities.Order
+	if err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(&o).Error; err != nil {
+		return entities.Order{}, err
+	}
+	if err := db.WithContext(ctx).Preload("Allocations").Where("order_id = ?", id).Order("line").Find(&o.Items).Error; err != nil {
+		return entities.Order{}, err
+	}
+	return o, nil
+}
+
+func (r *orderRepository) UpdateStatus(ctx context.Context, tx *gorm.DB, id, status string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Model(&entities.Order{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *orderRepository) AddTransition(ctx context.Context, tx *gorm.DB, t entities.OrderTransition) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Create(&t).Error
+}
+
+func (r *orderRepository) ListTransitions(ctx context.Context, tx *gorm.DB, orderID string) ([]entities.OrderTransition, error) {
+	db := r.getDB(tx)
+	var items []entities.OrderTransition
+	if err := db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *orderRepository) ClaimIdempotencyKey(ctx context.Context, tx *gorm.DB, k entities.OrderIdempotencyKey) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "buyer_id"}, {Name: "key"}}, DoNothing: true}).Create(&k)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *orderRepository) FindIdempotencyKey(ctx context.Context, tx *gorm.DB, buyerID, key string) (entities.OrderIdempotencyKey, error) {
+	db := r.getDB(tx)
+	var k entities.OrderIdempotencyKey
+	if err := db.WithContext(ctx).Where("buyer_id = ? AND key = ?", buyerID, key).Take(&k).Error; err != nil {
+		return entities.OrderIdempotencyKey{}, err
+	}
+	return k, nil
+}
+
+func (r *orderRepository) SaveIdempotencyResponse(ctx context.Context, tx *gorm.DB, buyerID, key string, orderID uuid.UUID, response string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Model(&entities.OrderIdempotencyKey{}).Where("buyer_id = ? AND key = ?", buyerID, key).
+		Updates(map[string]any{"order_id": orderID, "response": response}).Error
+}
+
+func (r *orderRepository) DeleteIdempotencyKeysBefore(ctx context.Context, tx *gorm.DB, cutoff time.Time, limit int) (int, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).Exec(`DELETE FROM order_idempotency_keys WHERE (buyer_id, key) IN (
+			SELECT buyer_id, key FROM order_idempotency_keys WHERE created_at < ?
+			ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED)`, cutoff, limit)
+	return int(res.RowsAffected), res.Error
+}
@@ -15,5 +15,11 @@ func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 		r.GET("/:id", ctrl.GetByID)
 		r.POST("", ctrl.Create)
 		r.DELETE("/:id", ctrl.Delete)
+		r.GET("/:id/history", ctrl.History)
+		r.POST("/:id/pay", ctrl.Pay)
+		r.POST("/:id/fulfill", ctrl.Fulfill)
+		r.POST("/:id/complete", ctrl.Complete)
+		r.POST("/:id/cancel", ctrl.Cancel)
+		r.POST("/:id/refund", ctrl.Refund)
 	}
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultIdempotencyKeyTTL is how long an order's idempotency key replays it.
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKeyTTLFromEnv reads ORDER_IDEMPOTENCY_KEY_TTL as a duration such as "48h",
+// defaulting to DefaultIdempotencyKeyTTL.
+func IdempotencyKeyTTLFromEnv() time.Duration {
+	if v := os.Getenv("ORDER_IDEMPOTENCY_KEY_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultIdempotencyKeyTTL
+}
+
+// IdempotencySweeper deletes order idempotency keys older than their TTL, so the table does not
+// grow with every order ever placed.
+type IdempotencySweeper struct {
+	service  OrderService
+	interval time.Duration
+	ttl      time.Duration
+	batch    int
+}
+
+func NewIdempotencySweeper(service OrderService, interval, ttl time.Duration) *IdempotencySweeper {
+	return &IdempotencySweeper{service: service, interval: interval, ttl: ttl, batch: 500}
+}
+
+// Run sweeps every interval until ctx is done.
+func (s *IdempotencySweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("idempotency key sweeper: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce deletes every key older than the TTL and returns how many it deleted.
+func (s *IdempotencySweeper) SweepOnce(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.ttl)
+	deleted := 0
+	for {
+		n, err := s.service.ExpireIdempotencyKeys(ctx, cutoff, s.batch)
+		deleted += n
+		if err != nil || n < s.batch {
+			return deleted, err
+		}
+	}
+}
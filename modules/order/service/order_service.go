@@ -1,74 +1,328 @@
 package service
 
 import (
-    "context"
-
-    "github.com/xkillx/go-gin-order-settlement/database/entities"
-    "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
-    "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
-    productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
-    pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
-    "github.com/google/uuid"
-    "gorm.io/gorm"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
 )
 
 type OrderService interface {
-    Create(ctx context.Context, req dto.OrderCreateRequest) (dto.OrderResponse, error)
-    GetByID(ctx context.Context, id string) (dto.OrderResponse, error)
-    List(ctx context.Context, p pkgdto.PaginationRequest) ([]dto.OrderResponse, pkgdto.PaginationResponse, error)
-    Delete(ctx context.Context, id string) error
+	Create(ctx context.Context, req dto.OrderCreateRequest) (dto.OrderResponse, error)
+	GetByID(ctx context.Context, id string) (dto.OrderResponse, error)
+	List(ctx context.Context, req dto.OrderListRequest, q query.Request) ([]dto.OrderResponse, pkgdto.PaginationResponse, error)
+	Delete(ctx context.Context, id string) error
+	// Transition moves an order to status, returning its stock when it is cancelled or refunded.
+	Transition(ctx context.Context, id, status, reason string) (dto.OrderResponse, error)
+	History(ctx context.Context, id string) ([]dto.OrderTransitionResponse, error)
+	// PlaceReserved creates an order in tx from stock the caller reserved earlier, turning the
+	// held quantities into sold ones.
+	PlaceReserved(ctx context.Context, tx *gorm.DB, buyerID string, lines []dto.OrderItemRequest, shipping dto.Shipping) (dto.OrderResponse, error)
+	// ExpireIdempotencyKeys deletes up to limit idempotency keys created before cutoff and
+	// returns how many it deleted; a retry after that places a new order.
+	ExpireIdempotencyKeys(ctx context.Context, cutoff time.Time, limit int) (int, error)
+}
+
+// Inventory takes order lines' stock from warehouses and returns it, and records every stock
+// change, i.e. the inventory service. It works in the caller's transaction, which holds the
+// products' row locks.
+type Inventory interface {
+	Allocate(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int, shipping dto.Shipping) ([]entities.OrderAllocation, error)
+	Return(ctx context.Context, tx *gorm.DB, productID uuid.UUID, allocations []entities.OrderAllocation) error
+	Record(ctx context.Context, tx *gorm.DB, movements ...entities.InventoryMovement) error
+}
+
+// EventPublisher records a domain event in the caller's transaction, i.e. the event outbox.
+type EventPublisher interface {
+	Publish(ctx context.Context, tx *gorm.DB, eventType string, data any) error
 }
 
 type orderService struct {
-    orderRepository   repository.OrderRepository
-    productRepository productRepo.ProductRepository
-    db                *gorm.DB
+	orderRepository   repository.OrderRepository
+	productRepository productRepo.ProductRepository
+	inventory         Inventory
+	events            EventPublisher
+	taxRateBps        int
+	db                *gorm.DB
+}
+
+// NewOrderService builds the service; stock is allocated to warehouses and recorded through
+// inventory, and events may be nil when nothing subscribes to order events.
+// taxRateBps is the tax charged on the subtotal in basis points (825 = 8.25%).
+func NewOrderService(orderRepo repository.OrderRepository, prodRepo productRepo.ProductRepository, inventory Inventory, events EventPublisher, taxRateBps int, db *gorm.DB) OrderService {
+	return &orderService{orderRepository: orderRepo, productRepository: prodRepo, inventory: inventory, events: events, taxRateBps: taxRateBps, db: db}
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, prodRepo productRepo.ProductRepository, db *gorm.DB) OrderService {
-    return &orderService{orderRepository: orderRepo, productRepository: prodRepo, db: db}
+// TaxRateFromEnv reads ORDER_TAX_RATE_BPS, defaulting to no tax.
+func TaxRateFromEnv() int {
+	if v := os.Getenv("ORDER_TAX_RATE_BPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return 0
 }
 
 func (s *orderService) Create(ctx context.Context, req dto.OrderCreateRequest) (dto.OrderResponse, error) {
-    pid, err := uuid.Parse(req.ProductID)
-    if err != nil {
-        return dto.OrderResponse{}, err
-    }
+	ids, quantities, err := mergeLines(req.Lines())
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
 
-	var created entities.Order
+	var (
+		result dto.OrderResponse
+		replay bool
+	)
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// Ensure product exists and has sufficient stock
-		ok, err := s.productRepository.DecrementStock(ctx, tx, pid, req.Quantity)
+		if req.IdempotencyKey != "" {
+			var err error
+			if result, replay, err = s.claimKey(ctx, tx, req); err != nil || replay {
+				return err
+			}
+		}
+		created, err := s.place(ctx, tx, req.BuyerID, ids, quantities, req.Shipping, s.productRepository.DecrementStock)
+		if err != nil {
+			return err
+		}
+		result = toOrderResponse(created)
+		if req.IdempotencyKey == "" {
+			return nil
+		}
+		response, err := json.Marshal(result)
 		if err != nil {
 			return err
 		}
+		return s.orderRepository.SaveIdempotencyResponse(ctx, tx, req.BuyerID, req.IdempotencyKey, created.ID, string(response))
+	})
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+	return result, nil
+}
+
+// claimKey claims req's idempotency key for its buyer in tx before the order is placed. When the key was
+// already used it returns the stored response to replay instead, or ErrIdempotencyKeyReused if
+// the earlier request differed. A retry racing the original waits on the claim until the
+// original commits, and then replays it; if the original rolled back, the retry places the order.
+func (s *orderService) claimKey(ctx context.Context, tx *gorm.DB, req dto.OrderCreateRequest) (dto.OrderResponse, bool, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return dto.OrderResponse{}, false, err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	claimed, err := s.orderRepository.ClaimIdempotencyKey(ctx, tx, entities.OrderIdempotencyKey{BuyerID: req.BuyerID, Key: req.IdempotencyKey, RequestHash: hash})
+	if err != nil || claimed {
+		return dto.OrderResponse{}, false, err
+	}
+	stored, err := s.orderRepository.FindIdempotencyKey(ctx, tx, req.BuyerID, req.IdempotencyKey)
+	if err != nil {
+		return dto.OrderResponse{}, false, err
+	}
+	if stored.RequestHash != hash {
+		return dto.OrderResponse{}, false, dto.ErrIdempotencyKeyReused
+	}
+	var res dto.OrderResponse
+	if err := json.Unmarshal([]byte(stored.Response), &res); err != nil {
+		return dto.OrderResponse{}, false, err
+	}
+	return res, true, nil
+}
+
+func (s *orderService) ExpireIdempotencyKeys(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	return s.orderRepository.DeleteIdempotencyKeysBefore(ctx, nil, cutoff, limit)
+}
+
+func (s *orderService) PlaceReserved(ctx context.Context, tx *gorm.DB, buyerID string, lines []dto.OrderItemRequest, shipping dto.Shipping) (dto.OrderResponse, error) {
+	ids, quantities, err := mergeLines(lines)
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+	created, err := s.place(ctx, tx, buyerID, ids, quantities, shipping, s.productRepository.ConsumeReserved)
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+	return toOrderResponse(created), nil
+}
+
+// mergeLines validates the requested lines and merges repeated products into one line, kept in
+// the order they were first requested.
+func mergeLines(lines []dto.OrderItemRequest) ([]uuid.UUID, map[uuid.UUID]int, error) {
+	if len(lines) == 0 {
+		return nil, nil, dto.ErrOrderItemsRequired
+	}
+	var (
+		ids        []uuid.UUID
+		quantities = make(map[uuid.UUID]int, len(lines))
+	)
+	for _, l := range lines {
+		pid, err := uuid.Parse(l.ProductID)
+		if err != nil {
+			return nil, nil, dto.ErrProductNotFound
+		}
+		if l.Quantity < 1 {
+			return nil, nil, dto.ErrInvalidQuantity
+		}
+		if _, seen := quantities[pid]; !seen {
+			ids = append(ids, pid)
+		}
+		quantities[pid] += l.Quantity
+	}
+	if len(ids) > dto.MaxOrderItems {
+		return nil, nil, dto.ErrTooManyOrderItems
+	}
+	return ids, quantities, nil
+}
+
+// place creates a pending order in tx, taking each line's stock with take and then from the
+// warehouses shipping allocates. take checks the product's total (available) stock and the
+// allocation its warehouse stock; both run under the product's row lock, so neither is oversold.
+func (s *orderService) place(ctx context.Context, tx *gorm.DB, buyerID string, ids []uuid.UUID, quantities map[uuid.UUID]int,
+	shipping dto.Shipping, take func(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error)) (entities.Order, error) {
+	// Products come back locked in id order and are decremented in that order, so
+	// concurrent orders over the same products cannot deadlock
+	products, err := s.productRepository.LockForUpdate(ctx, tx, ids)
+	if err != nil {
+		return entities.Order{}, err
+	}
+	if len(products) != len(ids) {
+		return entities.Order{}, dto.ErrProductNotFound
+	}
+	byID := make(map[uuid.UUID]entities.Product, len(products))
+	allocations := make(map[uuid.UUID][]entities.OrderAllocation, len(products))
+	for _, p := range products {
+		if p.Status == constants.ENUM_PRODUCT_STATUS_ARCHIVED {
+			return entities.Order{}, dto.ErrProductArchived
+		}
+		if p.Currency != products[0].Currency {
+			return entities.Order{}, dto.ErrMixedCurrency
+		}
+		ok, err := take(ctx, tx, p.ID, quantities[p.ID])
+		if err != nil {
+			return entities.Order{}, err
+		}
 		if !ok {
-			return dto.ErrInsufficientStock
+			return entities.Order{}, dto.ErrInsufficientStock
+		}
+		if allocations[p.ID], err = s.inventory.Allocate(ctx, tx, p.ID, quantities[p.ID], shipping); err != nil {
+			return entities.Order{}, err
 		}
+		byID[p.ID] = p
+	}
 
-		order := entities.Order{
-			ProductID: pid,
-			BuyerID:   req.BuyerID,
-			Quantity:  req.Quantity,
+	order := entities.Order{
+		BuyerID:  buyerID,
+		Status:   constants.ENUM_ORDER_STATUS_PENDING,
+		Currency: products[0].Currency,
+		Items:    make([]entities.OrderItem, 0, len(ids)),
+	}
+	for i, pid := range ids {
+		p, qty := byID[pid], quantities[pid]
+		item := entities.OrderItem{
+			Line:           i + 1,
+			ProductID:      pid,
+			ProductName:    p.Name,
+			Quantity:       qty,
+			UnitPriceCents: p.PriceCents,
+			Currency:       p.Currency,
+			LineTotalCents: p.PriceCents * int64(qty),
+			Allocations:    allocations[pid],
 		}
+		order.Items = append(order.Items, item)
+		order.SubtotalCents += item.LineTotalCents
+	}
+	order.TaxCents = taxCents(order.SubtotalCents, s.taxRateBps)
+	order.TotalCents = order.SubtotalCents + order.TaxCents
 
-		var errCreate error
-		created, errCreate = s.orderRepository.Create(ctx, tx, order)
-		return errCreate
-	})
+	created, err := s.orderRepository.Create(ctx, tx, order)
 	if err != nil {
-		return dto.OrderResponse{}, err
+		return entities.Order{}, err
+	}
+	if err := s.orderRepository.AddTransition(ctx, tx, entities.OrderTransition{
+		OrderID:  created.ID,
+		ToStatus: created.Status,
+	}); err != nil {
+		return entities.Order{}, err
+	}
+	var movements []entities.InventoryMovement
+	for _, it := range created.Items {
+		for _, a := range it.Allocations {
+			movements = append(movements, entities.InventoryMovement{
+				ProductID:   it.ProductID,
+				WarehouseID: &a.WarehouseID,
+				Kind:        constants.ENUM_INVENTORY_MOVEMENT_ORDER,
+				Delta:       -a.Quantity,
+				Reference:   created.ID.String(),
+				Actor:       buyerID,
+			})
+		}
 	}
+	if err := s.inventory.Record(ctx, tx, movements...); err != nil {
+		return entities.Order{}, err
+	}
+	if s.events != nil {
+		// Published in the same transaction so the event exists exactly when the order does
+		if err := s.events.Publish(ctx, tx, constants.ENUM_EVENT_ORDER_CREATED, toOrderResponse(created)); err != nil {
+			return entities.Order{}, err
+		}
+	}
+	return created, nil
+}
 
+// taxCents applies a basis point rate to the subtotal, rounding half cents up.
+func taxCents(subtotal int64, rateBps int) int64 {
+	return (subtotal*int64(rateBps) + 5_000) / 10_000
+}
+
+func toOrderResponse(o entities.Order) dto.OrderResponse {
+	items := make([]dto.OrderItemResponse, 0, len(o.Items))
+	for _, it := range o.Items {
+		allocations := make([]dto.OrderAllocationResponse, 0, len(it.Allocations))
+		for _, a := range it.Allocations {
+			allocations = append(allocations, dto.OrderAllocationResponse{WarehouseID: a.WarehouseID.String(), Quantity: a.Quantity})
+		}
+		items = append(items, dto.OrderItemResponse{
+			ProductID:      it.ProductID.String(),
+			ProductName:    it.ProductName,
+			Quantity:       it.Quantity,
+			UnitPriceCents: it.UnitPriceCents,
+			Currency:       it.Currency,
+			LineTotalCents: it.LineTotalCents,
+			Allocations:    allocations,
+		})
+	}
 	return dto.OrderResponse{
-		ID:        created.ID.String(),
-		ProductID: created.ProductID.String(),
-		BuyerID:   created.BuyerID,
-		Quantity:  created.Quantity,
-	}, nil
+		ID:            o.ID.String(),
+		BuyerID:       o.BuyerID,
+		Status:        o.Status,
+		Currency:      o.Currency,
+		SubtotalCents: o.SubtotalCents,
+		TaxCents:      o.TaxCents,
+		TotalCents:    o.TotalCents,
+		Items:         items,
+		CreatedAt:     o.CreatedAt,
+	}
 }
 
 func (s *orderService) GetByID(ctx context.Context, id string) (dto.OrderResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.OrderResponse{}, dto.ErrOrderNotFound
+	}
 	o, err := s.orderRepository.FindByID(ctx, s.db, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -76,36 +330,164 @@ func (s *orderService) GetByID(ctx context.Context, id string) (dto.OrderRespons
 		}
 		return dto.OrderResponse{}, err
 	}
-	return dto.OrderResponse{
-		ID:        o.ID.String(),
-		ProductID: o.ProductID.String(),
-		BuyerID:   o.BuyerID,
-		Quantity:  o.Quantity,
-	}, nil
+	return toOrderResponse(o), nil
 }
 
-func (s *orderService) List(ctx context.Context, p pkgdto.PaginationRequest) ([]dto.OrderResponse, pkgdto.PaginationResponse, error) {
-	p.Default()
-	items, total, err := s.orderRepository.List(ctx, s.db, p.GetLimit(), p.GetOffset())
+// List pages through the filtered orders by page, or with a cursor by keyset, which stays fast
+// however deep the page and skips counting the matches.
+func (s *orderService) List(ctx context.Context, req dto.OrderListRequest, q query.Request) ([]dto.OrderResponse, pkgdto.PaginationResponse, error) {
+	filter := repository.OrderFilter{
+		BuyerID:     req.BuyerID,
+		ProductID:   req.ProductID,
+		Status:      req.Status,
+		CreatedFrom: req.From,
+	}
+	if !req.To.IsZero() {
+		// 'to' is an inclusive calendar day
+		filter.CreatedTo = req.To.AddDate(0, 0, 1)
+	}
+
+	items, meta, err := s.orderRepository.List(ctx, s.db, filter, q)
 	if err != nil {
 		return nil, pkgdto.PaginationResponse{}, err
 	}
 	resp := make([]dto.OrderResponse, 0, len(items))
 	for _, it := range items {
-		resp = append(resp, dto.OrderResponse{
-			ID:        it.ID.String(),
-			ProductID: it.ProductID.String(),
-			BuyerID:   it.BuyerID,
-			Quantity:  it.Quantity,
-		})
+		resp = append(resp, toOrderResponse(it))
 	}
-	maxPage := total / int64(p.PerPage)
-	if total%int64(p.PerPage) != 0 {
-		maxPage++
-	}
-	return resp, pkgdto.PaginationResponse{Page: p.Page, PerPage: p.PerPage, Count: total, MaxPage: maxPage}, nil
+	return resp, meta, nil
 }
 
+// Delete removes a pending order, returning its stock, or one that was cancelled or refunded and
+// so already returned it. Paid orders have to be refunded first.
 func (s *orderService) Delete(ctx context.Context, id string) error {
-	return s.orderRepository.Delete(ctx, s.db, id)
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.ErrOrderNotFound
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		o, err := s.orderRepository.FindForUpdate(ctx, tx, id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return dto.ErrOrderNotFound
+			}
+			return err
+		}
+		switch o.Status {
+		case constants.ENUM_ORDER_STATUS_PENDING:
+			if err := s.restoreStock(ctx, tx, o, constants.ENUM_INVENTORY_MOVEMENT_CANCELLATION, "order deleted"); err != nil {
+				return err
+			}
+		case constants.ENUM_ORDER_STATUS_CANCELLED, constants.ENUM_ORDER_STATUS_REFUNDED:
+		default:
+			return dto.ErrOrderNotDeletable
+		}
+		return s.orderRepository.Delete(ctx, tx, id)
+	})
+}
+
+func (s *orderService) Transition(ctx context.Context, id, status, reason string) (dto.OrderResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.OrderResponse{}, dto.ErrOrderNotFound
+	}
+	var updated entities.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		o, err := s.orderRepository.FindForUpdate(ctx, tx, id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return dto.ErrOrderNotFound
+			}
+			return err
+		}
+		if !CanTransition(o.Status, status) {
+			return fmt.Errorf("%w: %s to %s", dto.ErrInvalidTransition, o.Status, status)
+		}
+		if releasesStock(status) {
+			kind := constants.ENUM_INVENTORY_MOVEMENT_CANCELLATION
+			if status == constants.ENUM_ORDER_STATUS_REFUNDED {
+				kind = constants.ENUM_INVENTORY_MOVEMENT_REFUND
+			}
+			if err := s.restoreStock(ctx, tx, o, kind, reason); err != nil {
+				return err
+			}
+		}
+		if err := s.orderRepository.UpdateStatus(ctx, tx, id, status); err != nil {
+			return err
+		}
+		if err := s.orderRepository.AddTransition(ctx, tx, entities.OrderTransition{
+			OrderID:    o.ID,
+			FromStatus: o.Status,
+			ToStatus:   status,
+			Reason:     reason,
+		}); err != nil {
+			return err
+		}
+		o.Status = status
+		updated = o
+		if s.events == nil {
+			return nil
+		}
+		return s.events.Publish(ctx, tx, orderEvents[status], toOrderResponse(o))
+	})
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+	return toOrderResponse(updated), nil
+}
+
+// restoreStock gives the order's quantities back to the warehouses they were allocated from, as
+// kind movements, locking products in the same id order as Create.
+func (s *orderService) restoreStock(ctx context.Context, tx *gorm.DB, o entities.Order, kind, reason string) error {
+	quantities := make(map[uuid.UUID]int, len(o.Items))
+	allocations := make(map[uuid.UUID][]entities.OrderAllocation, len(o.Items))
+	ids := make([]uuid.UUID, 0, len(o.Items))
+	for _, it := range o.Items {
+		if _, seen := quantities[it.ProductID]; !seen {
+			ids = append(ids, it.ProductID)
+		}
+		quantities[it.ProductID] += it.Quantity
+		allocations[it.ProductID] = append(allocations[it.ProductID], it.Allocations...)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	products, err := s.productRepository.LockForUpdate(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	var movements []entities.InventoryMovement
+	for _, p := range products {
+		if err := s.productRepository.IncrementStock(ctx, tx, p.ID, quantities[p.ID]); err != nil {
+			return err
+		}
+		if err := s.inventory.Return(ctx, tx, p.ID, allocations[p.ID]); err != nil {
+			return err
+		}
+		for _, a := range allocations[p.ID] {
+			movements = append(movements, entities.InventoryMovement{
+				ProductID:   p.ID,
+				WarehouseID: &a.WarehouseID,
+				Kind:        kind,
+				Delta:       a.Quantity,
+				Reference:   o.ID.String(),
+				Actor:       constants.ENUM_INVENTORY_ACTOR_SYSTEM,
+				Reason:      reason,
+			})
+		}
+	}
+	return s.inventory.Record(ctx, tx, movements...)
+}
+
+func (s *orderService) History(ctx context.Context, id string) ([]dto.OrderTransitionResponse, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	items, err := s.orderRepository.ListTransitions(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.OrderTransitionResponse, 0, len(items))
+	for _, t := range items {
+		out = append(out, dto.OrderTransitionResponse{FromStatus: t.FromStatus, ToStatus: t.ToStatus, Reason: t.Reason, CreatedAt: t.CreatedAt})
+	}
+	return out, nil
 }
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// orderTransitions lists the statuses each status may move to. Cancelled and refunded are terminal.
+var orderTransitions = map[string][]string{
+	constants.ENUM_ORDER_STATUS_PENDING:   {constants.ENUM_ORDER_STATUS_PAID, constants.ENUM_ORDER_STATUS_CANCELLED},
+	constants.ENUM_ORDER_STATUS_PAID:      {constants.ENUM_ORDER_STATUS_FULFILLED, constants.ENUM_ORDER_STATUS_REFUNDED},
+	constants.ENUM_ORDER_STATUS_FULFILLED: {constants.ENUM_ORDER_STATUS_COMPLETED, constants.ENUM_ORDER_STATUS_REFUNDED},
+	constants.ENUM_ORDER_STATUS_COMPLETED: {constants.ENUM_ORDER_STATUS_REFUNDED},
+}
+
+// orderEvents is the domain event written when an order enters a status.
+var orderEvents = map[string]string{
+	constants.ENUM_ORDER_STATUS_PAID:      constants.ENUM_EVENT_ORDER_PAID,
+	constants.ENUM_ORDER_STATUS_FULFILLED: constants.ENUM_EVENT_ORDER_FULFILLED,
+	constants.ENUM_ORDER_STATUS_COMPLETED: constants.ENUM_EVENT_ORDER_COMPLETED,
+	constants.ENUM_ORDER_STATUS_CANCELLED: constants.ENUM_EVENT_ORDER_CANCELLED,
+	constants.ENUM_ORDER_STATUS_REFUNDED:  constants.ENUM_EVENT_ORDER_REFUNDED,
+}
+
+// CanTransition reports whether an order in status from may move to status to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+// releasesStock reports whether entering status gives the order's stock back.
+func releasesStock(status string) bool {
+	return status == constants.ENUM_ORDER_STATUS_CANCELLED || status == constants.ENUM_ORDER_STATUS_REFUNDED
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
 	orderModule "github.com/xkillx/go-gin-order-settlement/modules/order"
 	orderController "github.com/xkillx/go-gin-order-settlement/modules/order/controller"
@@ -38,10 +39,10 @@ func setupTestServer(t *testing.T) (*gin.Engine, *do.Injector, *gorm.DB, *entiti
 	db := config.SetUpTestDatabaseConnection()
 
 	// Ensure schema
-	if err := db.AutoMigrate(&entities.Product{}, &entities.Order{}); err != nil {
-		t.Fatalf("automigrate failed: %v", err)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
 	}
-	// Truncate tables (orders first due to FK)
+	// Truncate tables (orders first due to FK, their items go with them)
 	if err := db.Exec("DELETE FROM orders").Error; err != nil {
 		t.Fatalf("failed to truncate orders: %v", err)
 	}
@@ -52,7 +53,7 @@ func setupTestServer(t *testing.T) (*gin.Engine, *do.Injector, *gorm.DB, *entiti
 	// Create repositories and service bound to this DB
 	prdRepo := productRepo.NewProductRepository(db)
 	ordRepo := orderRepo.NewOrderRepository(db)
-	svc := orderService.NewOrderService(ordRepo, prdRepo, db)
+	svc := orderService.NewOrderService(ordRepo, prdRepo, newInventory(db), nil, 0, db)
 
 	// Create a dummy product with stock = 100
 	ctx := context.Background()
@@ -63,6 +64,9 @@ func setupTestServer(t *testing.T) (*gin.Engine, *do.Injector, *gorm.DB, *entiti
 	if err != nil {
 		t.Fatalf("failed to create product: %v", err)
 	}
+	if err := newInventory(db).Open(ctx, db, product.ID, product.Stock); err != nil {
+		t.Fatalf("failed to open stock: %v", err)
+	}
 
 	// Wire only the Orders routes with a DI container that provides OrderController
 	inj := do.New()
@@ -0,0 +1,141 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+)
+
+func postOrder(server http.Handler, key string, body any) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(dto.IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyKeyReplaysOrderCreation(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	lamp := createProduct(t, db, "Lamp", 10, 4_000, "USD")
+	key := uuid.NewString()
+	body := map[string]any{"buyer_id": "buyer-1", "items": []map[string]any{{"product_id": lamp.ID.String(), "quantity": 2}}}
+
+	// A client retrying on a flaky network, with the retries racing the original
+	const retries = 20
+	var (
+		wg     sync.WaitGroup
+		bodies = make([]string, retries)
+		codes  = make([]int, retries)
+	)
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := postOrder(server, key, body)
+			codes[i], bodies[i] = rec.Code, rec.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range codes {
+		if codes[i] != http.StatusCreated {
+			t.Fatalf("retry %d expected 201, got %d: %s", i, codes[i], bodies[i])
+		}
+		if bodies[i] != bodies[0] {
+			t.Fatalf("retry %d should replay the original response:\n%s\n%s", i, bodies[i], bodies[0])
+		}
+	}
+	var orders int64
+	db.Model(&entities.OrderItem{}).Where("product_id = ?", lamp.ID).Count(&orders)
+	if orders != 1 || stockOf(t, db, lamp) != 8 {
+		t.Fatalf("expected one order taking 2 units, got %d orders and stock %d", orders, stockOf(t, db, lamp))
+	}
+
+	// The same key with another body is a client bug, not a retry
+	other := map[string]any{"buyer_id": "buyer-1", "items": []map[string]any{{"product_id": lamp.ID.String(), "quantity": 3}}}
+	if rec := postOrder(server, key, other); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("a reused key expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A failed attempt stores nothing, so its key can be retried once stock is back
+	failing := uuid.NewString()
+	tooMany := map[string]any{"buyer_id": "buyer-2", "items": []map[string]any{{"product_id": lamp.ID.String(), "quantity": 9}}}
+	if rec := postOrder(server, failing, tooMany); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for missing stock, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := db.Model(&entities.Product{}).Where("id = ?", lamp.ID).Update("stock", 20).Error; err != nil {
+		t.Fatalf("restock: %v", err)
+	}
+	if err := newInventory(db).Open(context.Background(), db, lamp.ID, 12); err != nil {
+		t.Fatalf("open stock: %v", err)
+	}
+	if rec := postOrder(server, failing, tooMany); rec.Code != http.StatusCreated {
+		t.Fatalf("retrying a failed key expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Without a key every request is a new order
+	for i := 0; i < 2; i++ {
+		if rec := postOrder(server, "", body); rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if got := stockOf(t, db, lamp); got != 7 {
+		t.Fatalf("expected stock 7, got %d", got)
+	}
+}
+
+func TestIdempotencyKeysAreScopedToBuyerAndExpire(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	lamp := createProduct(t, db, "Lamp", 10, 4_000, "USD")
+	key := uuid.NewString()
+	order := func(buyer string) map[string]any {
+		return map[string]any{"buyer_id": buyer, "items": []map[string]any{{"product_id": lamp.ID.String(), "quantity": 1}}}
+	}
+
+	// Two buyers picking the same key each get their own order
+	first := postOrder(server, key, order("buyer-a"))
+	second := postOrder(server, key, order("buyer-b"))
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated || first.Body.String() == second.Body.String() {
+		t.Fatalf("expected two distinct orders, got %d %s and %d %s", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if got := stockOf(t, db, lamp); got != 8 {
+		t.Fatalf("expected stock 8, got %d", got)
+	}
+
+	// Keys older than the TTL are swept, after which the key places a new order
+	if err := db.Model(&entities.OrderIdempotencyKey{}).Where("key = ?", key).
+		Update("created_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatalf("age keys: %v", err)
+	}
+	svc := orderService.NewOrderService(orderRepo.NewOrderRepository(db), productRepo.NewProductRepository(db), newInventory(db), nil, 0, db)
+	swept, err := orderService.NewIdempotencySweeper(svc, time.Minute, time.Hour).SweepOnce(context.Background())
+	if err != nil || swept < 2 {
+		t.Fatalf("expected both keys swept, got %d, %v", swept, err)
+	}
+	if rec := postOrder(server, key, order("buyer-a")); rec.Code != http.StatusCreated || rec.Body.String() == first.Body.String() {
+		t.Fatalf("an expired key expected a new order, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := stockOf(t, db, lamp); got != 7 {
+		t.Fatalf("expected stock 7, got %d", got)
+	}
+}
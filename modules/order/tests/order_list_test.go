@@ -0,0 +1,203 @@
+package order_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+)
+
+type orderPage struct {
+	Items      []dto.OrderResponse       `json:"items"`
+	Pagination pkgdto.PaginationResponse `json:"pagination"`
+}
+
+func listOrders(t *testing.T, server http.Handler, query url.Values) (*httptest.ResponseRecorder, orderPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders?"+query.Encode(), nil))
+	var body struct {
+		Data orderPage `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body.Data
+}
+
+func TestOrderListFiltersSortsAndSearches(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	kettle := createProduct(t, db, "Copper Kettle", 50, 3_000, "USD")
+	mug := createProduct(t, db, "Enamel Mug", 50, 800, "USD")
+
+	place := func(buyer string, productID string, qty int) dto.OrderResponse {
+		t.Helper()
+		rec := postJSON(t, server, "/api/orders", map[string]any{"buyer_id": buyer, "product_id": productID, "quantity": qty})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create order expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var created struct {
+			Data dto.OrderResponse `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &created)
+		return created.Data
+	}
+	first := place("alice", kettle.ID.String(), 1)
+	place("alice", mug.ID.String(), 2)
+	place("bob", mug.ID.String(), 5)
+	if rec := postJSON(t, server, "/api/orders/"+first.ID+"/pay", nil); rec.Code != http.StatusOK {
+		t.Fatalf("pay expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  int
+	}{
+		{"by buyer", url.Values{"buyer_id": {"alice"}}, 2},
+		{"by status", url.Values{"status": {"paid"}}, 1},
+		{"by product", url.Values{"product_id": {mug.ID.String()}}, 2},
+		{"by buyer and product", url.Values{"buyer_id": {"alice"}, "product_id": {mug.ID.String()}}, 1},
+		{"search product name", url.Values{"search": {"kettle"}}, 1},
+		{"search matches no product", url.Values{"search": {"teapot"}}, 0},
+		{"created in range", url.Values{"from": {"2000-01-01"}, "to": {"2999-12-31"}}, 3},
+		{"created before range", url.Values{"to": {"2000-01-01"}}, 0},
+		{"filter total at least", url.Values{"filter[total_cents][gte]": {"3000"}}, 2},
+		{"filter total range", url.Values{"filter[total_cents][gt]": {"1600"}, "filter[total_cents][lt]": {"4000"}}, 1},
+		{"filter status in", url.Values{"filter[status][in]": {"paid,cancelled"}}, 1},
+		{"filter buyer like", url.Values{"filter[buyer_id][like]": {"ALI"}}, 2},
+		{"filter shorthand eq", url.Values{"filter[buyer_id]": {"bob"}}, 1},
+		{"filter created after", url.Values{"filter[created_at][gte]": {"2000-01-01T00:00:00Z"}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, page := listOrders(t, server, tt.query)
+			if rec.Code != http.StatusOK || len(page.Items) != tt.want || page.Pagination.Count != int64(tt.want) {
+				t.Fatalf("expected %d orders, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Totals: bob 40.00, kettle 30.00, alice's mugs 16.00
+	_, page := listOrders(t, server, url.Values{"sort": {"-total_cents"}})
+	if len(page.Items) != 3 || page.Items[0].TotalCents != 4_000 || page.Items[2].TotalCents != 1_600 {
+		t.Fatalf("unexpected order by -total_cents: %+v", page.Items)
+	}
+
+	for _, bad := range []url.Values{
+		{"sort": {"buyer_id"}},
+		{"status": {"lost"}},
+		{"from": {"2024-02-01"}, "to": {"2024-01-01"}},
+		{"cursor": {"not-a-cursor"}},
+		{"sort": {"total_cents,total_cents"}},
+		{"filter[tax_cents][gt]": {"1"}},
+		{"filter[total_cents][like]": {"1"}},
+		{"filter[total_cents][gte]": {"a lot"}},
+		{"filter[created_at][gte]": {"yesterday"}},
+		{"filter[status": {"paid"}},
+	} {
+		if rec, _ := listOrders(t, server, bad); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%v expected 400, got %d: %s", bad, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestOrderListCursorPagination(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	pen := createProduct(t, db, "Fountain Pen", 100, 500, "USD")
+	const orders = 7
+	for i := 1; i <= orders; i++ {
+		// Distinct totals, with two orders sharing one to exercise the id tie-break
+		if rec := postJSON(t, server, "/api/orders", map[string]any{"buyer_id": "carol", "product_id": pen.ID.String(), "quantity": max(i, 2)}); rec.Code != http.StatusCreated {
+			t.Fatalf("create order expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	ids := func(items []dto.OrderResponse) string {
+		var s string
+		for _, o := range items {
+			s += o.ID + ","
+		}
+		return s
+	}
+	for _, sort := range []string{"-created_at", "total_cents"} {
+		seen := map[string]bool{}
+		var (
+			last  int64
+			pages []orderPage
+		)
+		query := url.Values{"per_page": {"3"}, "sort": {sort}}
+		for {
+			rec, page := listOrders(t, server, query)
+			if rec.Code != http.StatusOK || len(pages) > orders {
+				t.Fatalf("sort %s: expected 200, got %d: %s", sort, rec.Code, rec.Body.String())
+			}
+			if (len(pages) == 0) != (page.Pagination.PrevCursor == "") {
+				t.Fatalf("sort %s: only the first page should lack a prev_cursor, page %d: %+v", sort, len(pages), page.Pagination)
+			}
+			for _, o := range page.Items {
+				if seen[o.ID] {
+					t.Fatalf("sort %s: order %s returned twice", sort, o.ID)
+				}
+				if sort == "total_cents" && o.TotalCents < last {
+					t.Fatalf("sort %s: totals out of order", sort)
+				}
+				seen[o.ID], last = true, o.TotalCents
+			}
+			pages = append(pages, page)
+			if page.Pagination.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", page.Pagination.NextCursor)
+		}
+		if len(seen) != orders {
+			t.Fatalf("sort %s: expected %d orders across the pages, got %d", sort, orders, len(seen))
+		}
+
+		// prev_cursor walks back over the same pages
+		for i := len(pages) - 1; i > 0; i-- {
+			query.Set("cursor", pages[i].Pagination.PrevCursor)
+			rec, page := listOrders(t, server, query)
+			if rec.Code != http.StatusOK || ids(page.Items) != ids(pages[i-1].Items) {
+				t.Fatalf("sort %s: going back from page %d expected %s, got %d: %s", sort, i, ids(pages[i-1].Items), rec.Code, rec.Body.String())
+			}
+			if (i == 1) != (page.Pagination.PrevCursor == "") || page.Pagination.NextCursor == "" {
+				t.Fatalf("sort %s: unexpected cursors going back to page %d: %+v", sort, i-1, page.Pagination)
+			}
+		}
+	}
+
+	// Cursor pages count only on request, page requests unless told not to
+	_, first := listOrders(t, server, url.Values{"per_page": {"3"}})
+	cursorQuery := url.Values{"per_page": {"3"}, "cursor": {first.Pagination.NextCursor}}
+	if _, page := listOrders(t, server, cursorQuery); page.Pagination.Count != 0 {
+		t.Fatalf("cursor page expected no count, got %d", page.Pagination.Count)
+	}
+	cursorQuery.Set("count", "true")
+	if _, page := listOrders(t, server, cursorQuery); page.Pagination.Count != orders || page.Pagination.MaxPage != 3 {
+		t.Fatalf("cursor page with count=true expected %d orders over 3 pages, got %+v", orders, page.Pagination)
+	}
+	if _, page := listOrders(t, server, url.Values{"per_page": {"3"}, "count": {"false"}}); page.Pagination.Count != 0 || len(page.Items) != 3 {
+		t.Fatalf("count=false expected no count, got %+v", page.Pagination)
+	}
+
+	// Cursors are signed, so an edited one is rejected
+	tampered := []byte(first.Pagination.NextCursor)
+	tampered[len(tampered)/4] ^= 1
+	if rec, _ := listOrders(t, server, url.Values{"cursor": {string(tampered)}}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a tampered cursor expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A cursor only continues the sort it was issued for
+	_, page := listOrders(t, server, url.Values{"per_page": {"2"}})
+	rec, _ := listOrders(t, server, url.Values{"per_page": {"2"}, "sort": {"total_cents"}, "cursor": {page.Pagination.NextCursor}})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("a cursor from another sort expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,369 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+func createProduct(t *testing.T, db *gorm.DB, name string, stock int, priceCents int64, currency string) entities.Product {
+	t.Helper()
+	p, err := productRepo.NewProductRepository(db).Create(context.Background(), db, entities.Product{
+		Name: name, Stock: stock, PriceCents: priceCents, Currency: currency,
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	// Stock is held in the default warehouse, where orders take it from
+	if err := newInventory(db).Open(context.Background(), db, p.ID, stock); err != nil {
+		t.Fatalf("failed to open stock: %v", err)
+	}
+	return p
+}
+
+func newInventory(db *gorm.DB) inventoryService.InventoryService {
+	return inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(db), productRepo.NewProductRepository(db), warehouseRepo.NewWarehouseRepository(db), constants.ENUM_ALLOCATION_SPLIT, db)
+}
+
+func stockOf(t *testing.T, db *gorm.DB, p entities.Product) int {
+	t.Helper()
+	var reloaded entities.Product
+	if err := db.Where("id = ?", p.ID).Take(&reloaded).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	return reloaded.Stock
+}
+
+func TestMultiLineOrderSnapshotsPricesAndComputesTotals(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	shirt := createProduct(t, db, "Shirt", 10, 1_999, "USD")
+	mug := createProduct(t, db, "Mug", 10, 850, "USD")
+
+	body, _ := json.Marshal(map[string]any{
+		"buyer_id": "buyer-1",
+		"items": []map[string]any{
+			{"product_id": shirt.ID.String(), "quantity": 2},
+			{"product_id": mug.ID.String(), "quantity": 1},
+			// Repeated products are merged into the first line
+			{"product_id": shirt.ID.String(), "quantity": 1},
+		},
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Data dto.OrderResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	order := created.Data
+	if len(order.Items) != 2 || order.Items[0].ProductName != "Shirt" || order.Items[0].Quantity != 3 || order.Items[1].ProductName != "Mug" {
+		t.Fatalf("unexpected lines: %#v", order.Items)
+	}
+	if order.Items[0].UnitPriceCents != 1_999 || order.Items[0].LineTotalCents != 5_997 {
+		t.Fatalf("unexpected shirt line: %#v", order.Items[0])
+	}
+	if order.Currency != "USD" || order.SubtotalCents != 6_847 || order.TaxCents != 0 || order.TotalCents != 6_847 {
+		t.Fatalf("unexpected totals: %#v", order)
+	}
+	if stockOf(t, db, shirt) != 7 || stockOf(t, db, mug) != 9 {
+		t.Fatalf("stock not decremented per line")
+	}
+
+	// Repricing the product must not change the order
+	if err := db.Model(&entities.Product{}).Where("id = ?", shirt.ID).Update("price_cents", 2_500).Error; err != nil {
+		t.Fatalf("reprice: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/"+order.ID, nil))
+	var fetched struct {
+		Data dto.OrderResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &fetched)
+	if fetched.Data.SubtotalCents != 6_847 || len(fetched.Data.Items) != 2 || fetched.Data.Items[0].UnitPriceCents != 1_999 {
+		t.Fatalf("order changed after repricing: %s", rec.Body.String())
+	}
+}
+
+func TestOrderTaxAndAllOrNothingStock(t *testing.T) {
+	_, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	svc := orderService.NewOrderService(orderRepo.NewOrderRepository(db), productRepo.NewProductRepository(db), newInventory(db), nil, 825, db)
+	book := createProduct(t, db, "Book", 5, 1_250, "USD")
+	pen := createProduct(t, db, "Pen", 1, 199, "USD")
+	euro := createProduct(t, db, "Croissant", 5, 300, "EUR")
+
+	order, err := svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer-1", Items: []dto.OrderItemRequest{
+		{ProductID: book.ID.String(), Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// 8.25% of 12.50 is 1.03125, rounded to 1.03
+	if order.SubtotalCents != 1_250 || order.TaxCents != 103 || order.TotalCents != 1_353 {
+		t.Fatalf("unexpected totals: %#v", order)
+	}
+
+	// The pen line cannot be filled, so the book line must not take stock either
+	_, err = svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer-2", Items: []dto.OrderItemRequest{
+		{ProductID: book.ID.String(), Quantity: 2},
+		{ProductID: pen.ID.String(), Quantity: 2},
+	}})
+	if !errors.Is(err, dto.ErrInsufficientStock) {
+		t.Fatalf("expected insufficient stock, got %v", err)
+	}
+	if stockOf(t, db, book) != 4 || stockOf(t, db, pen) != 1 {
+		t.Fatalf("failed order changed stock")
+	}
+
+	_, err = svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer-3", Items: []dto.OrderItemRequest{
+		{ProductID: book.ID.String(), Quantity: 1},
+		{ProductID: euro.ID.String(), Quantity: 1},
+	}})
+	if !errors.Is(err, dto.ErrMixedCurrency) {
+		t.Fatalf("expected mixed currency error, got %v", err)
+	}
+	if stockOf(t, db, book) != 4 {
+		t.Fatalf("rejected order changed stock")
+	}
+}
+
+func TestInvalidOrderLinesNameTheProblem(t *testing.T) {
+	_, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	svc := orderService.NewOrderService(orderRepo.NewOrderRepository(db), productRepo.NewProductRepository(db), newInventory(db), nil, 0, db)
+	lamp := createProduct(t, db, "Lamp", 10, 1_000, "USD")
+
+	tooMany := make([]dto.OrderItemRequest, 0, dto.MaxOrderItems+1)
+	for i := 0; i <= dto.MaxOrderItems; i++ {
+		tooMany = append(tooMany, dto.OrderItemRequest{ProductID: uuid.NewString(), Quantity: 1})
+	}
+	// The service is also reached without request binding, from reservation confirm
+	for _, tt := range []struct {
+		items []dto.OrderItemRequest
+		want  error
+	}{
+		{tooMany, dto.ErrTooManyOrderItems},
+		{[]dto.OrderItemRequest{{ProductID: lamp.ID.String(), Quantity: 0}}, dto.ErrInvalidQuantity},
+		{[]dto.OrderItemRequest{{ProductID: "not-a-uuid", Quantity: 1}}, dto.ErrProductNotFound},
+		{nil, dto.ErrOrderItemsRequired},
+	} {
+		if _, err := svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer", Items: tt.items}); !errors.Is(err, tt.want) {
+			t.Fatalf("expected %v, got %v", tt.want, err)
+		}
+	}
+	if got := stockOf(t, db, lamp); got != 10 {
+		t.Fatalf("expected stock untouched, got %d", got)
+	}
+}
+
+func TestOverlappingOrdersDoNotDeadlock(t *testing.T) {
+	_, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	svc := orderService.NewOrderService(orderRepo.NewOrderRepository(db), productRepo.NewProductRepository(db), newInventory(db), nil, 0, db)
+	a := createProduct(t, db, "A", 100, 100, "USD")
+	b := createProduct(t, db, "B", 100, 100, "USD")
+
+	// Half the buyers list A first, the other half B first
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			items := []dto.OrderItemRequest{{ProductID: a.ID.String(), Quantity: 1}, {ProductID: b.ID.String(), Quantity: 1}}
+			if i%2 == 1 {
+				items[0], items[1] = items[1], items[0]
+			}
+			if _, err := svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer", Items: items}); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		t.Fatalf("expected every order to succeed, got %d errors, first: %v", len(errs), errs[0])
+	}
+	if stockOf(t, db, a) != 60 || stockOf(t, db, b) != 60 {
+		t.Fatalf("unexpected stock: a=%d b=%d", stockOf(t, db, a), stockOf(t, db, b))
+	}
+}
+
+func postJSON(t *testing.T, server http.Handler, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOrderLifecycleGuardsTransitionsAndRestoresStock(t *testing.T) {
+	server, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	lamp := createProduct(t, db, "Lamp", 10, 4_500, "USD")
+	rec := postJSON(t, server, "/api/orders", map[string]any{
+		"buyer_id": "buyer-1",
+		"items":    []map[string]any{{"product_id": lamp.ID.String(), "quantity": 3}},
+	})
+	var created struct {
+		Data dto.OrderResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	id := created.Data.ID
+	if rec.Code != http.StatusCreated || created.Data.Status != "pending" {
+		t.Fatalf("expected a pending order, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Cannot fulfill before payment
+	if rec := postJSON(t, server, "/api/orders/"+id+"/fulfill", nil); rec.Code != http.StatusConflict {
+		t.Fatalf("fulfill before pay expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, action := range []string{"pay", "fulfill"} {
+		if rec := postJSON(t, server, "/api/orders/"+id+"/"+action, nil); rec.Code != http.StatusOK {
+			t.Fatalf("%s expected 200, got %d: %s", action, rec.Code, rec.Body.String())
+		}
+	}
+	// Cancelling is only possible before payment; a paid order is refunded instead
+	if rec := postJSON(t, server, "/api/orders/"+id+"/cancel", nil); rec.Code != http.StatusConflict {
+		t.Fatalf("cancel after fulfil expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/orders/"+id, nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("deleting a fulfilled order expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if stockOf(t, db, lamp) != 7 {
+		t.Fatalf("expected stock 7 while the order is held")
+	}
+
+	rec = postJSON(t, server, "/api/orders/"+id+"/refund", map[string]any{"reason": "damaged in transit"})
+	var refunded struct {
+		Data dto.OrderResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &refunded)
+	if rec.Code != http.StatusOK || refunded.Data.Status != "refunded" {
+		t.Fatalf("refund expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if stockOf(t, db, lamp) != 10 {
+		t.Fatalf("refund did not restore stock, got %d", stockOf(t, db, lamp))
+	}
+	// Terminal: a second refund must not give the stock back again
+	if rec := postJSON(t, server, "/api/orders/"+id+"/refund", nil); rec.Code != http.StatusConflict {
+		t.Fatalf("second refund expected 409, got %d", rec.Code)
+	}
+	if stockOf(t, db, lamp) != 10 {
+		t.Fatalf("second refund changed stock")
+	}
+
+	hrec := httptest.NewRecorder()
+	server.ServeHTTP(hrec, httptest.NewRequest(http.MethodGet, "/api/orders/"+id+"/history", nil))
+	var history struct {
+		Data []dto.OrderTransitionResponse `json:"data"`
+	}
+	_ = json.Unmarshal(hrec.Body.Bytes(), &history)
+	want := [][2]string{{"", "pending"}, {"pending", "paid"}, {"paid", "fulfilled"}, {"fulfilled", "refunded"}}
+	if len(history.Data) != len(want) {
+		t.Fatalf("unexpected history: %s", hrec.Body.String())
+	}
+	for i, w := range want {
+		if history.Data[i].FromStatus != w[0] || history.Data[i].ToStatus != w[1] {
+			t.Fatalf("history[%d] = %+v, want %v", i, history.Data[i], w)
+		}
+	}
+	if history.Data[3].Reason != "damaged in transit" {
+		t.Fatalf("refund reason not recorded: %+v", history.Data[3])
+	}
+}
+
+func TestConcurrentCancelsRestoreStockOnceAndDeleteReturnsPendingStock(t *testing.T) {
+	_, _, db, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	svc := orderService.NewOrderService(orderRepo.NewOrderRepository(db), productRepo.NewProductRepository(db), newInventory(db), nil, 0, db)
+	chair := createProduct(t, db, "Chair", 10, 7_000, "USD")
+	order, err := svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer-1", Items: []dto.OrderItemRequest{{ProductID: chair.ID.String(), Quantity: 4}}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Transition(ctx, order.ID, "cancelled", "")
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+			} else if !errors.Is(err, dto.ErrInvalidTransition) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 || stockOf(t, db, chair) != 10 {
+		t.Fatalf("expected one cancel and stock 10, got %d cancels and stock %d", succeeded, stockOf(t, db, chair))
+	}
+
+	pending, err := svc.Create(ctx, dto.OrderCreateRequest{BuyerID: "buyer-2", Items: []dto.OrderItemRequest{{ProductID: chair.ID.String(), Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := svc.Delete(ctx, pending.ID); err != nil {
+		t.Fatalf("delete pending: %v", err)
+	}
+	if stockOf(t, db, chair) != 10 {
+		t.Fatalf("deleting a pending order must return its stock, got %d", stockOf(t, db, chair))
+	}
+	if err := svc.Delete(ctx, order.ID); err != nil {
+		t.Fatalf("delete cancelled: %v", err)
+	}
+	if stockOf(t, db, chair) != 10 {
+		t.Fatalf("deleting a cancelled order must not return stock twice, got %d", stockOf(t, db, chair))
+	}
+}
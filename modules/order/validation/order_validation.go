@@ -2,6 +2,7 @@ package validation
 
 import (
 	"github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -14,5 +15,37 @@ func NewOrderValidation() *OrderValidation {
 }
 
 func (v *OrderValidation) ValidateOrderCreateRequest(req dto.OrderCreateRequest) error {
-	return v.validate.Struct(req)
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if len(req.IdempotencyKey) > dto.MaxIdempotencyKeyLength {
+		return dto.ErrIdempotencyKeyTooLong
+	}
+	// Either items or the single-line product_id/quantity, never both
+	single := req.ProductID != "" || req.Quantity != 0
+	if len(req.Items) > 0 == single {
+		return dto.ErrOrderItemsRequired
+	}
+	if single && req.ProductID == "" {
+		return dto.ErrOrderItemsRequired
+	}
+	if single && req.Quantity < 1 {
+		return dto.ErrInvalidQuantity
+	}
+	return ValidateShipping(req.Shipping)
+}
+
+func (v *OrderValidation) ValidateOrderListRequest(req dto.OrderListRequest) error {
+	if !req.From.IsZero() && !req.To.IsZero() && req.From.After(req.To) {
+		return dto.ErrInvalidDateRange
+	}
+	return nil
+}
+
+// ValidateShipping checks the allocation options shared by orders and confirmed reservations.
+func ValidateShipping(s dto.Shipping) error {
+	if s.Allocation == constants.ENUM_ALLOCATION_NEAREST && s.ShipTo == nil {
+		return dto.ErrShipToRequired
+	}
+	return nil
 }
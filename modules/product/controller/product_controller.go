@@ -1,12 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/xkillx/go-gin-order-settlement/modules/product/dto"
 	"github.com/xkillx/go-gin-order-settlement/modules/product/service"
 	"github.com/xkillx/go-gin-order-settlement/modules/product/validation"
-	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/do"
@@ -51,7 +52,7 @@ func (c *productController) Create(ctx *gin.Context) {
 	result, err := c.service.Create(ctx.Request.Context(), req)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_PRODUCT, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(errorStatus(err), res)
 		return
 	}
 
@@ -73,15 +74,21 @@ func (c *productController) GetByID(ctx *gin.Context) {
 }
 
 func (c *productController) List(ctx *gin.Context) {
-	var p pkgdto.PaginationRequest
-	if err := ctx.ShouldBindQuery(&p); err != nil {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	var filter dto.ProductListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
-	p.Default()
 
-	items, meta, err := c.service.List(ctx.Request.Context(), p)
+	items, meta, err := c.service.List(ctx.Request.Context(), filter, q)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_PRODUCT, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
@@ -114,7 +121,7 @@ func (c *productController) Update(ctx *gin.Context) {
 	result, err := c.service.Update(ctx.Request.Context(), id, req)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_PRODUCT, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(errorStatus(err), res)
 		return
 	}
 
@@ -126,10 +133,21 @@ func (c *productController) Delete(ctx *gin.Context) {
 	id := ctx.Param("id")
 	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_PRODUCT, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		ctx.AbortWithStatusJSON(errorStatus(err), res)
 		return
 	}
 
 	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_PRODUCT, nil)
 	ctx.JSON(http.StatusOK, res)
 }
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrSKUTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -26,22 +26,64 @@ var (
 	ErrFailedCreate       = errors.New("failed to create product")
 	ErrFailedUpdate       = errors.New("failed to update product")
 	ErrFailedDelete       = errors.New("failed to delete product")
+	ErrStockNotEditable   = errors.New("stock cannot be set directly, use POST /api/inventory/products/:id/adjustments")
+	ErrSKUTaken           = errors.New("sku already exists")
+	ErrInvalidSKU         = errors.New("sku must be 1 to 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	ErrInvalidCategories  = errors.New("categories must be at most 10 distinct names of 1 to 64 characters")
+	ErrInvalidTags        = errors.New("tags must be at most 20 distinct lowercase words of 1 to 32 letters, digits or '-'")
+)
+
+const (
+	MaxCategories = 10
+	MaxTags       = 20
 )
 
 type (
+	// Prices are in the currency's minor unit (cents); currency defaults to USD
+	// A SKU is generated from the id when none is given; SKUs are stored upper case
 	ProductCreateRequest struct {
-		Name  string `json:"name" form:"name" binding:"required,min=2"`
-		Stock int    `json:"stock" form:"stock" binding:"required,min=0"`
+		SKU        string   `json:"sku" form:"sku"`
+		Name       string   `json:"name" form:"name" binding:"required,min=2"`
+		Stock      int      `json:"stock" form:"stock" binding:"required,min=0"`
+		PriceCents int64    `json:"price_cents" form:"price_cents" binding:"min=0"`
+		Currency   string   `json:"currency" form:"currency" binding:"omitempty,iso4217"`
+		Status     string   `json:"status" form:"status" binding:"omitempty,oneof=active archived"`
+		Categories []string `json:"categories" form:"categories"`
+		Tags       []string `json:"tags" form:"tags"`
 	}
 
+	// Stock is only accepted to reject it: stock changes go through inventory adjustments so each
+	// one is recorded. Categories and Tags replace the product's when sent, [] clearing them.
 	ProductUpdateRequest struct {
-		Name  string `json:"name" form:"name" binding:"omitempty,min=2"`
-		Stock *int   `json:"stock" form:"stock" binding:"omitempty,min=0"`
+		SKU        string   `json:"sku" form:"sku"`
+		Name       string   `json:"name" form:"name" binding:"omitempty,min=2"`
+		Stock      *int     `json:"stock" form:"stock" binding:"omitempty,min=0"`
+		PriceCents *int64   `json:"price_cents" form:"price_cents" binding:"omitempty,min=0"`
+		Currency   string   `json:"currency" form:"currency" binding:"omitempty,iso4217"`
+		Status     string   `json:"status" form:"status" binding:"omitempty,oneof=active archived"`
+		Categories []string `json:"categories" form:"categories"`
+		Tags       []string `json:"tags" form:"tags"`
+	}
+
+	// ProductListRequest is the product list's shorthand filters, alongside the shared list
+	// parameters. Category and Tag match products carrying that label.
+	ProductListRequest struct {
+		Status   string `form:"status" binding:"omitempty,oneof=active archived"`
+		Category string `form:"category" binding:"omitempty,max=64"`
+		Tag      string `form:"tag" binding:"omitempty,max=32"`
 	}
 
 	ProductResponse struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Stock int    `json:"stock"`
+		ID         string   `json:"id"`
+		SKU        string   `json:"sku"`
+		Name       string   `json:"name"`
+		Stock      int      `json:"stock"`
+		Reserved   int      `json:"reserved"`
+		Available  int      `json:"available"`
+		PriceCents int64    `json:"price_cents"`
+		Currency   string   `json:"currency"`
+		Status     string   `json:"status"`
+		Categories []string `json:"categories"`
+		Tags       []string `json:"tags"`
 	}
 )
@@ -2,8 +2,14 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -13,17 +19,54 @@ type (
 	ProductRepository interface {
 		Create(ctx context.Context, tx *gorm.DB, p entities.Product) (entities.Product, error)
 		FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Product, error)
-		List(ctx context.Context, tx *gorm.DB, limit, offset int) ([]entities.Product, int64, error)
+		FindBySKU(ctx context.Context, tx *gorm.DB, sku string) (entities.Product, error)
+		List(ctx context.Context, tx *gorm.DB, filter ProductFilter, q query.Request) ([]entities.Product, pkgdto.PaginationResponse, error)
 		Update(ctx context.Context, tx *gorm.DB, p entities.Product) (entities.Product, error)
-		Delete(ctx context.Context, tx *gorm.DB, id string) error
+		Delete(ctx context.Context, tx *gorm.DB, id string) (bool, error)
+		LockForUpdate(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]entities.Product, error)
 		DecrementStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error)
+		IncrementStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error
+		SetStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, stock int) error
+		Reserve(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error)
+		ReleaseReserved(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error
+		ConsumeReserved(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error)
 	}
 
 	productRepository struct {
 		db *gorm.DB
 	}
+
+	// ProductFilter is the product list's own parameters, applied on top of the shared filters;
+	// zero values are ignored.
+	ProductFilter struct {
+		Status   string
+		Category string
+		Tag      string
+	}
 )
 
+// productSchema is what the product list may be filtered and sorted by; search is a full-text
+// match on the name or an exact SKU.
+var productSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":          {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"sku":         {Column: "sku", Type: query.String, Ops: query.Text, Sort: true},
+		"name":        {Column: "name", Type: query.String, Ops: query.Text, Sort: true},
+		"status":      {Column: "status", Type: query.String, Ops: query.Equality},
+		"stock":       {Column: "stock", Type: query.Int, Ops: query.Range, Sort: true},
+		"reserved":    {Column: "reserved", Type: query.Int, Ops: query.Range},
+		"price_cents": {Column: "price_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"currency":    {Column: "currency", Type: query.String, Ops: query.Equality},
+		"created_at":  {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"updated_at":  {Column: "updated_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-created_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		// Same expression as idx_products_name_search, so the index is used
+		return db.Where("to_tsvector('simple', name) @@ plainto_tsquery('simple', ?) OR sku = ?", term, strings.ToUpper(term))
+	},
+}
+
 func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &productRepository{db: db}
 }
@@ -52,44 +95,146 @@ func (r *productRepository) FindByID(ctx context.Context, tx *gorm.DB, id string
 	return p, nil
 }
 
-func (r *productRepository) List(ctx context.Context, tx *gorm.DB, limit, offset int) ([]entities.Product, int64, error) {
+func (r *productRepository) FindBySKU(ctx context.Context, tx *gorm.DB, sku string) (entities.Product, error) {
 	db := r.getDB(tx)
-	var (
-		items []entities.Product
-		total int64
-	)
-	if err := db.WithContext(ctx).Model(&entities.Product{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	if err := db.WithContext(ctx).Model(&entities.Product{}).
-		Order("created_at DESC").
-		Limit(limit).Offset(offset).
-		Find(&items).Error; err != nil {
-		return nil, 0, err
+	var p entities.Product
+	if err := db.WithContext(ctx).Where("sku = ?", sku).Take(&p).Error; err != nil {
+		return entities.Product{}, err
+	}
+	return p, nil
+}
+
+// List leaves out deleted products, as every query on entities.Product does, and archived ones
+// unless the request filters on status.
+func (r *productRepository) List(ctx context.Context, tx *gorm.DB, filter ProductFilter, q query.Request) ([]entities.Product, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	hideArchived := filter.Status == ""
+	for _, f := range q.Filters {
+		if f.Field == "status" {
+			hideArchived = false
+		}
+	}
+	return query.Find[entities.Product](func() *gorm.DB {
+		products := applyProductFilter(db.WithContext(ctx).Model(&entities.Product{}), filter)
+		if hideArchived {
+			products = products.Where("status <> ?", constants.ENUM_PRODUCT_STATUS_ARCHIVED)
+		}
+		return products
+	}, q, productSchema)
+}
+
+func applyProductFilter(db *gorm.DB, filter ProductFilter) *gorm.DB {
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	// @> as idx_products_categories and idx_products_tags index it
+	if filter.Category != "" {
+		label, _ := json.Marshal([]string{filter.Category})
+		db = db.Where("categories @> ?::jsonb", string(label))
+	}
+	if filter.Tag != "" {
+		label, _ := json.Marshal([]string{filter.Tag})
+		db = db.Where("tags @> ?::jsonb", string(label))
 	}
-	return items, total, nil
+	return db
 }
 
 func (r *productRepository) Update(ctx context.Context, tx *gorm.DB, p entities.Product) (entities.Product, error) {
 	db := r.getDB(tx)
-	if err := db.WithContext(ctx).Clauses(clause.Returning{}).Updates(&p).Error; err != nil {
+	// Stock and reserved are only changed by the methods below, never from a loaded copy
+	if err := db.WithContext(ctx).Clauses(clause.Returning{}).Omit("stock", "reserved").Updates(&p).Error; err != nil {
 		return entities.Product{}, err
 	}
 	return p, nil
 }
 
-func (r *productRepository) Delete(ctx context.Context, tx *gorm.DB, id string) error {
+// Delete archives the product and soft deletes it, so it leaves listings while the orders that
+// reference it keep resolving. It reports false when there was no such product.
+func (r *productRepository) Delete(ctx context.Context, tx *gorm.DB, id string) (bool, error) {
 	db := r.getDB(tx)
-	return db.WithContext(ctx).Delete(&entities.Product{}, "id = ?", id).Error
+	res := db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     constants.ENUM_PRODUCT_STATUS_ARCHIVED,
+			"deleted_at": time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// LockForUpdate row-locks the products in id order. Every writer that touches several products
+// locks them through here, so two orders sharing products can never wait on each other in a cycle.
+func (r *productRepository) LockForUpdate(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) ([]entities.Product, error) {
+	db := r.getDB(tx)
+	var items []entities.Product
+	if err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 func (r *productRepository) DecrementStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error) {
 	db := r.getDB(tx)
 	res := db.WithContext(ctx).Model(&entities.Product{}).
-		Where("id = ? AND stock >= ?", productID, qty).
+		Where("id = ? AND stock - reserved >= ?", productID, qty).
 		Update("stock", gorm.Expr("stock - ?", qty))
 	if res.Error != nil {
 		return false, res.Error
 	}
 	return res.RowsAffected > 0, nil
 }
+
+func (r *productRepository) IncrementStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ?", productID).
+		Update("stock", gorm.Expr("stock + ?", qty)).Error
+}
+
+// SetStock overwrites on-hand stock; callers hold the product's row lock and record the movement.
+func (r *productRepository) SetStock(ctx context.Context, tx *gorm.DB, productID uuid.UUID, stock int) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ?", productID).
+		Update("stock", stock).Error
+}
+
+// Reserve holds qty of the available stock (stock - reserved) and reports false when there is not enough.
+func (r *productRepository) Reserve(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ? AND stock - reserved >= ?", productID, qty).
+		Update("reserved", gorm.Expr("reserved + ?", qty))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseReserved makes held stock available again.
+func (r *productRepository) ReleaseReserved(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ?", productID).
+		Update("reserved", gorm.Expr("reserved - ?", qty)).Error
+}
+
+// ConsumeReserved takes held stock for good, as when a reservation becomes an order.
+func (r *productRepository) ConsumeReserved(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).Model(&entities.Product{}).
+		Where("id = ? AND reserved >= ? AND stock >= ?", productID, qty, qty).
+		Updates(map[string]any{
+			"stock":    gorm.Expr("stock - ?", qty),
+			"reserved": gorm.Expr("reserved - ?", qty),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -2,92 +2,228 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
 	"github.com/xkillx/go-gin-order-settlement/modules/product/dto"
 	"github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
 	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"gorm.io/gorm"
 )
 
 type ProductService interface {
 	Create(ctx context.Context, req dto.ProductCreateRequest) (dto.ProductResponse, error)
 	GetByID(ctx context.Context, id string) (dto.ProductResponse, error)
-	List(ctx context.Context, p pkgdto.PaginationRequest) ([]dto.ProductResponse, pkgdto.PaginationResponse, error)
+	List(ctx context.Context, req dto.ProductListRequest, q query.Request) ([]dto.ProductResponse, pkgdto.PaginationResponse, error)
 	Update(ctx context.Context, id string, req dto.ProductUpdateRequest) (dto.ProductResponse, error)
 	Delete(ctx context.Context, id string) error
 }
 
+// StockLedger puts a new product's opening stock into the default warehouse and records it as the
+// product's first movement, in the caller's transaction, i.e. the inventory service.
+type StockLedger interface {
+	Open(ctx context.Context, tx *gorm.DB, productID uuid.UUID, qty int) error
+}
+
 type productService struct {
-	repo repository.ProductRepository
-	db   *gorm.DB
+	repo   repository.ProductRepository
+	ledger StockLedger
+	db     *gorm.DB
 }
 
-func NewProductService(repo repository.ProductRepository, db *gorm.DB) ProductService {
-	return &productService{repo: repo, db: db}
+func NewProductService(repo repository.ProductRepository, ledger StockLedger, db *gorm.DB) ProductService {
+	return &productService{repo: repo, ledger: ledger, db: db}
 }
 
 func (s *productService) Create(ctx context.Context, req dto.ProductCreateRequest) (dto.ProductResponse, error) {
 	p := entities.Product{
-		Name:  req.Name,
-		Stock: req.Stock,
+		SKU:        strings.ToUpper(req.SKU),
+		Name:       req.Name,
+		Stock:      req.Stock,
+		PriceCents: req.PriceCents,
+		Currency:   req.Currency,
+		Status:     req.Status,
+		Categories: trimLabels(req.Categories),
+		Tags:       req.Tags,
+	}
+	if p.Currency == "" {
+		p.Currency = constants.ENUM_CURRENCY_DEFAULT
+	}
+	if p.Status == "" {
+		p.Status = constants.ENUM_PRODUCT_STATUS_ACTIVE
+	}
+	var created entities.Product
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.checkSKU(ctx, tx, p.SKU, uuid.Nil); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.repo.Create(ctx, tx, p); err != nil {
+			return err
+		}
+		if created.Stock == 0 {
+			return nil
+		}
+		// The opening stock is the product's first movement, so its ledger adds up from the start
+		return s.ledger.Open(ctx, tx, created.ID, created.Stock)
+	})
+	if isSKUConflict(err) {
+		return dto.ProductResponse{}, dto.ErrSKUTaken
 	}
-	created, err := s.repo.Create(ctx, s.db, p)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
-	return dto.ProductResponse{ID: created.ID.String(), Name: created.Name, Stock: created.Stock}, nil
+	return toProductResponse(created), nil
 }
 
-func (s *productService) GetByID(ctx context.Context, id string) (dto.ProductResponse, error) {
+// checkSKU rejects a SKU another live product already has; products created without one get
+// a unique one derived from their id.
+func (s *productService) checkSKU(ctx context.Context, tx *gorm.DB, sku string, self uuid.UUID) error {
+	if sku == "" {
+		return nil
+	}
+	existing, err := s.repo.FindBySKU(ctx, tx, sku)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != self {
+		return dto.ErrSKUTaken
+	}
+	return nil
+}
+
+// isSKUConflict reports a unique violation on idx_products_sku: a concurrent create or rename
+// can still take the SKU between checkSKU and the write.
+func isSKUConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_products_sku"
+}
+
+// find loads a product that has not been deleted.
+func (s *productService) find(ctx context.Context, id string) (entities.Product, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.Product{}, dto.ErrProductNotFound
+	}
 	p, err := s.repo.FindByID(ctx, s.db, id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return dto.ProductResponse{}, dto.ErrProductNotFound
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Product{}, dto.ErrProductNotFound
 		}
+		return entities.Product{}, err
+	}
+	return p, nil
+}
+
+func (s *productService) GetByID(ctx context.Context, id string) (dto.ProductResponse, error) {
+	p, err := s.find(ctx, id)
+	if err != nil {
 		return dto.ProductResponse{}, err
 	}
-	return dto.ProductResponse{ID: p.ID.String(), Name: p.Name, Stock: p.Stock}, nil
+	return toProductResponse(p), nil
 }
 
-func (s *productService) List(ctx context.Context, p pkgdto.PaginationRequest) ([]dto.ProductResponse, pkgdto.PaginationResponse, error) {
-	p.Default()
-	items, total, err := s.repo.List(ctx, s.db, p.GetLimit(), p.GetOffset())
+func (s *productService) List(ctx context.Context, req dto.ProductListRequest, q query.Request) ([]dto.ProductResponse, pkgdto.PaginationResponse, error) {
+	filter := repository.ProductFilter{
+		Status:   req.Status,
+		Category: strings.TrimSpace(req.Category),
+		Tag:      req.Tag,
+	}
+	items, meta, err := s.repo.List(ctx, s.db, filter, q)
 	if err != nil {
 		return nil, pkgdto.PaginationResponse{}, err
 	}
 	resp := make([]dto.ProductResponse, 0, len(items))
 	for _, it := range items {
-		resp = append(resp, dto.ProductResponse{ID: it.ID.String(), Name: it.Name, Stock: it.Stock})
-	}
-	maxPage := total / int64(p.PerPage)
-	if total%int64(p.PerPage) != 0 {
-		maxPage++
+		resp = append(resp, toProductResponse(it))
 	}
-	return resp, pkgdto.PaginationResponse{Page: p.Page, PerPage: p.PerPage, Count: total, MaxPage: maxPage}, nil
+	return resp, meta, nil
 }
 
 func (s *productService) Update(ctx context.Context, id string, req dto.ProductUpdateRequest) (dto.ProductResponse, error) {
-	p, err := s.repo.FindByID(ctx, s.db, id)
+	p, err := s.find(ctx, id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return dto.ProductResponse{}, dto.ErrProductNotFound
-		}
 		return dto.ProductResponse{}, err
 	}
+	if req.SKU != "" {
+		p.SKU = strings.ToUpper(req.SKU)
+		if err := s.checkSKU(ctx, s.db, p.SKU, p.ID); err != nil {
+			return dto.ProductResponse{}, err
+		}
+	}
 	if req.Name != "" {
 		p.Name = req.Name
 	}
-	if req.Stock != nil {
-		p.Stock = *req.Stock
+	if req.PriceCents != nil {
+		p.PriceCents = *req.PriceCents
+	}
+	if req.Currency != "" {
+		p.Currency = req.Currency
+	}
+	if req.Status != "" {
+		p.Status = req.Status
+	}
+	// nil leaves the labels as they are; an empty list clears them
+	if req.Categories != nil {
+		p.Categories = trimLabels(req.Categories)
+	}
+	if req.Tags != nil {
+		p.Tags = req.Tags
 	}
 	updated, err := s.repo.Update(ctx, s.db, p)
+	if isSKUConflict(err) {
+		return dto.ProductResponse{}, dto.ErrSKUTaken
+	}
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
-	return dto.ProductResponse{ID: updated.ID.String(), Name: updated.Name, Stock: updated.Stock}, nil
+	return toProductResponse(updated), nil
+}
+
+func toProductResponse(p entities.Product) dto.ProductResponse {
+	return dto.ProductResponse{
+		ID:         p.ID.String(),
+		SKU:        p.SKU,
+		Name:       p.Name,
+		Stock:      p.Stock,
+		Reserved:   p.Reserved,
+		Available:  p.Stock - p.Reserved,
+		PriceCents: p.PriceCents,
+		Currency:   p.Currency,
+		Status:     p.Status,
+		Categories: p.Categories,
+		Tags:       p.Tags,
+	}
 }
 
+// trimLabels trims the categories as validated, keeping an empty list non-nil.
+func trimLabels(labels []string) []string {
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		out = append(out, strings.TrimSpace(l))
+	}
+	return out
+}
+
+// Delete archives the product and soft deletes it: it leaves listings and can no longer be
+// ordered, but the orders that reference it still resolve.
 func (s *productService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, s.db, id)
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.ErrProductNotFound
+	}
+	deleted, err := s.repo.Delete(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return dto.ErrProductNotFound
+	}
+	return nil
 }
@@ -0,0 +1,241 @@
+package product_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productModule "github.com/xkillx/go-gin-order-settlement/modules/product"
+	"github.com/xkillx/go-gin-order-settlement/modules/product/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/product/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	"github.com/xkillx/go-gin-order-settlement/modules/product/service"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+)
+
+type testEnv struct {
+	server http.Handler
+	orders orderService.OrderService
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"reservations", "orders", "products"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	prdRepo := repository.NewProductRepository(db)
+	inventory := inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(db), prdRepo,
+		warehouseRepo.NewWarehouseRepository(db), constants.ENUM_ALLOCATION_SPLIT, db)
+	products := service.NewProductService(prdRepo, inventory, db)
+	orders := orderService.NewOrderService(orderRepo.NewOrderRepository(db), prdRepo, inventory, nil, 0, db)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (controller.ProductController, error) {
+		return controller.NewProductController(i, products), nil
+	})
+	engine := gin.New()
+	productModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, orders: orders}
+}
+
+func call(t *testing.T, server http.Handler, method, path string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if out != nil {
+		_ = json.Unmarshal(rec.Body.Bytes(), &struct {
+			Data any `json:"data"`
+		}{Data: out})
+	}
+	return rec
+}
+
+func TestProductCatalogFields(t *testing.T) {
+	env := setupTestServer(t)
+
+	var kettle dto.ProductResponse
+	rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{
+		"sku": "kt-100", "name": "Copper Kettle", "stock": 5, "price_cents": 3_000,
+		"categories": []string{"Kitchen", "Gifts"}, "tags": []string{"copper", "sale"},
+	}, &kettle)
+	if rec.Code != http.StatusOK || kettle.SKU != "KT-100" || kettle.Status != constants.ENUM_PRODUCT_STATUS_ACTIVE || len(kettle.Categories) != 2 || len(kettle.Tags) != 2 {
+		t.Fatalf("create expected 200 with the catalog fields, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var mug dto.ProductResponse
+	if rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{"name": "Enamel Mug", "stock": 5, "tags": []string{"sale"}}, &mug); rec.Code != http.StatusOK || mug.SKU == "" {
+		t.Fatalf("create without a sku expected a generated one, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{"sku": "KT-100", "name": "Other Kettle", "stock": 1}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("a taken sku expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPut, "/api/products/"+mug.ID, map[string]any{"sku": "kt-100"}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("renaming onto a taken sku expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, bad := range []map[string]any{
+		{"sku": "-leading-dash", "name": "Bad", "stock": 1},
+		{"sku": "has space", "name": "Bad", "stock": 1},
+		{"name": "Bad", "stock": 1, "tags": []string{"Upper"}},
+		{"name": "Bad", "stock": 1, "tags": []string{"dup", "dup"}},
+		{"name": "Bad", "stock": 1, "categories": []string{"Kitchen", "kitchen"}},
+		{"name": "Bad", "stock": 1, "categories": []string{" "}},
+		{"name": "Bad", "stock": 1, "status": "retired"},
+	} {
+		if rec := call(t, env.server, http.MethodPost, "/api/products", bad, nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%v expected 400, got %d: %s", bad, rec.Code, rec.Body.String())
+		}
+	}
+
+	var updated dto.ProductResponse
+	if rec := call(t, env.server, http.MethodPut, "/api/products/"+kettle.ID, map[string]any{"tags": []string{}}, &updated); rec.Code != http.StatusOK || len(updated.Tags) != 0 || len(updated.Categories) != 2 {
+		t.Fatalf("clearing tags expected 200 with the categories kept, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	type page struct {
+		Items      []dto.ProductResponse     `json:"items"`
+		Pagination pkgdto.PaginationResponse `json:"pagination"`
+	}
+	for _, tt := range []struct {
+		query url.Values
+		want  int
+	}{
+		{url.Values{"category": {"Kitchen"}}, 1},
+		{url.Values{"tag": {"sale"}}, 1},
+		{url.Values{"search": {"kt-100"}}, 1},
+		{url.Values{"filter[sku][like]": {"kt"}}, 1},
+		{url.Values{"status": {"active"}}, 2},
+	} {
+		var p page
+		if rec := call(t, env.server, http.MethodGet, "/api/products?"+tt.query.Encode(), nil, &p); rec.Code != http.StatusOK || len(p.Items) != tt.want {
+			t.Fatalf("%v expected %d products, got %d: %s", tt.query, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestArchivedAndDeletedProducts(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+
+	var lamp dto.ProductResponse
+	if rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{"name": "Desk Lamp", "stock": 10, "price_cents": 2_000}, &lamp); rec.Code != http.StatusOK {
+		t.Fatalf("create expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	order, err := env.orders.Create(ctx, orderDto.OrderCreateRequest{BuyerID: "buyer-1", ProductID: lamp.ID, Quantity: 2})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	// Archived products leave the default list, unless asked for, and cannot be ordered
+	if rec := call(t, env.server, http.MethodPut, "/api/products/"+lamp.ID, map[string]any{"status": "archived"}, nil); rec.Code != http.StatusOK {
+		t.Fatalf("archive expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var archived struct {
+		Items []dto.ProductResponse `json:"items"`
+	}
+	if call(t, env.server, http.MethodGet, "/api/products", nil, &archived); len(archived.Items) != 0 {
+		t.Fatalf("an archived product should leave the default list, got %+v", archived.Items)
+	}
+	for _, query := range []string{"status=archived", "filter[status]=archived"} {
+		archived.Items = nil
+		if call(t, env.server, http.MethodGet, "/api/products?"+query, nil, &archived); len(archived.Items) != 1 || archived.Items[0].ID != lamp.ID {
+			t.Fatalf("%s should list the archived product, got %+v", query, archived.Items)
+		}
+	}
+	if _, err := env.orders.Create(ctx, orderDto.OrderCreateRequest{BuyerID: "buyer-1", ProductID: lamp.ID, Quantity: 1}); !errors.Is(err, orderDto.ErrProductArchived) {
+		t.Fatalf("ordering an archived product expected ErrProductArchived, got %v", err)
+	}
+
+	// Deleting soft deletes: the product is gone, its orders are not
+	if rec := call(t, env.server, http.MethodDelete, "/api/products/"+lamp.ID, nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodGet, "/api/products/"+lamp.ID, nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("a deleted product expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodDelete, "/api/products/"+lamp.ID, nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("deleting twice expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		Items []dto.ProductResponse `json:"items"`
+	}
+	if call(t, env.server, http.MethodGet, "/api/products", nil, &list); len(list.Items) != 0 {
+		t.Fatalf("a deleted product should leave the list, got %+v", list.Items)
+	}
+	got, err := env.orders.GetByID(ctx, order.ID)
+	if err != nil || len(got.Items) != 1 || got.Items[0].ProductID != lamp.ID {
+		t.Fatalf("an order of a deleted product should still resolve, got %+v, %v", got, err)
+	}
+
+	// Its SKU is free again
+	if rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{"sku": lamp.SKU, "name": "Desk Lamp II", "stock": 1}, nil); rec.Code != http.StatusOK {
+		t.Fatalf("reusing a deleted product's sku expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConcurrentCreatesWithOneSKU(t *testing.T) {
+	env := setupTestServer(t)
+
+	const n = 8
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := call(t, env.server, http.MethodPost, "/api/products", map[string]any{"sku": "RACE-1", "name": "Racer", "stock": 1}, nil)
+			codes[i] = rec.Code
+		}(i)
+	}
+	wg.Wait()
+
+	// Whichever create loses the race past the SKU check hits the unique index and still answers 409
+	created := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("expected 200 or 409, got %v", codes)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("expected exactly one create to win, got %v", codes)
+	}
+}
@@ -1,10 +1,18 @@
 package validation
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/xkillx/go-gin-order-settlement/modules/product/dto"
 	"github.com/go-playground/validator/v10"
 )
 
+var (
+	skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+	tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+)
+
 type ProductValidation struct {
 	validate *validator.Validate
 }
@@ -14,9 +22,52 @@ func NewProductValidation() *ProductValidation {
 }
 
 func (v *ProductValidation) ValidateProductCreateRequest(req dto.ProductCreateRequest) error {
+	if req.SKU != "" && !skuPattern.MatchString(req.SKU) {
+		return dto.ErrInvalidSKU
+	}
+	if err := validateLabels(req.Categories, req.Tags); err != nil {
+		return err
+	}
 	return v.validate.Struct(req)
 }
 
 func (v *ProductValidation) ValidateProductUpdateRequest(req dto.ProductUpdateRequest) error {
+	if req.Stock != nil {
+		return dto.ErrStockNotEditable
+	}
+	if req.SKU != "" && !skuPattern.MatchString(req.SKU) {
+		return dto.ErrInvalidSKU
+	}
+	if err := validateLabels(req.Categories, req.Tags); err != nil {
+		return err
+	}
 	return v.validate.Struct(req)
 }
+
+// validateLabels checks categories and tags. Categories are free text compared case-insensitively;
+// tags are lowercase slugs.
+func validateLabels(categories, tags []string) error {
+	if len(categories) > dto.MaxCategories {
+		return dto.ErrInvalidCategories
+	}
+	seen := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		key := strings.ToLower(strings.TrimSpace(c))
+		if key == "" || len(c) > 64 || seen[key] {
+			return dto.ErrInvalidCategories
+		}
+		seen[key] = true
+	}
+
+	if len(tags) > dto.MaxTags {
+		return dto.ErrInvalidTags
+	}
+	seen = make(map[string]bool, len(tags))
+	for _, t := range tags {
+		if !tagPattern.MatchString(t) || seen[t] {
+			return dto.ErrInvalidTags
+		}
+		seen[t] = true
+	}
+	return nil
+}
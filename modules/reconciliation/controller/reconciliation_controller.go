@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	ReconciliationController interface {
+		Summary(ctx *gin.Context)
+		ListItems(ctx *gin.Context)
+	}
+
+	reconciliationController struct {
+		service service.ReconciliationService
+	}
+)
+
+func NewReconciliationController(_ *do.Injector, s service.ReconciliationService) ReconciliationController {
+	return &reconciliationController{service: s}
+}
+
+func (c *reconciliationController) Summary(ctx *gin.Context) {
+	result, err := c.service.Summary(ctx.Request.Context(), ctx.Param("job_id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_RECONCILIATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_RECONCILIATION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *reconciliationController) ListItems(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var req dto.ReconciliationItemListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.ListItems(ctx.Request.Context(), ctx.Param("job_id"), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_RECONCILIATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_RECONCILIATION, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrReconciliationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrUnknownResult), errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
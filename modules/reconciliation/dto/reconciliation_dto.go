@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_RECONCILIATION      = "failed get reconciliation"
+	MESSAGE_FAILED_GET_LIST_RECONCILIATION = "failed get list reconciliation items"
+	MESSAGE_FAILED_PROSES_REQUEST          = "failed proses request"
+
+	// Success
+	MESSAGE_SUCCESS_GET_RECONCILIATION      = "success get reconciliation"
+	MESSAGE_SUCCESS_GET_LIST_RECONCILIATION = "success get list reconciliation items"
+
+	// Row classifications
+	RESULT_MATCHED         = "matched"
+	RESULT_MISSING_OURS    = "missing_ours"
+	RESULT_MISSING_THEIRS  = "missing_theirs"
+	RESULT_AMOUNT_MISMATCH = "amount_mismatch"
+	// RESULT_INVALID marks statement rows that could not be parsed or repeat a reference
+	RESULT_INVALID = "invalid"
+)
+
+var (
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+	ErrUnknownResult          = errors.New("unknown reconciliation result")
+)
+
+type (
+	ReconciliationItemListRequest struct {
+		Result string `form:"result"`
+	}
+
+	// ReconciliationSummary counts items per result for one reconciliation job.
+	ReconciliationSummary struct {
+		JobID     string           `json:"job_id"`
+		Status    string           `json:"status"`
+		Counts    map[string]int64 `json:"counts"`
+		Total     int64            `json:"total"`
+		ReportURL string           `json:"report_url,omitempty"`
+	}
+)
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	InsertItems(ctx context.Context, tx *gorm.DB, items []entities.ReconciliationItem) error
+	DeleteByJob(ctx context.Context, tx *gorm.DB, jobID string) error
+	CountByResult(ctx context.Context, tx *gorm.DB, jobID string) (map[string]int64, error)
+	List(ctx context.Context, tx *gorm.DB, jobID, result string, q query.Request) ([]entities.ReconciliationItem, pkgdto.PaginationResponse, error)
+}
+
+// itemSchema is what a job's reconciliation items may be filtered and sorted by, in statement
+// order by default; search matches part of the external reference.
+var itemSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":             {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"result":         {Column: "result", Type: query.String, Ops: query.Equality},
+		"external_ref":   {Column: "external_ref", Type: query.String, Ops: query.Text, Sort: true},
+		"statement_line": {Column: "statement_line", Type: query.Int, Ops: query.Range, Sort: true},
+		"transaction_id": {Column: "transaction_id", Type: query.UUID, Ops: query.Equality},
+	},
+	DefaultSort: "statement_line,external_ref",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		return db.Where("external_ref ILIKE ?", query.Contains(term))
+	},
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *reconciliationRepository) InsertItems(ctx context.Context, tx *gorm.DB, items []entities.ReconciliationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	db := r.getDB(tx)
+	return db.WithContext(ctx).CreateInBatches(&items, 500).Error
+}
+
+// DeleteByJob drops earlier results so a retried job starts from a clean slate.
+func (r *reconciliationRepository) DeleteByJob(ctx context.Context, tx *gorm.DB, jobID string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Where("job_id = ?", jobID).Delete(&entities.ReconciliationItem{}).Error
+}
+
+func (r *reconciliationRepository) CountByResult(ctx context.Context, tx *gorm.DB, jobID string) (map[string]int64, error) {
+	db := r.getDB(tx)
+	var rows []struct {
+		Result string
+		Count  int64
+	}
+	if err := db.WithContext(ctx).
+		Model(&entities.ReconciliationItem{}).
+		Select("result, COUNT(*) AS count").
+		Where("job_id = ?", jobID).
+		Group("result").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Result] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *reconciliationRepository) List(ctx context.Context, tx *gorm.DB, jobID, result string, q query.Request) ([]entities.ReconciliationItem, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	items := func() *gorm.DB {
+		i := db.WithContext(ctx).Model(&entities.ReconciliationItem{}).Where("job_id = ?", jobID)
+		if result != "" {
+			i = i.Where("result = ?", result)
+		}
+		return i
+	}
+	return query.Find[entities.ReconciliationItem](items, q, itemSchema)
+}
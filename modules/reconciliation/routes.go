@@ -0,0 +1,18 @@
+package reconciliation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/controller"
+)
+
+// RegisterRoutes exposes reconciliation results. Jobs are started with POST /jobs/reconciliation.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.ReconciliationController](injector)
+
+	r := server.Group("/api/reconciliations")
+	{
+		r.GET("/:job_id", ctrl.Summary)
+		r.GET("/:job_id/items", ctrl.ListItems)
+	}
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/repository"
+	txrepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+)
+
+const (
+	ReconciliationJobType = "reconciliation"
+
+	reconciliationOutputDir = "/tmp/reconciliations"
+	defaultToleranceDays    = 1
+)
+
+// ReconciliationPayload is the job payload. FilePath points at the uploaded statement CSV.
+// From/To (YYYY-MM-DD, To exclusive) bound the search for transactions missing from the
+// statement and default to the days the statement covers.
+type ReconciliationPayload struct {
+	FilePath          string   `json:"file_path"`
+	FileName          string   `json:"file_name"`
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	DateToleranceDays *flexInt `json:"date_tolerance_days"`
+}
+
+// flexInt accepts a JSON number or a quoted number, since multipart form fields arrive as strings.
+type flexInt int
+
+func (f *flexInt) UnmarshalJSON(b []byte) error {
+	n, err := strconv.Atoi(strings.Trim(string(b), `"`))
+	if err != nil {
+		return fmt.Errorf("expected an integer, got %s", b)
+	}
+	*f = flexInt(n)
+	return nil
+}
+
+// statementRow is one parsed line of the processor statement.
+type statementRow struct {
+	line        int
+	externalRef string
+	amountCents int64
+	paidAt      time.Time
+	err         error
+}
+
+// classifiedBatch carries the items for one statement batch and the transactions they matched.
+type classifiedBatch struct {
+	items   []entities.ReconciliationItem
+	matched []uuid.UUID
+	minDay  time.Time
+	maxDay  time.Time
+}
+
+// ReconciliationHandler matches a processor statement against our transactions by reference,
+// amount and date tolerance, using the same producer -> workers -> collector pipeline as settlement.
+type ReconciliationHandler struct {
+	transactionRepo    txrepo.TransactionRepository
+	reconciliationRepo repository.ReconciliationRepository
+
+	workers   int
+	batchSize int
+}
+
+func NewReconciliationHandler(t txrepo.TransactionRepository, r repository.ReconciliationRepository, workers, batchSize int) *ReconciliationHandler {
+	if workers < 1 {
+		workers = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+	return &ReconciliationHandler{transactionRepo: t, reconciliationRepo: r, workers: workers, batchSize: batchSize}
+}
+
+func (h *ReconciliationHandler) Type() string {
+	return ReconciliationJobType
+}
+
+type reconciliationParams struct {
+	path          string
+	from, to      time.Time
+	toleranceDays int
+}
+
+func parseReconciliationPayload(raw []byte) (reconciliationParams, error) {
+	var p ReconciliationPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return reconciliationParams{}, fmt.Errorf("%w: %v", jobservice.ErrInvalidPayload, err)
+	}
+	path, err := jobservice.ResolveUpload(p.FilePath)
+	if err != nil {
+		return reconciliationParams{}, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		return reconciliationParams{}, fmt.Errorf("%w: statement must be a .csv file", jobservice.ErrInvalidPayload)
+	}
+
+	params := reconciliationParams{path: path, toleranceDays: defaultToleranceDays}
+	if p.DateToleranceDays != nil {
+		if *p.DateToleranceDays < 0 {
+			return reconciliationParams{}, fmt.Errorf("%w: date_tolerance_days must not be negative", jobservice.ErrInvalidPayload)
+		}
+		params.toleranceDays = int(*p.DateToleranceDays)
+	}
+
+	const layout = "2006-01-02"
+	if p.From != "" {
+		if params.from, err = time.Parse(layout, p.From); err != nil {
+			return reconciliationParams{}, fmt.Errorf("%w: invalid 'from' date format, expected YYYY-MM-DD", jobservice.ErrInvalidPayload)
+		}
+	}
+	if p.To != "" {
+		if params.to, err = time.Parse(layout, p.To); err != nil {
+			return reconciliationParams{}, fmt.Errorf("%w: invalid 'to' date format, expected YYYY-MM-DD", jobservice.ErrInvalidPayload)
+		}
+	}
+	if (p.From == "") != (p.To == "") {
+		return reconciliationParams{}, fmt.Errorf("%w: 'from' and 'to' must be given together", jobservice.ErrInvalidPayload)
+	}
+	if !params.to.IsZero() && params.to.Before(params.from) {
+		return reconciliationParams{}, fmt.Errorf("%w: 'to' must be on or after 'from'", jobservice.ErrInvalidPayload)
+	}
+	return params, nil
+}
+
+// Prepare validates the payload and counts statement rows for progress.
+func (h *ReconciliationHandler) Prepare(ctx context.Context, payload json.RawMessage, job *entities.Job) error {
+	params, err := parseReconciliationPayload(payload)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(params.path)
+	if err != nil {
+		return fmt.Errorf("%w: cannot open statement: %v", jobservice.ErrInvalidPayload, err)
+	}
+	defer f.Close()
+
+	var lines int64
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) != "" {
+			lines++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%w: cannot read statement: %v", jobservice.ErrInvalidPayload, err)
+	}
+	if lines > 0 {
+		lines-- // header
+	}
+	job.Total = lines
+	job.FromDate = params.from
+	job.ToDate = params.to
+	return nil
+}
+
+func (h *ReconciliationHandler) Run(jobCtx context.Context, job entities.Job, progress *jobservice.Progress) error {
+	params, err := parseReconciliationPayload([]byte(job.Payload))
+	if err != nil {
+		return err
+	}
+	// A retry replaces the results of the previous attempt
+	if err := h.reconciliationRepo.DeleteByJob(jobCtx, nil, job.ID); err != nil {
+		return err
+	}
+
+	in, err := os.Open(params.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(reconciliationOutputDir, 0o755); err != nil {
+		return err
+	}
+	reportPath := filepath.Join(reconciliationOutputDir, job.ID+"_discrepancies.csv")
+	f, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"result", "external_ref", "statement_line", "statement_amount_cents", "our_amount_cents", "statement_paid_at", "our_paid_at", "detail"})
+
+	// Channels and concurrency setup
+	rowChan := make(chan []statementRow, h.workers*2)
+	resultChan := make(chan classifiedBatch, h.workers*2)
+	producerErr := make(chan error, 1)
+
+	// Start producer
+	go func() {
+		defer close(rowChan)
+		if err := readStatement(jobCtx, in, h.batchSize, rowChan); err != nil {
+			producerErr <- err
+		}
+	}()
+
+	// Start workers
+	tolerance := params.toleranceDays
+	var wgWorkers sync.WaitGroup
+	wgWorkers.Add(h.workers)
+	for i := 0; i < h.workers; i++ {
+		go func() {
+			defer wgWorkers.Done()
+			for {
+				select {
+				case <-jobCtx.Done():
+					return
+				case rows, ok := <-rowChan:
+					if !ok {
+						return
+					}
+					cb, err := h.classify(jobCtx, job.ID, rows, tolerance)
+					if err != nil {
+						select {
+						case producerErr <- err:
+						default:
+						}
+						return
+					}
+					select {
+					case <-jobCtx.Done():
+						return
+					case resultChan <- cb:
+					}
+				}
+			}
+		}()
+	}
+
+	// Close resultChan once all workers are done
+	go func() {
+		wgWorkers.Wait()
+		close(resultChan)
+	}()
+
+	writeItems := func(items []entities.ReconciliationItem) error {
+		if err := h.reconciliationRepo.InsertItems(jobCtx, nil, items); err != nil {
+			return err
+		}
+		for _, it := range items {
+			if it.Result != dto.RESULT_MATCHED {
+				_ = w.Write(reportRow(it))
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	// Collector: persist items and remember which of our transactions the statement accounted for
+	matched := make(map[uuid.UUID]struct{})
+	var minDay, maxDay time.Time
+	var processed int64
+collect:
+	for {
+		select {
+		case <-jobCtx.Done():
+			return jobCtx.Err()
+		case err := <-producerErr:
+			if jobCtx.Err() != nil {
+				return jobCtx.Err()
+			}
+			return fmt.Errorf("statement: %w", err)
+		case cb, ok := <-resultChan:
+			if !ok {
+				break collect
+			}
+			if err := writeItems(cb.items); err != nil {
+				return err
+			}
+			for _, id := range cb.matched {
+				matched[id] = struct{}{}
+			}
+			if !cb.minDay.IsZero() && (minDay.IsZero() || cb.minDay.Before(minDay)) {
+				minDay = cb.minDay
+			}
+			if cb.maxDay.After(maxDay) {
+				maxDay = cb.maxDay
+			}
+			processed += int64(len(cb.items))
+			_ = progress.Update(jobCtx, processed)
+		}
+	}
+	if jobCtx.Err() != nil {
+		return jobCtx.Err()
+	}
+	select {
+	case err := <-producerErr:
+		return fmt.Errorf("statement: %w", err)
+	default:
+	}
+
+	// Second pass: our transactions in the window that the statement never mentioned
+	from, to := params.from, params.to
+	if from.IsZero() {
+		from, to = minDay, maxDay.AddDate(0, 0, 1)
+	}
+	if !minDay.IsZero() || !params.from.IsZero() {
+		if err := h.collectMissingTheirs(jobCtx, job.ID, from, to, matched, writeItems); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return progress.SetResultPath(jobCtx, reportPath)
+}
+
+// classify looks up the referenced transactions for one batch of statement rows.
+func (h *ReconciliationHandler) classify(ctx context.Context, jobID string, rows []statementRow, toleranceDays int) (classifiedBatch, error) {
+	refs := make([]string, 0, len(rows))
+	for _, r := range rows {
+		if r.err == nil {
+			refs = append(refs, r.externalRef)
+		}
+	}
+	ours, err := h.transactionRepo.FindByExternalRefs(ctx, nil, refs)
+	if err != nil {
+		return classifiedBatch{}, err
+	}
+	byRef := make(map[string]entities.Transaction, len(ours))
+	for _, t := range ours {
+		byRef[t.ExternalRef] = t
+	}
+
+	cb := classifiedBatch{items: make([]entities.ReconciliationItem, 0, len(rows))}
+	for _, r := range rows {
+		item := entities.ReconciliationItem{
+			JobID:         jobID,
+			ExternalRef:   r.externalRef,
+			StatementLine: r.line,
+		}
+		if r.err != nil {
+			item.Result = dto.RESULT_INVALID
+			item.Detail = r.err.Error()
+			cb.items = append(cb.items, item)
+			continue
+		}
+		amount, paidAt := r.amountCents, r.paidAt
+		item.StatementAmountCents = &amount
+		item.StatementPaidAt = &paidAt
+
+		day := utcDay(r.paidAt)
+		if cb.minDay.IsZero() || day.Before(cb.minDay) {
+			cb.minDay = day
+		}
+		if day.After(cb.maxDay) {
+			cb.maxDay = day
+		}
+
+		t, ok := byRef[r.externalRef]
+		if !ok {
+			item.Result = dto.RESULT_MISSING_OURS
+			cb.items = append(cb.items, item)
+			continue
+		}
+		ourAmount, ourPaidAt := t.AmountCents, t.PaidAt
+		diffDays := int(day.Sub(utcDay(t.PaidAt)).Hours() / 24)
+		if diffDays < 0 {
+			diffDays = -diffDays
+		}
+		if diffDays > toleranceDays {
+			// Same reference on a different day is not a match; our row surfaces as missing_theirs
+			item.Result = dto.RESULT_MISSING_OURS
+			item.Detail = fmt.Sprintf("reference found with paid_at %s, %d days apart", ourPaidAt.UTC().Format("2006-01-02"), diffDays)
+			cb.items = append(cb.items, item)
+			continue
+		}
+
+		id := t.ID
+		item.TransactionID = &id
+		item.OurAmountCents = &ourAmount
+		item.OurPaidAt = &ourPaidAt
+		item.Result = dto.RESULT_MATCHED
+		if t.AmountCents != r.amountCents {
+			item.Result = dto.RESULT_AMOUNT_MISMATCH
+			item.Detail = fmt.Sprintf("statement %d, ours %d", r.amountCents, t.AmountCents)
+		}
+		cb.matched = append(cb.matched, t.ID)
+		cb.items = append(cb.items, item)
+	}
+	return cb, nil
+}
+
+// collectMissingTheirs streams our transactions in [from, to) and records every one the
+// statement did not account for.
+func (h *ReconciliationHandler) collectMissingTheirs(
+	ctx context.Context,
+	jobID string,
+	from, to time.Time,
+	matched map[uuid.UUID]struct{},
+	writeItems func([]entities.ReconciliationItem) error,
+) error {
+	stream := make(chan []entities.Transaction, 2)
+	streamErr := make(chan error, 1)
+	go func() {
+		defer close(stream)
+		streamErr <- h.transactionRepo.StreamByDateRange(ctx, from, to, h.batchSize, stream)
+	}()
+
+	for batch := range stream {
+		items := make([]entities.ReconciliationItem, 0)
+		for _, t := range batch {
+			if _, ok := matched[t.ID]; ok {
+				continue
+			}
+			id, amount, paidAt := t.ID, t.AmountCents, t.PaidAt
+			items = append(items, entities.ReconciliationItem{
+				JobID:          jobID,
+				Result:         dto.RESULT_MISSING_THEIRS,
+				ExternalRef:    t.ExternalRef,
+				TransactionID:  &id,
+				OurAmountCents: &amount,
+				OurPaidAt:      &paidAt,
+			})
+		}
+		if err := writeItems(items); err != nil {
+			return err
+		}
+	}
+	return <-streamErr
+}
+
+// readStatement parses the statement CSV (external_ref, amount_cents, paid_at) in batches.
+// Rows that cannot be parsed or repeat an earlier reference are passed on with err set.
+func readStatement(ctx context.Context, r io.Reader, batchSize int, out chan<- []statementRow) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	idx := make(map[string]int, len(header))
+	for i, col := range header {
+		idx[strings.TrimSpace(strings.ToLower(col))] = i
+	}
+	for _, col := range []string{"external_ref", "amount_cents", "paid_at"} {
+		if _, ok := idx[col]; !ok {
+			return fmt.Errorf("header is missing column %q", col)
+		}
+	}
+	field := func(rec []string, col string) string {
+		if i := idx[col]; i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	seen := make(map[string]int)
+	batch := make([]statementRow, 0, batchSize)
+	send := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- batch:
+		}
+		batch = make([]statementRow, 0, batchSize)
+		return true
+	}
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var row statementRow
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			row.line, row.err = perr.StartLine, err
+		} else {
+			row.line, _ = cr.FieldPos(0)
+			row.externalRef = field(rec, "external_ref")
+			row.err = parseStatementFields(&row, field(rec, "amount_cents"), field(rec, "paid_at"))
+			if row.err == nil {
+				if first, dup := seen[row.externalRef]; dup {
+					row.err = fmt.Errorf("duplicate reference, first seen on line %d", first)
+				} else {
+					seen[row.externalRef] = row.line
+				}
+			}
+		}
+		batch = append(batch, row)
+		if len(batch) >= batchSize && !send() {
+			return nil
+		}
+	}
+	send()
+	return nil
+}
+
+func parseStatementFields(row *statementRow, amount, paidAt string) error {
+	if row.externalRef == "" {
+		return errors.New("external_ref is required")
+	}
+	var err error
+	if row.amountCents, err = strconv.ParseInt(amount, 10, 64); err != nil {
+		return fmt.Errorf("invalid amount_cents %q", amount)
+	}
+	if row.paidAt, err = time.Parse(time.RFC3339, paidAt); err == nil {
+		return nil
+	}
+	if row.paidAt, err = time.Parse("2006-01-02", paidAt); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid paid_at %q, expected RFC3339 or YYYY-MM-DD", paidAt)
+}
+
+func reportRow(it entities.ReconciliationItem) []string {
+	line := ""
+	if it.StatementLine > 0 {
+		line = strconv.Itoa(it.StatementLine)
+	}
+	optInt := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(*v, 10)
+	}
+	optTime := func(v *time.Time) string {
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		it.Result,
+		it.ExternalRef,
+		line,
+		optInt(it.StatementAmountCents),
+		optInt(it.OurAmountCents),
+		optTime(it.StatementPaidAt),
+		optTime(it.OurPaidAt),
+		it.Detail,
+	}
+}
+
+func utcDay(t time.Time) time.Time {
+	u := t.UTC()
+	return time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobrepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/reconciliation/repository"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+var knownResults = map[string]struct{}{
+	dto.RESULT_MATCHED:         {},
+	dto.RESULT_MISSING_OURS:    {},
+	dto.RESULT_MISSING_THEIRS:  {},
+	dto.RESULT_AMOUNT_MISMATCH: {},
+	dto.RESULT_INVALID:         {},
+}
+
+type ReconciliationService interface {
+	Summary(ctx context.Context, jobID string) (dto.ReconciliationSummary, error)
+	ListItems(ctx context.Context, jobID string, req dto.ReconciliationItemListRequest, q query.Request) ([]entities.ReconciliationItem, pkgdto.PaginationResponse, error)
+}
+
+type reconciliationService struct {
+	repo    repository.ReconciliationRepository
+	jobRepo jobrepo.JobRepo
+	db      *gorm.DB
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository, jobRepo jobrepo.JobRepo, db *gorm.DB) ReconciliationService {
+	return &reconciliationService{repo: repo, jobRepo: jobRepo, db: db}
+}
+
+func (s *reconciliationService) job(ctx context.Context, jobID string) (entities.Job, error) {
+	job, err := s.jobRepo.Get(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Job{}, dto.ErrReconciliationNotFound
+		}
+		return entities.Job{}, err
+	}
+	if job.Type != ReconciliationJobType {
+		return entities.Job{}, dto.ErrReconciliationNotFound
+	}
+	return job, nil
+}
+
+func (s *reconciliationService) Summary(ctx context.Context, jobID string) (dto.ReconciliationSummary, error) {
+	job, err := s.job(ctx, jobID)
+	if err != nil {
+		return dto.ReconciliationSummary{}, err
+	}
+	counts, err := s.repo.CountByResult(ctx, s.db, jobID)
+	if err != nil {
+		return dto.ReconciliationSummary{}, err
+	}
+	summary := dto.ReconciliationSummary{JobID: job.ID, Status: job.Status, Counts: counts}
+	for _, c := range counts {
+		summary.Total += c
+	}
+	if job.Status == "COMPLETED" && job.ResultPath != "" {
+		summary.ReportURL = "/jobs/" + job.ID + "/download"
+	}
+	return summary, nil
+}
+
+func (s *reconciliationService) ListItems(ctx context.Context, jobID string, req dto.ReconciliationItemListRequest, q query.Request) ([]entities.ReconciliationItem, pkgdto.PaginationResponse, error) {
+	if _, err := s.job(ctx, jobID); err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	if req.Result != "" {
+		if _, ok := knownResults[req.Result]; !ok {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownResult
+		}
+	}
+	return s.repo.List(ctx, s.db, jobID, req.Result, q)
+}
@@ -0,0 +1,167 @@
+package reconciliation_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	reconciliationModule "github.com/xkillx/go-gin-order-settlement/modules/reconciliation"
+	reconciliationController "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/controller"
+	reconciliationRepo "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/repository"
+	reconciliationService "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/service"
+	settlement "github.com/xkillx/go-gin-order-settlement/modules/settlement"
+	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+	t.Cleanup(func() { _ = os.RemoveAll(utils.PATH) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"reconciliation_items", "jobs", "transaction_adjustments", "transactions"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	txRepository := transactionRepo.NewTransactionRepository(db)
+	jobRepository := jobRepo.NewJobRepository(db)
+	recRepository := reconciliationRepo.NewReconciliationRepository(db)
+	jobManager := settlementService.NewJobManager(txRepository, settlementRepo.NewSettlementRepository(db), ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db), jobRepository, db)
+	// Small batches so the statement spans several worker batches
+	jobManager.Register(reconciliationService.NewReconciliationHandler(txRepository, recRepository, 4, 2))
+	svc := reconciliationService.NewReconciliationService(recRepository, jobRepository, db)
+
+	inj := do.New()
+	do.ProvideNamed(inj, constants.DB, func(i *do.Injector) (*gorm.DB, error) { return db, nil })
+	do.Provide(inj, func(i *do.Injector) (*settlementService.JobManager, error) { return jobManager, nil })
+	do.Provide(inj, func(i *do.Injector) (reconciliationController.ReconciliationController, error) {
+		return reconciliationController.NewReconciliationController(i, svc), nil
+	})
+
+	engine := gin.New()
+	settlement.RegisterRoutes(engine, inj)
+	reconciliationModule.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+func TestReconciliationClassifiesStatementRows(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	day := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	ours := []entities.Transaction{
+		{ExternalRef: "r-match", MerchantID: "m1", AmountCents: 1_000, FeeCents: 10, Status: "paid", PaidAt: day},
+		{ExternalRef: "r-amount", MerchantID: "m1", AmountCents: 2_000, FeeCents: 10, Status: "paid", PaidAt: day},
+		{ExternalRef: "r-late", MerchantID: "m1", AmountCents: 3_000, FeeCents: 10, Status: "paid", PaidAt: day.AddDate(0, 0, -5)},
+		{ExternalRef: "r-ours-only", MerchantID: "m2", AmountCents: 4_000, FeeCents: 10, Status: "paid", PaidAt: day},
+	}
+	if err := db.Create(&ours).Error; err != nil {
+		t.Fatalf("seed transactions: %v", err)
+	}
+
+	statement := "external_ref,amount_cents,paid_at\n" +
+		"r-match,1000,2024-05-11\n" +
+		"r-amount,2500,2024-05-10\n" +
+		"r-late,3000,2024-05-10\n" +
+		"r-theirs-only,900,2024-05-10\n" +
+		"r-match,1000,2024-05-10\n"
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "statement.csv")
+	_, _ = fw.Write([]byte(statement))
+	_ = mw.WriteField("date_tolerance_days", "1")
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/jobs/reconciliation", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("start expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	jobID := created["job_id"].(string)
+
+	var summary struct {
+		Data struct {
+			Status    string           `json:"status"`
+			Counts    map[string]int64 `json:"counts"`
+			ReportURL string           `json:"report_url"`
+		} `json:"data"`
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		srec := httptest.NewRecorder()
+		server.ServeHTTP(srec, httptest.NewRequest(http.MethodGet, "/api/reconciliations/"+jobID, nil))
+		_ = json.Unmarshal(srec.Body.Bytes(), &summary)
+		if summary.Data.Status == "COMPLETED" || summary.Data.Status == "FAILED" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if summary.Data.Status != "COMPLETED" {
+		t.Fatalf("reconciliation did not complete: %#v", summary.Data)
+	}
+
+	want := map[string]int64{
+		"matched":         1,
+		"amount_mismatch": 1,
+		"missing_ours":    2, // r-late outside the tolerance, r-theirs-only
+		"missing_theirs":  1, // r-ours-only; r-late was paid before the statement window
+		"invalid":         1, // repeated r-match
+	}
+	for k, v := range want {
+		if summary.Data.Counts[k] != v {
+			t.Fatalf("expected %d %s, got counts %#v", v, k, summary.Data.Counts)
+		}
+	}
+
+	drec := httptest.NewRecorder()
+	server.ServeHTTP(drec, httptest.NewRequest(http.MethodGet, summary.Data.ReportURL, nil))
+	rows, err := csv.NewReader(bytes.NewReader(drec.Body.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse report: %v", err)
+	}
+	// Header plus every non-matched item
+	if len(rows) != 1+5 {
+		t.Fatalf("expected 5 discrepancies in report, got %v", rows)
+	}
+
+	lrec := httptest.NewRecorder()
+	server.ServeHTTP(lrec, httptest.NewRequest(http.MethodGet, "/api/reconciliations/"+jobID+"/items?result=amount_mismatch", nil))
+	var list struct {
+		Data struct {
+			Items []entities.ReconciliationItem `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(lrec.Body.Bytes(), &list)
+	if len(list.Data.Items) != 1 || list.Data.Items[0].ExternalRef != "r-amount" {
+		t.Fatalf("expected r-amount mismatch, got %s", lrec.Body.String())
+	}
+}
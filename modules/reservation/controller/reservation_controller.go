@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	ReservationController interface {
+		Create(ctx *gin.Context)
+		Get(ctx *gin.Context)
+		Release(ctx *gin.Context)
+		Confirm(ctx *gin.Context)
+	}
+
+	reservationController struct {
+		service   service.ReservationService
+		validator *validation.ReservationValidation
+	}
+)
+
+func NewReservationController(_ *do.Injector, s service.ReservationService) ReservationController {
+	return &reservationController{
+		service:   s,
+		validator: validation.NewReservationValidation(),
+	}
+}
+
+func (c *reservationController) Create(ctx *gin.Context) {
+	var req dto.ReservationCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateReservationCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_RESERVATION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Reserve(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_RESERVATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_RESERVATION, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *reservationController) Get(ctx *gin.Context) {
+	result, err := c.service.Get(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_RESERVATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_RESERVATION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *reservationController) Release(ctx *gin.Context) {
+	result, err := c.service.Release(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_RELEASE_RESERVATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RELEASE_RESERVATION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *reservationController) Confirm(ctx *gin.Context) {
+	var req dto.ReservationConfirmRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateReservationConfirmRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_RESERVATION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Confirm(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CONFIRM_RESERVATION, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CONFIRM_RESERVATION, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrReservationNotFound), errors.Is(err, dto.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrInsufficientStock), errors.Is(err, dto.ErrAllocationFailed), errors.Is(err, dto.ErrReservationNotActive),
+		errors.Is(err, dto.ErrProductArchived):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrReservationExpired):
+		return http.StatusGone
+	case errors.Is(err, dto.ErrTooManyOrderItems), errors.Is(err, dto.ErrInvalidQuantity):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY     = "failed get data from body"
+	MESSAGE_FAILED_VALIDATION_RESERVATION = "failed validation reservation"
+	MESSAGE_FAILED_CREATE_RESERVATION     = "failed create reservation"
+	MESSAGE_FAILED_GET_RESERVATION        = "failed get reservation"
+	MESSAGE_FAILED_RELEASE_RESERVATION    = "failed release reservation"
+	MESSAGE_FAILED_CONFIRM_RESERVATION    = "failed confirm reservation"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_RESERVATION  = "success create reservation"
+	MESSAGE_SUCCESS_GET_RESERVATION     = "success get reservation"
+	MESSAGE_SUCCESS_RELEASE_RESERVATION = "success release reservation"
+	MESSAGE_SUCCESS_CONFIRM_RESERVATION = "success confirm reservation"
+)
+
+const (
+	// DefaultTTL is how long stock is held when the request does not say
+	DefaultTTL = 15 * time.Minute
+	// MaxTTLSeconds caps a hold at one day
+	MaxTTLSeconds = 86_400
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrReservationExpired   = errors.New("reservation has expired")
+	// Shared with orders, which take stock from the same pool
+	ErrInsufficientStock = orderDto.ErrInsufficientStock
+	ErrProductNotFound   = orderDto.ErrProductNotFound
+	ErrProductArchived   = orderDto.ErrProductArchived
+	ErrAllocationFailed  = orderDto.ErrAllocationFailed
+	ErrTooManyOrderItems = orderDto.ErrTooManyOrderItems
+	ErrInvalidQuantity   = orderDto.ErrInvalidQuantity
+)
+
+type (
+	ReservationItemRequest = orderDto.OrderItemRequest
+
+	ReservationCreateRequest struct {
+		CartID     string                   `json:"cart_id" form:"cart_id" binding:"required,max=200"`
+		Items      []ReservationItemRequest `json:"items" form:"items" binding:"required,min=1,max=100,dive"`
+		TTLSeconds int                      `json:"ttl_seconds" form:"ttl_seconds" binding:"omitempty,min=1,max=86400"`
+	}
+
+	// ReservationConfirmRequest places the order; Shipping picks the warehouses it ships from.
+	ReservationConfirmRequest struct {
+		BuyerID string `json:"buyer_id" form:"buyer_id" binding:"required,min=1"`
+		orderDto.Shipping
+	}
+
+	ReservationItemResponse struct {
+		ProductID string `json:"product_id"`
+		Quantity  int    `json:"quantity"`
+	}
+
+	ReservationResponse struct {
+		ID        string                    `json:"id"`
+		CartID    string                    `json:"cart_id"`
+		Status    string                    `json:"status"`
+		ExpiresAt time.Time                 `json:"expires_at"`
+		OrderID   *string                   `json:"order_id"`
+		Items     []ReservationItemResponse `json:"items"`
+		CreatedAt time.Time                 `json:"created_at"`
+	}
+
+	ReservationConfirmResponse struct {
+		Reservation ReservationResponse    `json:"reservation"`
+		Order       orderDto.OrderResponse `json:"order"`
+	}
+)
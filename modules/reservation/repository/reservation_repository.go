@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, r entities.Reservation) (entities.Reservation, error)
+	FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Reservation, error)
+	// FindForUpdate loads a reservation with its items and row-locks it for the rest of tx.
+	FindForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Reservation, error)
+	// LockExpired row-locks up to limit active reservations that expired by now, with their items.
+	// Rows locked by another sweeper or a confirm in progress are skipped.
+	LockExpired(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entities.Reservation, error)
+	SetStatus(ctx context.Context, tx *gorm.DB, id, status string, orderID *string) error
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+func (r *reservationRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *reservationRepository) Create(ctx context.Context, tx *gorm.DB, res entities.Reservation) (entities.Reservation, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&res).Error; err != nil {
+		return entities.Reservation{}, err
+	}
+	return res, nil
+}
+
+func (r *reservationRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Reservation, error) {
+	db := r.getDB(tx)
+	var res entities.Reservation
+	if err := db.WithContext(ctx).Preload("Items", itemsByLine).Where("id = ?", id).Take(&res).Error; err != nil {
+		return entities.Reservation{}, err
+	}
+	return res, nil
+}
+
+func (r *reservationRepository) FindForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Reservation, error) {
+	db := r.getDB(tx)
+	var res entities.Reservation
+	if err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(&res).Error; err != nil {
+		return entities.Reservation{}, err
+	}
+	if err := db.WithContext(ctx).Where("reservation_id = ?", id).Order("line").Find(&res.Items).Error; err != nil {
+		return entities.Reservation{}, err
+	}
+	return res, nil
+}
+
+func (r *reservationRepository) LockExpired(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entities.Reservation, error) {
+	db := r.getDB(tx)
+	var items []entities.Reservation
+	if err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND expires_at <= ?", constants.ENUM_RESERVATION_STATUS_ACTIVE, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := db.WithContext(ctx).Where("reservation_id = ?", items[i].ID).Order("line").Find(&items[i].Items).Error; err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *reservationRepository) SetStatus(ctx context.Context, tx *gorm.DB, id, status string, orderID *string) error {
+	db := r.getDB(tx)
+	updates := map[string]any{"status": status}
+	if orderID != nil {
+		updates["order_id"] = *orderID
+	}
+	return db.WithContext(ctx).Model(&entities.Reservation{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func itemsByLine(db *gorm.DB) *gorm.DB {
+	return db.Order("line")
+}
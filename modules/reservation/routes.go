@@ -0,0 +1,21 @@
+package reservation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/controller"
+)
+
+// RegisterRoutes exposes checkout holds: reserve stock for a cart, then confirm it into an order
+// or release it before it expires.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.ReservationController](injector)
+
+	r := server.Group("/api/reservations")
+	{
+		r.POST("", ctrl.Create)
+		r.GET("/:id", ctrl.Get)
+		r.DELETE("/:id", ctrl.Release)
+		r.POST("/:id/confirm", ctrl.Confirm)
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+type ReservationService interface {
+	Reserve(ctx context.Context, req dto.ReservationCreateRequest) (dto.ReservationResponse, error)
+	Get(ctx context.Context, id string) (dto.ReservationResponse, error)
+	Release(ctx context.Context, id string) (dto.ReservationResponse, error)
+	Confirm(ctx context.Context, id string, req dto.ReservationConfirmRequest) (dto.ReservationConfirmResponse, error)
+	// ExpireDue releases up to limit reservations whose hold ran out and returns how many it released.
+	ExpireDue(ctx context.Context, limit int) (int, error)
+}
+
+// OrderPlacer creates an order in the caller's transaction from stock reserved for it.
+type OrderPlacer interface {
+	PlaceReserved(ctx context.Context, tx *gorm.DB, buyerID string, lines []orderDto.OrderItemRequest, shipping orderDto.Shipping) (orderDto.OrderResponse, error)
+}
+
+type reservationService struct {
+	repo        repository.ReservationRepository
+	productRepo productRepo.ProductRepository
+	orders      OrderPlacer
+	db          *gorm.DB
+}
+
+func NewReservationService(repo repository.ReservationRepository, prodRepo productRepo.ProductRepository, orders OrderPlacer, db *gorm.DB) ReservationService {
+	return &reservationService{repo: repo, productRepo: prodRepo, orders: orders, db: db}
+}
+
+func (s *reservationService) Reserve(ctx context.Context, req dto.ReservationCreateRequest) (dto.ReservationResponse, error) {
+	// Repeated products are merged into one line, kept in the order they were first requested
+	var (
+		ids        []uuid.UUID
+		quantities = make(map[uuid.UUID]int, len(req.Items))
+	)
+	for _, it := range req.Items {
+		pid, err := uuid.Parse(it.ProductID)
+		if err != nil {
+			return dto.ReservationResponse{}, dto.ErrProductNotFound
+		}
+		if _, seen := quantities[pid]; !seen {
+			ids = append(ids, pid)
+		}
+		quantities[pid] += it.Quantity
+	}
+	ttl := dto.DefaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	var created entities.Reservation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Same id-ordered locking as orders, so holds and orders over the same products cannot deadlock
+		products, err := s.productRepo.LockForUpdate(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		if len(products) != len(ids) {
+			return dto.ErrProductNotFound
+		}
+		for _, p := range products {
+			if p.Status == constants.ENUM_PRODUCT_STATUS_ARCHIVED {
+				return dto.ErrProductArchived
+			}
+			ok, err := s.productRepo.Reserve(ctx, tx, p.ID, quantities[p.ID])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return dto.ErrInsufficientStock
+			}
+		}
+
+		r := entities.Reservation{
+			CartID:    req.CartID,
+			Status:    constants.ENUM_RESERVATION_STATUS_ACTIVE,
+			ExpiresAt: time.Now().UTC().Add(ttl),
+		}
+		for i, pid := range ids {
+			r.Items = append(r.Items, entities.ReservationItem{Line: i + 1, ProductID: pid, Quantity: quantities[pid]})
+		}
+		created, err = s.repo.Create(ctx, tx, r)
+		return err
+	})
+	if err != nil {
+		return dto.ReservationResponse{}, err
+	}
+	return toReservationResponse(created), nil
+}
+
+func (s *reservationService) Get(ctx context.Context, id string) (dto.ReservationResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.ReservationResponse{}, dto.ErrReservationNotFound
+	}
+	r, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ReservationResponse{}, dto.ErrReservationNotFound
+		}
+		return dto.ReservationResponse{}, err
+	}
+	return toReservationResponse(r), nil
+}
+
+func (s *reservationService) Release(ctx context.Context, id string) (dto.ReservationResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.ReservationResponse{}, dto.ErrReservationNotFound
+	}
+	var released entities.Reservation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		r, err := s.lock(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if r.Status != constants.ENUM_RESERVATION_STATUS_ACTIVE {
+			return dto.ErrReservationNotActive
+		}
+		if err := s.release(ctx, tx, []entities.Reservation{r}, constants.ENUM_RESERVATION_STATUS_RELEASED); err != nil {
+			return err
+		}
+		r.Status = constants.ENUM_RESERVATION_STATUS_RELEASED
+		released = r
+		return nil
+	})
+	if err != nil {
+		return dto.ReservationResponse{}, err
+	}
+	return toReservationResponse(released), nil
+}
+
+func (s *reservationService) Confirm(ctx context.Context, id string, req dto.ReservationConfirmRequest) (dto.ReservationConfirmResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.ReservationConfirmResponse{}, dto.ErrReservationNotFound
+	}
+	var out dto.ReservationConfirmResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		r, err := s.lock(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if r.Status != constants.ENUM_RESERVATION_STATUS_ACTIVE {
+			return dto.ErrReservationNotActive
+		}
+		// An expired hold the sweeper has not reached yet is as good as gone
+		if !time.Now().UTC().Before(r.ExpiresAt) {
+			return dto.ErrReservationExpired
+		}
+
+		lines := make([]orderDto.OrderItemRequest, 0, len(r.Items))
+		for _, it := range r.Items {
+			lines = append(lines, orderDto.OrderItemRequest{ProductID: it.ProductID.String(), Quantity: it.Quantity})
+		}
+		order, err := s.orders.PlaceReserved(ctx, tx, req.BuyerID, lines, req.Shipping)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetStatus(ctx, tx, id, constants.ENUM_RESERVATION_STATUS_CONFIRMED, &order.ID); err != nil {
+			return err
+		}
+		r.Status = constants.ENUM_RESERVATION_STATUS_CONFIRMED
+		orderID := uuid.MustParse(order.ID)
+		r.OrderID = &orderID
+		out = dto.ReservationConfirmResponse{Reservation: toReservationResponse(r), Order: order}
+		return nil
+	})
+	if err != nil {
+		return dto.ReservationConfirmResponse{}, err
+	}
+	return out, nil
+}
+
+func (s *reservationService) ExpireDue(ctx context.Context, limit int) (int, error) {
+	expired := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		due, err := s.repo.LockExpired(ctx, tx, time.Now().UTC(), limit)
+		if err != nil {
+			return err
+		}
+		if err := s.release(ctx, tx, due, constants.ENUM_RESERVATION_STATUS_EXPIRED); err != nil {
+			return err
+		}
+		expired = len(due)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (s *reservationService) lock(ctx context.Context, tx *gorm.DB, id string) (entities.Reservation, error) {
+	r, err := s.repo.FindForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Reservation{}, dto.ErrReservationNotFound
+		}
+		return entities.Reservation{}, err
+	}
+	return r, nil
+}
+
+// release gives locked, active reservations' stock back and records how they ended. The products
+// of every reservation are locked together, in the same id order as orders and holds, so a batch
+// of expiring holds cannot deadlock against an order over the same products.
+func (s *reservationService) release(ctx context.Context, tx *gorm.DB, rs []entities.Reservation, status string) error {
+	var ids []uuid.UUID
+	quantities := make(map[uuid.UUID]int)
+	for _, r := range rs {
+		for _, it := range r.Items {
+			if _, seen := quantities[it.ProductID]; !seen {
+				ids = append(ids, it.ProductID)
+			}
+			quantities[it.ProductID] += it.Quantity
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	products, err := s.productRepo.LockForUpdate(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := s.productRepo.ReleaseReserved(ctx, tx, p.ID, quantities[p.ID]); err != nil {
+			return err
+		}
+	}
+	for _, r := range rs {
+		if err := s.repo.SetStatus(ctx, tx, r.ID.String(), status, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toReservationResponse(r entities.Reservation) dto.ReservationResponse {
+	items := make([]dto.ReservationItemResponse, 0, len(r.Items))
+	for _, it := range r.Items {
+		items = append(items, dto.ReservationItemResponse{ProductID: it.ProductID.String(), Quantity: it.Quantity})
+	}
+	var orderID *string
+	if r.OrderID != nil {
+		id := r.OrderID.String()
+		orderID = &id
+	}
+	return dto.ReservationResponse{
+		ID:        r.ID.String(),
+		CartID:    r.CartID,
+		Status:    r.Status,
+		ExpiresAt: r.ExpiresAt,
+		OrderID:   orderID,
+		Items:     items,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Sweeper releases reservations whose hold expired, so abandoned carts give their stock back.
+type Sweeper struct {
+	service  ReservationService
+	interval time.Duration
+	batch    int
+}
+
+func NewSweeper(service ReservationService, interval time.Duration) *Sweeper {
+	return &Sweeper{service: service, interval: interval, batch: 100}
+}
+
+// Run sweeps every interval until ctx is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("reservation sweeper: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce releases every reservation expired by now and returns how many it released.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		n, err := s.service.ExpireDue(ctx, s.batch)
+		released += n
+		if err != nil || n < s.batch {
+			return released, err
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package reservation_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	orderModule "github.com/xkillx/go-gin-order-settlement/modules/order"
+	orderController "github.com/xkillx/go-gin-order-settlement/modules/order/controller"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	reservationModule "github.com/xkillx/go-gin-order-settlement/modules/reservation"
+	reservationController "github.com/xkillx/go-gin-order-settlement/modules/reservation/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/dto"
+	reservationRepo "github.com/xkillx/go-gin-order-settlement/modules/reservation/repository"
+	reservationService "github.com/xkillx/go-gin-order-settlement/modules/reservation/service"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+type testEnv struct {
+	server  *gin.Engine
+	db      *gorm.DB
+	sweeper *reservationService.Sweeper
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"reservations", "orders", "products"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	prdRepo := productRepo.NewProductRepository(db)
+	orders := orderService.NewOrderService(orderRepo.NewOrderRepository(db), prdRepo, newInventory(db), nil, 0, db)
+	svc := reservationService.NewReservationService(reservationRepo.NewReservationRepository(db), prdRepo, orders, db)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (reservationController.ReservationController, error) {
+		return reservationController.NewReservationController(i, svc), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (orderController.OrderController, error) {
+		return orderController.NewOrderController(i, orders), nil
+	})
+
+	engine := gin.New()
+	reservationModule.RegisterRoutes(engine, inj)
+	orderModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, db: db, sweeper: reservationService.NewSweeper(svc, time.Hour)}
+}
+
+func createProduct(t *testing.T, db *gorm.DB, stock int) entities.Product {
+	t.Helper()
+	p, err := productRepo.NewProductRepository(db).Create(context.Background(), db, entities.Product{
+		Name: "Reserved Product", Stock: stock, PriceCents: 1_000, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	if err := newInventory(db).Open(context.Background(), db, p.ID, stock); err != nil {
+		t.Fatalf("failed to open stock: %v", err)
+	}
+	return p
+}
+
+func newInventory(db *gorm.DB) inventoryService.InventoryService {
+	return inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(db), productRepo.NewProductRepository(db),
+		warehouseRepo.NewWarehouseRepository(db), constants.ENUM_ALLOCATION_SPLIT, db)
+}
+
+func reload(t *testing.T, db *gorm.DB, p entities.Product) entities.Product {
+	t.Helper()
+	var reloaded entities.Product
+	if err := db.Where("id = ?", p.ID).Take(&reloaded).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	return reloaded
+}
+
+func call(t *testing.T, server http.Handler, method, path string, body any) (*httptest.ResponseRecorder, dto.ReservationResponse) {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var resp struct {
+		Data dto.ReservationResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp.Data
+}
+
+func reserveBody(cartID string, p entities.Product, qty, ttlSeconds int) map[string]any {
+	return map[string]any{
+		"cart_id":     cartID,
+		"items":       []map[string]any{{"product_id": p.ID.String(), "quantity": qty}},
+		"ttl_seconds": ttlSeconds,
+	}
+}
+
+func TestConcurrentReservations500(t *testing.T) {
+	env := setupTestServer(t)
+	product := createProduct(t, env.db, 100)
+
+	ts := httptest.NewServer(env.server)
+	defer ts.Close()
+
+	var (
+		wg            sync.WaitGroup
+		successCount  int32
+		conflictCount int32
+		otherCount    int32
+		idsMu         sync.Mutex
+		ids           []string
+	)
+	start := make(chan struct{})
+	concurrencySem := make(chan struct{}, 100)
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConns: 200, MaxIdleConnsPerHost: 200, MaxConnsPerHost: 200},
+	}
+
+	for i := 0; i < 500; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			concurrencySem <- struct{}{}
+			defer func() { <-concurrencySem }()
+
+			b, _ := json.Marshal(reserveBody(fmt.Sprintf("cart-%d", i), product, 1, 600))
+			resp, err := client.Post(ts.URL+"/api/reservations", "application/json", bytes.NewReader(b))
+			if err != nil {
+				atomic.AddInt32(&otherCount, 1)
+				return
+			}
+			defer resp.Body.Close()
+
+			switch resp.StatusCode {
+			case http.StatusCreated:
+				atomic.AddInt32(&successCount, 1)
+				var created struct {
+					Data dto.ReservationResponse `json:"data"`
+				}
+				_ = json.NewDecoder(resp.Body).Decode(&created)
+				idsMu.Lock()
+				ids = append(ids, created.Data.ID)
+				idsMu.Unlock()
+			case http.StatusConflict:
+				atomic.AddInt32(&conflictCount, 1)
+			default:
+				atomic.AddInt32(&otherCount, 1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	if successCount != 100 || conflictCount != 400 {
+		t.Fatalf("unexpected counts: success=%d conflict=%d other=%d (expected success=100, conflict=400, other=0)", successCount, conflictCount, otherCount)
+	}
+	if p := reload(t, env.db, product); p.Stock != 100 || p.Reserved != 100 {
+		t.Fatalf("holds must not take stock yet: stock=%d reserved=%d", p.Stock, p.Reserved)
+	}
+
+	// Fully reserved stock cannot be bought around the holds
+	rec, _ := call(t, env.server, http.MethodPost, "/api/orders", map[string]any{"buyer_id": "walk-in", "product_id": product.ID.String(), "quantity": 1})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("direct order against reserved stock expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Confirm half, release a quarter and let the rest expire, all at once
+	var expiring []string
+	for i, id := range ids {
+		switch i % 4 {
+		case 0, 1:
+			wg.Add(1)
+			go func(i int, id string) {
+				defer wg.Done()
+				rec, _ := call(t, env.server, http.MethodPost, "/api/reservations/"+id+"/confirm", map[string]any{"buyer_id": fmt.Sprintf("buyer-%d", i)})
+				if rec.Code != http.StatusCreated {
+					t.Errorf("confirm expected 201, got %d: %s", rec.Code, rec.Body.String())
+				}
+			}(i, id)
+		case 2:
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				if rec, _ := call(t, env.server, http.MethodDelete, "/api/reservations/"+id, nil); rec.Code != http.StatusOK {
+					t.Errorf("release expected 200, got %d: %s", rec.Code, rec.Body.String())
+				}
+			}(id)
+		default:
+			expiring = append(expiring, id)
+		}
+	}
+	if err := env.db.Model(&entities.Reservation{}).Where("id IN ?", expiring).Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire holds: %v", err)
+	}
+	var swept int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		n, err := env.sweeper.SweepOnce(context.Background())
+		if err != nil {
+			t.Errorf("sweep: %v", err)
+		}
+		swept = n
+	}()
+	wg.Wait()
+
+	if swept != 25 {
+		t.Fatalf("expected 25 expired holds, swept %d", swept)
+	}
+	if p := reload(t, env.db, product); p.Stock != 50 || p.Reserved != 0 {
+		t.Fatalf("expected stock 50 and nothing reserved, got stock=%d reserved=%d", p.Stock, p.Reserved)
+	}
+	var orderCount int64
+	env.db.Model(&entities.Order{}).Count(&orderCount)
+	if orderCount != 50 {
+		t.Fatalf("expected 50 orders from confirmed holds, got %d", orderCount)
+	}
+}
+
+func TestReservationLifecycleGuards(t *testing.T) {
+	env := setupTestServer(t)
+	product := createProduct(t, env.db, 5)
+
+	rec, held := call(t, env.server, http.MethodPost, "/api/reservations", reserveBody("cart-a", product, 3, 600))
+	if rec.Code != http.StatusCreated || held.Status != "active" || held.ExpiresAt.Before(time.Now().Add(9*time.Minute)) {
+		t.Fatalf("expected an active 10 minute hold, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := call(t, env.server, http.MethodPost, "/api/reservations", reserveBody("cart-b", product, 3, 0)); rec.Code != http.StatusConflict {
+		t.Fatalf("only 2 are available, expected 409, got %d", rec.Code)
+	}
+
+	// A hold past its expiry cannot be confirmed even before the sweeper ran
+	_, short := call(t, env.server, http.MethodPost, "/api/reservations", reserveBody("cart-c", product, 2, 1))
+	if err := env.db.Model(&entities.Reservation{}).Where("id = ?", short.ID).Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire hold: %v", err)
+	}
+	if rec, _ := call(t, env.server, http.MethodPost, "/api/reservations/"+short.ID+"/confirm", map[string]any{"buyer_id": "late"}); rec.Code != http.StatusGone {
+		t.Fatalf("confirming an expired hold expected 410, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if n, err := env.sweeper.SweepOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected one expired hold, got %d: %v", n, err)
+	}
+	if _, got := call(t, env.server, http.MethodGet, "/api/reservations/"+short.ID, nil); got.Status != "expired" {
+		t.Fatalf("expected expired status, got %q", got.Status)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/reservations/"+held.ID+"/confirm", bytes.NewReader([]byte(`{"buyer_id":"buyer-a"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	env.server.ServeHTTP(rec, req)
+	var confirmed struct {
+		Data dto.ReservationConfirmResponse `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &confirmed)
+	if rec.Code != http.StatusCreated || confirmed.Data.Reservation.Status != "confirmed" || confirmed.Data.Order.TotalCents != 3_000 {
+		t.Fatalf("confirm expected an order for 3 items, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if confirmed.Data.Reservation.OrderID == nil || *confirmed.Data.Reservation.OrderID != confirmed.Data.Order.ID {
+		t.Fatalf("reservation should point at its order: %s", rec.Body.String())
+	}
+
+	// Confirmed and expired holds are final
+	if rec, _ := call(t, env.server, http.MethodPost, "/api/reservations/"+held.ID+"/confirm", map[string]any{"buyer_id": "again"}); rec.Code != http.StatusConflict {
+		t.Fatalf("second confirm expected 409, got %d", rec.Code)
+	}
+	if rec, _ := call(t, env.server, http.MethodDelete, "/api/reservations/"+short.ID, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("releasing an expired hold expected 409, got %d", rec.Code)
+	}
+	if p := reload(t, env.db, product); p.Stock != 2 || p.Reserved != 0 {
+		t.Fatalf("expected stock 2 and nothing reserved, got stock=%d reserved=%d", p.Stock, p.Reserved)
+	}
+}
+
+func TestSweeperAndOverlappingOrdersDoNotDeadlock(t *testing.T) {
+	env := setupTestServer(t)
+	a := createProduct(t, env.db, 200)
+	b := createProduct(t, env.db, 200)
+	if a.ID.String() > b.ID.String() {
+		a, b = b, a
+	}
+
+	// Holds on the higher product id expire before holds on the lower one, so a sweep that locked
+	// products hold by hold would take them in the opposite order to the orders below
+	const holds = 40
+	now := time.Now()
+	for i := 0; i < holds; i++ {
+		p, expires := b, now.Add(-time.Hour+time.Duration(i)*time.Second)
+		if i%2 == 1 {
+			p, expires = a, now.Add(-time.Minute+time.Duration(i)*time.Second)
+		}
+		rec, held := call(t, env.server, http.MethodPost, "/api/reservations", reserveBody(fmt.Sprintf("cart-%d", i), p, 1, 600))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("reserve expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if err := env.db.Model(&entities.Reservation{}).Where("id = ?", held.ID).Update("expires_at", expires).Error; err != nil {
+			t.Fatalf("expire hold: %v", err)
+		}
+	}
+
+	var (
+		wg    sync.WaitGroup
+		fails atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := map[string]any{"buyer_id": "buyer", "items": []map[string]any{
+				{"product_id": a.ID.String(), "quantity": 1}, {"product_id": b.ID.String(), "quantity": 1},
+			}}
+			if rec, _ := call(t, env.server, http.MethodPost, "/api/orders", body); rec.Code != http.StatusCreated {
+				fails.Add(1)
+			}
+		}()
+	}
+	swept := 0
+	for i := 0; i < 4; i++ {
+		n, err := env.sweeper.SweepOnce(context.Background())
+		if err != nil {
+			t.Fatalf("sweep: %v", err)
+		}
+		swept += n
+	}
+	wg.Wait()
+
+	if fails.Load() != 0 || swept != holds {
+		t.Fatalf("expected every order to succeed and %d holds swept, got %d failures and %d swept", holds, fails.Load(), swept)
+	}
+	for _, p := range []entities.Product{a, b} {
+		if got := reload(t, env.db, p); got.Stock != 180 || got.Reserved != 0 {
+			t.Fatalf("expected stock 180 and nothing reserved, got stock=%d reserved=%d", got.Stock, got.Reserved)
+		}
+	}
+}
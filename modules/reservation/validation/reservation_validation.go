@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	orderValidation "github.com/xkillx/go-gin-order-settlement/modules/order/validation"
+	"github.com/xkillx/go-gin-order-settlement/modules/reservation/dto"
+)
+
+type ReservationValidation struct {
+	validate *validator.Validate
+}
+
+func NewReservationValidation() *ReservationValidation {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &ReservationValidation{validate: validate}
+}
+
+func (v *ReservationValidation) ValidateReservationCreateRequest(req dto.ReservationCreateRequest) error {
+	return v.validate.Struct(req)
+}
+
+func (v *ReservationValidation) ValidateReservationConfirmRequest(req dto.ReservationConfirmRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	return orderValidation.ValidateShipping(req.Shipping)
+}
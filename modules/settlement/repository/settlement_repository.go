@@ -5,12 +5,54 @@ import (
 	"time"
 
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type SettlementRepo interface {
-	UpsertBatch(ctx context.Context, settlements []entities.Settlement, runID string) error
+	UpsertBatch(ctx context.Context, tx *gorm.DB, settlements []entities.Settlement, runID string) error
+	AdjustmentTotals(ctx context.Context, from, to time.Time) ([]AdjustmentDayTotal, error)
+	TransactionTotals(ctx context.Context, from, to time.Time) ([]TransactionDayTotal, error)
+	ListByDateRange(ctx context.Context, from, to time.Time) ([]entities.Settlement, error)
+	List(ctx context.Context, q query.Request) ([]entities.Settlement, pkgdto.PaginationResponse, error)
+	SuspendedMerchantIDs(ctx context.Context) (map[string]struct{}, error)
+}
+
+// TransactionDayTotal sums a merchant's transactions paid on one UTC day.
+type TransactionDayTotal struct {
+	MerchantID string
+	Day        time.Time
+	GrossCents int64
+	FeeCents   int64
+	TxnCount   int64
+}
+
+// AdjustmentDayTotal sums the refunds and chargebacks a merchant issued on one UTC day.
+type AdjustmentDayTotal struct {
+	MerchantID      string
+	Day             time.Time
+	RefundCents     int64
+	ChargebackCents int64
+}
+
+// settlementSchema is what the settlement list may be filtered and sorted by. A settlement is
+// keyed by merchant and day, not an id.
+var settlementSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"merchant_id":      {Column: "merchant_id", Type: query.String, Ops: query.Equality, Sort: true},
+		"date":             {Column: "date", Type: query.Date, Ops: query.Range, Sort: true},
+		"gross_cents":      {Column: "gross_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"fee_cents":        {Column: "fee_cents", Type: query.Int, Ops: query.Range},
+		"net_cents":        {Column: "net_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"txn_count":        {Column: "txn_count", Type: query.Int, Ops: query.Range, Sort: true},
+		"refund_cents":     {Column: "refund_cents", Type: query.Int, Ops: query.Range},
+		"chargeback_cents": {Column: "chargeback_cents", Type: query.Int, Ops: query.Range},
+	},
+	Keys:        []string{"merchant_id", "date"},
+	DefaultSort: "-date",
 }
 
 type settlementRepository struct {
@@ -21,10 +63,18 @@ func NewSettlementRepository(db *gorm.DB) SettlementRepo {
 	return &settlementRepository{db: db}
 }
 
+func (r *settlementRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
 // UpsertBatch inserts or updates settlements based on the unique (merchant_id, date) index.
 // runID is accepted for traceability by callers but not persisted in the settlement rows.
 func (r *settlementRepository) UpsertBatch(
 	ctx context.Context,
+	tx *gorm.DB,
 	settlements []entities.Settlement,
 	runID string,
 ) error {
@@ -38,10 +88,86 @@ func (r *settlementRepository) UpsertBatch(
 		settlements[i].UpdatedAt = now
 	}
 
-	return r.db.WithContext(ctx).
+	return r.getDB(tx).WithContext(ctx).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "merchant_id"}, {Name: "date"}},
-			DoUpdates: clause.AssignmentColumns([]string{"gross_cents", "fee_cents", "net_cents", "txn_count", "updated_at"}),
+			DoUpdates: clause.AssignmentColumns([]string{"gross_cents", "fee_cents", "net_cents", "txn_count", "refund_cents", "chargeback_cents", "updated_at"}),
 		}).
 		Create(&settlements).Error
 }
+
+// AdjustmentTotals groups refunds and chargebacks issued in [from, to) by merchant and issue day.
+func (r *settlementRepository) AdjustmentTotals(ctx context.Context, from, to time.Time) ([]AdjustmentDayTotal, error) {
+	var totals []AdjustmentDayTotal
+	err := r.db.WithContext(ctx).
+		Model(&entities.TransactionAdjustment{}).
+		Select(`merchant_id,
+			(issued_at AT TIME ZONE 'UTC')::date AS day,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount_cents END), 0) AS refund_cents,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount_cents END), 0) AS chargeback_cents`,
+			constants.ENUM_ADJUSTMENT_TYPE_REFUND, constants.ENUM_ADJUSTMENT_TYPE_CHARGEBACK).
+		Where("issued_at >= ? AND issued_at < ?", from, to).
+		Group("merchant_id, day").
+		Order("day ASC, merchant_id ASC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// TransactionTotals recomputes per (merchant, UTC day) totals straight from transactions paid in [from, to).
+func (r *settlementRepository) TransactionTotals(ctx context.Context, from, to time.Time) ([]TransactionDayTotal, error) {
+	var totals []TransactionDayTotal
+	err := r.db.WithContext(ctx).
+		Model(&entities.Transaction{}).
+		Select(`merchant_id,
+			(paid_at AT TIME ZONE 'UTC')::date AS day,
+			COALESCE(SUM(amount_cents), 0) AS gross_cents,
+			COALESCE(SUM(fee_cents), 0) AS fee_cents,
+			COUNT(*) AS txn_count`).
+		Scopes(entities.SettleableTransactions).
+		Where("paid_at >= ? AND paid_at < ?", from, to).
+		Group("merchant_id, day").
+		Order("day ASC, merchant_id ASC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// ListByDateRange returns stored settlements for days in [from, to).
+func (r *settlementRepository) ListByDateRange(ctx context.Context, from, to time.Time) ([]entities.Settlement, error) {
+	var rows []entities.Settlement
+	err := r.db.WithContext(ctx).
+		Where("date >= ? AND date < ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC, merchant_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *settlementRepository) List(ctx context.Context, q query.Request) ([]entities.Settlement, pkgdto.PaginationResponse, error) {
+	return query.Find[entities.Settlement](func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&entities.Settlement{})
+	}, q, settlementSchema)
+}
+
+// SuspendedMerchantIDs lists merchants whose payouts are frozen.
+func (r *settlementRepository) SuspendedMerchantIDs(ctx context.Context) (map[string]struct{}, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).
+		Model(&entities.Merchant{}).
+		Where("status = ?", constants.ENUM_MERCHANT_STATUS_SUSPENDED).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	suspended := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		suspended[id] = struct{}{}
+	}
+	return suspended, nil
+}
@@ -1,18 +1,25 @@
 package settlement
 
 import (
+    "encoding/json"
     "errors"
     "fmt"
     "net/http"
     "os"
     "path/filepath"
+    "strings"
     "time"
 
     "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
     "github.com/samber/do"
     jobrepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+    jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+    settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
     settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
     "github.com/xkillx/go-gin-order-settlement/pkg/constants"
+    "github.com/xkillx/go-gin-order-settlement/pkg/query"
+    "github.com/xkillx/go-gin-order-settlement/pkg/utils"
     "gorm.io/gorm"
 )
 
@@ -21,44 +28,75 @@ func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 	// Resolve dependencies
 	db := do.MustInvokeNamed[*gorm.DB](injector, constants.DB)
 	jobRepository := jobrepo.NewJobRepository(db)
+	settlementRepository := settlementRepo.NewSettlementRepository(db)
 	jobManager := do.MustInvoke[*settlementService.JobManager](injector)
 
-	// 1) POST /jobs/settlement
-	server.POST("/jobs/settlement", func(c *gin.Context) {
-		var req struct {
-			From string `json:"from" binding:"required"`
-			To   string `json:"to" binding:"required"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	// listError answers a failed list with 400 for bad list parameters and 500 otherwise
+	listError := func(c *gin.Context, err error) {
+		if errors.Is(err, query.ErrInvalidQuery) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 
-		const layout = "2006-01-02"
-		fromDate, err := time.Parse(layout, req.From)
+	// startJob validates the raw body against the handler registered for jobType and queues the job
+	startJob := func(c *gin.Context, jobType string) {
+		var payload []byte
+		var err error
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			payload, err = uploadPayload(c)
+		} else {
+			payload, err = c.GetRawData()
+		}
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' date format, expected YYYY-MM-DD"})
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 			return
 		}
-		toDate, err := time.Parse(layout, req.To)
+
+		job, err := jobManager.Start(c.Request.Context(), jobType, payload)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'to' date format, expected YYYY-MM-DD"})
+			switch {
+			case errors.Is(err, jobservice.ErrUnknownJobType):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, jobservice.ErrInvalidPayload):
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
-		if toDate.Before(fromDate) {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "'to' must be on or after 'from'"})
+		c.JSON(http.StatusAccepted, gin.H{
+			"job_id": job.ID,
+			"type":   job.Type,
+			"status": job.Status,
+		})
+	}
+
+	// 1) POST /jobs/settlement
+	server.POST("/jobs/settlement", func(c *gin.Context) {
+		startJob(c, settlementService.SettlementJobType)
+	})
+
+	// 1b) POST /jobs/:type for any registered job type.
+	// gin requires one wildcard name per path segment, so the type shares the :id slot with /jobs/:id/cancel.
+	server.POST("/jobs/:id", func(c *gin.Context) {
+		startJob(c, c.Param("id"))
+	})
+
+	// 1c) GET /jobs lists jobs with the shared list parameters (filter[...], sort, cursor)
+	server.GET("/jobs", func(c *gin.Context) {
+		q, err := query.FromContext(c)
+		if err != nil {
+			listError(c, err)
 			return
 		}
-
-		jobID, err := jobManager.StartSettlementJob(c.Request.Context(), fromDate, toDate)
+		jobs, meta, err := jobRepository.List(c.Request.Context(), q)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			listError(c, err)
 			return
 		}
-		c.JSON(http.StatusAccepted, gin.H{
-			"job_id": jobID,
-			"status": "QUEUED",
-		})
+		c.JSON(http.StatusOK, gin.H{"items": jobs, "pagination": meta})
 	})
 
 	// 2) GET /jobs/:id
@@ -75,14 +113,23 @@ func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 		}
 
 		payload := gin.H{
-			"job_id":   j.ID,
-			"status":   j.Status,
-			"progress": j.Progress,
+			"job_id":    j.ID,
+			"type":      j.Type,
+			"status":    j.Status,
+			"progress":  j.Progress,
 			"processed": j.Processed,
-			"total":    j.Total,
+			"total":     j.Total,
+			"attempts":  j.Attempts,
+		}
+		if j.Error != "" {
+			payload["error"] = j.Error
 		}
 		if j.Status == "COMPLETED" && j.ResultPath != "" {
-			payload["download_url"] = "/downloads/" + j.ID + ".csv"
+			if j.Type == settlementService.SettlementJobType {
+				payload["download_url"] = "/downloads/" + j.ID + ".csv"
+			} else {
+				payload["download_url"] = "/jobs/" + j.ID + "/download"
+			}
 		}
 		c.JSON(http.StatusOK, payload)
 	})
@@ -104,20 +151,93 @@ func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// Try to cancel in-memory running job
-		wasRunning := jobManager.Cancel(id)
-		if wasRunning {
-			_ = jobRepository.SetStatus(c.Request.Context(), id, "CANCELLING")
-			c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status": "CANCELLING"})
+		// Try to cancel in-memory running job; both writes below only apply while the job is
+		// still unfinished, so they never overwrite a final status
+		status := "CANCELLED"
+		var err error
+		if jobManager.Cancel(id) {
+			status = "CANCELLING"
+			err = jobManager.MarkCancelling(c.Request.Context(), id)
+		} else {
+			// Not running: cancel in place and skip anything waiting on it
+			err = jobManager.CancelPending(c.Request.Context(), id)
+		}
+		if errors.Is(err, jobservice.ErrJobNotCancellable) {
+			j, getErr := jobRepository.Get(c.Request.Context(), id)
+			if getErr != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": getErr.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error(), "job_id": id, "status": j.Status})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status": status})
+	})
+
+	// 3b) POST /jobs/:id/retry re-queues a failed or cancelled job with its original payload
+	server.POST("/jobs/:id/retry", func(c *gin.Context) {
+		id := c.Param("id")
+		job, err := jobManager.Retry(c.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			case errors.Is(err, jobservice.ErrJobNotRetryable):
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, jobservice.ErrInvalidPayload), errors.Is(err, jobservice.ErrUnknownJobType):
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
-		_ = jobRepository.SetStatus(c.Request.Context(), id, "CANCELLED")
-		c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status": "CANCELLED"})
+		c.JSON(http.StatusAccepted, gin.H{
+			"job_id":   job.ID,
+			"type":     job.Type,
+			"status":   job.Status,
+			"attempts": job.Attempts,
+		})
+	})
+
+	// 3c) GET /jobs/:id/download serves the result file of any completed job
+	server.GET("/jobs/:id/download", func(c *gin.Context) {
+		j, err := jobRepository.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "job not found"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if j.ResultPath == "" {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		if _, err := os.Stat(j.ResultPath); err != nil {
+			if os.IsNotExist(err) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "file not found"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if strings.EqualFold(filepath.Ext(j.ResultPath), ".csv") {
+			c.Header("Content-Type", "text/csv")
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(j.ResultPath)))
+		c.File(j.ResultPath)
 	})
 
 	// 4) GET /downloads/:job_id.csv -> serves /tmp/settlements/<job_id>.csv
 	server.GET("/downloads/:job_id.csv", func(c *gin.Context) {
-		jobID := c.Param("job_id")
+		// gin treats the whole last segment as the parameter, named "job_id.csv"
+		jobID := strings.TrimSuffix(c.Param("job_id.csv"), ".csv")
 		fullPath := filepath.Join("/tmp/settlements", jobID+".csv")
 
 		if _, err := os.Stat(fullPath); err != nil {
@@ -133,4 +253,119 @@ func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(fullPath)))
 		c.File(fullPath)
 	})
+
+	// 4b) GET /settlements/verify?from=YYYY-MM-DD&to=YYYY-MM-DD recomputes totals from transactions
+	// and diffs them against stored settlements; format=csv downloads the discrepancy report
+	verifier := settlementService.NewSettlementVerifier(settlementRepository)
+	server.GET("/settlements/verify", func(c *gin.Context) {
+		const layout = "2006-01-02"
+		from, err := time.Parse(layout, c.Query("from"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' date format, expected YYYY-MM-DD"})
+			return
+		}
+		to, err := time.Parse(layout, c.Query("to"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid 'to' date format, expected YYYY-MM-DD"})
+			return
+		}
+		if to.Before(from) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "'to' must be on or after 'from'"})
+			return
+		}
+
+		report, err := verifier.Verify(c.Request.Context(), from, to)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if c.Query("format") == "csv" {
+			c.Header("Content-Type", "text/csv")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"settlement_verify_%s_%s.csv\"", report.From, report.To))
+			if err := report.WriteCSV(c.Writer); err != nil {
+				_ = c.Error(err)
+			}
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
+	// 4c) GET /settlements lists stored settlements with the shared list parameters
+	server.GET("/settlements", func(c *gin.Context) {
+		q, err := query.FromContext(c)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		settlements, meta, err := settlementRepository.List(c.Request.Context(), q)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": settlements, "pagination": meta})
+	})
+
+	// 5) POST /workflows creates a DAG of jobs; steps start once every parent step COMPLETES
+	server.POST("/workflows", func(c *gin.Context) {
+		var req struct {
+			Steps []jobservice.WorkflowStep `json:"steps" binding:"required,min=1,dive"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+
+		wf, err := jobManager.StartWorkflow(c.Request.Context(), req.Steps)
+		if err != nil {
+			switch {
+			case errors.Is(err, jobservice.ErrInvalidWorkflow),
+				errors.Is(err, jobservice.ErrInvalidPayload),
+				errors.Is(err, jobservice.ErrUnknownJobType):
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, wf)
+	})
+
+	// 6) GET /workflows/:id returns the DAG with per-job and overall status
+	server.GET("/workflows/:id", func(c *gin.Context) {
+		wf, err := jobManager.GetWorkflow(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "workflow not found"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, wf)
+	})
+}
+
+// uploadPayload stores the multipart "file" field with utils.UploadFile and turns the request into
+// a job payload: the stored file_path, the original file_name and any other form fields.
+func uploadPayload(c *gin.Context) ([]byte, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	name := uuid.NewString() + strings.ToLower(filepath.Ext(file.Filename))
+	if err := utils.UploadFile(file, jobservice.UploadDir+"/"+name); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]string{}
+	if form, err := c.MultipartForm(); err == nil {
+		for k, v := range form.Value {
+			if len(v) > 0 {
+				payload[k] = v[0]
+			}
+		}
+	}
+	payload["file_path"] = jobservice.UploadPath(name)
+	payload["file_name"] = file.Filename
+	return json.Marshal(payload)
 }
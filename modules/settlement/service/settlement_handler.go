@@ -0,0 +1,497 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	settrepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	txrepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	"gorm.io/gorm"
+)
+
+const SettlementJobType = "settlement"
+
+// SettlementPayload is the body accepted by POST /jobs/settlement.
+type SettlementPayload struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// LedgerPoster records settlement rows as journal entries, inside tx when one is given.
+type LedgerPoster interface {
+	PostSettlements(ctx context.Context, tx *gorm.DB, jobID string, settlements []entities.Settlement) error
+}
+
+// SettlementHandler aggregates transactions into daily per-merchant settlements
+// and writes them to the settlements table, the ledger and a CSV export.
+type SettlementHandler struct {
+	transactionRepo txrepo.TransactionRepo
+	settlementRepo  settrepo.SettlementRepo
+	ledger          LedgerPoster
+	db              *gorm.DB
+
+	workers   int
+	batchSize int
+	// evictFinalized writes and drops (merchant, day) aggregates as soon as the
+	// ordered stream has moved past their day, keeping collector memory flat.
+	evictFinalized bool
+	// verifier, when set, re-checks the written settlements as the final stage and
+	// fails the job on any discrepancy.
+	verifier *SettlementVerifier
+}
+
+func NewSettlementHandler(t txrepo.TransactionRepo, s settrepo.SettlementRepo, l LedgerPoster, db *gorm.DB, workers, batchSize int, evictFinalized, verify bool) *SettlementHandler {
+	h := &SettlementHandler{
+		transactionRepo: t,
+		settlementRepo:  s,
+		ledger:          l,
+		db:              db,
+		workers:         workers,
+		batchSize:       batchSize,
+		evictFinalized:  evictFinalized,
+	}
+	if verify {
+		h.verifier = NewSettlementVerifier(s)
+	}
+	return h
+}
+
+func (h *SettlementHandler) Type() string {
+	return SettlementJobType
+}
+
+// Prepare parses the date range and counts transactions for progress/estimation.
+func (h *SettlementHandler) Prepare(ctx context.Context, payload json.RawMessage, job *entities.Job) error {
+	var req SettlementPayload
+	if err := json.Unmarshal(payload, &req); err != nil || req.From == "" || req.To == "" {
+		return fmt.Errorf("%w: invalid request body", jobservice.ErrInvalidPayload)
+	}
+
+	const layout = "2006-01-02"
+	fromDate, err := time.Parse(layout, req.From)
+	if err != nil {
+		return fmt.Errorf("%w: invalid 'from' date format, expected YYYY-MM-DD", jobservice.ErrInvalidPayload)
+	}
+	toDate, err := time.Parse(layout, req.To)
+	if err != nil {
+		return fmt.Errorf("%w: invalid 'to' date format, expected YYYY-MM-DD", jobservice.ErrInvalidPayload)
+	}
+	if toDate.Before(fromDate) {
+		return fmt.Errorf("%w: 'to' must be on or after 'from'", jobservice.ErrInvalidPayload)
+	}
+
+	total, err := h.transactionRepo.Count(ctx, fromDate, toDate)
+	if err != nil {
+		return err
+	}
+	job.FromDate = fromDate
+	job.ToDate = toDate
+	job.Total = total
+	return nil
+}
+
+// sequencedBatch tags each streamed batch with its position in the paid_at ordered stream
+// so the collector can tell which days can no longer change.
+type sequencedBatch struct {
+	seq int64
+	txs []entities.Transaction
+}
+
+// internal helper type for worker -> collector communication
+// count indicates how many transactions contributed to this aggregate
+// so progress can be updated accurately.
+// seq and maxDay identify the source batch and the latest UTC day it contained.
+type partialResult struct {
+	agg    map[string]entities.Settlement
+	count  int
+	seq    int64
+	maxDay time.Time
+}
+
+func (h *SettlementHandler) Run(jobCtx context.Context, job entities.Job, progress *jobservice.Progress) error {
+	jobID := job.ID
+	from, to := job.FromDate, job.ToDate
+
+	// Prepare CSV output
+	f, w, err := createCSVWriter(jobID)
+	if err != nil {
+		return fmt.Errorf("create csv: %w", err)
+	}
+	defer f.Close()
+	_ = progress.SetResultPath(jobCtx, filepath.Join(settlementOutputDir, jobID+".csv"))
+	_ = w.Write([]string{"merchant_id", "date", "gross", "fee", "net", "txn_count", "refunds", "chargebacks"})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("csv header: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("csv header fsync: %w", err)
+	}
+
+	// Suspended merchants are held back: nothing is written or posted for them until they are
+	// reactivated and the range is settled again.
+	suspended, err := h.settlementRepo.SuspendedMerchantIDs(jobCtx)
+	if err != nil {
+		return fmt.Errorf("suspended merchants: %w", err)
+	}
+
+	// Channels and concurrency setup
+	streamChan := make(chan []entities.Transaction, h.workers*2)
+	batchChan := make(chan sequencedBatch, h.workers*2)
+	resultChan := make(chan partialResult, h.workers*2)
+	producerErr := make(chan error, 1)
+
+	// Start producer
+	go func() {
+		err := h.transactionRepo.StreamByDateRange(jobCtx, from, to, h.batchSize, streamChan)
+		if err != nil {
+			producerErr <- err
+		}
+		close(streamChan)
+	}()
+
+	// Number batches in stream order before fanning out to workers
+	go func() {
+		defer close(batchChan)
+		var seq int64
+		for txs := range streamChan {
+			select {
+			case <-jobCtx.Done():
+				return
+			case batchChan <- sequencedBatch{seq: seq, txs: txs}:
+			}
+			seq++
+		}
+	}()
+
+	// Start workers
+	var wgWorkers sync.WaitGroup
+	wgWorkers.Add(h.workers)
+	for i := 0; i < h.workers; i++ {
+		go func() {
+			defer wgWorkers.Done()
+			for {
+				select {
+				case <-jobCtx.Done():
+					return
+				case sb, ok := <-batchChan:
+					if !ok {
+						return
+					}
+					batch := sb.txs
+					// Pre-check cancellation
+					select {
+					case <-jobCtx.Done():
+						return
+					default:
+					}
+
+					agg := make(map[string]entities.Settlement, len(batch))
+					var maxDay time.Time
+					for _, tx := range batch {
+						day := time.Date(tx.PaidAt.UTC().Year(), tx.PaidAt.UTC().Month(), tx.PaidAt.UTC().Day(), 0, 0, 0, 0, time.UTC)
+						if day.After(maxDay) {
+							maxDay = day
+						}
+						key := tx.MerchantID + "|" + day.Format("2006-01-02")
+						cur := agg[key]
+						cur.MerchantID = tx.MerchantID
+						cur.Date = day
+						cur.GrossCents += tx.AmountCents
+						cur.FeeCents += tx.FeeCents
+						cur.NetCents += tx.AmountCents - tx.FeeCents
+						cur.TxnCount += 1
+						agg[key] = cur
+					}
+					// Post-check cancellation
+					select {
+					case <-jobCtx.Done():
+						return
+					case resultChan <- partialResult{agg: agg, count: len(batch), seq: sb.seq, maxDay: maxDay}:
+					}
+				}
+			}
+		}()
+	}
+
+	// Close resultChan once all workers are done
+	go func() {
+		wgWorkers.Wait()
+		close(resultChan)
+	}()
+
+	// Collector: merge and periodically flush
+	global := make(map[string]*entities.Settlement)
+	changed := make(map[string]struct{})
+	batchesSinceFlush := 0
+	const flushEveryBatches = 50
+	var processed int64
+
+	// Eviction bookkeeping: keys grouped by day, and the contiguous prefix of merged batches.
+	// Because the stream is ordered by paid_at, once every batch up to seq N has been merged,
+	// no later batch can contain a day earlier than batch N's max day.
+	keysByDay := make(map[time.Time]map[string]struct{})
+	doneBatches := make(map[int64]time.Time)
+	var nextSeq int64
+	var watermark time.Time
+
+	// writeRows upserts settlement rows. Rows that are final are also posted to the ledger in the
+	// same transaction, so a day's entries exist exactly when its settlement row is complete, and
+	// streamed to the CSV export once.
+	writeRows := func(rows []entities.Settlement, final bool) error {
+		if len(suspended) > 0 {
+			payable := rows[:0]
+			for _, r := range rows {
+				if _, held := suspended[r.MerchantID]; !held {
+					payable = append(payable, r)
+				}
+			}
+			rows = payable
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if !final {
+			return h.settlementRepo.UpsertBatch(jobCtx, nil, rows, jobID)
+		}
+		err := h.db.WithContext(jobCtx).Transaction(func(tx *gorm.DB) error {
+			if err := h.settlementRepo.UpsertBatch(jobCtx, tx, rows, jobID); err != nil {
+				return err
+			}
+			// The ledger skips days this job already posted, so a retried job posts nothing twice
+			if err := h.ledger.PostSettlements(jobCtx, tx, jobID, rows); err != nil {
+				return fmt.Errorf("ledger: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Stream rows to CSV
+		for _, s := range rows {
+			_ = w.Write([]string{
+				s.MerchantID,
+				s.Date.Format("2006-01-02"),
+				strconv.FormatInt(s.GrossCents, 10),
+				strconv.FormatInt(s.FeeCents, 10),
+				strconv.FormatInt(s.NetCents, 10),
+				strconv.FormatInt(s.TxnCount, 10),
+				strconv.FormatInt(s.RefundCents, 10),
+				strconv.FormatInt(s.ChargebackCents, 10),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return f.Sync()
+	}
+
+	// finalize writes every aggregate whose day is before the watermark (all of them when
+	// all is true) exactly once, then drops it from memory.
+	finalize := func(all bool) error {
+		rows := make([]entities.Settlement, 0)
+		for day, keys := range keysByDay {
+			if !all && !day.Before(watermark) {
+				continue
+			}
+			for k := range keys {
+				if s, ok := global[k]; ok {
+					rows = append(rows, *s)
+				}
+				delete(global, k)
+				delete(changed, k)
+			}
+			delete(keysByDay, day)
+		}
+		return writeRows(rows, true)
+	}
+
+	flush := func(force bool) error {
+		if h.evictFinalized {
+			if err := finalize(force); err != nil {
+				return err
+			}
+		} else if force {
+			// Only the final flush knows every aggregate is complete, so it alone posts to the ledger
+			rows := make([]entities.Settlement, 0, len(global))
+			for _, s := range global {
+				rows = append(rows, *s)
+			}
+			if err := writeRows(rows, true); err != nil {
+				return err
+			}
+		} else {
+			if len(changed) == 0 {
+				return nil
+			}
+			rows := make([]entities.Settlement, 0, len(changed))
+			for k := range changed {
+				if s, ok := global[k]; ok {
+					rows = append(rows, *s)
+				}
+			}
+			if err := writeRows(rows, false); err != nil {
+				return err
+			}
+		}
+		// Update progress after each flush
+		_ = progress.Update(jobCtx, processed)
+		// Reset trackers
+		changed = make(map[string]struct{})
+		batchesSinceFlush = 0
+		return nil
+	}
+
+	// Refunds and chargebacks net into the day they were issued, not the original paid_at day.
+	// They are aggregated up front so eviction finalizes them together with that day's sales.
+	adjustments, err := h.settlementRepo.AdjustmentTotals(jobCtx, from, to)
+	if err != nil {
+		return fmt.Errorf("adjustments: %w", err)
+	}
+	for _, a := range adjustments {
+		day := time.Date(a.Day.Year(), a.Day.Month(), a.Day.Day(), 0, 0, 0, 0, time.UTC)
+		key := a.MerchantID + "|" + day.Format("2006-01-02")
+		global[key] = &entities.Settlement{
+			MerchantID:      a.MerchantID,
+			Date:            day,
+			NetCents:        -(a.RefundCents + a.ChargebackCents),
+			RefundCents:     a.RefundCents,
+			ChargebackCents: a.ChargebackCents,
+		}
+		changed[key] = struct{}{}
+		if h.evictFinalized {
+			if keysByDay[day] == nil {
+				keysByDay[day] = make(map[string]struct{})
+			}
+			keysByDay[day][key] = struct{}{}
+		}
+	}
+
+	// Main collect loop
+	for {
+		select {
+		case <-jobCtx.Done():
+			return jobCtx.Err()
+		case err := <-producerErr:
+			if err != nil {
+				// If we were cancelled, treat producer error as part of cancellation
+				if jobCtx.Err() != nil {
+					return jobCtx.Err()
+				}
+				return fmt.Errorf("producer: %w", err)
+			}
+			// no error from producer, continue
+		case pr, ok := <-resultChan:
+			if !ok {
+				// If cancelled, do not flush or mark completed
+				if jobCtx.Err() != nil {
+					return jobCtx.Err()
+				}
+				// final flush and successful completion
+				if err := flush(true); err != nil {
+					return fmt.Errorf("final flush: %w", err)
+				}
+				if h.verifier != nil {
+					return h.verify(jobCtx, jobID, from, to)
+				}
+				return nil
+			}
+			// If cancelled, stop processing incoming results to avoid marking FAILED due to context cancellation during flush
+			if jobCtx.Err() != nil {
+				return jobCtx.Err()
+			}
+
+			// Merge partial
+			for k, v := range pr.agg {
+				if cur, ok := global[k]; ok {
+					cur.GrossCents += v.GrossCents
+					cur.FeeCents += v.FeeCents
+					cur.NetCents += v.NetCents
+					cur.TxnCount += v.TxnCount
+					global[k] = cur
+				} else {
+					vv := v // create local copy
+					global[k] = &vv
+				}
+				changed[k] = struct{}{}
+				if h.evictFinalized {
+					if keysByDay[v.Date] == nil {
+						keysByDay[v.Date] = make(map[string]struct{})
+					}
+					keysByDay[v.Date][k] = struct{}{}
+				}
+			}
+			advanced := false
+			if h.evictFinalized {
+				doneBatches[pr.seq] = pr.maxDay
+				for {
+					d, ok := doneBatches[nextSeq]
+					if !ok {
+						break
+					}
+					if d.After(watermark) {
+						watermark = d
+						advanced = true
+					}
+					delete(doneBatches, nextSeq)
+					nextSeq++
+				}
+			}
+			processed += int64(pr.count)
+			batchesSinceFlush++
+			// In eviction mode a new watermark means whole days just became final
+			if advanced || batchesSinceFlush >= flushEveryBatches {
+				if err := flush(false); err != nil {
+					return fmt.Errorf("flush: %w", err)
+				}
+			}
+		}
+	}
+}
+
+// createCSVWriter ensures the output directory exists and returns an open file and CSV writer.
+// File path: /tmp/settlements/<jobID>.csv
+func createCSVWriter(jobID string) (*os.File, *csv.Writer, error) {
+	if err := os.MkdirAll(settlementOutputDir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	outPath := filepath.Join(settlementOutputDir, jobID+".csv")
+	f, err := os.Create(outPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := csv.NewWriter(f)
+	return f, w, nil
+}
+
+// verify diffs the settlements for the job window against transactions and writes a
+// discrepancy report next to the export when they disagree.
+func (h *SettlementHandler) verify(ctx context.Context, jobID string, from, to time.Time) error {
+	report, err := h.verifier.Verify(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if report.OK {
+		return nil
+	}
+	path := filepath.Join(settlementOutputDir, jobID+"_verify.csv")
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("verify report: %w", err)
+	}
+	defer f.Close()
+	if err := report.WriteCSV(f); err != nil {
+		return fmt.Errorf("verify report: %w", err)
+	}
+	return fmt.Errorf("settlement verification found %d discrepancies, see %s", len(report.Discrepancies), path)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	settrepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+)
+
+const (
+	DISCREPANCY_MISSING    = "missing_settlement"
+	DISCREPANCY_UNEXPECTED = "unexpected_settlement"
+	DISCREPANCY_MISMATCH   = "mismatch"
+)
+
+// SettlementTotals are the figures compared for one (merchant_id, date).
+type SettlementTotals struct {
+	GrossCents      int64 `json:"gross_cents"`
+	FeeCents        int64 `json:"fee_cents"`
+	NetCents        int64 `json:"net_cents"`
+	TxnCount        int64 `json:"txn_count"`
+	RefundCents     int64 `json:"refund_cents"`
+	ChargebackCents int64 `json:"chargeback_cents"`
+}
+
+// Discrepancy is a (merchant_id, date) whose stored settlement differs from the totals
+// recomputed from transactions.
+type Discrepancy struct {
+	MerchantID string            `json:"merchant_id"`
+	Date       string            `json:"date"`
+	Kind       string            `json:"kind"`
+	Expected   *SettlementTotals `json:"expected,omitempty"`
+	Actual     *SettlementTotals `json:"actual,omitempty"`
+}
+
+// VerificationReport is the outcome of verifying settlements for days in [From, To).
+type VerificationReport struct {
+	From          string        `json:"from"`
+	To            string        `json:"to"`
+	Checked       int           `json:"checked"`
+	OK            bool          `json:"ok"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// SettlementVerifier recomputes settlement totals from transactions and adjustments and
+// diffs them against the settlements table.
+type SettlementVerifier struct {
+	settlementRepo settrepo.SettlementRepo
+}
+
+func NewSettlementVerifier(s settrepo.SettlementRepo) *SettlementVerifier {
+	return &SettlementVerifier{settlementRepo: s}
+}
+
+func (v *SettlementVerifier) Verify(ctx context.Context, from, to time.Time) (VerificationReport, error) {
+	expected := make(map[string]*SettlementTotals)
+	keyOf := func(merchantID string, day time.Time) string {
+		return merchantID + "|" + day.UTC().Format("2006-01-02")
+	}
+	get := func(key string) *SettlementTotals {
+		t, ok := expected[key]
+		if !ok {
+			t = &SettlementTotals{}
+			expected[key] = t
+		}
+		return t
+	}
+
+	txTotals, err := v.settlementRepo.TransactionTotals(ctx, from, to)
+	if err != nil {
+		return VerificationReport{}, err
+	}
+	for _, t := range txTotals {
+		e := get(keyOf(t.MerchantID, t.Day))
+		e.GrossCents += t.GrossCents
+		e.FeeCents += t.FeeCents
+		e.NetCents += t.GrossCents - t.FeeCents
+		e.TxnCount += t.TxnCount
+	}
+	adjTotals, err := v.settlementRepo.AdjustmentTotals(ctx, from, to)
+	if err != nil {
+		return VerificationReport{}, err
+	}
+	for _, a := range adjTotals {
+		e := get(keyOf(a.MerchantID, a.Day))
+		e.RefundCents += a.RefundCents
+		e.ChargebackCents += a.ChargebackCents
+		e.NetCents -= a.RefundCents + a.ChargebackCents
+	}
+
+	stored, err := v.settlementRepo.ListByDateRange(ctx, from, to)
+	if err != nil {
+		return VerificationReport{}, err
+	}
+	actual := make(map[string]*SettlementTotals, len(stored))
+	for _, s := range stored {
+		actual[keyOf(s.MerchantID, s.Date)] = totalsOf(s)
+	}
+
+	// Suspended merchants are not settled, so there is nothing to compare for them
+	suspended, err := v.settlementRepo.SuspendedMerchantIDs(ctx)
+	if err != nil {
+		return VerificationReport{}, err
+	}
+	keys := make(map[string]struct{}, len(expected)+len(actual))
+	for _, m := range []map[string]*SettlementTotals{expected, actual} {
+		for k := range m {
+			merchantID, _ := splitKey(k)
+			if _, held := suspended[merchantID]; held {
+				continue
+			}
+			keys[k] = struct{}{}
+		}
+	}
+
+	report := VerificationReport{
+		From:          from.Format("2006-01-02"),
+		To:            to.Format("2006-01-02"),
+		Checked:       len(keys),
+		Discrepancies: make([]Discrepancy, 0),
+	}
+	for k := range keys {
+		e, a := expected[k], actual[k]
+		var kind string
+		switch {
+		case a == nil:
+			kind = DISCREPANCY_MISSING
+		case e == nil:
+			kind = DISCREPANCY_UNEXPECTED
+		case *e != *a:
+			kind = DISCREPANCY_MISMATCH
+		default:
+			continue
+		}
+		merchantID, date := splitKey(k)
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			MerchantID: merchantID,
+			Date:       date,
+			Kind:       kind,
+			Expected:   e,
+			Actual:     a,
+		})
+	}
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		di, dj := report.Discrepancies[i], report.Discrepancies[j]
+		if di.Date != dj.Date {
+			return di.Date < dj.Date
+		}
+		return di.MerchantID < dj.MerchantID
+	})
+	report.OK = len(report.Discrepancies) == 0
+	return report, nil
+}
+
+// WriteCSV writes one row per discrepancy with expected and actual figures side by side.
+func (r VerificationReport) WriteCSV(out io.Writer) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{
+		"merchant_id", "date", "kind",
+		"expected_gross", "actual_gross", "expected_fee", "actual_fee", "expected_net", "actual_net",
+		"expected_txn_count", "actual_txn_count", "expected_refunds", "actual_refunds",
+		"expected_chargebacks", "actual_chargebacks",
+	})
+	for _, d := range r.Discrepancies {
+		e, a := d.Expected, d.Actual
+		if e == nil {
+			e = &SettlementTotals{}
+		}
+		if a == nil {
+			a = &SettlementTotals{}
+		}
+		_ = w.Write([]string{
+			d.MerchantID, d.Date, d.Kind,
+			strconv.FormatInt(e.GrossCents, 10), strconv.FormatInt(a.GrossCents, 10),
+			strconv.FormatInt(e.FeeCents, 10), strconv.FormatInt(a.FeeCents, 10),
+			strconv.FormatInt(e.NetCents, 10), strconv.FormatInt(a.NetCents, 10),
+			strconv.FormatInt(e.TxnCount, 10), strconv.FormatInt(a.TxnCount, 10),
+			strconv.FormatInt(e.RefundCents, 10), strconv.FormatInt(a.RefundCents, 10),
+			strconv.FormatInt(e.ChargebackCents, 10), strconv.FormatInt(a.ChargebackCents, 10),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func totalsOf(s entities.Settlement) *SettlementTotals {
+	return &SettlementTotals{
+		GrossCents:      s.GrossCents,
+		FeeCents:        s.FeeCents,
+		NetCents:        s.NetCents,
+		TxnCount:        s.TxnCount,
+		RefundCents:     s.RefundCents,
+		ChargebackCents: s.ChargebackCents,
+	}
+}
+
+func splitKey(key string) (merchantID, date string) {
+	// Dates are fixed width, so the merchant ID is everything before the last separator
+	return key[:len(key)-len("2006-01-02")-1], key[len(key)-len("2006-01-02"):]
+}
@@ -3,11 +3,13 @@ package settlement
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -20,6 +22,8 @@ import (
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
 	seeds "github.com/xkillx/go-gin-order-settlement/database/seeders/seeds"
 	jobrepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
 	settlement "github.com/xkillx/go-gin-order-settlement/modules/settlement"
 	settrepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
 	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
@@ -40,9 +44,17 @@ func truncateTables(t *testing.T, db *gorm.DB) {
 	if err := db.Exec("DELETE FROM settlements").Error; err != nil {
 		t.Fatalf("truncate settlements: %v", err)
 	}
+	for _, table := range []string{"journal_lines", "journal_entries", "ledger_accounts"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("truncate %s: %v", table, err)
+		}
+	}
 	if err := db.Exec("DELETE FROM jobs").Error; err != nil {
 		t.Fatalf("truncate jobs: %v", err)
 	}
+	if err := db.Exec("DELETE FROM transaction_adjustments").Error; err != nil {
+		t.Fatalf("truncate transaction_adjustments: %v", err)
+	}
 	if err := db.Exec("DELETE FROM transactions").Error; err != nil {
 		t.Fatalf("truncate transactions: %v", err)
 	}
@@ -105,6 +117,7 @@ func (r *slowTransactionRepository) StreamByDateRange(ctx context.Context, from,
 
 		var batch []entities.Transaction
 		if err := r.db.WithContext(ctx).
+			Scopes(entities.SettleableTransactions).
 			Where("paid_at >= ? AND paid_at < ?", from, to).
 			Order("paid_at ASC, id ASC").
 			Limit(batchSize).
@@ -151,7 +164,8 @@ func newTestEnvWithTxRepo(t *testing.T, db *gorm.DB, txRepo txrepo.TransactionRe
 
 	stRepo := settrepo.NewSettlementRepository(db)
 	jobRepo := jobrepo.NewJobRepository(db)
-	jobManager := settlementService.NewJobManager(txRepo, stRepo, jobRepo)
+	ledger := ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db)
+	jobManager := settlementService.NewJobManager(txRepo, stRepo, ledger, jobRepo, db)
 
 	injector := do.New()
 	do.ProvideNamed(injector, constants.DB, func(i *do.Injector) (*gorm.DB, error) { return db, nil })
@@ -305,3 +319,416 @@ func TestSettlementCancelJob(t *testing.T) {
 	}
 	t.Fatalf("job did not reach CANCELLED in time")
 }
+
+func TestSettlementEvictFinalizedJob(t *testing.T) {
+	// Small batches so the stream crosses several day boundaries mid-job
+	prevBatch := os.Getenv("BATCH_SIZE")
+	prevEvict := os.Getenv("SETTLEMENT_EVICT_FINALIZED")
+	os.Setenv("BATCH_SIZE", "100")
+	os.Setenv("SETTLEMENT_EVICT_FINALIZED", "true")
+	t.Cleanup(func() {
+		os.Setenv("BATCH_SIZE", prevBatch)
+		os.Setenv("SETTLEMENT_EVICT_FINALIZED", prevEvict)
+	})
+
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+	seedWithSeeder(t)
+
+	fromDate := time.Now().UTC().Add(-72 * time.Hour).Format("2006-01-02")
+	toDate := time.Now().UTC().Add(24 * time.Hour).Format("2006-01-02")
+	b, _ := json.Marshal(map[string]string{"from": fromDate, "to": toDate})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var create map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &create)
+	jobID := create["job_id"].(string)
+
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		grec := httptest.NewRecorder()
+		greq := httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil)
+		env.server.ServeHTTP(grec, greq)
+		_ = json.Unmarshal(grec.Body.Bytes(), &last)
+		if last["status"].(string) == "COMPLETED" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if last["status"].(string) != "COMPLETED" {
+		t.Fatalf("job did not complete, last: %#v", last)
+	}
+
+	// Every (merchant, day) must be written exactly once and account for every transaction
+	f, err := os.Open(filepath.Join("/tmp/settlements", jobID+".csv"))
+	if err != nil {
+		t.Fatalf("open csv: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	seen := make(map[string]bool)
+	var txnCount int64
+	for _, row := range rows[1:] {
+		key := row[0] + "|" + row[1]
+		if seen[key] {
+			t.Fatalf("duplicate csv row for %s", key)
+		}
+		seen[key] = true
+		n, _ := strconv.ParseInt(row[5], 10, 64)
+		txnCount += n
+	}
+	if total := int64(last["total"].(float64)); txnCount != total {
+		t.Fatalf("expected csv txn_count sum %d, got %d", total, txnCount)
+	}
+}
+
+func TestSettlementNetsRefundsOnIssueDay(t *testing.T) {
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	sale := entities.Transaction{MerchantID: "m-refund", AmountCents: 10_000, FeeCents: 300, Status: "partially_refunded", PaidAt: day1, ExternalRef: "sale-1"}
+	if err := env.db.Create(&sale).Error; err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+	refund := entities.TransactionAdjustment{TransactionID: sale.ID, Type: "refund", ExternalRef: "refund-1", MerchantID: sale.MerchantID, AmountCents: 2_500, IssuedAt: day2}
+	if err := env.db.Omit("Transaction").Create(&refund).Error; err != nil {
+		t.Fatalf("create refund: %v", err)
+	}
+
+	b, _ := json.Marshal(map[string]string{"from": "2024-03-01", "to": "2024-03-03"})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var create map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &create)
+	jobID := create["job_id"].(string)
+
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		grec := httptest.NewRecorder()
+		env.server.ServeHTTP(grec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		_ = json.Unmarshal(grec.Body.Bytes(), &last)
+		if last["status"].(string) == "COMPLETED" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if last["status"].(string) != "COMPLETED" {
+		t.Fatalf("job did not complete, last: %#v", last)
+	}
+
+	var rows []entities.Settlement
+	if err := env.db.Where("merchant_id = ?", "m-refund").Order("date ASC").Find(&rows).Error; err != nil {
+		t.Fatalf("load settlements: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected settlements on sale and refund days, got %#v", rows)
+	}
+	if rows[0].NetCents != 9_700 || rows[0].RefundCents != 0 {
+		t.Fatalf("sale day should not include the refund, got %#v", rows[0])
+	}
+	if rows[1].NetCents != -2_500 || rows[1].RefundCents != 2_500 || rows[1].TxnCount != 0 {
+		t.Fatalf("refund should net into its issue day, got %#v", rows[1])
+	}
+}
+
+func TestSettlementSkipsUnsettledTransactions(t *testing.T) {
+	t.Setenv("SETTLEMENT_VERIFY", "true")
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+
+	day := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	txs := []entities.Transaction{
+		{MerchantID: "m-status", AmountCents: 1_000, FeeCents: 30, Status: "paid", PaidAt: day, ExternalRef: "st-paid"},
+		{MerchantID: "m-status", AmountCents: 5_000, FeeCents: 150, Status: "failed", PaidAt: day.Add(time.Hour), ExternalRef: "st-failed"},
+		{MerchantID: "m-status", AmountCents: 7_000, FeeCents: 210, Status: "authorized", PaidAt: day.Add(2 * time.Hour), ExternalRef: "st-authorized"},
+	}
+	if err := env.db.Create(&txs).Error; err != nil {
+		t.Fatalf("create transactions: %v", err)
+	}
+
+	b, _ := json.Marshal(map[string]string{"from": "2024-06-03", "to": "2024-06-04"})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, req)
+	var create map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &create)
+	jobID, _ := create["job_id"].(string)
+
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		grec := httptest.NewRecorder()
+		env.server.ServeHTTP(grec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		_ = json.Unmarshal(grec.Body.Bytes(), &last)
+		if s, _ := last["status"].(string); s == "COMPLETED" || s == "FAILED" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// The verifying stage recomputes the totals with the same status filter, so the job completes
+	if last["status"] != "COMPLETED" || last["total"] != float64(1) {
+		t.Fatalf("job over one paid transaction expected COMPLETED, got %#v", last)
+	}
+
+	var rows []entities.Settlement
+	if err := env.db.Where("merchant_id = ?", "m-status").Find(&rows).Error; err != nil {
+		t.Fatalf("load settlements: %v", err)
+	}
+	if len(rows) != 1 || rows[0].GrossCents != 1_000 || rows[0].FeeCents != 30 || rows[0].TxnCount != 1 {
+		t.Fatalf("only the paid transaction should settle, got %#v", rows)
+	}
+
+	rec = httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settlements/verify?from=2024-06-03&to=2024-06-04", nil))
+	var report settlementService.VerificationReport
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	if rec.Code != http.StatusOK || !report.OK {
+		t.Fatalf("verify expected no discrepancies, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSettlementVerification(t *testing.T) {
+	t.Setenv("SETTLEMENT_VERIFY", "true")
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+
+	day := time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC)
+	txs := []entities.Transaction{
+		{MerchantID: "m-verify", AmountCents: 1_000, FeeCents: 25, Status: "paid", PaidAt: day, ExternalRef: "v-1"},
+		{MerchantID: "m-verify", AmountCents: 2_000, FeeCents: 50, Status: "paid", PaidAt: day.Add(time.Hour), ExternalRef: "v-2"},
+	}
+	if err := env.db.Create(&txs).Error; err != nil {
+		t.Fatalf("create transactions: %v", err)
+	}
+
+	runJob := func() map[string]any {
+		b, _ := json.Marshal(map[string]string{"from": "2024-04-01", "to": "2024-04-04"})
+		req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		env.server.ServeHTTP(rec, req)
+		var create map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &create)
+		jobID := create["job_id"].(string)
+
+		deadline := time.Now().Add(20 * time.Second)
+		var last map[string]any
+		for time.Now().Before(deadline) {
+			grec := httptest.NewRecorder()
+			env.server.ServeHTTP(grec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+			_ = json.Unmarshal(grec.Body.Bytes(), &last)
+			if s := last["status"].(string); s == "COMPLETED" || s == "FAILED" {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		return last
+	}
+
+	if last := runJob(); last["status"] != "COMPLETED" {
+		t.Fatalf("verified job expected COMPLETED, got %#v", last)
+	}
+
+	// Drift: a settlement row with no transactions behind it
+	stray := entities.Settlement{MerchantID: "m-stray", Date: day, GrossCents: 500, NetCents: 500, TxnCount: 1}
+	if err := env.db.Create(&stray).Error; err != nil {
+		t.Fatalf("create stray settlement: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settlements/verify?from=2024-04-01&to=2024-04-04", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report settlementService.VerificationReport
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	if report.OK || len(report.Discrepancies) != 1 || report.Discrepancies[0].Kind != settlementService.DISCREPANCY_UNEXPECTED {
+		t.Fatalf("expected one unexpected settlement, got %#v", report)
+	}
+
+	if last := runJob(); last["status"] != "FAILED" {
+		t.Fatalf("job over drifted settlements expected FAILED, got %#v", last)
+	}
+}
+
+func TestSettlementHoldsSuspendedMerchants(t *testing.T) {
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+	if err := env.db.Exec("DELETE FROM merchants WHERE id IN ('m-frozen', 'm-open')").Error; err != nil {
+		t.Fatalf("truncate merchants: %v", err)
+	}
+	merchants := []entities.Merchant{
+		{ID: "m-frozen", LegalName: "Frozen", Status: "suspended", SettlementSchedule: "daily", ContactEmail: "f@example.com"},
+		{ID: "m-open", LegalName: "Open", Status: "active", SettlementSchedule: "daily", ContactEmail: "o@example.com"},
+	}
+	if err := env.db.Create(&merchants).Error; err != nil {
+		t.Fatalf("create merchants: %v", err)
+	}
+	t.Cleanup(func() { env.db.Exec("DELETE FROM merchants WHERE id IN ('m-frozen', 'm-open')") })
+
+	day := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	txs := []entities.Transaction{
+		{MerchantID: "m-frozen", AmountCents: 1_000, FeeCents: 10, Status: "paid", PaidAt: day, ExternalRef: "s-frozen"},
+		{MerchantID: "m-open", AmountCents: 2_000, FeeCents: 20, Status: "paid", PaidAt: day, ExternalRef: "s-open"},
+	}
+	if err := env.db.Create(&txs).Error; err != nil {
+		t.Fatalf("create transactions: %v", err)
+	}
+
+	b, _ := json.Marshal(map[string]string{"from": "2024-07-01", "to": "2024-07-02"})
+	req := httptest.NewRequest(http.MethodPost, "/jobs/settlement", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	env.server.ServeHTTP(rec, req)
+	var create map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &create)
+	jobID := create["job_id"].(string)
+
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		grec := httptest.NewRecorder()
+		env.server.ServeHTTP(grec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		_ = json.Unmarshal(grec.Body.Bytes(), &last)
+		if s := last["status"].(string); s == "COMPLETED" || s == "FAILED" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if last["status"] != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %#v", last)
+	}
+
+	var settled []entities.Settlement
+	if err := env.db.Find(&settled).Error; err != nil {
+		t.Fatalf("load settlements: %v", err)
+	}
+	if len(settled) != 1 || settled[0].MerchantID != "m-open" {
+		t.Fatalf("expected only m-open to be settled, got %#v", settled)
+	}
+
+	vrec := httptest.NewRecorder()
+	env.server.ServeHTTP(vrec, httptest.NewRequest(http.MethodGet, "/settlements/verify?from=2024-07-01&to=2024-07-02", nil))
+	var report settlementService.VerificationReport
+	_ = json.Unmarshal(vrec.Body.Bytes(), &report)
+	if !report.OK {
+		t.Fatalf("expected held merchant to be excluded from verification, got %#v", report)
+	}
+}
+
+func TestSettlementAndJobLists(t *testing.T) {
+	env := newTestEnv(t)
+	truncateTables(t, env.db)
+
+	var settlements []entities.Settlement
+	for i, merchant := range []string{"m-a", "m-b"} {
+		for day := 1; day <= 3; day++ {
+			settlements = append(settlements, entities.Settlement{
+				MerchantID: merchant,
+				Date:       time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+				GrossCents: int64(1_000 * (i + day)),
+				NetCents:   int64(900 * (i + day)),
+				TxnCount:   1,
+			})
+		}
+	}
+	if err := env.db.Create(&settlements).Error; err != nil {
+		t.Fatalf("create settlements: %v", err)
+	}
+	jobs := []entities.Job{
+		{ID: "list-1", Type: "settlement", Status: "COMPLETED", FromDate: time.Now(), ToDate: time.Now()},
+		{ID: "list-2", Type: "report", Status: "FAILED", FromDate: time.Now(), ToDate: time.Now()},
+	}
+	if err := env.db.Create(&jobs).Error; err != nil {
+		t.Fatalf("create jobs: %v", err)
+	}
+
+	type page struct {
+		Items      []json.RawMessage `json:"items"`
+		Pagination struct {
+			Count      int64  `json:"count"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+	list := func(path string) (int, page) {
+		rec := httptest.NewRecorder()
+		env.server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var p page
+		_ = json.Unmarshal(rec.Body.Bytes(), &p)
+		return rec.Code, p
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/settlements", 6},
+		{"/settlements?filter[merchant_id]=m-b", 3},
+		{"/settlements?filter[date][gte]=2024-05-02&filter[date][lte]=2024-05-02", 2},
+		{"/settlements?filter[gross_cents][gt]=2000", 3},
+		{"/jobs", 2},
+		{"/jobs?filter[status][in]=FAILED,CANCELLED", 1},
+		{"/jobs?search=report", 1},
+	}
+	for _, tt := range tests {
+		if code, p := list(tt.path); code != http.StatusOK || len(p.Items) != tt.want || p.Pagination.Count != int64(tt.want) {
+			t.Fatalf("%s expected %d items, got %d with %d items", tt.path, tt.want, code, len(p.Items))
+		}
+	}
+
+	// Settlements have no id, so cursors continue on (merchant_id, date)
+	seen := map[string]bool{}
+	path := "/settlements?per_page=4&sort=-net_cents"
+	for pages := 0; ; pages++ {
+		code, p := list(path)
+		if code != http.StatusOK || pages > len(settlements) {
+			t.Fatalf("settlement pages expected 200, got %d", code)
+		}
+		for _, raw := range p.Items {
+			var s entities.Settlement
+			_ = json.Unmarshal(raw, &s)
+			key := s.MerchantID + s.Date.Format(time.DateOnly)
+			if seen[key] {
+				t.Fatalf("settlement %s returned twice", key)
+			}
+			seen[key] = true
+		}
+		if p.Pagination.NextCursor == "" {
+			break
+		}
+		path = "/settlements?per_page=4&sort=-net_cents&cursor=" + p.Pagination.NextCursor
+	}
+	if len(seen) != len(settlements) {
+		t.Fatalf("expected %d settlements across the pages, got %d", len(settlements), len(seen))
+	}
+
+	for _, bad := range []string{
+		"/settlements?sort=fee_cents",
+		"/settlements?search=m-a",
+		"/settlements?filter[date][gte]=May",
+		"/jobs?filter[payload]=x",
+		"/jobs?cursor=nope",
+	} {
+		if code, _ := list(bad); code != http.StatusBadRequest {
+			t.Fatalf("%s expected 400, got %d", bad, code)
+		}
+	}
+}
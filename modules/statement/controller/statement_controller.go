@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	StatementController interface {
+		Summary(ctx *gin.Context)
+		ListDeliveries(ctx *gin.Context)
+		Preview(ctx *gin.Context)
+	}
+
+	statementController struct {
+		service service.StatementService
+	}
+)
+
+func NewStatementController(_ *do.Injector, s service.StatementService) StatementController {
+	return &statementController{service: s}
+}
+
+func (c *statementController) Summary(ctx *gin.Context) {
+	result, err := c.service.Summary(ctx.Request.Context(), ctx.Param("job_id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_STATEMENT_JOB, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_STATEMENT_JOB, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *statementController) ListDeliveries(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var req dto.DeliveryListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.ListDeliveries(ctx.Request.Context(), ctx.Param("job_id"), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_DELIVERY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_DELIVERY, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Preview returns the printable statement itself rather than a JSON envelope.
+func (c *statementController) Preview(ctx *gin.Context) {
+	var req dto.PreviewRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	contentType, doc, err := c.service.Preview(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_RENDER_STATEMENT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+	ctx.Data(http.StatusOK, contentType, doc)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrStatementJobNotFound), errors.Is(err, dto.ErrMerchantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrUnknownDeliveryState), errors.Is(err, dto.ErrInvalidMonth), errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_STATEMENT_JOB = "failed get statement job"
+	MESSAGE_FAILED_GET_LIST_DELIVERY = "failed get list statement deliveries"
+	MESSAGE_FAILED_RENDER_STATEMENT  = "failed render statement"
+	MESSAGE_FAILED_PROSES_REQUEST    = "failed proses request"
+
+	// Success
+	MESSAGE_SUCCESS_GET_STATEMENT_JOB = "success get statement job"
+	MESSAGE_SUCCESS_GET_LIST_DELIVERY = "success get list statement deliveries"
+)
+
+var (
+	ErrStatementJobNotFound = errors.New("statement job not found")
+	ErrUnknownDeliveryState = errors.New("unknown statement delivery status")
+	ErrMerchantNotFound     = errors.New("merchant not found")
+	ErrInvalidMonth         = errors.New("month must be formatted as YYYY-MM and not be in the future")
+)
+
+type (
+	DeliveryListRequest struct {
+		Status string `form:"status"`
+	}
+
+	// PreviewRequest renders one merchant's statement without sending it.
+	PreviewRequest struct {
+		MerchantID string `form:"merchant_id" binding:"required"`
+		Month      string `form:"month" binding:"required"`
+		Format     string `form:"format" binding:"omitempty,oneof=html pdf"`
+	}
+
+	// StatementJobSummary counts deliveries per status for one statement job.
+	StatementJobSummary struct {
+		JobID  string           `json:"job_id"`
+		Month  string           `json:"month"`
+		Status string           `json:"status"`
+		Counts map[string]int64 `json:"counts"`
+		Total  int64            `json:"total"`
+	}
+
+	StatementDay struct {
+		Date            string
+		TxnCount        int64
+		GrossCents      int64
+		FeeCents        int64
+		RefundCents     int64
+		ChargebackCents int64
+		NetCents        int64
+	}
+
+	// MonthlyStatement is the data rendered into the statement template and PDF.
+	MonthlyStatement struct {
+		MerchantID string
+		LegalName  string
+		Month      string
+		From       string
+		To         string
+		Days       []StatementDay
+		Totals     StatementDay
+	}
+)
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StatementRepository interface {
+	// Merchants lists the merchants to send statements to, all of them when ids is empty.
+	Merchants(ctx context.Context, tx *gorm.DB, ids []string) ([]entities.Merchant, error)
+	FindMerchant(ctx context.Context, tx *gorm.DB, id string) (entities.Merchant, error)
+	Settlements(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time) ([]entities.Settlement, error)
+	SaveDelivery(ctx context.Context, tx *gorm.DB, d entities.StatementDelivery) error
+	Deliveries(ctx context.Context, tx *gorm.DB, jobID string) (map[string]entities.StatementDelivery, error)
+	CountByStatus(ctx context.Context, tx *gorm.DB, jobID string) (map[string]int64, error)
+	ListDeliveries(ctx context.Context, tx *gorm.DB, jobID, status string, q query.Request) ([]entities.StatementDelivery, pkgdto.PaginationResponse, error)
+}
+
+// deliverySchema is what a job's deliveries may be filtered and sorted by. A job sends each
+// merchant one statement, so merchant_id is their key; search matches part of it or the email.
+var deliverySchema = query.Schema{
+	Fields: map[string]query.Field{
+		"merchant_id": {Column: "merchant_id", Type: query.String, Ops: query.Text, Sort: true},
+		"email":       {Column: "email", Type: query.String, Ops: query.Text},
+		"status":      {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"attempts":    {Column: "attempts", Type: query.Int, Ops: query.Range, Sort: true},
+		"created_at":  {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	Keys:        []string{"merchant_id"},
+	DefaultSort: "merchant_id",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		pattern := query.Contains(term)
+		return db.Where("merchant_id ILIKE ? OR email ILIKE ?", pattern, pattern)
+	},
+}
+
+type statementRepository struct {
+	db *gorm.DB
+}
+
+func NewStatementRepository(db *gorm.DB) StatementRepository {
+	return &statementRepository{db: db}
+}
+
+func (r *statementRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *statementRepository) Merchants(ctx context.Context, tx *gorm.DB, ids []string) ([]entities.Merchant, error) {
+	db := r.getDB(tx)
+	query := db.WithContext(ctx).Order("id ASC")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var merchants []entities.Merchant
+	if err := query.Find(&merchants).Error; err != nil {
+		return nil, err
+	}
+	return merchants, nil
+}
+
+func (r *statementRepository) FindMerchant(ctx context.Context, tx *gorm.DB, id string) (entities.Merchant, error) {
+	db := r.getDB(tx)
+	var m entities.Merchant
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&m).Error; err != nil {
+		return entities.Merchant{}, err
+	}
+	return m, nil
+}
+
+// Settlements returns the merchant's settlement days in [from, to).
+func (r *statementRepository) Settlements(ctx context.Context, tx *gorm.DB, merchantID string, from, to time.Time) ([]entities.Settlement, error) {
+	db := r.getDB(tx)
+	var rows []entities.Settlement
+	if err := db.WithContext(ctx).
+		Where("merchant_id = ? AND date >= ? AND date < ?", merchantID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SaveDelivery records the latest outcome for the (job, merchant) pair.
+func (r *statementRepository) SaveDelivery(ctx context.Context, tx *gorm.DB, d entities.StatementDelivery) error {
+	db := r.getDB(tx)
+	d.UpdatedAt = time.Now().UTC()
+	return db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "job_id"}, {Name: "merchant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "status", "attempts", "error", "sent_at", "updated_at"}),
+		}).
+		Create(&d).Error
+}
+
+// Deliveries returns the recorded deliveries of a job keyed by merchant, so a retry can skip
+// merchants that already received their statement.
+func (r *statementRepository) Deliveries(ctx context.Context, tx *gorm.DB, jobID string) (map[string]entities.StatementDelivery, error) {
+	db := r.getDB(tx)
+	var rows []entities.StatementDelivery
+	if err := db.WithContext(ctx).Where("job_id = ?", jobID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	deliveries := make(map[string]entities.StatementDelivery, len(rows))
+	for _, d := range rows {
+		deliveries[d.MerchantID] = d
+	}
+	return deliveries, nil
+}
+
+func (r *statementRepository) CountByStatus(ctx context.Context, tx *gorm.DB, jobID string) (map[string]int64, error) {
+	db := r.getDB(tx)
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.WithContext(ctx).
+		Model(&entities.StatementDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("job_id = ?", jobID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *statementRepository) ListDeliveries(ctx context.Context, tx *gorm.DB, jobID, status string, q query.Request) ([]entities.StatementDelivery, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	deliveries := func() *gorm.DB {
+		d := db.WithContext(ctx).Model(&entities.StatementDelivery{}).Where("job_id = ?", jobID)
+		if status != "" {
+			d = d.Where("status = ?", status)
+		}
+		return d
+	}
+	return query.Find[entities.StatementDelivery](deliveries, q, deliverySchema)
+}
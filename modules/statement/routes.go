@@ -0,0 +1,19 @@
+package statement
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/controller"
+)
+
+// RegisterRoutes exposes statement delivery results. Jobs are started with POST /jobs/merchant_statements.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.StatementController](injector)
+
+	r := server.Group("/api/statements")
+	{
+		r.GET("/preview", ctrl.Preview)
+		r.GET("/:job_id", ctrl.Summary)
+		r.GET("/:job_id/deliveries", ctrl.ListDeliveries)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+const (
+	monthLayout = "2006-01"
+	dateLayout  = "2006-01-02"
+
+	statementTemplate = "monthly_statement.html"
+)
+
+// parseMonth returns the first day of month and of the following month. An empty month means
+// the last complete month.
+func parseMonth(month string, now time.Time) (time.Time, time.Time, error) {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month == "" {
+		return current.AddDate(0, -1, 0), current, nil
+	}
+	from, err := time.Parse(monthLayout, month)
+	if err != nil || from.After(current) {
+		return time.Time{}, time.Time{}, dto.ErrInvalidMonth
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+func buildStatement(m entities.Merchant, from, to time.Time, rows []entities.Settlement) dto.MonthlyStatement {
+	st := dto.MonthlyStatement{
+		MerchantID: m.ID,
+		LegalName:  m.LegalName,
+		Month:      from.Format(monthLayout),
+		From:       from.Format(dateLayout),
+		To:         to.AddDate(0, 0, -1).Format(dateLayout),
+		Days:       make([]dto.StatementDay, 0, len(rows)),
+		Totals:     dto.StatementDay{Date: "Total"},
+	}
+	for _, r := range rows {
+		st.Days = append(st.Days, dto.StatementDay{
+			Date:            r.Date.Format(dateLayout),
+			TxnCount:        r.TxnCount,
+			GrossCents:      r.GrossCents,
+			FeeCents:        r.FeeCents,
+			RefundCents:     r.RefundCents,
+			ChargebackCents: r.ChargebackCents,
+			NetCents:        r.NetCents,
+		})
+		st.Totals.TxnCount += r.TxnCount
+		st.Totals.GrossCents += r.GrossCents
+		st.Totals.FeeCents += r.FeeCents
+		st.Totals.RefundCents += r.RefundCents
+		st.Totals.ChargebackCents += r.ChargebackCents
+		st.Totals.NetCents += r.NetCents
+	}
+	return st
+}
+
+func renderHTML(st dto.MonthlyStatement) (string, error) {
+	return utils.RenderEmailTemplate(statementTemplate, st)
+}
+
+// renderPDF lays the statement out as a printable table, mirroring the HTML template.
+func renderPDF(st dto.MonthlyStatement) []byte {
+	row := func(d dto.StatementDay) string {
+		return fmt.Sprintf("%-10s %6d %14s %12s %12s %12s %14s", d.Date, d.TxnCount,
+			utils.FormatCents(d.GrossCents), utils.FormatCents(d.FeeCents), utils.FormatCents(d.RefundCents),
+			utils.FormatCents(d.ChargebackCents), utils.FormatCents(d.NetCents))
+	}
+	rule := strings.Repeat("-", 86)
+	lines := []string{
+		"Settlement Statement " + st.Month,
+		"",
+		st.LegalName,
+		"Merchant ID: " + st.MerchantID,
+		"Period: " + st.From + " to " + st.To,
+		"",
+	}
+	if len(st.Days) == 0 {
+		return utils.TextPDF(append(lines, "No settlements were made for this period."))
+	}
+	lines = append(lines,
+		fmt.Sprintf("%-10s %6s %14s %12s %12s %12s %14s", "Date", "Txns", "Gross", "Fees", "Refunds", "Chargebacks", "Net"),
+		rule,
+	)
+	for _, d := range st.Days {
+		lines = append(lines, row(d))
+	}
+	return utils.TextPDF(append(lines, rule, row(st.Totals)))
+}
+
+func statementFilename(st dto.MonthlyStatement) string {
+	return fmt.Sprintf("statement_%s_%s.pdf", st.MerchantID, st.Month)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+const StatementJobType = "merchant_statements"
+
+// StatementPayload is the job payload. Month (YYYY-MM) defaults to the last complete month and
+// MerchantIDs to every merchant.
+type StatementPayload struct {
+	Month       string   `json:"month"`
+	MerchantIDs []string `json:"merchant_ids"`
+}
+
+// MailFunc sends one mail; utils.SendMail in production.
+type MailFunc func(toEmail, subject, body string, attachments ...utils.Attachment) error
+
+// StatementHandler renders each merchant's monthly statement and mails it with the PDF attached.
+// Sends are retried with backoff; merchants that still fail make the job fail, and retrying the
+// job only resends to them.
+type StatementHandler struct {
+	repo repository.StatementRepository
+	send MailFunc
+
+	attempts int
+	backoff  time.Duration
+}
+
+func NewStatementHandler(r repository.StatementRepository, send MailFunc, attempts int, backoff time.Duration) *StatementHandler {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &StatementHandler{repo: r, send: send, attempts: attempts, backoff: backoff}
+}
+
+func (h *StatementHandler) Type() string {
+	return StatementJobType
+}
+
+func parseStatementPayload(raw []byte) (StatementPayload, time.Time, time.Time, error) {
+	var p StatementPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return StatementPayload{}, time.Time{}, time.Time{}, fmt.Errorf("%w: %v", jobservice.ErrInvalidPayload, err)
+	}
+	from, to, err := parseMonth(p.Month, time.Now().UTC())
+	if err != nil {
+		return StatementPayload{}, time.Time{}, time.Time{}, fmt.Errorf("%w: %v", jobservice.ErrInvalidPayload, err)
+	}
+	p.Month = from.Format(monthLayout)
+	return p, from, to, nil
+}
+
+// Prepare validates the payload and counts the merchants to send to.
+func (h *StatementHandler) Prepare(ctx context.Context, payload json.RawMessage, job *entities.Job) error {
+	p, from, to, err := parseStatementPayload(payload)
+	if err != nil {
+		return err
+	}
+	merchants, err := h.repo.Merchants(ctx, nil, p.MerchantIDs)
+	if err != nil {
+		return err
+	}
+	if len(merchants) == 0 {
+		return fmt.Errorf("%w: no matching merchants", jobservice.ErrInvalidPayload)
+	}
+	// The default month is pinned in the stored payload, so a retry after the month rolls over
+	// still sends the month the job was created for
+	pinned, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	job.Payload = string(pinned)
+	job.Total = int64(len(merchants))
+	job.FromDate = from
+	job.ToDate = to
+	return nil
+}
+
+func (h *StatementHandler) Run(ctx context.Context, job entities.Job, progress *jobservice.Progress) error {
+	p, from, to, err := parseStatementPayload([]byte(job.Payload))
+	if err != nil {
+		return err
+	}
+	merchants, err := h.repo.Merchants(ctx, nil, p.MerchantIDs)
+	if err != nil {
+		return err
+	}
+	if err := progress.SetTotal(ctx, int64(len(merchants))); err != nil {
+		return err
+	}
+	previous, err := h.repo.Deliveries(ctx, nil, job.ID)
+	if err != nil {
+		return err
+	}
+
+	var processed, failed int64
+	for _, m := range merchants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		delivery := previous[m.ID]
+		if delivery.Status != constants.ENUM_STATEMENT_DELIVERY_SENT {
+			delivery, err = h.deliver(ctx, job.ID, m, from, to, delivery)
+			if err != nil {
+				return err
+			}
+			if delivery.Status == constants.ENUM_STATEMENT_DELIVERY_FAILED {
+				failed++
+			}
+		}
+		processed++
+		if err := progress.Update(ctx, processed); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("statement delivery failed for %d of %d merchants", failed, len(merchants))
+	}
+	return nil
+}
+
+// deliver renders and sends one statement, recording the outcome. The returned error is only set
+// when the outcome could not be recorded.
+func (h *StatementHandler) deliver(ctx context.Context, jobID string, m entities.Merchant, from, to time.Time, d entities.StatementDelivery) (entities.StatementDelivery, error) {
+	d.JobID = jobID
+	d.MerchantID = m.ID
+	d.Month = from.Format(monthLayout)
+	d.Email = m.ContactEmail
+
+	sendErr := h.sendStatement(ctx, m, from, to, &d)
+	if sendErr != nil {
+		d.Status = constants.ENUM_STATEMENT_DELIVERY_FAILED
+		d.Error = sendErr.Error()
+	} else {
+		now := time.Now().UTC()
+		d.Status = constants.ENUM_STATEMENT_DELIVERY_SENT
+		d.Error = ""
+		d.SentAt = &now
+	}
+	// Record the outcome even if the job is being cancelled
+	if err := h.repo.SaveDelivery(context.WithoutCancel(ctx), nil, d); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+func (h *StatementHandler) sendStatement(ctx context.Context, m entities.Merchant, from, to time.Time, d *entities.StatementDelivery) error {
+	rows, err := h.repo.Settlements(ctx, nil, m.ID, from, to)
+	if err != nil {
+		return err
+	}
+	st := buildStatement(m, from, to, rows)
+	body, err := renderHTML(st)
+	if err != nil {
+		return err
+	}
+	attachment := utils.Attachment{Filename: statementFilename(st), ContentType: "application/pdf", Data: renderPDF(st)}
+	subject := "Settlement statement " + st.Month
+
+	wait := h.backoff
+	for attempt := 1; ; attempt++ {
+		d.Attempts++
+		err = h.send(m.ContactEmail, subject, body, attachment)
+		if err == nil || attempt >= h.attempts {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
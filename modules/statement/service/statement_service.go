@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobrepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/statement/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+var knownDeliveryStatuses = map[string]struct{}{
+	constants.ENUM_STATEMENT_DELIVERY_PENDING: {},
+	constants.ENUM_STATEMENT_DELIVERY_SENT:    {},
+	constants.ENUM_STATEMENT_DELIVERY_FAILED:  {},
+}
+
+type StatementService interface {
+	Summary(ctx context.Context, jobID string) (dto.StatementJobSummary, error)
+	ListDeliveries(ctx context.Context, jobID string, req dto.DeliveryListRequest, q query.Request) ([]entities.StatementDelivery, pkgdto.PaginationResponse, error)
+	// Preview renders a merchant's statement as it would be sent: HTML, or the PDF attachment
+	// when format is "pdf". It returns the content type and document.
+	Preview(ctx context.Context, req dto.PreviewRequest) (string, []byte, error)
+}
+
+type statementService struct {
+	repo    repository.StatementRepository
+	jobRepo jobrepo.JobRepo
+	db      *gorm.DB
+}
+
+func NewStatementService(repo repository.StatementRepository, jobRepo jobrepo.JobRepo, db *gorm.DB) StatementService {
+	return &statementService{repo: repo, jobRepo: jobRepo, db: db}
+}
+
+func (s *statementService) job(ctx context.Context, jobID string) (entities.Job, error) {
+	job, err := s.jobRepo.Get(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Job{}, dto.ErrStatementJobNotFound
+		}
+		return entities.Job{}, err
+	}
+	if job.Type != StatementJobType {
+		return entities.Job{}, dto.ErrStatementJobNotFound
+	}
+	return job, nil
+}
+
+func (s *statementService) Summary(ctx context.Context, jobID string) (dto.StatementJobSummary, error) {
+	job, err := s.job(ctx, jobID)
+	if err != nil {
+		return dto.StatementJobSummary{}, err
+	}
+	counts, err := s.repo.CountByStatus(ctx, s.db, jobID)
+	if err != nil {
+		return dto.StatementJobSummary{}, err
+	}
+	summary := dto.StatementJobSummary{
+		JobID:  job.ID,
+		Month:  job.FromDate.Format(monthLayout),
+		Status: job.Status,
+		Counts: counts,
+		Total:  job.Total,
+	}
+	// Merchants the job has not reached yet
+	var recorded int64
+	for _, c := range counts {
+		recorded += c
+	}
+	if pending := job.Total - recorded; pending > 0 {
+		counts[constants.ENUM_STATEMENT_DELIVERY_PENDING] += pending
+	}
+	return summary, nil
+}
+
+func (s *statementService) ListDeliveries(ctx context.Context, jobID string, req dto.DeliveryListRequest, q query.Request) ([]entities.StatementDelivery, pkgdto.PaginationResponse, error) {
+	if _, err := s.job(ctx, jobID); err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	if req.Status != "" {
+		if _, ok := knownDeliveryStatuses[req.Status]; !ok {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownDeliveryState
+		}
+	}
+	return s.repo.ListDeliveries(ctx, s.db, jobID, req.Status, q)
+}
+
+func (s *statementService) Preview(ctx context.Context, req dto.PreviewRequest) (string, []byte, error) {
+	from, to, err := parseMonth(req.Month, time.Now().UTC())
+	if err != nil {
+		return "", nil, err
+	}
+	m, err := s.repo.FindMerchant(ctx, s.db, req.MerchantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, dto.ErrMerchantNotFound
+		}
+		return "", nil, err
+	}
+	rows, err := s.repo.Settlements(ctx, s.db, m.ID, from, to)
+	if err != nil {
+		return "", nil, err
+	}
+	st := buildStatement(m, from, to, rows)
+	if req.Format == "pdf" {
+		return "application/pdf", renderPDF(st), nil
+	}
+	body, err := renderHTML(st)
+	if err != nil {
+		return "", nil, err
+	}
+	return "text/html; charset=utf-8", []byte(body), nil
+}
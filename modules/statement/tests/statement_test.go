@@ -0,0 +1,268 @@
+package statement_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	settlement "github.com/xkillx/go-gin-order-settlement/modules/settlement"
+	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	statementModule "github.com/xkillx/go-gin-order-settlement/modules/statement"
+	statementController "github.com/xkillx/go-gin-order-settlement/modules/statement/controller"
+	statementRepo "github.com/xkillx/go-gin-order-settlement/modules/statement/repository"
+	statementService "github.com/xkillx/go-gin-order-settlement/modules/statement/service"
+	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// fakeMailer records sent mails and fails for the merchants' addresses listed in failing.
+type fakeMailer struct {
+	mu      sync.Mutex
+	sent    map[string][]utils.Attachment
+	failing map[string]bool
+}
+
+func (f *fakeMailer) send(to, subject, body string, attachments ...utils.Attachment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[to] {
+		return errors.New("smtp: connection refused")
+	}
+	f.sent[to] = attachments
+	return nil
+}
+
+func (f *fakeMailer) sentTo(to string) []utils.Attachment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sent[to]
+}
+
+func setupTestServer(t *testing.T, mailer *fakeMailer) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if err := db.Exec("DELETE FROM statement_deliveries").Error; err != nil {
+		t.Fatalf("failed to truncate statement_deliveries: %v", err)
+	}
+	for _, table := range []string{"settlements", "merchants"} {
+		column := "merchant_id"
+		if table == "merchants" {
+			column = "id"
+		}
+		if err := db.Exec("DELETE FROM " + table + " WHERE " + column + " LIKE 'st-%'").Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	jobRepository := jobRepo.NewJobRepository(db)
+	stRepository := statementRepo.NewStatementRepository(db)
+	jobManager := settlementService.NewJobManager(transactionRepo.NewTransactionRepository(db), settlementRepo.NewSettlementRepository(db),
+		ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db), jobRepository, db)
+	jobManager.Register(statementService.NewStatementHandler(stRepository, mailer.send, 2, time.Millisecond))
+	svc := statementService.NewStatementService(stRepository, jobRepository, db)
+
+	inj := do.New()
+	do.ProvideNamed(inj, constants.DB, func(i *do.Injector) (*gorm.DB, error) { return db, nil })
+	do.Provide(inj, func(i *do.Injector) (*settlementService.JobManager, error) { return jobManager, nil })
+	do.Provide(inj, func(i *do.Injector) (statementController.StatementController, error) {
+		return statementController.NewStatementController(i, svc), nil
+	})
+
+	engine := gin.New()
+	settlement.RegisterRoutes(engine, inj)
+	statementModule.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+func seedMerchantMonth(t *testing.T, db *gorm.DB, id string) {
+	t.Helper()
+	m := entities.Merchant{ID: id, LegalName: "Merchant " + id, Status: "active", SettlementSchedule: "monthly", ContactEmail: id + "@example.com"}
+	if err := db.Create(&m).Error; err != nil {
+		t.Fatalf("seed merchant: %v", err)
+	}
+	rows := []entities.Settlement{
+		{MerchantID: id, Date: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), GrossCents: 10_000, FeeCents: 300, NetCents: 9_700, TxnCount: 2},
+		{MerchantID: id, Date: time.Date(2024, 4, 9, 0, 0, 0, 0, time.UTC), GrossCents: 5_000, FeeCents: 150, RefundCents: 1_000, NetCents: 3_850, TxnCount: 1},
+		// Outside the statement month
+		{MerchantID: id, Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), GrossCents: 1, NetCents: 1, TxnCount: 1},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("seed settlements: %v", err)
+	}
+}
+
+func startJob(t *testing.T, server *gin.Engine, path string, body any) string {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("%s expected 202, got %d: %s", path, rec.Code, rec.Body.String())
+	}
+	var created map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	return created["job_id"].(string)
+}
+
+func waitForJob(t *testing.T, server *gin.Engine, jobID string) string {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		var job map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+		if s, _ := job["status"].(string); s == "COMPLETED" || s == "FAILED" || s == "CANCELLED" {
+			return s
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", jobID)
+	return ""
+}
+
+func summary(t *testing.T, server *gin.Engine, jobID string) map[string]int64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/statements/"+jobID, nil))
+	var resp struct {
+		Data struct {
+			Counts map[string]int64 `json:"counts"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Data.Counts
+}
+
+func TestStatementJobRetriesOnlyFailedMerchants(t *testing.T) {
+	mailer := &fakeMailer{sent: map[string][]utils.Attachment{}, failing: map[string]bool{"st-b@example.com": true}}
+	env, db := setupTestServer(t, mailer)
+	seedMerchantMonth(t, db, "st-a")
+	seedMerchantMonth(t, db, "st-b")
+
+	jobID := startJob(t, env, "/jobs/merchant_statements", map[string]any{"month": "2024-04", "merchant_ids": []string{"st-a", "st-b"}})
+	if status := waitForJob(t, env, jobID); status != "FAILED" {
+		t.Fatalf("expected FAILED while one merchant cannot be reached, got %s", status)
+	}
+	if counts := summary(t, env, jobID); counts["sent"] != 1 || counts["failed"] != 1 {
+		t.Fatalf("expected one sent and one failed delivery, got %#v", counts)
+	}
+	attachments := mailer.sentTo("st-a@example.com")
+	if len(attachments) != 1 || !bytes.HasPrefix(attachments[0].Data, []byte("%PDF-")) {
+		t.Fatalf("expected a PDF attachment, got %#v", attachments)
+	}
+	if !bytes.Contains(attachments[0].Data, []byte("97.00")) || bytes.Contains(attachments[0].Data, []byte("2024-05-01")) {
+		t.Fatalf("statement should only cover April")
+	}
+
+	// The retry must not mail st-a a second time
+	mailer.mu.Lock()
+	mailer.failing = map[string]bool{}
+	delete(mailer.sent, "st-a@example.com")
+	mailer.mu.Unlock()
+	retry := httptest.NewRecorder()
+	env.ServeHTTP(retry, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/retry", nil))
+	if retry.Code != http.StatusAccepted {
+		t.Fatalf("retry expected 202, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if status := waitForJob(t, env, jobID); status != "COMPLETED" {
+		t.Fatalf("expected COMPLETED after retry, got %s", status)
+	}
+	if mailer.sentTo("st-a@example.com") != nil {
+		t.Fatalf("retry resent the statement to a merchant that already received it")
+	}
+	if mailer.sentTo("st-b@example.com") == nil {
+		t.Fatalf("retry did not send the failed statement")
+	}
+
+	rec := httptest.NewRecorder()
+	env.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/statements/"+jobID+"/deliveries?status=sent", nil))
+	var list struct {
+		Data struct {
+			Items []entities.StatementDelivery `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data.Items) != 2 {
+		t.Fatalf("expected both deliveries sent, got %s", rec.Body.String())
+	}
+	for _, d := range list.Data.Items {
+		if d.MerchantID == "st-b" && d.Attempts != 3 {
+			t.Fatalf("st-b should record 2 failed attempts and 1 success, got %d", d.Attempts)
+		}
+	}
+}
+
+func TestStatementPreviewRendersTemplate(t *testing.T) {
+	mailer := &fakeMailer{sent: map[string][]utils.Attachment{}, failing: map[string]bool{}}
+	server, db := setupTestServer(t, mailer)
+	seedMerchantMonth(t, db, "st-c")
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/statements/preview?merchant_id=st-c&month=2024-04", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("preview expected 200 html, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Merchant st-c") || !strings.Contains(body, "135.50") {
+		t.Fatalf("preview should show the merchant and the month's net total, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/statements/preview?merchant_id=st-c&month=2999-01", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("future month expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatementJobPinsDefaultMonth(t *testing.T) {
+	mailer := &fakeMailer{sent: map[string][]utils.Attachment{}, failing: map[string]bool{}}
+	env, db := setupTestServer(t, mailer)
+	seedMerchantMonth(t, db, "st-c")
+
+	now := time.Now().UTC()
+	want := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format("2006-01")
+	jobID := startJob(t, env, "/jobs/merchant_statements", map[string]any{"merchant_ids": []string{"st-c"}})
+	if status := waitForJob(t, env, jobID); status != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %s", status)
+	}
+
+	// The stored payload names the month, so a retry cannot drift to a later one
+	var job entities.Job
+	if err := db.Where("id = ?", jobID).Take(&job).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	var payload statementService.StatementPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil || payload.Month != want {
+		t.Fatalf("expected the payload pinned to %s, got %q", want, job.Payload)
+	}
+	var delivery entities.StatementDelivery
+	if err := db.Where("job_id = ? AND merchant_id = ?", jobID, "st-c").Take(&delivery).Error; err != nil || delivery.Month != want {
+		t.Fatalf("expected a delivery for %s, got %+v, %v", want, delivery, err)
+	}
+}
@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	TransactionController interface {
+		Create(ctx *gin.Context)
+		GetByID(ctx *gin.Context)
+		List(ctx *gin.Context)
+		UpdateStatus(ctx *gin.Context)
+		Refund(ctx *gin.Context)
+		Chargeback(ctx *gin.Context)
+		ListAdjustments(ctx *gin.Context)
+	}
+
+	transactionController struct {
+		service  service.TransactionService
+		validate *validation.TransactionValidation
+	}
+)
+
+func NewTransactionController(_ *do.Injector, s service.TransactionService) TransactionController {
+	return &transactionController{
+		service:  s,
+		validate: validation.NewTransactionValidation(),
+	}
+}
+
+// Create accepts either a single transaction object or a JSON array for batch ingestion.
+func (c *transactionController) Create(ctx *gin.Context) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		c.createBatch(ctx, body)
+		return
+	}
+
+	var req dto.TransactionCreateRequest
+	if err := binding.JSON.BindBody(body, &req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validate.ValidateTransactionCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_TRANSACTION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, created, err := c.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, dto.ErrExternalRefConflict) {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if !created {
+		// Idempotent replay of an earlier ingestion with the same external_ref
+		res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REPLAY_TRANSACTION, result)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TRANSACTION, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *transactionController) createBatch(ctx *gin.Context, body []byte) {
+	// Decode without binding validation so one bad item does not reject the whole batch
+	var reqs []dto.TransactionCreateRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+	if err := c.validate.ValidateTransactionBatch(reqs); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_TRANSACTION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	// Invalid items are reported individually; the valid remainder is still ingested
+	results := make([]dto.TransactionIngestResult, len(reqs))
+	valid := make([]dto.TransactionCreateRequest, 0, len(reqs))
+	validIdx := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		if err := c.validate.ValidateTransactionCreateRequest(req); err != nil {
+			results[i] = dto.TransactionIngestResult{
+				Index:       i,
+				ExternalRef: req.ExternalRef,
+				Result:      dto.INGEST_RESULT_INVALID,
+				Error:       err.Error(),
+			}
+			continue
+		}
+		valid = append(valid, req)
+		validIdx = append(validIdx, i)
+	}
+
+	if len(valid) > 0 {
+		ingested, err := c.service.CreateBatch(ctx.Request.Context(), valid)
+		if err != nil {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
+			ctx.JSON(http.StatusBadRequest, res)
+			return
+		}
+		for j, r := range ingested {
+			r.Index = validIdx[j]
+			results[validIdx[j]] = r
+		}
+	}
+
+	summary := make(map[string]int)
+	for _, r := range results {
+		summary[r.Result]++
+	}
+	payload := gin.H{
+		"items":   results,
+		"summary": summary,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TRANSACTION, payload)
+	ctx.JSON(http.StatusMultiStatus, res)
+}
+
+func (c *transactionController) GetByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+	result, err := c.service.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRANSACTION, err.Error(), nil)
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TRANSACTION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *transactionController) List(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	var filter dto.TransactionListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.List(ctx.Request.Context(), filter, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_TRANSACTION, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_TRANSACTION, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *transactionController) UpdateStatus(ctx *gin.Context) {
+	var req dto.TransactionStatusUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+	if err := c.validate.ValidateStatusUpdateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_TRANSACTION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.UpdateStatus(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_STATUS, err.Error(), nil)
+		ctx.JSON(lifecycleErrorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_STATUS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *transactionController) Refund(ctx *gin.Context) {
+	c.createAdjustment(ctx, c.service.Refund, dto.MESSAGE_FAILED_CREATE_REFUND, dto.MESSAGE_SUCCESS_CREATE_REFUND)
+}
+
+func (c *transactionController) Chargeback(ctx *gin.Context) {
+	c.createAdjustment(ctx, c.service.Chargeback, dto.MESSAGE_FAILED_CREATE_CHARGEBACK, dto.MESSAGE_SUCCESS_CREATE_CHARGEBACK)
+}
+
+type adjustFunc func(ctx context.Context, id string, req dto.AdjustmentCreateRequest) (dto.AdjustmentResult, bool, error)
+
+func (c *transactionController) createAdjustment(ctx *gin.Context, adjust adjustFunc, failedMsg, successMsg string) {
+	var req dto.AdjustmentCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+	if err := c.validate.ValidateAdjustmentCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_TRANSACTION, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, created, err := adjust(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(failedMsg, err.Error(), nil)
+		ctx.JSON(lifecycleErrorStatus(err), res)
+		return
+	}
+	if !created {
+		res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REPLAY_ADJUSTMENT, result)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	res := utils.BuildResponseSuccess(successMsg, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *transactionController) ListAdjustments(ctx *gin.Context) {
+	items, err := c.service.ListAdjustments(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ADJUSTMENTS, err.Error(), nil)
+		ctx.JSON(lifecycleErrorStatus(err), res)
+		return
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ADJUSTMENTS, items)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// lifecycleErrorStatus maps state machine and adjustment errors to HTTP status codes.
+func lifecycleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrInvalidTransition),
+		errors.Is(err, dto.ErrNotRefundable),
+		errors.Is(err, dto.ErrAdjustmentRefConflict):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrStatusNotManual),
+		errors.Is(err, dto.ErrAdjustmentExceeds),
+		errors.Is(err, dto.ErrIssuedBeforePaid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
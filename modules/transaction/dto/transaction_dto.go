@@ -0,0 +1,130 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY     = "failed get data from body"
+	MESSAGE_FAILED_CREATE_TRANSACTION     = "failed create transaction"
+	MESSAGE_FAILED_GET_TRANSACTION        = "failed get transaction"
+	MESSAGE_FAILED_GET_LIST_TRANSACTION   = "failed get list transaction"
+	MESSAGE_FAILED_PROSES_REQUEST         = "failed proses request"
+	MESSAGE_FAILED_VALIDATION_TRANSACTION = "Validation failed"
+	MESSAGE_FAILED_UPDATE_STATUS          = "failed update transaction status"
+	MESSAGE_FAILED_CREATE_REFUND          = "failed create refund"
+	MESSAGE_FAILED_CREATE_CHARGEBACK      = "failed create chargeback"
+	MESSAGE_FAILED_GET_ADJUSTMENTS        = "failed get transaction adjustments"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_TRANSACTION   = "success create transaction"
+	MESSAGE_SUCCESS_REPLAY_TRANSACTION   = "transaction already exists"
+	MESSAGE_SUCCESS_GET_TRANSACTION      = "success get transaction"
+	MESSAGE_SUCCESS_GET_LIST_TRANSACTION = "success get list transaction"
+	MESSAGE_SUCCESS_UPDATE_STATUS        = "success update transaction status"
+	MESSAGE_SUCCESS_CREATE_REFUND        = "success create refund"
+	MESSAGE_SUCCESS_CREATE_CHARGEBACK    = "success create chargeback"
+	MESSAGE_SUCCESS_REPLAY_ADJUSTMENT    = "adjustment already exists"
+	MESSAGE_SUCCESS_GET_ADJUSTMENTS      = "success get transaction adjustments"
+
+	// MaxBatchSize caps a single batch ingestion request.
+	MaxBatchSize = 1000
+
+	// Per-item ingestion outcomes
+	INGEST_RESULT_CREATED   = "created"
+	INGEST_RESULT_DUPLICATE = "duplicate"
+	INGEST_RESULT_CONFLICT  = "conflict"
+	INGEST_RESULT_INVALID   = "invalid"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrExternalRefConflict = errors.New("external_ref already used by a different transaction")
+	ErrAmountNotPositive   = errors.New("amount_cents must be positive")
+	ErrFeeNegative         = errors.New("fee_cents must not be negative")
+	ErrFeeExceedsAmount    = errors.New("fee_cents must not exceed amount_cents")
+	ErrUnknownStatus       = errors.New("unknown transaction status")
+	ErrPaidAtInFuture      = errors.New("paid_at must not be in the future")
+	ErrEmptyBatch          = errors.New("batch must contain at least one transaction")
+	ErrBatchTooLarge       = errors.New("batch exceeds maximum size")
+	ErrDuplicateRefInBatch = errors.New("external_ref appears more than once in batch")
+	ErrUnknownMerchant     = errors.New("merchant_id does not reference a known merchant")
+
+	ErrInvalidTransition     = errors.New("transaction status transition not allowed")
+	ErrStatusNotManual       = errors.New("refund and dispute statuses are set by creating refunds or chargebacks")
+	ErrNotRefundable         = errors.New("only paid or partially refunded transactions can be refunded or charged back")
+	ErrAdjustmentExceeds     = errors.New("amount_cents exceeds the transaction's remaining amount")
+	ErrAdjustmentRefConflict = errors.New("external_ref already used by a different refund or chargeback")
+	ErrIssuedBeforePaid      = errors.New("issued_at must not be before the transaction's paid_at")
+	ErrIssuedAtInFuture      = errors.New("issued_at must not be in the future")
+)
+
+type (
+	TransactionCreateRequest struct {
+		ExternalRef string    `json:"external_ref" form:"external_ref" binding:"required,min=1,max=255"`
+		MerchantID  string    `json:"merchant_id" form:"merchant_id" binding:"required,min=1"`
+		AmountCents int64     `json:"amount_cents" form:"amount_cents" binding:"required"`
+		FeeCents    int64     `json:"fee_cents" form:"fee_cents"`
+		Status      string    `json:"status" form:"status" binding:"required"`
+		PaidAt      time.Time `json:"paid_at" form:"paid_at" binding:"required"`
+	}
+
+	TransactionListRequest struct {
+		MerchantID string    `form:"merchant_id"`
+		Status     string    `form:"status"`
+		From       time.Time `form:"from" time_format:"2006-01-02"`
+		To         time.Time `form:"to" time_format:"2006-01-02"`
+	}
+
+	TransactionResponse struct {
+		ID          string    `json:"id"`
+		ExternalRef string    `json:"external_ref"`
+		MerchantID  string    `json:"merchant_id"`
+		AmountCents int64     `json:"amount_cents"`
+		FeeCents    int64     `json:"fee_cents"`
+		Status      string    `json:"status"`
+		PaidAt      time.Time `json:"paid_at"`
+	}
+
+	TransactionStatusUpdateRequest struct {
+		Status string `json:"status" form:"status" binding:"required"`
+	}
+
+	// AdjustmentCreateRequest creates a refund or chargeback. AmountCents defaults to the
+	// remaining amount and IssuedAt to now.
+	AdjustmentCreateRequest struct {
+		ExternalRef string     `json:"external_ref" form:"external_ref" binding:"required,min=1,max=255"`
+		AmountCents int64      `json:"amount_cents" form:"amount_cents" binding:"min=0"`
+		Reason      string     `json:"reason" form:"reason" binding:"max=1000"`
+		IssuedAt    *time.Time `json:"issued_at" form:"issued_at"`
+	}
+
+	AdjustmentResponse struct {
+		ID            string    `json:"id"`
+		TransactionID string    `json:"transaction_id"`
+		Type          string    `json:"type"`
+		ExternalRef   string    `json:"external_ref"`
+		MerchantID    string    `json:"merchant_id"`
+		AmountCents   int64     `json:"amount_cents"`
+		Reason        string    `json:"reason,omitempty"`
+		IssuedAt      time.Time `json:"issued_at"`
+	}
+
+	// AdjustmentResult is returned by the refund and chargeback endpoints together with the
+	// transaction in its new state.
+	AdjustmentResult struct {
+		Adjustment  AdjustmentResponse  `json:"adjustment"`
+		Transaction TransactionResponse `json:"transaction"`
+	}
+
+	// TransactionIngestResult reports the outcome for one item of a batch.
+	TransactionIngestResult struct {
+		Index       int                  `json:"index"`
+		ExternalRef string               `json:"external_ref"`
+		Result      string               `json:"result"`
+		Error       string               `json:"error,omitempty"`
+		Transaction *TransactionResponse `json:"transaction,omitempty"`
+	}
+)
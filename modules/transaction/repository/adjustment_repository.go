@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindByIDForUpdate loads a transaction and locks its row until tx ends, serializing
+// concurrent refunds and chargebacks against the same transaction.
+func (r *transactionRepository) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Transaction, error) {
+	db := r.getDB(tx)
+	var t entities.Transaction
+	if err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Take(&t).Error; err != nil {
+		return entities.Transaction{}, err
+	}
+	return t, nil
+}
+
+func (r *transactionRepository) UpdateStatus(ctx context.Context, tx *gorm.DB, id uuid.UUID, status string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).
+		Model(&entities.Transaction{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": status, "updated_at": time.Now().UTC()}).Error
+}
+
+func (r *transactionRepository) CreateAdjustment(ctx context.Context, tx *gorm.DB, a entities.TransactionAdjustment) (entities.TransactionAdjustment, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Omit("Transaction").Create(&a).Error; err != nil {
+		return entities.TransactionAdjustment{}, err
+	}
+	return a, nil
+}
+
+func (r *transactionRepository) FindAdjustmentByExternalRef(ctx context.Context, tx *gorm.DB, ref string) (entities.TransactionAdjustment, error) {
+	db := r.getDB(tx)
+	var a entities.TransactionAdjustment
+	if err := db.WithContext(ctx).Where("external_ref = ?", ref).Take(&a).Error; err != nil {
+		return entities.TransactionAdjustment{}, err
+	}
+	return a, nil
+}
+
+func (r *transactionRepository) ListAdjustments(ctx context.Context, tx *gorm.DB, transactionID string) ([]entities.TransactionAdjustment, error) {
+	db := r.getDB(tx)
+	var items []entities.TransactionAdjustment
+	if err := db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("issued_at ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
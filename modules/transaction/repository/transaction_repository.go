@@ -2,28 +2,173 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// TransactionRepo is the narrow streaming contract settlement jobs depend on. Both methods only
+// see settleable transactions (entities.SettleableTransactions).
 type TransactionRepo interface {
 	Count(ctx context.Context, from, to time.Time) (int64, error)
 	StreamByDateRange(ctx context.Context, from, to time.Time, batchSize int, out chan<- []entities.Transaction) error
 }
+
+// TransactionRepository adds the reads and writes used by the ingestion API.
+type TransactionRepository interface {
+	TransactionRepo
+	InsertIgnoreDuplicates(ctx context.Context, tx *gorm.DB, txs []entities.Transaction) error
+	FindByExternalRefs(ctx context.Context, tx *gorm.DB, refs []string) ([]entities.Transaction, error)
+	FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Transaction, error)
+	List(ctx context.Context, tx *gorm.DB, filter TransactionFilter, q query.Request) ([]entities.Transaction, pkgdto.PaginationResponse, error)
+	CopyIgnoreDuplicates(ctx context.Context, txs []entities.Transaction) (map[string]struct{}, error)
+	KnownMerchantIDs(ctx context.Context, tx *gorm.DB, merchantIDs []string) (map[string]struct{}, error)
+
+	// Lifecycle: status changes, refunds and chargebacks
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id string) (entities.Transaction, error)
+	UpdateStatus(ctx context.Context, tx *gorm.DB, id uuid.UUID, status string) error
+	CreateAdjustment(ctx context.Context, tx *gorm.DB, a entities.TransactionAdjustment) (entities.TransactionAdjustment, error)
+	FindAdjustmentByExternalRef(ctx context.Context, tx *gorm.DB, ref string) (entities.TransactionAdjustment, error)
+	ListAdjustments(ctx context.Context, tx *gorm.DB, transactionID string) ([]entities.TransactionAdjustment, error)
+}
+
+// TransactionFilter narrows List; zero values are ignored. PaidFrom is inclusive, PaidTo exclusive.
+type TransactionFilter struct {
+	MerchantID string
+	Status     string
+	PaidFrom   time.Time
+	PaidTo     time.Time
+}
+
+// transactionSchema is what the transaction list may be filtered and sorted by; search matches
+// part of the external reference.
+var transactionSchema = query.Schema{
+	Fields: map[string]query.Field{
+		"id":           {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+		"external_ref": {Column: "external_ref", Type: query.String, Ops: query.Text},
+		"merchant_id":  {Column: "merchant_id", Type: query.String, Ops: query.Text},
+		"amount_cents": {Column: "amount_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"fee_cents":    {Column: "fee_cents", Type: query.Int, Ops: query.Range, Sort: true},
+		"status":       {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+		"paid_at":      {Column: "paid_at", Type: query.Time, Ops: query.Range, Sort: true},
+		"created_at":   {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+	},
+	DefaultSort: "-paid_at",
+	Search: func(db *gorm.DB, term string) *gorm.DB {
+		return db.Where("external_ref ILIKE ?", query.Contains(term))
+	},
+}
+
 type transactionRepository struct {
 	db *gorm.DB
 }
 
-func NewTransactionRepository(db *gorm.DB) TransactionRepo {
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
 	return &transactionRepository{db: db}
 }
 
+func (r *transactionRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+// InsertIgnoreDuplicates inserts transactions, silently skipping rows whose external_ref already exists.
+// Callers re-read by external ref to learn which rows were actually stored. Only non-empty refs are
+// unique, so the conflict target repeats the partial index's predicate.
+func (r *transactionRepository) InsertIgnoreDuplicates(ctx context.Context, tx *gorm.DB, txs []entities.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	db := r.getDB(tx)
+	return db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "external_ref"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "external_ref <> ''"}}},
+			DoNothing:   true,
+		}).
+		Create(&txs).Error
+}
+
+// KnownMerchantIDs returns the subset of merchantIDs that exist in the merchants table.
+func (r *transactionRepository) KnownMerchantIDs(ctx context.Context, tx *gorm.DB, merchantIDs []string) (map[string]struct{}, error) {
+	known := make(map[string]struct{}, len(merchantIDs))
+	if len(merchantIDs) == 0 {
+		return known, nil
+	}
+	db := r.getDB(tx)
+	var ids []string
+	if err := db.WithContext(ctx).
+		Model(&entities.Merchant{}).
+		Where("id IN ?", merchantIDs).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		known[id] = struct{}{}
+	}
+	return known, nil
+}
+
+func (r *transactionRepository) FindByExternalRefs(ctx context.Context, tx *gorm.DB, refs []string) ([]entities.Transaction, error) {
+	db := r.getDB(tx)
+	var items []entities.Transaction
+	if len(refs) == 0 {
+		return items, nil
+	}
+	if err := db.WithContext(ctx).Where("external_ref IN ?", refs).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *transactionRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Transaction, error) {
+	db := r.getDB(tx)
+	var t entities.Transaction
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&t).Error; err != nil {
+		return entities.Transaction{}, err
+	}
+	return t, nil
+}
+
+func (r *transactionRepository) List(ctx context.Context, tx *gorm.DB, filter TransactionFilter, q query.Request) ([]entities.Transaction, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	return query.Find[entities.Transaction](func() *gorm.DB {
+		return applyTransactionFilter(db.WithContext(ctx).Model(&entities.Transaction{}), filter)
+	}, q, transactionSchema)
+}
+
+func applyTransactionFilter(db *gorm.DB, filter TransactionFilter) *gorm.DB {
+	if filter.MerchantID != "" {
+		db = db.Where("merchant_id = ?", filter.MerchantID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if !filter.PaidFrom.IsZero() {
+		db = db.Where("paid_at >= ?", filter.PaidFrom)
+	}
+	if !filter.PaidTo.IsZero() {
+		db = db.Where("paid_at < ?", filter.PaidTo)
+	}
+	return db
+}
+
 func (r *transactionRepository) Count(ctx context.Context, from, to time.Time) (int64, error) {
 	var cnt int64
 	err := r.db.WithContext(ctx).
 		Model(&entities.Transaction{}).
+		Scopes(entities.SettleableTransactions).
 		Where("paid_at >= ? AND paid_at < ?", from, to).
 		Count(&cnt).Error
 	if err != nil {
@@ -53,6 +198,7 @@ func (r *transactionRepository) StreamByDateRange(
 
 		var batch []entities.Transaction
 		err := r.db.WithContext(ctx).
+			Scopes(entities.SettleableTransactions).
 			Where("paid_at >= ? AND paid_at < ?", from, to).
 			Order("paid_at ASC, id ASC").
 			Limit(batchSize).
@@ -78,3 +224,83 @@ func (r *transactionRepository) StreamByDateRange(
 		}
 	}
 }
+
+// copyColumns are the transaction columns written by CopyIgnoreDuplicates.
+var copyColumns = []string{"id", "external_ref", "merchant_id", "amount_cents", "fee_cents", "status", "paid_at", "created_at", "updated_at"}
+
+// CopyIgnoreDuplicates bulk loads transactions with pgx COPY, like BulkTransactionSeeder. Rows are
+// copied into a temporary staging table first, which has no unique indexes, and then moved over
+// with a single INSERT ... SELECT ... ON CONFLICT DO NOTHING: a row whose external_ref or id is
+// already taken, in the table or earlier in the same batch, is skipped instead of aborting the
+// whole batch. It returns the external refs that were actually inserted. Rows without a ref all
+// map to "", so the result cannot tell which of those were stored; callers that need to know must
+// give every row a ref.
+func (r *transactionRepository) CopyIgnoreDuplicates(ctx context.Context, txs []entities.Transaction) (map[string]struct{}, error) {
+	inserted := make(map[string]struct{}, len(txs))
+	if len(txs) == 0 {
+		return inserted, nil
+	}
+
+	now := time.Now().UTC()
+	rows := make([][]any, 0, len(txs))
+	for _, t := range txs {
+		rows = append(rows, []any{t.ID, t.ExternalRef, t.MerchantID, t.AmountCents, t.FeeCents, t.Status, t.PaidAt, now, now})
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("bulk copy requires a pgx connection, got %T", driverConn)
+		}
+		return copyViaStaging(ctx, stdConn.Conn(), rows, inserted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+func copyViaStaging(ctx context.Context, conn *pgx.Conn, rows [][]any, inserted map[string]struct{}) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `CREATE TEMP TABLE transactions_staging (LIKE transactions INCLUDING DEFAULTS) ON COMMIT DROP`); err != nil {
+		return fmt.Errorf("create staging table: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"transactions_staging"}, copyColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy: %w", err)
+	}
+
+	cols := "id, external_ref, merchant_id, amount_cents, fee_cents, status, paid_at, created_at, updated_at"
+	res, err := tx.Query(ctx, `INSERT INTO transactions (`+cols+`) SELECT `+cols+` FROM transactions_staging
+		ON CONFLICT DO NOTHING RETURNING external_ref`)
+	if err != nil {
+		return fmt.Errorf("move staged rows: %w", err)
+	}
+	for res.Next() {
+		var ref sql.NullString
+		if err := res.Scan(&ref); err != nil {
+			res.Close()
+			return err
+		}
+		inserted[ref.String] = struct{}{}
+	}
+	res.Close()
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,22 @@
+package transaction
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/controller"
+)
+
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.TransactionController](injector)
+
+	r := server.Group("/api/transactions")
+	{
+		r.GET("", ctrl.List)
+		r.GET("/:id", ctrl.GetByID)
+		r.POST("", ctrl.Create)
+		r.PATCH("/:id/status", ctrl.UpdateStatus)
+		r.GET("/:id/adjustments", ctrl.ListAdjustments)
+		r.POST("/:id/refunds", ctrl.Refund)
+		r.POST("/:id/chargebacks", ctrl.Chargeback)
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+func (s *transactionService) UpdateStatus(ctx context.Context, id string, req dto.TransactionStatusUpdateRequest) (dto.TransactionResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.TransactionResponse{}, dto.ErrTransactionNotFound
+	}
+	var updated entities.Transaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		t, err := s.repo.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if t.Status == req.Status {
+			updated = t
+			return nil
+		}
+		if _, ok := manualStatuses[req.Status]; !ok {
+			return dto.ErrStatusNotManual
+		}
+		if !canTransition(t.Status, req.Status) {
+			return fmt.Errorf("%w: %s -> %s", dto.ErrInvalidTransition, t.Status, req.Status)
+		}
+		if err := s.repo.UpdateStatus(ctx, tx, t.ID, req.Status); err != nil {
+			return err
+		}
+		t.Status = req.Status
+		updated = t
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.TransactionResponse{}, dto.ErrTransactionNotFound
+		}
+		return dto.TransactionResponse{}, err
+	}
+	return toResponse(updated), nil
+}
+
+func (s *transactionService) Refund(ctx context.Context, id string, req dto.AdjustmentCreateRequest) (dto.AdjustmentResult, bool, error) {
+	return s.adjust(ctx, id, constants.ENUM_ADJUSTMENT_TYPE_REFUND, req)
+}
+
+func (s *transactionService) Chargeback(ctx context.Context, id string, req dto.AdjustmentCreateRequest) (dto.AdjustmentResult, bool, error) {
+	return s.adjust(ctx, id, constants.ENUM_ADJUSTMENT_TYPE_CHARGEBACK, req)
+}
+
+// adjust records a refund or chargeback while holding the transaction row lock, so the
+// remaining amount and the resulting status are computed from a consistent view.
+func (s *transactionService) adjust(ctx context.Context, id, kind string, req dto.AdjustmentCreateRequest) (dto.AdjustmentResult, bool, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return dto.AdjustmentResult{}, false, dto.ErrTransactionNotFound
+	}
+	var (
+		result  dto.AdjustmentResult
+		created bool
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		t, err := s.repo.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Replays of the same processor reference return the recorded adjustment
+		existing, err := s.repo.FindAdjustmentByExternalRef(ctx, tx, req.ExternalRef)
+		switch {
+		case err == nil:
+			if existing.TransactionID != t.ID || existing.Type != kind ||
+				(req.AmountCents != 0 && req.AmountCents != existing.AmountCents) {
+				return dto.ErrAdjustmentRefConflict
+			}
+			result = dto.AdjustmentResult{Adjustment: toAdjustmentResponse(existing), Transaction: toResponse(t)}
+			return nil
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if !refundable(t.Status) {
+			return fmt.Errorf("%w (status %s)", dto.ErrNotRefundable, t.Status)
+		}
+
+		previous, err := s.repo.ListAdjustments(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		remaining := t.AmountCents
+		for _, a := range previous {
+			remaining -= a.AmountCents
+		}
+		amount := req.AmountCents
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount <= 0 || amount > remaining {
+			return fmt.Errorf("%w (%d remaining)", dto.ErrAdjustmentExceeds, remaining)
+		}
+
+		issuedAt := time.Now().UTC()
+		if req.IssuedAt != nil {
+			issuedAt = req.IssuedAt.UTC()
+		}
+		issuedAt = issuedAt.Truncate(time.Microsecond)
+		if issuedAt.Before(t.PaidAt) {
+			return dto.ErrIssuedBeforePaid
+		}
+
+		next := constants.ENUM_TRANSACTION_STATUS_DISPUTED
+		if kind == constants.ENUM_ADJUSTMENT_TYPE_REFUND {
+			next = constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED
+			if amount == remaining {
+				next = constants.ENUM_TRANSACTION_STATUS_REFUNDED
+			}
+		}
+		if !canTransition(t.Status, next) {
+			return fmt.Errorf("%w: %s -> %s", dto.ErrInvalidTransition, t.Status, next)
+		}
+
+		adj, err := s.repo.CreateAdjustment(ctx, tx, entities.TransactionAdjustment{
+			TransactionID: t.ID,
+			Type:          kind,
+			ExternalRef:   req.ExternalRef,
+			MerchantID:    t.MerchantID,
+			AmountCents:   amount,
+			Reason:        req.Reason,
+			IssuedAt:      issuedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdateStatus(ctx, tx, t.ID, next); err != nil {
+			return err
+		}
+		t.Status = next
+		result = dto.AdjustmentResult{Adjustment: toAdjustmentResponse(adj), Transaction: toResponse(t)}
+		created = true
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.AdjustmentResult{}, false, dto.ErrTransactionNotFound
+		}
+		return dto.AdjustmentResult{}, false, err
+	}
+	return result, created, nil
+}
+
+func (s *transactionService) ListAdjustments(ctx context.Context, id string) ([]dto.AdjustmentResponse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, dto.ErrTransactionNotFound
+	}
+	if _, err := s.repo.FindByID(ctx, s.db, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	items, err := s.repo.ListAdjustments(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.AdjustmentResponse, 0, len(items))
+	for _, a := range items {
+		resp = append(resp, toAdjustmentResponse(a))
+	}
+	return resp, nil
+}
+
+func toAdjustmentResponse(a entities.TransactionAdjustment) dto.AdjustmentResponse {
+	return dto.AdjustmentResponse{
+		ID:            a.ID.String(),
+		TransactionID: a.TransactionID.String(),
+		Type:          a.Type,
+		ExternalRef:   a.ExternalRef,
+		MerchantID:    a.MerchantID,
+		AmountCents:   a.AmountCents,
+		Reason:        a.Reason,
+		IssuedAt:      a.IssuedAt,
+	}
+}
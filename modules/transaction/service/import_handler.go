@@ -0,0 +1,350 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/validation"
+)
+
+const (
+	ImportJobType = "transaction_import"
+
+	importOutputDir = "/tmp/imports"
+	importBatchSize = 1000
+)
+
+// importColumns is the CSV header an import file must start with.
+var importColumns = []string{"external_ref", "merchant_id", "amount_cents", "fee_cents", "status", "paid_at"}
+
+// ImportPayload is the job payload. FilePath points at a file uploaded with the job request.
+type ImportPayload struct {
+	FilePath string `json:"file_path"`
+	FileName string `json:"file_name"`
+}
+
+// importRow is one parsed line of an import file.
+type importRow struct {
+	line int
+	req  dto.TransactionCreateRequest
+	err  error
+}
+
+// ImportHandler loads processor transaction files (CSV or JSONL) into transactions with COPY.
+// Rejected rows are written to an error report that becomes the job's download.
+type ImportHandler struct {
+	transactionRepo repository.TransactionRepository
+	validation      *validation.TransactionValidation
+}
+
+func NewImportHandler(t repository.TransactionRepository) *ImportHandler {
+	return &ImportHandler{transactionRepo: t, validation: validation.NewTransactionValidation()}
+}
+
+func (h *ImportHandler) Type() string { return ImportJobType }
+
+func (h *ImportHandler) Prepare(ctx context.Context, raw json.RawMessage, job *entities.Job) error {
+	var p ImportPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("%w: %v", jobservice.ErrInvalidPayload, err)
+	}
+	path, err := jobservice.ResolveUpload(p.FilePath)
+	if err != nil {
+		return err
+	}
+	if _, err := importFormat(path); err != nil {
+		return err
+	}
+
+	// Count data lines up front so progress has a denominator
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: cannot open import file: %v", jobservice.ErrInvalidPayload, err)
+	}
+	defer f.Close()
+	var lines int64
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) != "" {
+			lines++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%w: cannot read import file: %v", jobservice.ErrInvalidPayload, err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") && lines > 0 {
+		lines-- // header
+	}
+	job.Total = lines
+	return nil
+}
+
+func (h *ImportHandler) Run(ctx context.Context, job entities.Job, progress *jobservice.Progress) error {
+	var p ImportPayload
+	if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+		return err
+	}
+	path, err := jobservice.ResolveUpload(p.FilePath)
+	if err != nil {
+		return err
+	}
+	format, err := importFormat(path)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(importOutputDir, 0o755); err != nil {
+		return err
+	}
+	reportPath := filepath.Join(importOutputDir, job.ID+"_errors.csv")
+	out, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	report := csv.NewWriter(out)
+	if err := report.Write([]string{"line", "external_ref", "error"}); err != nil {
+		return err
+	}
+	reject := func(line int, ref, msg string) error {
+		return report.Write([]string{strconv.Itoa(line), ref, msg})
+	}
+
+	rows := make(chan importRow, importBatchSize)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		if format == "csv" {
+			readErr <- readCSV(ctx, in, rows)
+		} else {
+			readErr <- readJSONL(ctx, in, rows)
+		}
+	}()
+
+	var processed int64
+	seen := make(map[string]struct{})
+	batch := make([]entities.Transaction, 0, importBatchSize)
+	lines := make(map[string]int, importBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		merchantIDs := make([]string, 0, len(batch))
+		for _, t := range batch {
+			merchantIDs = append(merchantIDs, t.MerchantID)
+		}
+		known, err := h.transactionRepo.KnownMerchantIDs(ctx, nil, merchantIDs)
+		if err != nil {
+			return err
+		}
+		loadable := make([]entities.Transaction, 0, len(batch))
+		for _, t := range batch {
+			if _, ok := known[t.MerchantID]; !ok {
+				if err := reject(lines[t.ExternalRef], t.ExternalRef, dto.ErrUnknownMerchant.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			loadable = append(loadable, t)
+		}
+		inserted, err := h.transactionRepo.CopyIgnoreDuplicates(ctx, loadable)
+		if err != nil {
+			return err
+		}
+		for _, t := range loadable {
+			if _, ok := inserted[t.ExternalRef]; !ok {
+				if err := reject(lines[t.ExternalRef], t.ExternalRef, "external_ref already exists"); err != nil {
+					return err
+				}
+			}
+		}
+		processed += int64(len(batch))
+		batch = batch[:0]
+		clear(lines)
+		report.Flush()
+		if err := report.Error(); err != nil {
+			return err
+		}
+		return progress.Update(ctx, processed)
+	}
+
+	for r := range rows {
+		if ctx.Err() != nil {
+			break
+		}
+		err := r.err
+		if err == nil {
+			err = h.validation.ValidateTransactionCreateRequest(r.req)
+		}
+		if err == nil {
+			if _, dup := seen[r.req.ExternalRef]; dup {
+				err = dto.ErrDuplicateRefInBatch
+			}
+		}
+		if err != nil {
+			processed++
+			if err := reject(r.line, r.req.ExternalRef, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		seen[r.req.ExternalRef] = struct{}{}
+		lines[r.req.ExternalRef] = r.line
+		batch = append(batch, entities.Transaction{
+			ID:          uuid.New(),
+			ExternalRef: r.req.ExternalRef,
+			MerchantID:  r.req.MerchantID,
+			AmountCents: r.req.AmountCents,
+			FeeCents:    r.req.FeeCents,
+			Status:      r.req.Status,
+			PaidAt:      r.req.PaidAt.UTC(),
+		})
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := <-readErr; err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	report.Flush()
+	if err := report.Error(); err != nil {
+		return err
+	}
+	return progress.SetResultPath(ctx, reportPath)
+}
+
+func importFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv", nil
+	case ".jsonl", ".ndjson":
+		return "jsonl", nil
+	}
+	return "", fmt.Errorf("%w: file must be .csv or .jsonl", jobservice.ErrInvalidPayload)
+}
+
+func readCSV(ctx context.Context, r io.Reader, rows chan<- importRow) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read csv header: %w", err)
+	}
+	idx := make(map[string]int, len(header))
+	for i, col := range header {
+		idx[strings.TrimSpace(strings.ToLower(col))] = i
+	}
+	for _, col := range importColumns {
+		if _, ok := idx[col]; !ok {
+			return fmt.Errorf("csv header is missing column %q", col)
+		}
+	}
+	field := func(rec []string, col string) string {
+		if i := idx[col]; i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var row importRow
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			row.line, row.err = perr.StartLine, err
+		} else {
+			row.line, _ = cr.FieldPos(0)
+			row.req.ExternalRef = field(rec, "external_ref")
+			row.req.MerchantID = field(rec, "merchant_id")
+			row.req.Status = field(rec, "status")
+			row.err = parseCSVNumbers(&row.req, field(rec, "amount_cents"), field(rec, "fee_cents"), field(rec, "paid_at"))
+		}
+		select {
+		case rows <- row:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func parseCSVNumbers(req *dto.TransactionCreateRequest, amount, fee, paidAt string) error {
+	var err error
+	if req.AmountCents, err = strconv.ParseInt(amount, 10, 64); err != nil {
+		return fmt.Errorf("invalid amount_cents %q", amount)
+	}
+	if fee != "" {
+		if req.FeeCents, err = strconv.ParseInt(fee, 10, 64); err != nil {
+			return fmt.Errorf("invalid fee_cents %q", fee)
+		}
+	}
+	if req.PaidAt, err = time.Parse(time.RFC3339, paidAt); err != nil {
+		return fmt.Errorf("invalid paid_at %q, expected RFC3339", paidAt)
+	}
+	return nil
+}
+
+func readJSONL(ctx context.Context, r io.Reader, rows chan<- importRow) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(text), &row.req); err != nil {
+			row.err = fmt.Errorf("invalid json: %v", err)
+		}
+		select {
+		case rows <- row:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return sc.Err()
+}
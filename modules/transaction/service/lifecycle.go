@@ -0,0 +1,72 @@
+package service
+
+import "github.com/xkillx/go-gin-order-settlement/pkg/constants"
+
+// transitions is the transaction state machine:
+//
+//	authorized -> captured -> paid -> partially_refunded / refunded / disputed
+//
+// authorized and captured may also fail. Refund and dispute states are only entered by
+// creating a refund or chargeback record.
+var transitions = map[string][]string{
+	constants.ENUM_TRANSACTION_STATUS_AUTHORIZED: {
+		constants.ENUM_TRANSACTION_STATUS_CAPTURED,
+		constants.ENUM_TRANSACTION_STATUS_FAILED,
+	},
+	constants.ENUM_TRANSACTION_STATUS_CAPTURED: {
+		constants.ENUM_TRANSACTION_STATUS_PAID,
+		constants.ENUM_TRANSACTION_STATUS_FAILED,
+	},
+	constants.ENUM_TRANSACTION_STATUS_PAID: {
+		constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_DISPUTED,
+	},
+	constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED: {
+		constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_DISPUTED,
+	},
+}
+
+// manualStatuses can be set directly through PATCH /api/transactions/:id/status.
+var manualStatuses = map[string]struct{}{
+	constants.ENUM_TRANSACTION_STATUS_CAPTURED: {},
+	constants.ENUM_TRANSACTION_STATUS_PAID:     {},
+	constants.ENUM_TRANSACTION_STATUS_FAILED:   {},
+}
+
+func canTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable reports whether status to can be reached from status from in zero or more steps.
+func reachable(from, to string) bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to {
+			return true
+		}
+		for _, next := range transitions[cur] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// refundable reports whether refunds and chargebacks may be issued against a transaction.
+func refundable(status string) bool {
+	return status == constants.ENUM_TRANSACTION_STATUS_PAID ||
+		status == constants.ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED
+}
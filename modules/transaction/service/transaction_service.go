@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type TransactionService interface {
+	// Create ingests one transaction. created is false when the external_ref was already
+	// ingested with identical data and the stored transaction is replayed.
+	Create(ctx context.Context, req dto.TransactionCreateRequest) (res dto.TransactionResponse, created bool, err error)
+	// CreateBatch ingests already validated transactions and reports a result per item.
+	CreateBatch(ctx context.Context, reqs []dto.TransactionCreateRequest) ([]dto.TransactionIngestResult, error)
+	GetByID(ctx context.Context, id string) (dto.TransactionResponse, error)
+	List(ctx context.Context, req dto.TransactionListRequest, q query.Request) ([]dto.TransactionResponse, pkgdto.PaginationResponse, error)
+	// UpdateStatus moves a transaction along the state machine (captured, paid or failed).
+	UpdateStatus(ctx context.Context, id string, req dto.TransactionStatusUpdateRequest) (dto.TransactionResponse, error)
+	// Refund and Chargeback record an adjustment against a paid transaction. created is false
+	// when the external_ref was already recorded with the same data.
+	Refund(ctx context.Context, id string, req dto.AdjustmentCreateRequest) (res dto.AdjustmentResult, created bool, err error)
+	Chargeback(ctx context.Context, id string, req dto.AdjustmentCreateRequest) (res dto.AdjustmentResult, created bool, err error)
+	ListAdjustments(ctx context.Context, id string) ([]dto.AdjustmentResponse, error)
+}
+
+type transactionService struct {
+	repo repository.TransactionRepository
+	db   *gorm.DB
+}
+
+func NewTransactionService(repo repository.TransactionRepository, db *gorm.DB) TransactionService {
+	return &transactionService{repo: repo, db: db}
+}
+
+func (s *transactionService) Create(ctx context.Context, req dto.TransactionCreateRequest) (dto.TransactionResponse, bool, error) {
+	results, err := s.ingest(ctx, []dto.TransactionCreateRequest{req})
+	if err != nil {
+		return dto.TransactionResponse{}, false, err
+	}
+	r := results[0]
+	switch r.Result {
+	case dto.INGEST_RESULT_INVALID:
+		return dto.TransactionResponse{}, false, dto.ErrUnknownMerchant
+	case dto.INGEST_RESULT_CONFLICT:
+		return dto.TransactionResponse{}, false, dto.ErrExternalRefConflict
+	case dto.INGEST_RESULT_DUPLICATE:
+		return *r.Transaction, false, nil
+	default:
+		return *r.Transaction, true, nil
+	}
+}
+
+func (s *transactionService) CreateBatch(ctx context.Context, reqs []dto.TransactionCreateRequest) ([]dto.TransactionIngestResult, error) {
+	return s.ingest(ctx, reqs)
+}
+
+// ingest inserts with ON CONFLICT DO NOTHING on external_ref, then re-reads every ref:
+// a stored row carrying the ID we generated was created by this call, anything else is
+// an earlier ingestion that is either an exact replay or a conflicting reuse of the ref.
+// Items whose merchant_id is not a registered merchant are reported invalid and skipped.
+func (s *transactionService) ingest(ctx context.Context, reqs []dto.TransactionCreateRequest) ([]dto.TransactionIngestResult, error) {
+	merchantIDs := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		merchantIDs = append(merchantIDs, req.MerchantID)
+	}
+	known, err := s.repo.KnownMerchantIDs(ctx, s.db, merchantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.TransactionIngestResult, len(reqs))
+	rows := make([]entities.Transaction, 0, len(reqs))
+	rowIdx := make([]int, 0, len(reqs))
+	refs := make([]string, 0, len(reqs))
+	// Keep generated IDs aside: an insert that skips conflicting rows may scan
+	// RETURNING values onto the wrong slice elements.
+	generated := make([]uuid.UUID, 0, len(reqs))
+	for i, req := range reqs {
+		if _, ok := known[req.MerchantID]; !ok {
+			results[i] = dto.TransactionIngestResult{
+				Index:       i,
+				ExternalRef: req.ExternalRef,
+				Result:      dto.INGEST_RESULT_INVALID,
+				Error:       dto.ErrUnknownMerchant.Error(),
+			}
+			continue
+		}
+		rowIdx = append(rowIdx, i)
+		id := uuid.New()
+		generated = append(generated, id)
+		rows = append(rows, entities.Transaction{
+			ID:          id,
+			ExternalRef: req.ExternalRef,
+			MerchantID:  req.MerchantID,
+			AmountCents: req.AmountCents,
+			FeeCents:    req.FeeCents,
+			Status:      req.Status,
+			// Postgres keeps microseconds; truncate so replays compare equal
+			PaidAt: req.PaidAt.UTC().Truncate(time.Microsecond),
+		})
+		refs = append(refs, req.ExternalRef)
+	}
+
+	if len(rows) == 0 {
+		return results, nil
+	}
+
+	var stored []entities.Transaction
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.InsertIgnoreDuplicates(ctx, tx, rows); err != nil {
+			return err
+		}
+		var err error
+		stored, err = s.repo.FindByExternalRefs(ctx, tx, refs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byRef := make(map[string]entities.Transaction, len(stored))
+	for _, t := range stored {
+		byRef[t.ExternalRef] = t
+	}
+
+	for i, row := range rows {
+		row.ID = generated[i]
+		res := dto.TransactionIngestResult{Index: rowIdx[i], ExternalRef: row.ExternalRef}
+		existing, ok := byRef[row.ExternalRef]
+		switch {
+		case !ok:
+			// Should not happen: the row was either inserted or already present
+			res.Result = dto.INGEST_RESULT_CONFLICT
+			res.Error = dto.ErrExternalRefConflict.Error()
+		case existing.ID == row.ID:
+			res.Result = dto.INGEST_RESULT_CREATED
+			resp := toResponse(existing)
+			res.Transaction = &resp
+		case sameTransaction(existing, row):
+			res.Result = dto.INGEST_RESULT_DUPLICATE
+			resp := toResponse(existing)
+			res.Transaction = &resp
+		default:
+			res.Result = dto.INGEST_RESULT_CONFLICT
+			res.Error = dto.ErrExternalRefConflict.Error()
+		}
+		results[rowIdx[i]] = res
+	}
+	return results, nil
+}
+
+func (s *transactionService) GetByID(ctx context.Context, id string) (dto.TransactionResponse, error) {
+	t, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.TransactionResponse{}, dto.ErrTransactionNotFound
+		}
+		return dto.TransactionResponse{}, err
+	}
+	return toResponse(t), nil
+}
+
+func (s *transactionService) List(ctx context.Context, req dto.TransactionListRequest, q query.Request) ([]dto.TransactionResponse, pkgdto.PaginationResponse, error) {
+	filter := repository.TransactionFilter{
+		MerchantID: req.MerchantID,
+		Status:     req.Status,
+		PaidFrom:   req.From,
+	}
+	if !req.To.IsZero() {
+		// 'to' is an inclusive calendar day
+		filter.PaidTo = req.To.AddDate(0, 0, 1)
+	}
+	items, meta, err := s.repo.List(ctx, s.db, filter, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.TransactionResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toResponse(it))
+	}
+	return resp, meta, nil
+}
+
+func sameTransaction(a, b entities.Transaction) bool {
+	// A stored transaction that has since been refunded or disputed still matches its original ingestion
+	return a.MerchantID == b.MerchantID &&
+		a.AmountCents == b.AmountCents &&
+		a.FeeCents == b.FeeCents &&
+		(a.Status == b.Status || reachable(b.Status, a.Status)) &&
+		a.PaidAt.Equal(b.PaidAt)
+}
+
+func toResponse(t entities.Transaction) dto.TransactionResponse {
+	return dto.TransactionResponse{
+		ID:          t.ID.String(),
+		ExternalRef: t.ExternalRef,
+		MerchantID:  t.MerchantID,
+		AmountCents: t.AmountCents,
+		FeeCents:    t.FeeCents,
+		Status:      t.Status,
+		PaidAt:      t.PaidAt,
+	}
+}
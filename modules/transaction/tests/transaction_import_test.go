@@ -0,0 +1,171 @@
+package transaction_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	settlement "github.com/xkillx/go-gin-order-settlement/modules/settlement"
+	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	transactionService "github.com/xkillx/go-gin-order-settlement/modules/transaction/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+func setupImportServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+	t.Cleanup(func() { _ = os.RemoveAll(utils.PATH) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"jobs", "transaction_adjustments", "transactions"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	seedMerchants(t, db, "merchant-1", "merchant-2")
+
+	txRepository := transactionRepo.NewTransactionRepository(db)
+	jobManager := settlementService.NewJobManager(txRepository, settlementRepo.NewSettlementRepository(db), ledgerService.NewLedgerService(ledgerRepo.NewLedgerRepository(db), db), jobRepo.NewJobRepository(db), db)
+	jobManager.Register(transactionService.NewImportHandler(txRepository))
+
+	inj := do.New()
+	do.ProvideNamed(inj, constants.DB, func(i *do.Injector) (*gorm.DB, error) { return db, nil })
+	do.Provide(inj, func(i *do.Injector) (*settlementService.JobManager, error) { return jobManager, nil })
+
+	engine := gin.New()
+	settlement.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+func uploadImport(t *testing.T, server *gin.Engine, name, content string) string {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", name)
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/"+transactionService.ImportJobType, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("import expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp["job_id"].(string)
+}
+
+func waitForJob(t *testing.T, server *gin.Engine, jobID string) map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	var last map[string]any
+	for time.Now().Before(deadline) {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		_ = json.Unmarshal(rec.Body.Bytes(), &last)
+		switch last["status"] {
+		case "COMPLETED", "FAILED", "CANCELLED":
+			return last
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job did not finish, last: %#v", last)
+	return nil
+}
+
+func TestImportCSVLoadsValidRowsAndReportsRejects(t *testing.T) {
+	server, db := setupImportServer(t)
+
+	paidAt := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	file := "external_ref,merchant_id,amount_cents,fee_cents,status,paid_at\n" +
+		"imp-1,merchant-1,1000,30,paid," + paidAt + "\n" +
+		"imp-2,merchant-1,abc,30,paid," + paidAt + "\n" +
+		"imp-3,merchant-2,500,600,paid," + paidAt + "\n" +
+		"imp-1,merchant-1,1000,30,paid," + paidAt + "\n" +
+		"imp-4,merchant-2,700,20,captured," + paidAt + "\n"
+
+	last := waitForJob(t, server, uploadImport(t, server, "daily.csv", file))
+	if last["status"] != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %#v", last)
+	}
+	if last["processed"].(float64) != 5 || last["total"].(float64) != 5 {
+		t.Fatalf("expected 5/5 processed, got %#v", last)
+	}
+
+	var count int64
+	if err := db.Model(&entities.Transaction{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 imported transactions, got %d", count)
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, last["download_url"].(string), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("download expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rows, err := csv.NewReader(bytes.NewReader(rec.Body.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse report: %v", err)
+	}
+	lines := map[string]bool{}
+	for _, r := range rows[1:] {
+		lines[r[0]] = true
+	}
+	if len(lines) != 3 || !lines["3"] || !lines["4"] || !lines["5"] {
+		t.Fatalf("expected rejected lines 3, 4 and 5, got %v", rows)
+	}
+}
+
+func TestImportJSONLSkipsExistingExternalRefs(t *testing.T) {
+	server, db := setupImportServer(t)
+
+	paidAt := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	line := func(ref string) string {
+		return `{"external_ref":"` + ref + `","merchant_id":"merchant-1","amount_cents":900,"fee_cents":10,"status":"paid","paid_at":"` + paidAt + `"}` + "\n"
+	}
+
+	first := waitForJob(t, server, uploadImport(t, server, "day1.jsonl", line("j-1")+line("j-2")))
+	if first["status"] != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %#v", first)
+	}
+	second := waitForJob(t, server, uploadImport(t, server, "day2.jsonl", line("j-2")+line("j-3")))
+	if second["status"] != "COMPLETED" {
+		t.Fatalf("expected COMPLETED, got %#v", second)
+	}
+
+	var count int64
+	if err := db.Model(&entities.Transaction{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 transactions after re-import, got %d", count)
+	}
+}
@@ -0,0 +1,234 @@
+package transaction_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	transactionModule "github.com/xkillx/go-gin-order-settlement/modules/transaction"
+	transactionController "github.com/xkillx/go-gin-order-settlement/modules/transaction/controller"
+	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	transactionService "github.com/xkillx/go-gin-order-settlement/modules/transaction/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"transaction_adjustments", "transactions"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+	seedMerchants(t, db, "merchant-1", "merchant-2")
+
+	svc := transactionService.NewTransactionService(transactionRepo.NewTransactionRepository(db), db)
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (transactionController.TransactionController, error) {
+		return transactionController.NewTransactionController(i, svc), nil
+	})
+
+	engine := gin.New()
+	transactionModule.RegisterRoutes(engine, inj)
+	return engine, db
+}
+
+// seedMerchants registers merchants so ingestion accepts their merchant_id.
+func seedMerchants(t *testing.T, db *gorm.DB, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		m := entities.Merchant{ID: id, LegalName: "Merchant " + id, Status: "active", SettlementSchedule: "daily", ContactEmail: id + "@example.com"}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&m).Error; err != nil {
+			t.Fatalf("seed merchant %s: %v", id, err)
+		}
+	}
+}
+
+func post(t *testing.T, server *gin.Engine, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIngestTransactionIsIdempotentOnExternalRef(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	paidAt := time.Now().UTC().Add(-time.Hour)
+	body := map[string]any{
+		"external_ref": "gw-1001",
+		"merchant_id":  "merchant-1",
+		"amount_cents": 10_000,
+		"fee_cents":    320,
+		"status":       "paid",
+		"paid_at":      paidAt,
+	}
+
+	if rec := post(t, server, body); rec.Code != http.StatusCreated {
+		t.Fatalf("first ingest expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(t, server, body); rec.Code != http.StatusOK {
+		t.Fatalf("replay expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body["amount_cents"] = 20_000
+	if rec := post(t, server, body); rec.Code != http.StatusConflict {
+		t.Fatalf("conflicting reuse expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var count int64
+	if err := db.Model(&entities.Transaction{}).Where("external_ref = ?", "gw-1001").Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected exactly one stored transaction, got %d", count)
+	}
+}
+
+func TestIngestTransactionValidation(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	base := func() map[string]any {
+		return map[string]any{
+			"external_ref": "gw-2001",
+			"merchant_id":  "merchant-1",
+			"amount_cents": 1_000,
+			"fee_cents":    30,
+			"status":       "paid",
+			"paid_at":      time.Now().UTC().Add(-time.Minute),
+		}
+	}
+
+	cases := map[string]func(m map[string]any){
+		"fee above amount": func(m map[string]any) { m["fee_cents"] = 2_000 },
+		"unknown status":   func(m map[string]any) { m["status"] = "settled" },
+		"future paid_at":   func(m map[string]any) { m["paid_at"] = time.Now().UTC().Add(time.Hour) },
+		"negative amount":  func(m map[string]any) { m["amount_cents"] = -5 },
+	}
+	for name, mutate := range cases {
+		body := base()
+		mutate(body)
+		if rec := post(t, server, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestIngestTransactionBatchReportsPerItem(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	paidAt := time.Now().UTC().Add(-time.Hour)
+	batch := []map[string]any{
+		{"external_ref": "gw-3001", "merchant_id": "merchant-1", "amount_cents": 500, "fee_cents": 20, "status": "paid", "paid_at": paidAt},
+		{"external_ref": "gw-3002", "merchant_id": "merchant-2", "amount_cents": 700, "fee_cents": 900, "status": "paid", "paid_at": paidAt},
+		{"external_ref": "gw-3003", "merchant_id": "merchant-2", "amount_cents": 800, "fee_cents": 25, "status": "captured", "paid_at": paidAt},
+	}
+	rec := post(t, server, batch)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("batch expected 207, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			Summary map[string]int `json:"summary"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Data.Summary["created"] != 2 || resp.Data.Summary["invalid"] != 1 {
+		t.Fatalf("unexpected batch summary: %#v", resp.Data.Summary)
+	}
+
+	// Resending the batch replays the valid items as duplicates
+	rec = post(t, server, batch)
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Data.Summary["duplicate"] != 2 {
+		t.Fatalf("expected 2 duplicates on resend, got %#v", resp.Data.Summary)
+	}
+}
+
+func TestIngestRejectsUnknownMerchant(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	paidAt := time.Now().UTC().Add(-time.Hour)
+	body := map[string]any{
+		"external_ref": "gw-4001",
+		"merchant_id":  "merchant-unknown",
+		"amount_cents": 1_000,
+		"fee_cents":    30,
+		"status":       "paid",
+		"paid_at":      paidAt,
+	}
+	if rec := post(t, server, body); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown merchant expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	batch := []map[string]any{
+		{"external_ref": "gw-4002", "merchant_id": "merchant-1", "amount_cents": 500, "fee_cents": 20, "status": "paid", "paid_at": paidAt},
+		{"external_ref": "gw-4003", "merchant_id": "merchant-unknown", "amount_cents": 500, "fee_cents": 20, "status": "paid", "paid_at": paidAt},
+	}
+	rec := post(t, server, batch)
+	var resp struct {
+		Data struct {
+			Items []struct {
+				Result string `json:"result"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Data.Items) != 2 || resp.Data.Items[0].Result != "created" || resp.Data.Items[1].Result != "invalid" {
+		t.Fatalf("expected created then invalid, got %s", rec.Body.String())
+	}
+}
+
+func TestBlankExternalRefsDoNotCollide(t *testing.T) {
+	_, db := setupTestServer(t)
+	ctx := context.Background()
+	repo := transactionRepo.NewTransactionRepository(db)
+
+	paidAt := time.Now().UTC().Add(-time.Hour)
+	row := func(ref string) entities.Transaction {
+		return entities.Transaction{ID: uuid.New(), ExternalRef: ref, MerchantID: "merchant-1", AmountCents: 500, Status: "paid", PaidAt: paidAt}
+	}
+
+	if err := repo.InsertIgnoreDuplicates(ctx, nil, []entities.Transaction{row(""), row(""), row("gw-blank-1")}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	inserted, err := repo.CopyIgnoreDuplicates(ctx, []entities.Transaction{row(""), row("gw-blank-1"), row("gw-blank-2"), row("gw-blank-2")})
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if _, ok := inserted["gw-blank-1"]; ok {
+		t.Fatalf("an existing ref should be skipped, got %v", inserted)
+	}
+	if _, ok := inserted["gw-blank-2"]; !ok {
+		t.Fatalf("a new ref should be inserted once, got %v", inserted)
+	}
+
+	var blank, total int64
+	db.Model(&entities.Transaction{}).Where("external_ref = ''").Count(&blank)
+	db.Model(&entities.Transaction{}).Count(&total)
+	if blank != 3 || total != 5 {
+		t.Fatalf("expected 3 blank-ref rows of 5, got %d of %d", blank, total)
+	}
+}
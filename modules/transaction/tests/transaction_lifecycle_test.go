@@ -0,0 +1,107 @@
+package transaction_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func send(t *testing.T, server *gin.Engine, method, path string, body any) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp.Data
+}
+
+func ingest(t *testing.T, server *gin.Engine, ref, status string) string {
+	t.Helper()
+	rec, data := send(t, server, http.MethodPost, "/api/transactions", map[string]any{
+		"external_ref": ref,
+		"merchant_id":  "merchant-1",
+		"amount_cents": 10_000,
+		"fee_cents":    300,
+		"status":       status,
+		"paid_at":      time.Now().UTC().Add(-time.Hour),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("ingest expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return data["id"].(string)
+}
+
+func TestTransactionStatusFollowsStateMachine(t *testing.T) {
+	server, _ := setupTestServer(t)
+	id := ingest(t, server, "life-1", "authorized")
+
+	if rec, _ := send(t, server, http.MethodPatch, "/api/transactions/"+id+"/status", map[string]string{"status": "paid"}); rec.Code != http.StatusConflict {
+		t.Fatalf("authorized -> paid expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := send(t, server, http.MethodPost, "/api/transactions/"+id+"/refunds", map[string]any{"external_ref": "rf-0"}); rec.Code != http.StatusConflict {
+		t.Fatalf("refund of unpaid transaction expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, status := range []string{"captured", "paid"} {
+		rec, data := send(t, server, http.MethodPatch, "/api/transactions/"+id+"/status", map[string]string{"status": status})
+		if rec.Code != http.StatusOK || data["status"] != status {
+			t.Fatalf("transition to %s expected 200, got %d: %s", status, rec.Code, rec.Body.String())
+		}
+	}
+	if rec, _ := send(t, server, http.MethodPatch, "/api/transactions/"+id+"/status", map[string]string{"status": "refunded"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("setting refunded directly expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPartialRefundsAndChargeback(t *testing.T) {
+	server, _ := setupTestServer(t)
+	id := ingest(t, server, "life-2", "paid")
+	base := "/api/transactions/" + id
+
+	rec, data := send(t, server, http.MethodPost, base+"/refunds", map[string]any{"external_ref": "rf-1", "amount_cents": 4_000})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("partial refund expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if status := data["transaction"].(map[string]any)["status"]; status != "partially_refunded" {
+		t.Fatalf("expected partially_refunded, got %v", status)
+	}
+
+	// Replaying the processor reference does not refund twice
+	if rec, _ := send(t, server, http.MethodPost, base+"/refunds", map[string]any{"external_ref": "rf-1", "amount_cents": 4_000}); rec.Code != http.StatusOK {
+		t.Fatalf("refund replay expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := send(t, server, http.MethodPost, base+"/refunds", map[string]any{"external_ref": "rf-2", "amount_cents": 7_000}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("over-refund expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec, data = send(t, server, http.MethodPost, base+"/chargebacks", map[string]any{"external_ref": "cb-1", "reason": "fraud"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("chargeback expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if amount := data["adjustment"].(map[string]any)["amount_cents"]; amount != float64(6_000) {
+		t.Fatalf("chargeback should default to the remaining 6000, got %v", amount)
+	}
+	if rec, _ := send(t, server, http.MethodPost, base+"/refunds", map[string]any{"external_ref": "rf-3", "amount_cents": 1}); rec.Code != http.StatusConflict {
+		t.Fatalf("refund of disputed transaction expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, base+"/adjustments", nil)
+	list := httptest.NewRecorder()
+	server.ServeHTTP(list, req)
+	var resp struct {
+		Data []map[string]any `json:"data"`
+	}
+	_ = json.Unmarshal(list.Body.Bytes(), &resp)
+	if list.Code != http.StatusOK || len(resp.Data) != 2 {
+		t.Fatalf("expected 2 adjustments, got %d: %s", list.Code, list.Body.String())
+	}
+}
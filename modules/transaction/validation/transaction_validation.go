@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/transaction/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+// knownStatuses are the statuses a transaction may be ingested with.
+var knownStatuses = map[string]struct{}{
+	constants.ENUM_TRANSACTION_STATUS_AUTHORIZED: {},
+	constants.ENUM_TRANSACTION_STATUS_CAPTURED:   {},
+	constants.ENUM_TRANSACTION_STATUS_PAID:       {},
+	constants.ENUM_TRANSACTION_STATUS_FAILED:     {},
+}
+
+type TransactionValidation struct {
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+func NewTransactionValidation() *TransactionValidation {
+	// Reuse the gin binding tags so batch items decoded without binding get the same checks
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &TransactionValidation{validate: validate, now: time.Now}
+}
+
+func (v *TransactionValidation) ValidateTransactionCreateRequest(req dto.TransactionCreateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if req.AmountCents <= 0 {
+		return dto.ErrAmountNotPositive
+	}
+	if req.FeeCents < 0 {
+		return dto.ErrFeeNegative
+	}
+	if req.FeeCents > req.AmountCents {
+		return dto.ErrFeeExceedsAmount
+	}
+	if _, ok := knownStatuses[req.Status]; !ok {
+		return fmt.Errorf("%w: %q", dto.ErrUnknownStatus, req.Status)
+	}
+	if req.PaidAt.After(v.now()) {
+		return dto.ErrPaidAtInFuture
+	}
+	return nil
+}
+
+func (v *TransactionValidation) ValidateTransactionBatch(reqs []dto.TransactionCreateRequest) error {
+	if len(reqs) == 0 {
+		return dto.ErrEmptyBatch
+	}
+	if len(reqs) > dto.MaxBatchSize {
+		return fmt.Errorf("%w (%d)", dto.ErrBatchTooLarge, dto.MaxBatchSize)
+	}
+	seen := make(map[string]struct{}, len(reqs))
+	for _, r := range reqs {
+		if _, dup := seen[r.ExternalRef]; dup {
+			return fmt.Errorf("%w: %q", dto.ErrDuplicateRefInBatch, r.ExternalRef)
+		}
+		seen[r.ExternalRef] = struct{}{}
+	}
+	return nil
+}
+
+func (v *TransactionValidation) ValidateStatusUpdateRequest(req dto.TransactionStatusUpdateRequest) error {
+	return v.validate.Struct(req)
+}
+
+func (v *TransactionValidation) ValidateAdjustmentCreateRequest(req dto.AdjustmentCreateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if req.IssuedAt != nil && req.IssuedAt.After(v.now()) {
+		return dto.ErrIssuedAtInFuture
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	WarehouseController interface {
+		Create(ctx *gin.Context)
+		GetByID(ctx *gin.Context)
+		List(ctx *gin.Context)
+		Update(ctx *gin.Context)
+		Stock(ctx *gin.Context)
+	}
+
+	warehouseController struct {
+		service   service.WarehouseService
+		validator *validation.WarehouseValidation
+	}
+)
+
+func NewWarehouseController(_ *do.Injector, s service.WarehouseService) WarehouseController {
+	return &warehouseController{
+		service:   s,
+		validator: validation.NewWarehouseValidation(),
+	}
+}
+
+func (c *warehouseController) Create(ctx *gin.Context) {
+	var req dto.WarehouseCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateWarehouseCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_WAREHOUSE, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_WAREHOUSE, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_WAREHOUSE, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *warehouseController) GetByID(ctx *gin.Context) {
+	result, err := c.service.GetByID(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_WAREHOUSE, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_WAREHOUSE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *warehouseController) List(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.List(ctx.Request.Context(), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_WAREHOUSE, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_WAREHOUSE, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *warehouseController) Update(ctx *gin.Context) {
+	var req dto.WarehouseUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateWarehouseUpdateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_WAREHOUSE, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.Update(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_WAREHOUSE, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_WAREHOUSE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *warehouseController) Stock(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.Stock(ctx.Request.Context(), ctx.Param("id"), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_WAREHOUSE_STOCK, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_WAREHOUSE_STOCK, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrWarehouseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrWarehouseCodeTaken):
+		return http.StatusConflict
+	case errors.Is(err, query.ErrInvalidQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
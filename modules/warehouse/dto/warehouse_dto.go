@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY   = "failed get data from body"
+	MESSAGE_FAILED_PROSES_REQUEST       = "failed proses request"
+	MESSAGE_FAILED_VALIDATION_WAREHOUSE = "failed validation warehouse"
+	MESSAGE_FAILED_CREATE_WAREHOUSE     = "failed create warehouse"
+	MESSAGE_FAILED_GET_WAREHOUSE        = "failed get warehouse"
+	MESSAGE_FAILED_GET_LIST_WAREHOUSE   = "failed get list warehouse"
+	MESSAGE_FAILED_UPDATE_WAREHOUSE     = "failed update warehouse"
+	MESSAGE_FAILED_GET_WAREHOUSE_STOCK  = "failed get warehouse stock"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_WAREHOUSE    = "success create warehouse"
+	MESSAGE_SUCCESS_GET_WAREHOUSE       = "success get warehouse"
+	MESSAGE_SUCCESS_GET_LIST_WAREHOUSE  = "success get list warehouse"
+	MESSAGE_SUCCESS_UPDATE_WAREHOUSE    = "success update warehouse"
+	MESSAGE_SUCCESS_GET_WAREHOUSE_STOCK = "success get warehouse stock"
+)
+
+var (
+	ErrWarehouseNotFound  = errors.New("warehouse not found")
+	ErrWarehouseCodeTaken = errors.New("warehouse code already exists")
+	ErrIncompleteLocation = errors.New("latitude and longitude must be given together")
+)
+
+type (
+	// A location is optional, but without one the warehouse is never the nearest
+	WarehouseCreateRequest struct {
+		Code      string   `json:"code" form:"code" binding:"required,min=1,max=50"`
+		Name      string   `json:"name" form:"name" binding:"required,min=2"`
+		Latitude  *float64 `json:"latitude" form:"latitude" binding:"omitempty,min=-90,max=90"`
+		Longitude *float64 `json:"longitude" form:"longitude" binding:"omitempty,min=-180,max=180"`
+	}
+
+	// WarehouseUpdateRequest replaces the name and location; the code is fixed once created
+	WarehouseUpdateRequest struct {
+		Name      string   `json:"name" form:"name" binding:"required,min=2"`
+		Latitude  *float64 `json:"latitude" form:"latitude" binding:"omitempty,min=-90,max=90"`
+		Longitude *float64 `json:"longitude" form:"longitude" binding:"omitempty,min=-180,max=180"`
+	}
+
+	WarehouseResponse struct {
+		ID        string    `json:"id"`
+		Code      string    `json:"code"`
+		Name      string    `json:"name"`
+		Latitude  *float64  `json:"latitude"`
+		Longitude *float64  `json:"longitude"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	WarehouseStockResponse struct {
+		WarehouseID string `json:"warehouse_id"`
+		ProductID   string `json:"product_id"`
+		Stock       int    `json:"stock"`
+	}
+)
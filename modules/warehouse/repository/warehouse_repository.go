@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WarehouseRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, w entities.Warehouse) (entities.Warehouse, error)
+	FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Warehouse, error)
+	FindByCode(ctx context.Context, tx *gorm.DB, code string) (entities.Warehouse, error)
+	List(ctx context.Context, tx *gorm.DB, q query.Request) ([]entities.Warehouse, pkgdto.PaginationResponse, error)
+	Update(ctx context.Context, tx *gorm.DB, w entities.Warehouse) (entities.Warehouse, error)
+	// ListStock returns the warehouse's non-empty stock levels.
+	ListStock(ctx context.Context, tx *gorm.DB, warehouseID string, q query.Request) ([]entities.WarehouseStock, pkgdto.PaginationResponse, error)
+}
+
+// What the warehouses and a warehouse's stock may be filtered and sorted by; warehouse search
+// matches part of the code or name. A warehouse holds one level per product, so product_id keys
+// its stock.
+var (
+	warehouseSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":         {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"code":       {Column: "code", Type: query.String, Ops: query.Text, Sort: true},
+			"name":       {Column: "name", Type: query.String, Ops: query.Text, Sort: true},
+			"created_at": {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "code",
+		Search: func(db *gorm.DB, term string) *gorm.DB {
+			pattern := query.Contains(term)
+			return db.Where("code ILIKE ? OR name ILIKE ?", pattern, pattern)
+		},
+	}
+
+	warehouseStockSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"product_id": {Column: "product_id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"stock":      {Column: "stock", Type: query.Int, Ops: query.Range, Sort: true},
+			"updated_at": {Column: "updated_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		Keys:        []string{"product_id"},
+		DefaultSort: "product_id",
+	}
+)
+
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+func (r *warehouseRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *warehouseRepository) Create(ctx context.Context, tx *gorm.DB, w entities.Warehouse) (entities.Warehouse, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&w).Error; err != nil {
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func (r *warehouseRepository) FindByID(ctx context.Context, tx *gorm.DB, id string) (entities.Warehouse, error) {
+	db := r.getDB(tx)
+	var w entities.Warehouse
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&w).Error; err != nil {
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func (r *warehouseRepository) FindByCode(ctx context.Context, tx *gorm.DB, code string) (entities.Warehouse, error) {
+	db := r.getDB(tx)
+	var w entities.Warehouse
+	if err := db.WithContext(ctx).Where("code = ?", code).Take(&w).Error; err != nil {
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func (r *warehouseRepository) List(ctx context.Context, tx *gorm.DB, q query.Request) ([]entities.Warehouse, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	return query.Find[entities.Warehouse](func() *gorm.DB {
+		return db.WithContext(ctx).Model(&entities.Warehouse{})
+	}, q, warehouseSchema)
+}
+
+func (r *warehouseRepository) Update(ctx context.Context, tx *gorm.DB, w entities.Warehouse) (entities.Warehouse, error) {
+	db := r.getDB(tx)
+	// Select so a location can be cleared back to null
+	if err := db.WithContext(ctx).Clauses(clause.Returning{}).Select("name", "latitude", "longitude").Updates(&w).Error; err != nil {
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func (r *warehouseRepository) ListStock(ctx context.Context, tx *gorm.DB, warehouseID string, q query.Request) ([]entities.WarehouseStock, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	return query.Find[entities.WarehouseStock](func() *gorm.DB {
+		return db.WithContext(ctx).Model(&entities.WarehouseStock{}).Where("warehouse_id = ? AND stock > 0", warehouseID)
+	}, q, warehouseStockSchema)
+}
@@ -0,0 +1,22 @@
+package warehouse
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/controller"
+)
+
+// RegisterRoutes exposes the stock locations and what each of them holds. Stock itself moves
+// through the inventory APIs.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.WarehouseController](injector)
+
+	r := server.Group("/api/warehouses")
+	{
+		r.GET("", ctrl.List)
+		r.POST("", ctrl.Create)
+		r.GET("/:id", ctrl.GetByID)
+		r.PUT("/:id", ctrl.Update)
+		r.GET("/:id/stock", ctrl.Stock)
+	}
+}
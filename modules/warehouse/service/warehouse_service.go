@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+)
+
+type WarehouseService interface {
+	Create(ctx context.Context, req dto.WarehouseCreateRequest) (dto.WarehouseResponse, error)
+	GetByID(ctx context.Context, id string) (dto.WarehouseResponse, error)
+	List(ctx context.Context, q query.Request) ([]dto.WarehouseResponse, pkgdto.PaginationResponse, error)
+	Update(ctx context.Context, id string, req dto.WarehouseUpdateRequest) (dto.WarehouseResponse, error)
+	Stock(ctx context.Context, id string, q query.Request) ([]dto.WarehouseStockResponse, pkgdto.PaginationResponse, error)
+}
+
+type warehouseService struct {
+	repo repository.WarehouseRepository
+	db   *gorm.DB
+}
+
+func NewWarehouseService(repo repository.WarehouseRepository, db *gorm.DB) WarehouseService {
+	return &warehouseService{repo: repo, db: db}
+}
+
+func (s *warehouseService) Create(ctx context.Context, req dto.WarehouseCreateRequest) (dto.WarehouseResponse, error) {
+	if _, err := s.repo.FindByCode(ctx, s.db, req.Code); err == nil {
+		return dto.WarehouseResponse{}, dto.ErrWarehouseCodeTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.WarehouseResponse{}, err
+	}
+	created, err := s.repo.Create(ctx, s.db, entities.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	})
+	if err != nil {
+		return dto.WarehouseResponse{}, err
+	}
+	return toWarehouseResponse(created), nil
+}
+
+func (s *warehouseService) GetByID(ctx context.Context, id string) (dto.WarehouseResponse, error) {
+	w, err := s.find(ctx, id)
+	if err != nil {
+		return dto.WarehouseResponse{}, err
+	}
+	return toWarehouseResponse(w), nil
+}
+
+func (s *warehouseService) List(ctx context.Context, q query.Request) ([]dto.WarehouseResponse, pkgdto.PaginationResponse, error) {
+	items, meta, err := s.repo.List(ctx, s.db, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.WarehouseResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, toWarehouseResponse(it))
+	}
+	return resp, meta, nil
+}
+
+func (s *warehouseService) Update(ctx context.Context, id string, req dto.WarehouseUpdateRequest) (dto.WarehouseResponse, error) {
+	w, err := s.find(ctx, id)
+	if err != nil {
+		return dto.WarehouseResponse{}, err
+	}
+	w.Name = req.Name
+	w.Latitude = req.Latitude
+	w.Longitude = req.Longitude
+	updated, err := s.repo.Update(ctx, s.db, w)
+	if err != nil {
+		return dto.WarehouseResponse{}, err
+	}
+	return toWarehouseResponse(updated), nil
+}
+
+func (s *warehouseService) Stock(ctx context.Context, id string, q query.Request) ([]dto.WarehouseStockResponse, pkgdto.PaginationResponse, error) {
+	if _, err := s.find(ctx, id); err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	items, meta, err := s.repo.ListStock(ctx, s.db, id, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	resp := make([]dto.WarehouseStockResponse, 0, len(items))
+	for _, it := range items {
+		resp = append(resp, dto.WarehouseStockResponse{
+			WarehouseID: it.WarehouseID.String(),
+			ProductID:   it.ProductID.String(),
+			Stock:       it.Stock,
+		})
+	}
+	return resp, meta, nil
+}
+
+func (s *warehouseService) find(ctx context.Context, id string) (entities.Warehouse, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.Warehouse{}, dto.ErrWarehouseNotFound
+	}
+	w, err := s.repo.FindByID(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Warehouse{}, dto.ErrWarehouseNotFound
+		}
+		return entities.Warehouse{}, err
+	}
+	return w, nil
+}
+
+func toWarehouseResponse(w entities.Warehouse) dto.WarehouseResponse {
+	return dto.WarehouseResponse{
+		ID:        w.ID.String(),
+		Code:      w.Code,
+		Name:      w.Name,
+		Latitude:  w.Latitude,
+		Longitude: w.Longitude,
+		CreatedAt: w.CreatedAt,
+	}
+}
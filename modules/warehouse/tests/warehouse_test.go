@@ -0,0 +1,292 @@
+package warehouse_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	inventoryModule "github.com/xkillx/go-gin-order-settlement/modules/inventory"
+	inventoryController "github.com/xkillx/go-gin-order-settlement/modules/inventory/controller"
+	inventoryDto "github.com/xkillx/go-gin-order-settlement/modules/inventory/dto"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	orderModule "github.com/xkillx/go-gin-order-settlement/modules/order"
+	orderController "github.com/xkillx/go-gin-order-settlement/modules/order/controller"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productDto "github.com/xkillx/go-gin-order-settlement/modules/product/dto"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	productService "github.com/xkillx/go-gin-order-settlement/modules/product/service"
+	warehouseModule "github.com/xkillx/go-gin-order-settlement/modules/warehouse"
+	warehouseController "github.com/xkillx/go-gin-order-settlement/modules/warehouse/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/dto"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	warehouseService "github.com/xkillx/go-gin-order-settlement/modules/warehouse/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+type testEnv struct {
+	server   *gin.Engine
+	db       *gorm.DB
+	products productService.ProductService
+	orders   orderService.OrderService
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"reservations", "orders", "products"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	prdRepo := productRepo.NewProductRepository(db)
+	whRepo := warehouseRepo.NewWarehouseRepository(db)
+	inventory := inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(db), prdRepo, whRepo, constants.ENUM_ALLOCATION_SPLIT, db)
+	warehouses := warehouseService.NewWarehouseService(whRepo, db)
+	products := productService.NewProductService(prdRepo, inventory, db)
+	orders := orderService.NewOrderService(orderRepo.NewOrderRepository(db), prdRepo, inventory, nil, 0, db)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (warehouseController.WarehouseController, error) {
+		return warehouseController.NewWarehouseController(i, warehouses), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (inventoryController.InventoryController, error) {
+		return inventoryController.NewInventoryController(i, inventory), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (orderController.OrderController, error) {
+		return orderController.NewOrderController(i, orders), nil
+	})
+
+	engine := gin.New()
+	warehouseModule.RegisterRoutes(engine, inj)
+	inventoryModule.RegisterRoutes(engine, inj)
+	orderModule.RegisterRoutes(engine, inj)
+	return testEnv{server: engine, db: db, products: products, orders: orders}
+}
+
+func call(t *testing.T, server http.Handler, method, path string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if out != nil {
+		_ = json.Unmarshal(rec.Body.Bytes(), &struct {
+			Data any `json:"data"`
+		}{Data: out})
+	}
+	return rec
+}
+
+// createWarehouse adds a warehouse under a unique code, as warehouses outlive each test's data.
+func createWarehouse(t *testing.T, env testEnv, name string, lat, lng float64) string {
+	t.Helper()
+	var w dto.WarehouseResponse
+	body := map[string]any{"code": name + "-" + uuid.NewString()[:8], "name": name, "latitude": lat, "longitude": lng}
+	if rec := call(t, env.server, http.MethodPost, "/api/warehouses", body, &w); rec.Code != http.StatusCreated {
+		t.Fatalf("create warehouse expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return w.ID
+}
+
+// stockProduct creates a product whose stock sits in the given warehouses.
+func stockProduct(t *testing.T, env testEnv, stock map[string]int) string {
+	t.Helper()
+	product, err := env.products.Create(context.Background(), productDto.ProductCreateRequest{Name: "Warehouse Widget", PriceCents: 1_000})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	for warehouseID, qty := range stock {
+		body := map[string]any{"kind": "restock", "delta": qty, "warehouse_id": warehouseID, "actor": "receiving", "reason": "delivery"}
+		if rec := call(t, env.server, http.MethodPost, "/api/inventory/products/"+product.ID+"/adjustments", body, nil); rec.Code != http.StatusCreated {
+			t.Fatalf("restock expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	return product.ID
+}
+
+func locations(t *testing.T, env testEnv, productID string) map[string]int {
+	t.Helper()
+	var items []inventoryDto.StockLocationResponse
+	if rec := call(t, env.server, http.MethodGet, "/api/inventory/products/"+productID+"/locations", nil, &items); rec.Code != http.StatusOK {
+		t.Fatalf("locations expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	out := make(map[string]int, len(items))
+	for _, l := range items {
+		out[l.WarehouseID] = l.Stock
+	}
+	return out
+}
+
+func stockOf(t *testing.T, db *gorm.DB, id string) int {
+	t.Helper()
+	var p entities.Product
+	if err := db.Where("id = ?", id).Take(&p).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	return p.Stock
+}
+
+func TestWarehouseCodesAreUnique(t *testing.T) {
+	env := setupTestServer(t)
+
+	code := "dup-" + uuid.NewString()[:8]
+	if rec := call(t, env.server, http.MethodPost, "/api/warehouses", map[string]any{"code": code, "name": "First"}, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("create warehouse expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPost, "/api/warehouses", map[string]any{"code": code, "name": "Second"}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("a duplicate code expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(t, env.server, http.MethodPost, "/api/warehouses", map[string]any{"code": code + "x", "name": "Half", "latitude": 10.0}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a latitude without longitude expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOrdersFollowAllocationRules(t *testing.T) {
+	env := setupTestServer(t)
+
+	berlin := createWarehouse(t, env, "berlin", 52.52, 13.40)
+	munich := createWarehouse(t, env, "munich", 48.14, 11.58)
+	productID := stockProduct(t, env, map[string]int{berlin: 3, munich: 5})
+	hamburg := map[string]any{"latitude": 53.55, "longitude": 9.99}
+
+	order := func(body map[string]any, want int) orderDto.OrderResponse {
+		t.Helper()
+		body["buyer_id"] = "buyer-1"
+		body["product_id"] = productID
+		var o orderDto.OrderResponse
+		if rec := call(t, env.server, http.MethodPost, "/api/orders", body, &o); rec.Code != want {
+			t.Fatalf("order %v expected %d, got %d: %s", body, want, rec.Code, rec.Body.String())
+		}
+		return o
+	}
+
+	o := order(map[string]any{"quantity": 2, "allocation": "nearest", "ship_to": hamburg}, http.StatusCreated)
+	if a := o.Items[0].Allocations; len(a) != 1 || a[0].WarehouseID != berlin || a[0].Quantity != 2 {
+		t.Fatalf("nearest expected 2 from berlin, got %+v", a)
+	}
+	order(map[string]any{"quantity": 1, "allocation": "nearest"}, http.StatusBadRequest)
+	order(map[string]any{"quantity": 6, "allocation": "most_stock"}, http.StatusConflict)
+
+	o = order(map[string]any{"quantity": 4, "allocation": "split", "ship_to": hamburg}, http.StatusCreated)
+	if a := o.Items[0].Allocations; len(a) != 2 || a[0].WarehouseID != berlin || a[0].Quantity != 1 || a[1].WarehouseID != munich || a[1].Quantity != 3 {
+		t.Fatalf("split expected 1 from berlin and 3 from munich, got %+v", a)
+	}
+	if got := locations(t, env, productID); got[berlin] != 0 || got[munich] != 2 {
+		t.Fatalf("expected berlin 0 and munich 2, got %v", got)
+	}
+
+	// Cancelling returns the stock to the warehouses it was taken from
+	if _, err := env.orders.Transition(context.Background(), o.ID, constants.ENUM_ORDER_STATUS_CANCELLED, "changed mind"); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+	if got := locations(t, env, productID); got[berlin] != 1 || got[munich] != 5 {
+		t.Fatalf("expected berlin 1 and munich 5 after the cancellation, got %v", got)
+	}
+	if got := stockOf(t, env.db, productID); got != 6 {
+		t.Fatalf("expected stock 6, got %d", got)
+	}
+}
+
+func TestTransferMovesStockBetweenWarehouses(t *testing.T) {
+	env := setupTestServer(t)
+
+	north := createWarehouse(t, env, "north", 55.0, 10.0)
+	south := createWarehouse(t, env, "south", 45.0, 10.0)
+	productID := stockProduct(t, env, map[string]int{south: 5})
+
+	transfer := func(from, to string, qty int) *httptest.ResponseRecorder {
+		body := map[string]any{"product_id": productID, "from_warehouse_id": from, "to_warehouse_id": to, "quantity": qty, "actor": "planner"}
+		return call(t, env.server, http.MethodPost, "/api/inventory/transfers", body, nil)
+	}
+	if rec := transfer(south, north, 2); rec.Code != http.StatusCreated {
+		t.Fatalf("transfer expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := transfer(south, north, 4); rec.Code != http.StatusConflict {
+		t.Fatalf("transferring more than is held expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := transfer(south, south, 1); rec.Code != http.StatusBadRequest {
+		t.Fatalf("a transfer to the same warehouse expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := locations(t, env, productID); got[north] != 2 || got[south] != 3 {
+		t.Fatalf("expected north 2 and south 3, got %v", got)
+	}
+	if got := stockOf(t, env.db, productID); got != 5 {
+		t.Fatalf("a transfer must not change total stock, got %d", got)
+	}
+
+	var list struct {
+		Items []inventoryDto.StockTransferResponse `json:"items"`
+	}
+	if rec := call(t, env.server, http.MethodGet, "/api/inventory/transfers?product_id="+productID, nil, &list); rec.Code != http.StatusOK || len(list.Items) != 1 {
+		t.Fatalf("expected 1 transfer, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report inventoryDto.StockVerifyResponse
+	if rec := call(t, env.server, http.MethodGet, "/api/inventory/verify", nil, &report); rec.Code != http.StatusOK || !report.Consistent {
+		t.Fatalf("expected a consistent ledger after the transfer, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConcurrentOrdersAcrossWarehouses(t *testing.T) {
+	env := setupTestServer(t)
+
+	east := createWarehouse(t, env, "east", 50.0, 20.0)
+	west := createWarehouse(t, env, "west", 50.0, 0.0)
+	productID := stockProduct(t, env, map[string]int{east: 10, west: 20})
+
+	const buyers = 100
+	var (
+		wg      sync.WaitGroup
+		success int32
+	)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := orderDto.OrderCreateRequest{BuyerID: "buyer", ProductID: productID, Quantity: 1}
+			// Half ship to each side, so both warehouses are drawn from at once
+			req.Allocation = constants.ENUM_ALLOCATION_SPLIT
+			req.ShipTo = &orderDto.Location{Latitude: 50.0, Longitude: float64(20 * (i % 2))}
+			if _, err := env.orders.Create(context.Background(), req); err == nil {
+				atomic.AddInt32(&success, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if success != 30 {
+		t.Fatalf("expected exactly 30 orders, got %d", success)
+	}
+	if got := locations(t, env, productID); got[east] != 0 || got[west] != 0 {
+		t.Fatalf("expected both warehouses emptied, got %v", got)
+	}
+	if got := stockOf(t, env.db, productID); got != 0 {
+		t.Fatalf("expected stock 0, got %d", got)
+	}
+}
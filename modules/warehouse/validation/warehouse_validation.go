@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/warehouse/dto"
+)
+
+type WarehouseValidation struct {
+	validate *validator.Validate
+}
+
+func NewWarehouseValidation() *WarehouseValidation {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &WarehouseValidation{validate: validate}
+}
+
+func (v *WarehouseValidation) ValidateWarehouseCreateRequest(req dto.WarehouseCreateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	return checkLocation(req.Latitude, req.Longitude)
+}
+
+func (v *WarehouseValidation) ValidateWarehouseUpdateRequest(req dto.WarehouseUpdateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	return checkLocation(req.Latitude, req.Longitude)
+}
+
+func checkLocation(lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return dto.ErrIncompleteLocation
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/validation"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+)
+
+type (
+	WebhookController interface {
+		CreateEndpoint(ctx *gin.Context)
+		GetEndpoint(ctx *gin.Context)
+		ListEndpoints(ctx *gin.Context)
+		UpdateEndpoint(ctx *gin.Context)
+		DeleteEndpoint(ctx *gin.Context)
+		ListDeliveries(ctx *gin.Context)
+		GetDelivery(ctx *gin.Context)
+		Redeliver(ctx *gin.Context)
+	}
+
+	webhookController struct {
+		service   service.WebhookService
+		validator *validation.WebhookValidation
+	}
+)
+
+func NewWebhookController(_ *do.Injector, s service.WebhookService) WebhookController {
+	return &webhookController{
+		service:   s,
+		validator: validation.NewWebhookValidation(),
+	}
+}
+
+func (c *webhookController) CreateEndpoint(ctx *gin.Context) {
+	var req dto.EndpointCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateEndpointCreateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_WEBHOOK, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.CreateEndpoint(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ENDPOINT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ENDPOINT, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *webhookController) GetEndpoint(ctx *gin.Context) {
+	result, err := c.service.GetEndpoint(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ENDPOINT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ENDPOINT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) ListEndpoints(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.ListEndpoints(ctx.Request.Context(), q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_ENDPOINT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_ENDPOINT, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) UpdateEndpoint(ctx *gin.Context) {
+	var req dto.EndpointUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := c.validator.ValidateEndpointUpdateRequest(req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VALIDATION_WEBHOOK, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.service.UpdateEndpoint(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_ENDPOINT, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_ENDPOINT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) DeleteEndpoint(ctx *gin.Context) {
+	if err := c.service.DeleteEndpoint(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_ENDPOINT, err.Error(), nil)
+		ctx.AbortWithStatusJSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_ENDPOINT, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) ListDeliveries(ctx *gin.Context) {
+	q, err := query.FromContext(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	var req dto.DeliveryListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	items, meta, err := c.service.ListDeliveries(ctx.Request.Context(), req, q)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_DELIVERY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	payload := gin.H{
+		"items":      items,
+		"pagination": meta,
+	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_DELIVERY, payload)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) GetDelivery(ctx *gin.Context) {
+	result, err := c.service.GetDelivery(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DELIVERY, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_DELIVERY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *webhookController) Redeliver(ctx *gin.Context) {
+	result, err := c.service.Redeliver(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REDELIVER, err.Error(), nil)
+		ctx.JSON(errorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REDELIVER, result)
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrEndpointNotFound), errors.Is(err, dto.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrDeliveryInFlight):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrUnknownEventType), errors.Is(err, dto.ErrUnknownDeliveryStatus), errors.Is(err, query.ErrInvalidQuery),
+		errors.Is(err, dto.ErrEndpointNotAllowed):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,128 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_DATA_FROM_BODY = "failed get data from body"
+	MESSAGE_FAILED_VALIDATION_WEBHOOK = "Validation failed"
+	MESSAGE_FAILED_CREATE_ENDPOINT    = "failed create webhook endpoint"
+	MESSAGE_FAILED_GET_ENDPOINT       = "failed get webhook endpoint"
+	MESSAGE_FAILED_GET_LIST_ENDPOINT  = "failed get list webhook endpoint"
+	MESSAGE_FAILED_UPDATE_ENDPOINT    = "failed update webhook endpoint"
+	MESSAGE_FAILED_DELETE_ENDPOINT    = "failed delete webhook endpoint"
+	MESSAGE_FAILED_GET_DELIVERY       = "failed get webhook delivery"
+	MESSAGE_FAILED_GET_LIST_DELIVERY  = "failed get list webhook delivery"
+	MESSAGE_FAILED_REDELIVER          = "failed redeliver webhook"
+	MESSAGE_FAILED_PROSES_REQUEST     = "failed proses request"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_ENDPOINT   = "success create webhook endpoint"
+	MESSAGE_SUCCESS_GET_ENDPOINT      = "success get webhook endpoint"
+	MESSAGE_SUCCESS_GET_LIST_ENDPOINT = "success get list webhook endpoint"
+	MESSAGE_SUCCESS_UPDATE_ENDPOINT   = "success update webhook endpoint"
+	MESSAGE_SUCCESS_DELETE_ENDPOINT   = "success delete webhook endpoint"
+	MESSAGE_SUCCESS_GET_DELIVERY      = "success get webhook delivery"
+	MESSAGE_SUCCESS_GET_LIST_DELIVERY = "success get list webhook delivery"
+	MESSAGE_SUCCESS_REDELIVER         = "success redeliver webhook"
+)
+
+// EventTypes lists the events endpoints can subscribe to.
+var EventTypes = []string{
+	constants.ENUM_EVENT_JOB_COMPLETED,
+	constants.ENUM_EVENT_JOB_FAILED,
+	constants.ENUM_EVENT_JOB_CANCELLED,
+	constants.ENUM_EVENT_ORDER_CREATED,
+	constants.ENUM_EVENT_ORDER_PAID,
+	constants.ENUM_EVENT_ORDER_FULFILLED,
+	constants.ENUM_EVENT_ORDER_COMPLETED,
+	constants.ENUM_EVENT_ORDER_CANCELLED,
+	constants.ENUM_EVENT_ORDER_REFUNDED,
+}
+
+var (
+	ErrEndpointNotFound      = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound      = errors.New("webhook delivery not found")
+	ErrDeliveryInFlight      = errors.New("webhook delivery is being sent")
+	ErrUnknownEventType      = errors.New("unknown event type")
+	ErrUnknownDeliveryStatus = errors.New("unknown delivery status")
+	ErrFailedEncryptSecret   = errors.New("failed to encrypt webhook secret")
+	ErrFailedDecryptSecret   = errors.New("failed to decrypt webhook secret")
+	ErrEndpointNotHTTP       = errors.New("webhook url must use http or https")
+	ErrEndpointNotAllowed    = errors.New("webhook url must not point to a loopback, link-local or private address")
+)
+
+type (
+	EndpointCreateRequest struct {
+		URL         string   `json:"url" binding:"required,url"`
+		Description string   `json:"description" binding:"omitempty,max=255"`
+		EventTypes  []string `json:"event_types" binding:"required,min=1,dive,required"`
+		// Secret is generated when empty
+		Secret string `json:"secret" binding:"omitempty,min=16,max=128"`
+	}
+
+	EndpointUpdateRequest struct {
+		URL         string   `json:"url" binding:"omitempty,url"`
+		Description *string  `json:"description" binding:"omitempty,max=255"`
+		EventTypes  []string `json:"event_types" binding:"omitempty,min=1,dive,required"`
+		Active      *bool    `json:"active"`
+	}
+
+	EndpointResponse struct {
+		ID          string   `json:"id"`
+		URL         string   `json:"url"`
+		Description string   `json:"description"`
+		EventTypes  []string `json:"event_types"`
+		Active      bool     `json:"active"`
+		// Secret is only returned when the endpoint is created
+		Secret    string    `json:"secret,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	DeliveryListRequest struct {
+		EndpointID string `form:"endpoint_id"`
+		EventType  string `form:"event_type"`
+		Status     string `form:"status"`
+	}
+
+	DeliveryAttempt struct {
+		Attempt        int       `json:"attempt"`
+		ResponseStatus int       `json:"response_status"`
+		ResponseBody   string    `json:"response_body"`
+		Error          string    `json:"error,omitempty"`
+		DurationMS     int64     `json:"duration_ms"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+
+	DeliveryResponse struct {
+		ID             string            `json:"id"`
+		EventID        string            `json:"event_id"`
+		EndpointID     string            `json:"endpoint_id"`
+		EventType      string            `json:"event_type"`
+		Status         string            `json:"status"`
+		Attempts       int               `json:"attempts"`
+		MaxAttempts    int               `json:"max_attempts"`
+		NextAttemptAt  time.Time         `json:"next_attempt_at"`
+		ResponseStatus int               `json:"response_status"`
+		LastError      string            `json:"last_error,omitempty"`
+		DeliveredAt    *time.Time        `json:"delivered_at"`
+		CreatedAt      time.Time         `json:"created_at"`
+		Payload        json.RawMessage   `json:"payload,omitempty"`
+		AttemptLog     []DeliveryAttempt `json:"attempt_log,omitempty"`
+	}
+
+	// Envelope is the JSON body POSTed to endpoints.
+	Envelope struct {
+		ID        string          `json:"id"`
+		Type      string          `json:"type"`
+		CreatedAt time.Time       `json:"created_at"`
+		Data      json.RawMessage `json:"data"`
+	}
+)
@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, tx *gorm.DB, e entities.WebhookEndpoint) (entities.WebhookEndpoint, error)
+	FindEndpoint(ctx context.Context, tx *gorm.DB, id string) (entities.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, tx *gorm.DB, q query.Request) ([]entities.WebhookEndpoint, pkgdto.PaginationResponse, error)
+	ActiveEndpoints(ctx context.Context, tx *gorm.DB) ([]entities.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, tx *gorm.DB, e entities.WebhookEndpoint) (entities.WebhookEndpoint, error)
+	// DeleteEndpoint removes the endpoint together with its deliveries and their attempt log.
+	DeleteEndpoint(ctx context.Context, tx *gorm.DB, id string) error
+
+	// CreateEvent inserts an event unless one with the same id exists.
+	CreateEvent(ctx context.Context, tx *gorm.DB, ev entities.WebhookEvent) (entities.WebhookEvent, error)
+	// LockUndispatchedEvents locks up to limit events that have not been fanned out yet, oldest
+	// first. Events locked by another dispatcher are skipped.
+	LockUndispatchedEvents(ctx context.Context, tx *gorm.DB, limit int) ([]entities.WebhookEvent, error)
+	MarkEventsDispatched(ctx context.Context, tx *gorm.DB, ids []string, at time.Time) error
+	// CreateDeliveries inserts deliveries, ignoring ones that already exist for the event and endpoint.
+	CreateDeliveries(ctx context.Context, tx *gorm.DB, ds []entities.WebhookDelivery) error
+
+	// ClaimDueDeliveries leases up to limit due deliveries until leaseUntil and counts the
+	// attempt. Deliveries whose lease ran out are claimed again.
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, a entities.WebhookDeliveryAttempt) error
+	MarkSucceeded(ctx context.Context, id string, responseStatus int, at time.Time) error
+	MarkRetry(ctx context.Context, id string, responseStatus int, next time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, id string, responseStatus int, lastErr string) error
+	// Redeliver schedules a delivery that is not in flight to be sent again now with extra
+	// attempts. It reports whether the delivery was rescheduled.
+	Redeliver(ctx context.Context, tx *gorm.DB, id string, extraAttempts int, at time.Time) (bool, error)
+
+	FindDelivery(ctx context.Context, tx *gorm.DB, id string) (entities.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, tx *gorm.DB, endpointID, eventType, status string, q query.Request) ([]entities.WebhookDelivery, pkgdto.PaginationResponse, error)
+	ListAttempts(ctx context.Context, tx *gorm.DB, deliveryID string) ([]entities.WebhookDeliveryAttempt, error)
+}
+
+// What the endpoint and delivery lists may be filtered and sorted by; endpoint search matches
+// part of the url or description.
+var (
+	endpointSchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":         {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"url":        {Column: "url", Type: query.String, Ops: query.Text, Sort: true},
+			"active":     {Column: "active", Type: query.Bool, Ops: []query.Op{query.Eq, query.Ne}},
+			"created_at": {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "-created_at",
+		Search: func(db *gorm.DB, term string) *gorm.DB {
+			pattern := query.Contains(term)
+			return db.Where("url ILIKE ? OR description ILIKE ?", pattern, pattern)
+		},
+	}
+
+	deliverySchema = query.Schema{
+		Fields: map[string]query.Field{
+			"id":              {Column: "id", Type: query.UUID, Ops: query.Equality, Sort: true},
+			"event_id":        {Column: "event_id", Type: query.UUID, Ops: query.Equality},
+			"endpoint_id":     {Column: "endpoint_id", Type: query.UUID, Ops: query.Equality},
+			"event_type":      {Column: "event_type", Type: query.String, Ops: query.Equality},
+			"status":          {Column: "status", Type: query.String, Ops: query.Equality, Sort: true},
+			"attempts":        {Column: "attempts", Type: query.Int, Ops: query.Range, Sort: true},
+			"next_attempt_at": {Column: "next_attempt_at", Type: query.Time, Ops: query.Range, Sort: true},
+			"created_at":      {Column: "created_at", Type: query.Time, Ops: query.Range, Sort: true},
+		},
+		DefaultSort: "-created_at",
+	}
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) getDB(tx *gorm.DB) *gorm.DB {
+	if tx != nil {
+		return tx
+	}
+	return r.db
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, tx *gorm.DB, e entities.WebhookEndpoint) (entities.WebhookEndpoint, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Create(&e).Error; err != nil {
+		return entities.WebhookEndpoint{}, err
+	}
+	return e, nil
+}
+
+func (r *webhookRepository) FindEndpoint(ctx context.Context, tx *gorm.DB, id string) (entities.WebhookEndpoint, error) {
+	db := r.getDB(tx)
+	var e entities.WebhookEndpoint
+	if err := db.WithContext(ctx).Where("id = ?", id).Take(&e).Error; err != nil {
+		return entities.WebhookEndpoint{}, err
+	}
+	return e, nil
+}
+
+func (r *webhookRepository) ListEndpoints(ctx context.Context, tx *gorm.DB, q query.Request) ([]entities.WebhookEndpoint, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	return query.Find[entities.WebhookEndpoint](func() *gorm.DB {
+		return db.WithContext(ctx).Model(&entities.WebhookEndpoint{})
+	}, q, endpointSchema)
+}
+
+func (r *webhookRepository) ActiveEndpoints(ctx context.Context, tx *gorm.DB) ([]entities.WebhookEndpoint, error) {
+	db := r.getDB(tx)
+	var items []entities.WebhookEndpoint
+	if err := db.WithContext(ctx).Where("active = ?", true).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *webhookRepository) UpdateEndpoint(ctx context.Context, tx *gorm.DB, e entities.WebhookEndpoint) (entities.WebhookEndpoint, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Save(&e).Error; err != nil {
+		return entities.WebhookEndpoint{}, err
+	}
+	return e, nil
+}
+
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, tx *gorm.DB, id string) error {
+	db := r.getDB(tx)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&entities.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&entities.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("endpoint_id = ?", id).Delete(&entities.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&entities.WebhookEndpoint{}).Error
+	})
+}
+
+func (r *webhookRepository) CreateEvent(ctx context.Context, tx *gorm.DB, ev entities.WebhookEvent) (entities.WebhookEvent, error) {
+	db := r.getDB(tx)
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ev).Error; err != nil {
+		return entities.WebhookEvent{}, err
+	}
+	return ev, nil
+}
+
+func (r *webhookRepository) LockUndispatchedEvents(ctx context.Context, tx *gorm.DB, limit int) ([]entities.WebhookEvent, error) {
+	db := r.getDB(tx)
+	var items []entities.WebhookEvent
+	if err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("dispatched_at IS NULL").
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *webhookRepository) MarkEventsDispatched(ctx context.Context, tx *gorm.DB, ids []string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db := r.getDB(tx)
+	return db.WithContext(ctx).
+		Model(&entities.WebhookEvent{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"dispatched_at": at, "updated_at": at}).Error
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, tx *gorm.DB, ds []entities.WebhookDelivery) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	db := r.getDB(tx)
+	return db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}, {Name: "endpoint_id"}},
+			DoNothing: true,
+		}).
+		Create(&ds).Error
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	var claimed []entities.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?",
+				[]string{constants.ENUM_WEBHOOK_DELIVERY_PENDING, constants.ENUM_WEBHOOK_DELIVERY_SENDING}, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]string, len(claimed))
+		eventIDs := make([]string, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID.String()
+			eventIDs[i] = claimed[i].EventID.String()
+			claimed[i].Status = constants.ENUM_WEBHOOK_DELIVERY_SENDING
+			claimed[i].Attempts++
+			claimed[i].NextAttemptAt = leaseUntil
+		}
+		var events []entities.WebhookEvent
+		if err := tx.Where("id IN ?", eventIDs).Find(&events).Error; err != nil {
+			return err
+		}
+		byID := make(map[string]entities.WebhookEvent, len(events))
+		for _, ev := range events {
+			byID[ev.ID.String()] = ev
+		}
+		for i := range claimed {
+			claimed[i].Event = byID[claimed[i].EventID.String()]
+		}
+		return tx.Model(&entities.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"status":          constants.ENUM_WEBHOOK_DELIVERY_SENDING,
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": leaseUntil,
+				"updated_at":      now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, a entities.WebhookDeliveryAttempt) error {
+	return r.db.WithContext(ctx).Create(&a).Error
+}
+
+func (r *webhookRepository) MarkSucceeded(ctx context.Context, id string, responseStatus int, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          constants.ENUM_WEBHOOK_DELIVERY_SUCCEEDED,
+			"response_status": responseStatus,
+			"delivered_at":    at,
+			"last_error":      "",
+			"updated_at":      at,
+		}).Error
+}
+
+func (r *webhookRepository) MarkRetry(ctx context.Context, id string, responseStatus int, next time.Time, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          constants.ENUM_WEBHOOK_DELIVERY_PENDING,
+			"response_status": responseStatus,
+			"next_attempt_at": next,
+			"last_error":      lastErr,
+			"updated_at":      time.Now().UTC(),
+		}).Error
+}
+
+func (r *webhookRepository) MarkFailed(ctx context.Context, id string, responseStatus int, lastErr string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          constants.ENUM_WEBHOOK_DELIVERY_FAILED,
+			"response_status": responseStatus,
+			"last_error":      lastErr,
+			"updated_at":      time.Now().UTC(),
+		}).Error
+}
+
+func (r *webhookRepository) Redeliver(ctx context.Context, tx *gorm.DB, id string, extraAttempts int, at time.Time) (bool, error) {
+	db := r.getDB(tx)
+	res := db.WithContext(ctx).
+		Model(&entities.WebhookDelivery{}).
+		Where("id = ? AND status <> ?", id, constants.ENUM_WEBHOOK_DELIVERY_SENDING).
+		Updates(map[string]any{
+			"status":          constants.ENUM_WEBHOOK_DELIVERY_PENDING,
+			"max_attempts":    gorm.Expr("attempts + ?", extraAttempts),
+			"next_attempt_at": at,
+			"updated_at":      at,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *webhookRepository) FindDelivery(ctx context.Context, tx *gorm.DB, id string) (entities.WebhookDelivery, error) {
+	db := r.getDB(tx)
+	var d entities.WebhookDelivery
+	if err := db.WithContext(ctx).Preload("Event").Where("id = ?", id).Take(&d).Error; err != nil {
+		return entities.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, tx *gorm.DB, endpointID, eventType, status string, q query.Request) ([]entities.WebhookDelivery, pkgdto.PaginationResponse, error) {
+	db := r.getDB(tx)
+	deliveries := func() *gorm.DB {
+		d := db.WithContext(ctx).Model(&entities.WebhookDelivery{})
+		if endpointID != "" {
+			d = d.Where("endpoint_id = ?", endpointID)
+		}
+		if eventType != "" {
+			d = d.Where("event_type = ?", eventType)
+		}
+		if status != "" {
+			d = d.Where("status = ?", status)
+		}
+		return d
+	}
+	return query.Find[entities.WebhookDelivery](deliveries, q, deliverySchema)
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, tx *gorm.DB, deliveryID string) ([]entities.WebhookDeliveryAttempt, error) {
+	db := r.getDB(tx)
+	var items []entities.WebhookDeliveryAttempt
+	if err := db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("attempt ASC, created_at ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
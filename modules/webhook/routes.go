@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/controller"
+)
+
+// RegisterRoutes exposes endpoint registration and the delivery log.
+func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
+	ctrl := do.MustInvoke[controller.WebhookController](injector)
+
+	r := server.Group("/api/webhooks")
+	{
+		r.GET("/endpoints", ctrl.ListEndpoints)
+		r.GET("/endpoints/:id", ctrl.GetEndpoint)
+		r.POST("/endpoints", ctrl.CreateEndpoint)
+		r.PATCH("/endpoints/:id", ctrl.UpdateEndpoint)
+		r.DELETE("/endpoints/:id", ctrl.DeleteEndpoint)
+
+		r.GET("/deliveries", ctrl.ListDeliveries)
+		r.GET("/deliveries/:id", ctrl.GetDelivery)
+		r.POST("/deliveries/:id/redeliver", ctrl.Redeliver)
+	}
+}
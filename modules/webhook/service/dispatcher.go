@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// maxLoggedBody caps how much of an endpoint's response is kept in the attempt log.
+const maxLoggedBody = 2048
+
+// Dispatcher moves recorded events to endpoints. Each tick it fans new events out into
+// one delivery per subscribed endpoint, then POSTs every due delivery. A non-2xx response or a
+// transport error is retried with exponential backoff until the delivery runs out of attempts.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	db     *gorm.DB
+	client *http.Client
+
+	maxAttempts int
+	interval    time.Duration
+	batch       int
+	lease       time.Duration
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+func NewDispatcher(repo repository.WebhookRepository, db *gorm.DB, client *http.Client, maxAttempts int, interval, backoff time.Duration) *Dispatcher {
+	if client == nil {
+		client = guardedClient(10 * time.Second)
+	}
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &Dispatcher{
+		repo:        repo,
+		db:          db,
+		client:      client,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		batch:       50,
+		lease:       2 * time.Minute,
+		backoff:     backoff,
+		maxBackoff:  6 * time.Hour,
+	}
+}
+
+// Run dispatches every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	utils.RunEvery(ctx, d.interval, "webhook dispatcher", d.DispatchOnce)
+}
+
+// DispatchOnce fans out pending events and sends every delivery that is due now.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) error {
+	for {
+		n, err := d.fanOut(ctx)
+		if err != nil {
+			return err
+		}
+		if n < d.batch {
+			break
+		}
+	}
+	for {
+		n, err := d.deliverDue(ctx)
+		if err != nil {
+			return err
+		}
+		if n < d.batch {
+			return nil
+		}
+	}
+}
+
+// fanOut turns a batch of outbox events into deliveries and marks them dispatched in one
+// transaction, so a crash in between repeats the fan-out rather than losing it.
+func (d *Dispatcher) fanOut(ctx context.Context) (int, error) {
+	var n int
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		events, err := d.repo.LockUndispatchedEvents(ctx, tx, d.batch)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		n = len(events)
+		endpoints, err := d.repo.ActiveEndpoints(ctx, tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		var deliveries []entities.WebhookDelivery
+		ids := make([]string, len(events))
+		for i, ev := range events {
+			ids[i] = ev.ID.String()
+			for _, e := range endpoints {
+				if !subscribed(e, ev.Type) {
+					continue
+				}
+				deliveries = append(deliveries, entities.WebhookDelivery{
+					EventID:       ev.ID,
+					EndpointID:    e.ID,
+					EventType:     ev.Type,
+					Status:        constants.ENUM_WEBHOOK_DELIVERY_PENDING,
+					MaxAttempts:   d.maxAttempts,
+					NextAttemptAt: now,
+				})
+			}
+		}
+		if err := d.repo.CreateDeliveries(ctx, tx, deliveries); err != nil {
+			return err
+		}
+		return d.repo.MarkEventsDispatched(ctx, tx, ids, now)
+	})
+	return n, err
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	batch, err := d.repo.ClaimDueDeliveries(ctx, now, now.Add(d.lease), d.batch)
+	if err != nil {
+		return 0, err
+	}
+	endpoints := map[string]*entities.WebhookEndpoint{}
+	for _, del := range batch {
+		key := del.EndpointID.String()
+		e, ok := endpoints[key]
+		if !ok {
+			found, err := d.repo.FindEndpoint(ctx, nil, key)
+			if err == nil {
+				e = &found
+			}
+			endpoints[key] = e
+		}
+		if err := d.deliver(ctx, del, e); err != nil {
+			return len(batch), err
+		}
+	}
+	return len(batch), nil
+}
+
+// deliver makes one attempt and records its outcome. Only storage errors are returned.
+func (d *Dispatcher) deliver(ctx context.Context, del entities.WebhookDelivery, e *entities.WebhookEndpoint) error {
+	id := del.ID.String()
+	if e == nil || !e.Active {
+		return d.repo.MarkFailed(ctx, id, 0, "endpoint deleted or disabled")
+	}
+
+	started := time.Now()
+	status, body, sendErr := d.send(ctx, del, *e)
+	attempt := entities.WebhookDeliveryAttempt{
+		DeliveryID:     del.ID,
+		Attempt:        del.Attempts,
+		ResponseStatus: status,
+		ResponseBody:   body,
+		DurationMS:     time.Since(started).Milliseconds(),
+	}
+	if sendErr == nil && (status < 200 || status > 299) {
+		sendErr = fmt.Errorf("endpoint responded %d", status)
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := d.repo.RecordAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	switch {
+	case sendErr == nil:
+		return d.repo.MarkSucceeded(ctx, id, status, time.Now().UTC())
+	case del.Attempts >= del.MaxAttempts:
+		return d.repo.MarkFailed(ctx, id, status, sendErr.Error())
+	default:
+		return d.repo.MarkRetry(ctx, id, status, time.Now().UTC().Add(utils.Backoff(d.backoff, d.maxBackoff, del.Attempts)), sendErr.Error())
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, del entities.WebhookDelivery, e entities.WebhookEndpoint) (int, string, error) {
+	secret, err := utils.AESDecrypt(e.SecretEncrypted)
+	if err != nil || secret == "" {
+		return 0, "", dto.ErrFailedDecryptSecret
+	}
+	payload, err := json.Marshal(dto.Envelope{
+		ID:        del.EventID.String(),
+		Type:      del.EventType,
+		CreatedAt: del.Event.CreatedAt.UTC(),
+		Data:      json.RawMessage(del.Event.Payload),
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, del.EventID.String())
+	req.Header.Set(HeaderEventType, del.EventType)
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+)
+
+// sharedAddressSpace is 100.64.0.0/10 (RFC 6598), used for carrier-grade NAT and by some clouds
+// for internal services.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// allowPrivateTargets lets endpoints reach loopback and private addresses. Only for local
+// development and tests, through WEBHOOK_ALLOW_PRIVATE_TARGETS=true.
+func allowPrivateTargets() bool {
+	return os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS") == "true"
+}
+
+// blockedIP reports addresses a webhook must never be sent to: loopback, link-local (which
+// includes cloud metadata services), private, shared, unspecified and multicast ones.
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip)
+}
+
+// CheckTarget rejects an endpoint URL whose host is, or resolves to, a blocked address. A host
+// that does not resolve yet is accepted; the dispatcher checks every address it dials.
+func CheckTarget(ctx context.Context, raw string) error {
+	if allowPrivateTargets() {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return dto.ErrEndpointNotHTTP
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedIP(ip) {
+			return dto.ErrEndpointNotAllowed
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, a := range addrs {
+		if blockedIP(a.IP) {
+			return dto.ErrEndpointNotAllowed
+		}
+	}
+	return nil
+}
+
+// guardedClient dials only allowed addresses. The check runs on the address actually dialled,
+// after DNS resolution, so a name re-pointed at an internal host after registration and
+// redirects to one are refused too. Proxies are not used, as they would dial on our behalf.
+func guardedClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			if allowPrivateTargets() {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return fmt.Errorf("%w: %s", dto.ErrEndpointNotAllowed, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	eventservice "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/repository"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"github.com/xkillx/go-gin-order-settlement/pkg/query"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxAttempts is how often a delivery is tried before it is marked failed.
+const DefaultMaxAttempts = 8
+
+// secretPrefix marks generated signing secrets so they are recognisable in logs and secret scanners.
+const secretPrefix = "whsec_"
+
+var knownDeliveryStatuses = map[string]struct{}{
+	constants.ENUM_WEBHOOK_DELIVERY_PENDING:   {},
+	constants.ENUM_WEBHOOK_DELIVERY_SENDING:   {},
+	constants.ENUM_WEBHOOK_DELIVERY_SUCCEEDED: {},
+	constants.ENUM_WEBHOOK_DELIVERY_FAILED:    {},
+}
+
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, req dto.EndpointCreateRequest) (dto.EndpointResponse, error)
+	GetEndpoint(ctx context.Context, id string) (dto.EndpointResponse, error)
+	ListEndpoints(ctx context.Context, q query.Request) ([]dto.EndpointResponse, pkgdto.PaginationResponse, error)
+	UpdateEndpoint(ctx context.Context, id string, req dto.EndpointUpdateRequest) (dto.EndpointResponse, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	// HandleEvent records a domain event from the bus for delivery. Events endpoints cannot
+	// subscribe to are ignored, and an event seen again is recorded only once.
+	HandleEvent(ctx context.Context, ev eventservice.Event) error
+
+	ListDeliveries(ctx context.Context, req dto.DeliveryListRequest, q query.Request) ([]dto.DeliveryResponse, pkgdto.PaginationResponse, error)
+	// GetDelivery returns a delivery with its payload and the log of every attempt.
+	GetDelivery(ctx context.Context, id string) (dto.DeliveryResponse, error)
+	// Redeliver sends a delivery again now, whatever its outcome so far, with a fresh set of attempts.
+	Redeliver(ctx context.Context, id string) (dto.DeliveryResponse, error)
+}
+
+type webhookService struct {
+	repo        repository.WebhookRepository
+	db          *gorm.DB
+	maxAttempts int
+}
+
+func NewWebhookService(repo repository.WebhookRepository, db *gorm.DB, maxAttempts int) WebhookService {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &webhookService{repo: repo, db: db, maxAttempts: maxAttempts}
+}
+
+func (s *webhookService) CreateEndpoint(ctx context.Context, req dto.EndpointCreateRequest) (dto.EndpointResponse, error) {
+	secret := req.Secret
+	if secret == "" {
+		raw := make([]byte, 24)
+		if _, err := rand.Read(raw); err != nil {
+			return dto.EndpointResponse{}, err
+		}
+		secret = secretPrefix + hex.EncodeToString(raw)
+	}
+	enc, err := utils.AESEncrypt(secret)
+	if err != nil {
+		return dto.EndpointResponse{}, dto.ErrFailedEncryptSecret
+	}
+	created, err := s.repo.CreateEndpoint(ctx, s.db, entities.WebhookEndpoint{
+		URL:             req.URL,
+		Description:     req.Description,
+		EventTypes:      joinEventTypes(req.EventTypes),
+		SecretEncrypted: enc,
+		Active:          true,
+	})
+	if err != nil {
+		return dto.EndpointResponse{}, err
+	}
+	resp := toEndpointResponse(created)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *webhookService) GetEndpoint(ctx context.Context, id string) (dto.EndpointResponse, error) {
+	e, err := s.findEndpoint(ctx, id)
+	if err != nil {
+		return dto.EndpointResponse{}, err
+	}
+	return toEndpointResponse(e), nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, q query.Request) ([]dto.EndpointResponse, pkgdto.PaginationResponse, error) {
+	items, meta, err := s.repo.ListEndpoints(ctx, s.db, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	out := make([]dto.EndpointResponse, len(items))
+	for i := range items {
+		out[i] = toEndpointResponse(items[i])
+	}
+	return out, meta, nil
+}
+
+func (s *webhookService) UpdateEndpoint(ctx context.Context, id string, req dto.EndpointUpdateRequest) (dto.EndpointResponse, error) {
+	e, err := s.findEndpoint(ctx, id)
+	if err != nil {
+		return dto.EndpointResponse{}, err
+	}
+	if req.URL != "" {
+		e.URL = req.URL
+	}
+	if req.Description != nil {
+		e.Description = *req.Description
+	}
+	if len(req.EventTypes) > 0 {
+		e.EventTypes = joinEventTypes(req.EventTypes)
+	}
+	if req.Active != nil {
+		e.Active = *req.Active
+	}
+	updated, err := s.repo.UpdateEndpoint(ctx, s.db, e)
+	if err != nil {
+		return dto.EndpointResponse{}, err
+	}
+	return toEndpointResponse(updated), nil
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id string) error {
+	if _, err := s.findEndpoint(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(ctx, s.db, id)
+}
+
+func (s *webhookService) HandleEvent(ctx context.Context, ev eventservice.Event) error {
+	if !slices.Contains(dto.EventTypes, ev.Type) {
+		return nil
+	}
+	id, err := uuid.Parse(ev.ID)
+	if err != nil {
+		return err
+	}
+	// Keeping the outbox id makes redelivery by the relay a no-op and gives integrators a
+	// stable X-Webhook-Id to deduplicate on
+	_, err = s.repo.CreateEvent(ctx, s.db, entities.WebhookEvent{
+		ID:        id,
+		Type:      ev.Type,
+		Payload:   string(ev.Payload),
+		Timestamp: entities.Timestamp{CreatedAt: ev.OccurredAt},
+	})
+	return err
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, req dto.DeliveryListRequest, q query.Request) ([]dto.DeliveryResponse, pkgdto.PaginationResponse, error) {
+	if req.Status != "" {
+		if _, ok := knownDeliveryStatuses[req.Status]; !ok {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownDeliveryStatus
+		}
+	}
+	if req.EventType != "" && !slices.Contains(dto.EventTypes, req.EventType) {
+		return nil, pkgdto.PaginationResponse{}, dto.ErrUnknownEventType
+	}
+	if req.EndpointID != "" {
+		if _, err := uuid.Parse(req.EndpointID); err != nil {
+			return nil, pkgdto.PaginationResponse{}, dto.ErrEndpointNotFound
+		}
+	}
+	items, meta, err := s.repo.ListDeliveries(ctx, s.db, req.EndpointID, req.EventType, req.Status, q)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	out := make([]dto.DeliveryResponse, len(items))
+	for i := range items {
+		out[i] = toDeliveryResponse(items[i])
+	}
+	return out, meta, nil
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, id string) (dto.DeliveryResponse, error) {
+	d, err := s.findDelivery(ctx, id)
+	if err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, s.db, id)
+	if err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	resp := toDeliveryResponse(d)
+	resp.Payload = json.RawMessage(d.Event.Payload)
+	resp.AttemptLog = make([]dto.DeliveryAttempt, len(attempts))
+	for i, a := range attempts {
+		resp.AttemptLog[i] = dto.DeliveryAttempt{
+			Attempt:        a.Attempt,
+			ResponseStatus: a.ResponseStatus,
+			ResponseBody:   a.ResponseBody,
+			Error:          a.Error,
+			DurationMS:     a.DurationMS,
+			CreatedAt:      a.CreatedAt,
+		}
+	}
+	return resp, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, id string) (dto.DeliveryResponse, error) {
+	d, err := s.findDelivery(ctx, id)
+	if err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	// Endpoints registered before URLs were checked may still point inside the network
+	e, err := s.findEndpoint(ctx, d.EndpointID.String())
+	if err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	if err := CheckTarget(ctx, e.URL); err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	ok, err := s.repo.Redeliver(ctx, s.db, id, s.maxAttempts, time.Now().UTC())
+	if err != nil {
+		return dto.DeliveryResponse{}, err
+	}
+	if !ok {
+		return dto.DeliveryResponse{}, dto.ErrDeliveryInFlight
+	}
+	return s.GetDelivery(ctx, id)
+}
+
+func (s *webhookService) findEndpoint(ctx context.Context, id string) (entities.WebhookEndpoint, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.WebhookEndpoint{}, dto.ErrEndpointNotFound
+	}
+	e, err := s.repo.FindEndpoint(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.WebhookEndpoint{}, dto.ErrEndpointNotFound
+		}
+		return entities.WebhookEndpoint{}, err
+	}
+	return e, nil
+}
+
+func (s *webhookService) findDelivery(ctx context.Context, id string) (entities.WebhookDelivery, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.WebhookDelivery{}, dto.ErrDeliveryNotFound
+	}
+	d, err := s.repo.FindDelivery(ctx, s.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.WebhookDelivery{}, dto.ErrDeliveryNotFound
+		}
+		return entities.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// joinEventTypes stores subscriptions as a sorted, de-duplicated comma separated list.
+func joinEventTypes(types []string) string {
+	out := slices.Clone(types)
+	slices.Sort(out)
+	return strings.Join(slices.Compact(out), ",")
+}
+
+func splitEventTypes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// subscribed reports whether the endpoint wants events of this type.
+func subscribed(e entities.WebhookEndpoint, eventType string) bool {
+	return slices.Contains(splitEventTypes(e.EventTypes), eventType)
+}
+
+func toEndpointResponse(e entities.WebhookEndpoint) dto.EndpointResponse {
+	return dto.EndpointResponse{
+		ID:          e.ID.String(),
+		URL:         e.URL,
+		Description: e.Description,
+		EventTypes:  splitEventTypes(e.EventTypes),
+		Active:      e.Active,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(d entities.WebhookDelivery) dto.DeliveryResponse {
+	return dto.DeliveryResponse{
+		ID:             d.ID.String(),
+		EventID:        d.EventID.String(),
+		EndpointID:     d.EndpointID.String(),
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		MaxAttempts:    d.MaxAttempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
@@ -0,0 +1,399 @@
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/database"
+	"github.com/xkillx/go-gin-order-settlement/database/entities"
+	eventRepo "github.com/xkillx/go-gin-order-settlement/modules/event/repository"
+	eventservice "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	jobservice "github.com/xkillx/go-gin-order-settlement/modules/job/service"
+	orderDto "github.com/xkillx/go-gin-order-settlement/modules/order/dto"
+	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
+	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
+	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	webhookModule "github.com/xkillx/go-gin-order-settlement/modules/webhook"
+	webhookController "github.com/xkillx/go-gin-order-settlement/modules/webhook/controller"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+	webhookRepo "github.com/xkillx/go-gin-order-settlement/modules/webhook/repository"
+	webhookService "github.com/xkillx/go-gin-order-settlement/modules/webhook/service"
+	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// receiver is an integrator endpoint that answers with the queued status codes, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"ok":true}`))
+}
+
+func (r *receiver) received() ([]*http.Request, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*http.Request(nil), r.requests...), append([][]byte(nil), r.bodies...)
+}
+
+type testEnv struct {
+	server     *gin.Engine
+	db         *gorm.DB
+	service    webhookService.WebhookService
+	dispatcher *webhookService.Dispatcher
+	outbox     eventservice.OutboxService
+	relay      *eventservice.Relay
+}
+
+func setupTestServer(t *testing.T) testEnv {
+	t.Helper()
+	t.Setenv("AES_KEY", "8e71bbce7451ba2835de5aea73e4f3f96821455240823d2fd8174975b8321bfc")
+	// The integrators below are httptest servers on loopback
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_TARGETS", "true")
+
+	gin.SetMode(gin.TestMode)
+	db := config.SetUpTestDatabaseConnection()
+	t.Cleanup(func() { config.CloseDatabaseConnection(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, table := range []string{"webhook_delivery_attempts", "webhook_deliveries", "webhook_events", "webhook_endpoints"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatalf("failed to truncate %s: %v", table, err)
+		}
+	}
+
+	repo := webhookRepo.NewWebhookRepository(db)
+	svc := webhookService.NewWebhookService(repo, db, 3)
+	// No backoff so every DispatchOnce retries right away
+	dispatcher := webhookService.NewDispatcher(repo, db, nil, 3, time.Hour, 0)
+
+	outboxRepository := eventRepo.NewOutboxRepository(db)
+	bus := eventservice.NewBus()
+	bus.Subscribe(eventservice.AllEvents, "webhook", svc.HandleEvent)
+
+	inj := do.New()
+	do.Provide(inj, func(i *do.Injector) (webhookController.WebhookController, error) {
+		return webhookController.NewWebhookController(i, svc), nil
+	})
+	engine := gin.New()
+	webhookModule.RegisterRoutes(engine, inj)
+	return testEnv{
+		server:     engine,
+		db:         db,
+		service:    svc,
+		dispatcher: dispatcher,
+		outbox:     eventservice.NewOutboxService(outboxRepository, db, 3),
+		relay:      eventservice.NewRelay(outboxRepository, bus, time.Hour, 0),
+	}
+}
+
+func send(t *testing.T, server *gin.Engine, method, path string, body any) (int, map[string]any) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp.Data
+}
+
+func createEndpoint(t *testing.T, server *gin.Engine, url string, events ...string) (string, string) {
+	t.Helper()
+	code, data := send(t, server, http.MethodPost, "/api/webhooks/endpoints", map[string]any{"url": url, "event_types": events})
+	if code != http.StatusCreated {
+		t.Fatalf("create endpoint expected 201, got %d: %#v", code, data)
+	}
+	return data["id"].(string), data["secret"].(string)
+}
+
+func listDeliveries(t *testing.T, server *gin.Engine, query string) []map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks/deliveries?"+query, nil))
+	var resp struct {
+		Data struct {
+			Items []map[string]any `json:"items"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Data.Items
+}
+
+func TestSignatureVerifiesBodyAndTimestamp(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"evt"}`)
+	header := webhookService.Sign("whsec_test", now, body)
+	if err := webhookService.Verify("whsec_test", header, body, 5*time.Minute, now); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if err := webhookService.Verify("whsec_test", header, []byte(`{"id":"other"}`), 5*time.Minute, now); err == nil {
+		t.Fatal("a tampered body must not verify")
+	}
+	if err := webhookService.Verify("whsec_other", header, body, 5*time.Minute, now); err == nil {
+		t.Fatal("a different secret must not verify")
+	}
+	if err := webhookService.Verify("whsec_test", header, body, 5*time.Minute, now.Add(time.Hour)); err == nil {
+		t.Fatal("a replayed request outside the tolerance must not verify")
+	}
+}
+
+func TestOrderCreatedIsSignedRetriedAndRedelivered(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+	hook := &receiver{statuses: []int{http.StatusInternalServerError}}
+	integrator := httptest.NewServer(hook)
+	defer integrator.Close()
+
+	endpointID, secret := createEndpoint(t, env.server, integrator.URL, "order.created")
+	// Subscribed to jobs only, so it must not receive order events
+	createEndpoint(t, env.server, integrator.URL+"/jobs", "job.completed")
+
+	prdRepo := productRepo.NewProductRepository(env.db)
+	inventory := inventoryService.NewInventoryService(inventoryRepo.NewInventoryRepository(env.db), prdRepo,
+		warehouseRepo.NewWarehouseRepository(env.db), constants.ENUM_ALLOCATION_SPLIT, env.db)
+	product, err := prdRepo.Create(ctx, env.db, entities.Product{Name: "Webhook Product", Stock: 5})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if err := inventory.Open(ctx, env.db, product.ID, product.Stock); err != nil {
+		t.Fatalf("open stock: %v", err)
+	}
+	orders := orderService.NewOrderService(orderRepo.NewOrderRepository(env.db), prdRepo, inventory, env.outbox, 0, env.db)
+	order, err := orders.Create(ctx, orderDto.OrderCreateRequest{ProductID: product.ID.String(), BuyerID: "buyer-1", Quantity: 2})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	// A rejected order rolls back its event with it
+	if _, err := orders.Create(ctx, orderDto.OrderCreateRequest{ProductID: product.ID.String(), BuyerID: "buyer-2", Quantity: 99}); err == nil {
+		t.Fatal("expected insufficient stock")
+	}
+
+	if _, err := env.relay.RelayOnce(ctx); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	var relayed []entities.OutboxEvent
+	env.db.Where("type = ? AND payload LIKE ?", "order.created", "%"+order.ID+"%").Find(&relayed)
+	if len(relayed) != 1 || relayed[0].Status != "published" {
+		t.Fatalf("expected the order event to be published from the outbox, got %#v", relayed)
+	}
+	// The relay is at-least-once, so seeing the same event again must not add a delivery
+	redelivered := eventservice.Event{ID: relayed[0].ID.String(), Type: relayed[0].Type, Payload: json.RawMessage(relayed[0].Payload), OccurredAt: relayed[0].CreatedAt}
+	if err := env.service.HandleEvent(ctx, redelivered); err != nil {
+		t.Fatalf("handle again: %v", err)
+	}
+
+	// First attempt gets a 500, the second succeeds
+	for i := 0; i < 2; i++ {
+		if err := env.dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+	}
+
+	requests, bodies := hook.received()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests (one failure, one retry), got %d", len(requests))
+	}
+	for i, req := range requests {
+		if req.URL.Path != "/" || req.Header.Get(webhookService.HeaderEventType) != "order.created" {
+			t.Fatalf("unexpected request %s %s", req.URL.Path, req.Header.Get(webhookService.HeaderEventType))
+		}
+		if err := webhookService.Verify(secret, req.Header.Get(webhookService.HeaderSignature), bodies[i], time.Minute, time.Now()); err != nil {
+			t.Fatalf("signature did not verify: %v", err)
+		}
+	}
+	var envelope dto.Envelope
+	_ = json.Unmarshal(bodies[1], &envelope)
+	var data orderDto.OrderResponse
+	_ = json.Unmarshal(envelope.Data, &data)
+	if envelope.ID != relayed[0].ID.String() || envelope.Type != "order.created" || data.ID != order.ID || len(data.Items) != 1 || data.Items[0].Quantity != 2 {
+		t.Fatalf("unexpected payload %s", bodies[1])
+	}
+
+	items := listDeliveries(t, env.server, "endpoint_id="+endpointID)
+	if len(items) != 1 || items[0]["status"] != "succeeded" || items[0]["attempts"].(float64) != 2 {
+		t.Fatalf("expected one succeeded delivery after 2 attempts, got %#v", items)
+	}
+	deliveryID := items[0]["id"].(string)
+	code, delivery := send(t, env.server, http.MethodGet, "/api/webhooks/deliveries/"+deliveryID, nil)
+	attemptLog, _ := delivery["attempt_log"].([]any)
+	if code != http.StatusOK || len(attemptLog) != 2 || attemptLog[0].(map[string]any)["response_status"].(float64) != 500 {
+		t.Fatalf("expected the attempt log to show the 500 then the 200, got %#v", delivery)
+	}
+
+	code, _ = send(t, env.server, http.MethodPost, "/api/webhooks/deliveries/"+deliveryID+"/redeliver", nil)
+	if code != http.StatusAccepted {
+		t.Fatalf("redeliver expected 202, got %d", code)
+	}
+	if err := env.dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if requests, _ := hook.received(); len(requests) != 3 || requests[2].Header.Get(webhookService.HeaderEventID) != envelope.ID {
+		t.Fatalf("expected the same event to be redelivered, got %d requests", len(requests))
+	}
+	if items := listDeliveries(t, env.server, "event_type=order.created"); len(items) != 1 {
+		t.Fatalf("expected a single order.created delivery, got %#v", items)
+	}
+}
+
+func TestPrivateTargetsAreRefused(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+	hook := &receiver{}
+	integrator := httptest.NewServer(hook)
+	defer integrator.Close()
+	// Registered while loopback was allowed, as an endpoint from before the check would be
+	createEndpoint(t, env.server, integrator.URL, "order.created")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_TARGETS", "")
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.10/hook",
+		"http://[::1]/hook",
+	} {
+		if code, data := send(t, env.server, http.MethodPost, "/api/webhooks/endpoints", map[string]any{"url": url, "event_types": []string{"order.created"}}); code != http.StatusBadRequest {
+			t.Fatalf("%s expected 400, got %d: %#v", url, code, data)
+		}
+	}
+
+	// The dispatcher refuses the dial, so nothing reaches the internal host
+	event := eventservice.Event{ID: uuid.NewString(), Type: "order.created", Payload: json.RawMessage(`{}`), OccurredAt: time.Now().UTC()}
+	if err := env.service.HandleEvent(ctx, event); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+	if err := env.dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if requests, _ := hook.received(); len(requests) != 0 {
+		t.Fatalf("expected no request to reach a loopback endpoint, got %d", len(requests))
+	}
+	items := listDeliveries(t, env.server, "event_type=order.created")
+	if len(items) != 1 || !strings.Contains(fmt.Sprint(items[0]["last_error"]), "private address") {
+		t.Fatalf("expected the delivery to record the refused dial, got %#v", items)
+	}
+	if code, _ := send(t, env.server, http.MethodPost, "/api/webhooks/deliveries/"+items[0]["id"].(string)+"/redeliver", nil); code != http.StatusBadRequest {
+		t.Fatalf("redelivering to a loopback endpoint expected 400, got %d", code)
+	}
+}
+
+// finishHandler completes or fails depending on its payload.
+type finishHandler struct{}
+
+func (finishHandler) Type() string { return "webhook_test" }
+
+func (finishHandler) Prepare(_ context.Context, _ json.RawMessage, job *entities.Job) error {
+	job.FromDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	job.ToDate = job.FromDate
+	return nil
+}
+
+func (finishHandler) Run(_ context.Context, job entities.Job, _ *jobservice.Progress) error {
+	if string(job.Payload) == `{"fail":true}` {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func TestJobEventsAreWrittenWithFinalStatus(t *testing.T) {
+	env := setupTestServer(t)
+	ctx := context.Background()
+	hook := &receiver{}
+	integrator := httptest.NewServer(hook)
+	defer integrator.Close()
+	createEndpoint(t, env.server, integrator.URL, "job.failed")
+
+	jobs := jobRepo.NewJobRepository(env.db)
+	manager := jobservice.NewJobManager(jobs)
+	manager.Register(finishHandler{})
+	manager.SubscribeTx(eventservice.NewJobEvents(env.outbox))
+
+	ok, err := manager.Start(ctx, "webhook_test", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	failed, err := manager.Start(ctx, "webhook_test", json.RawMessage(`{"fail":true}`))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	for _, id := range []string{ok.ID, failed.ID} {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			job, _ := jobs.Get(ctx, id)
+			if job.Status == jobservice.StatusCompleted || job.Status == jobservice.StatusFailed {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("job %s did not finish", id)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	var events []entities.OutboxEvent
+	env.db.Where("payload LIKE ? OR payload LIKE ?", "%"+ok.ID+"%", "%"+failed.ID+"%").Order("type").Find(&events)
+	if len(events) != 2 || events[0].Type != "job.completed" || events[1].Type != "job.failed" {
+		t.Fatalf("expected one completed and one failed event in the outbox, got %#v", events)
+	}
+
+	if _, err := env.relay.RelayOnce(ctx); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+
+	if err := env.dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	_, bodies := hook.received()
+	if len(bodies) != 1 {
+		t.Fatalf("expected only the job.failed event to be delivered, got %d", len(bodies))
+	}
+	var envelope dto.Envelope
+	_ = json.Unmarshal(bodies[0], &envelope)
+	var data eventservice.JobEvent
+	_ = json.Unmarshal(envelope.Data, &data)
+	if data.JobID != failed.ID || data.Status != jobservice.StatusFailed || data.Error == "" {
+		t.Fatalf("unexpected job event %s", bodies[0])
+	}
+}
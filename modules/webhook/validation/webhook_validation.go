@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/dto"
+	"github.com/xkillx/go-gin-order-settlement/modules/webhook/service"
+)
+
+type WebhookValidation struct {
+	validate *validator.Validate
+}
+
+func NewWebhookValidation() *WebhookValidation {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	return &WebhookValidation{validate: validate}
+}
+
+func (v *WebhookValidation) ValidateEndpointCreateRequest(req dto.EndpointCreateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if err := validateURL(req.URL); err != nil {
+		return err
+	}
+	return validateEventTypes(req.EventTypes)
+}
+
+func (v *WebhookValidation) ValidateEndpointUpdateRequest(req dto.EndpointUpdateRequest) error {
+	if err := v.validate.Struct(req); err != nil {
+		return err
+	}
+	if req.URL != "" {
+		if err := validateURL(req.URL); err != nil {
+			return err
+		}
+	}
+	return validateEventTypes(req.EventTypes)
+}
+
+// validateURL accepts http(s) URLs whose host is not, and does not resolve to, a loopback,
+// link-local or private address.
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return dto.ErrEndpointNotHTTP
+	}
+	return service.CheckTarget(context.Background(), raw)
+}
+
+func validateEventTypes(types []string) error {
+	for _, t := range types {
+		if !slices.Contains(dto.EventTypes, t) {
+			return fmt.Errorf("%w: %s", dto.ErrUnknownEventType, t)
+		}
+	}
+	return nil
+}
@@ -7,9 +7,124 @@ const (
 	ENUM_RUN_PRODUCTION = "production"
 	ENUM_RUN_TESTING    = "testing"
 
+	ENUM_TRANSACTION_STATUS_AUTHORIZED = "authorized"
+	ENUM_TRANSACTION_STATUS_CAPTURED   = "captured"
+	ENUM_TRANSACTION_STATUS_PAID       = "paid"
+	ENUM_TRANSACTION_STATUS_FAILED     = "failed"
+
+	ENUM_TRANSACTION_STATUS_PARTIALLY_REFUNDED = "partially_refunded"
+	ENUM_TRANSACTION_STATUS_REFUNDED           = "refunded"
+	ENUM_TRANSACTION_STATUS_DISPUTED           = "disputed"
+
+	ENUM_ADJUSTMENT_TYPE_REFUND     = "refund"
+	ENUM_ADJUSTMENT_TYPE_CHARGEBACK = "chargeback"
+
+	ENUM_MERCHANT_STATUS_ACTIVE    = "active"
+	ENUM_MERCHANT_STATUS_SUSPENDED = "suspended"
+
+	ENUM_SETTLEMENT_SCHEDULE_DAILY   = "daily"
+	ENUM_SETTLEMENT_SCHEDULE_WEEKLY  = "weekly"
+	ENUM_SETTLEMENT_SCHEDULE_MONTHLY = "monthly"
+
+	ENUM_STATEMENT_DELIVERY_PENDING = "pending"
+	ENUM_STATEMENT_DELIVERY_SENT    = "sent"
+	ENUM_STATEMENT_DELIVERY_FAILED  = "failed"
+
+	// Domain event outbox states. An event is "publishing" while a relay holds its lease.
+	ENUM_OUTBOX_STATUS_PENDING    = "pending"
+	ENUM_OUTBOX_STATUS_PUBLISHING = "publishing"
+	ENUM_OUTBOX_STATUS_PUBLISHED  = "published"
+	ENUM_OUTBOX_STATUS_FAILED     = "failed"
+
+	// Mail outbox states. A message is "sending" while a dispatcher holds its lease.
+	ENUM_MAIL_STATUS_PENDING = "pending"
+	ENUM_MAIL_STATUS_SENDING = "sending"
+	ENUM_MAIL_STATUS_SENT    = "sent"
+	ENUM_MAIL_STATUS_FAILED  = "failed"
+
+	// Webhook delivery states, same lifecycle as the mail outbox
+	ENUM_WEBHOOK_DELIVERY_PENDING   = "pending"
+	ENUM_WEBHOOK_DELIVERY_SENDING   = "sending"
+	ENUM_WEBHOOK_DELIVERY_SUCCEEDED = "succeeded"
+	ENUM_WEBHOOK_DELIVERY_FAILED    = "failed"
+
+	// Domain event types written to the outbox
+	ENUM_EVENT_JOB_COMPLETED = "job.completed"
+	ENUM_EVENT_JOB_FAILED    = "job.failed"
+	ENUM_EVENT_JOB_CANCELLED = "job.cancelled"
+	ENUM_EVENT_ORDER_CREATED = "order.created"
+	// Written on each order status change: "order." followed by the new status
+	ENUM_EVENT_ORDER_PAID      = "order.paid"
+	ENUM_EVENT_ORDER_FULFILLED = "order.fulfilled"
+	ENUM_EVENT_ORDER_COMPLETED = "order.completed"
+	ENUM_EVENT_ORDER_CANCELLED = "order.cancelled"
+	ENUM_EVENT_ORDER_REFUNDED  = "order.refunded"
+
+	// Order lifecycle: pending -> paid -> fulfilled -> completed, with cancelled (before
+	// payment) and refunded (after it) as terminal branches that return the stock.
+	ENUM_ORDER_STATUS_PENDING   = "pending"
+	ENUM_ORDER_STATUS_PAID      = "paid"
+	ENUM_ORDER_STATUS_FULFILLED = "fulfilled"
+	ENUM_ORDER_STATUS_COMPLETED = "completed"
+	ENUM_ORDER_STATUS_CANCELLED = "cancelled"
+	ENUM_ORDER_STATUS_REFUNDED  = "refunded"
+
+	// Ledger account types. Merchant payable and reserve are kept per merchant,
+	// the others are platform-wide.
+	ENUM_LEDGER_ACCOUNT_CLEARING         = "clearing"
+	ENUM_LEDGER_ACCOUNT_MERCHANT_PAYABLE = "merchant_payable"
+	ENUM_LEDGER_ACCOUNT_FEE_REVENUE      = "fee_revenue"
+	ENUM_LEDGER_ACCOUNT_RESERVE          = "reserve"
+	ENUM_LEDGER_ACCOUNT_REFUNDS          = "refunds"
+	ENUM_LEDGER_ACCOUNT_CHARGEBACKS      = "chargebacks"
+
+	ENUM_LEDGER_NORMAL_DEBIT  = "debit"
+	ENUM_LEDGER_NORMAL_CREDIT = "credit"
+
+	ENUM_JOURNAL_SOURCE_SETTLEMENT = "settlement"
+	ENUM_JOURNAL_SOURCE_MANUAL     = "manual"
+
+	// Stock reservation states. Only active reservations count towards Product.Reserved.
+	ENUM_RESERVATION_STATUS_ACTIVE    = "active"
+	ENUM_RESERVATION_STATUS_CONFIRMED = "confirmed"
+	ENUM_RESERVATION_STATUS_RELEASED  = "released"
+	ENUM_RESERVATION_STATUS_EXPIRED   = "expired"
+
+	// Inventory movement kinds, one per reason stock can change
+	ENUM_INVENTORY_MOVEMENT_INITIAL      = "initial"
+	ENUM_INVENTORY_MOVEMENT_ORDER        = "order"
+	ENUM_INVENTORY_MOVEMENT_CANCELLATION = "cancellation"
+	ENUM_INVENTORY_MOVEMENT_REFUND       = "refund"
+	ENUM_INVENTORY_MOVEMENT_ADJUSTMENT   = "adjustment"
+	ENUM_INVENTORY_MOVEMENT_RESTOCK      = "restock"
+	ENUM_INVENTORY_MOVEMENT_TRANSFER     = "transfer"
+
+	// Actor recorded for stock changes the service makes on its own, e.g. returning a cancelled order's stock
+	ENUM_INVENTORY_ACTOR_SYSTEM = "system"
+
+	// Warehouse created by the migrations; stock that predates warehouses, and new products'
+	// opening stock, is kept here
+	ENUM_WAREHOUSE_DEFAULT_CODE = "default"
+
+	// How an order line picks the warehouses it ships from: the nearest one holding the whole
+	// line, the one with the most stock, or split over several, nearest (or fullest) first
+	ENUM_ALLOCATION_NEAREST    = "nearest"
+	ENUM_ALLOCATION_MOST_STOCK = "most_stock"
+	ENUM_ALLOCATION_SPLIT      = "split"
+
+	// Currency for products created without one
+	ENUM_CURRENCY_DEFAULT = "USD"
+
+	// Archived products are hidden from the default product list and can no longer be ordered or reserved
+	ENUM_PRODUCT_STATUS_ACTIVE   = "active"
+	ENUM_PRODUCT_STATUS_ARCHIVED = "archived"
+
 	ENUM_PAGINATION_PER_PAGE = 10
 	ENUM_PAGINATION_PAGE     = 1
 
 	DB         = "db"
 	JWTService = "JWTService"
+
+	// CTX_MERCHANT_ID holds the merchant authenticated by API key
+	CTX_MERCHANT_ID = "merchant_id"
 )
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidCursor = errors.New("cursor is invalid or was tampered with")
+
+// cursorKey signs cursors with CURSOR_SECRET. Without it a random key is made once per process,
+// so cursors stop working across restarts and between instances.
+var cursorKey = sync.OnceValue(func() []byte {
+	if k := os.Getenv("CURSOR_SECRET"); k != "" {
+		return []byte(k)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("cursor key: " + err.Error())
+	}
+	return key
+})
+
+func cursorSignature(payload string) string {
+	mac := hmac.New(sha256.New, cursorKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// EncodeCursor turns v into an opaque cursor, signed so clients cannot forge positions.
+func EncodeCursor(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + cursorSignature(payload), nil
+}
+
+// DecodeCursor checks the signature of a cursor made by EncodeCursor and unmarshals it into v.
+func DecodeCursor(cursor string, v any) error {
+	payload, signature, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(cursorSignature(payload))) {
+		return ErrInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -5,6 +5,11 @@ type (
 		Search  string `form:"search"`
 		Page    int    `form:"page"`
 		PerPage int    `form:"per_page"`
+		// Cursor is a next_cursor or prev_cursor from an earlier page; it replaces Page
+		Cursor string `form:"cursor"`
+		// Count asks for count and max_page. Page requests count unless it is false, cursor
+		// requests only when it is true.
+		Count *bool `form:"count"`
 	}
 
 	PaginationResponse struct {
@@ -12,9 +17,26 @@ type (
 		PerPage int   `json:"per_page"`
 		MaxPage int64 `json:"max_page"`
 		Count   int64 `json:"count"`
+		// NextCursor continues a keyset-paginated list where this page ended; empty on the last page
+		NextCursor string `json:"next_cursor,omitempty"`
+		// PrevCursor returns to the rows before this page; empty on the first page
+		PrevCursor string `json:"prev_cursor,omitempty"`
 	}
 )
 
+// IsCursor reports whether the request pages by cursor rather than by page number.
+func (p *PaginationRequest) IsCursor() bool {
+	return p.Cursor != ""
+}
+
+// WantCount reports whether the response should count every match, which costs a full COUNT(*).
+func (p *PaginationRequest) WantCount() bool {
+	if p.Count != nil {
+		return *p.Count
+	}
+	return !p.IsCursor()
+}
+
 func (p *PaginationRequest) GetOffset() int {
 	return (p.Page - 1) * p.PerPage
 }
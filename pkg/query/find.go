@@ -0,0 +1,381 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// Find lists the page of T that r asks for out of query's rows. query is called again to count
+// the matches, when r wants a count, and to build cursors; scopes, such as preloads, only apply
+// to the fetch. One row past the page is fetched to tell whether the list goes on: next_cursor
+// points past the page's last row and prev_cursor before its first.
+func Find[T any](query func() *gorm.DB, r Request, s Schema, scopes ...func(*gorm.DB) *gorm.DB) ([]T, pkgdto.PaginationResponse, error) {
+	r.Default()
+	p, err := compile(r, s)
+	if err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+
+	fetch := p.where(query()).Scopes(scopes...)
+	if p.from != nil {
+		sql, args := p.keyset()
+		fetch = fetch.Where(sql, args...)
+	} else {
+		fetch = fetch.Offset(r.GetOffset())
+	}
+	var items []T
+	if err := fetch.Order(p.order()).Limit(r.GetLimit() + 1).Find(&items).Error; err != nil {
+		return nil, pkgdto.PaginationResponse{}, err
+	}
+	more := len(items) > r.PerPage
+	if more {
+		items = items[:r.PerPage]
+	}
+	// Going forward the extra row means a next page, and any cursor or offset a previous one;
+	// a prev_cursor walks back in reverse order, so there it is the other way around
+	hasNext, hasPrev := more, r.IsCursor() || r.GetOffset() > 0
+	if p.backward {
+		slices.Reverse(items)
+		hasNext, hasPrev = true, more
+	}
+
+	meta := pkgdto.PaginationResponse{PerPage: r.PerPage}
+	if len(items) > 0 && hasNext {
+		if meta.NextCursor, err = p.cursorAt(query(), items[len(items)-1], false); err != nil {
+			return nil, pkgdto.PaginationResponse{}, err
+		}
+	}
+	if len(items) > 0 && hasPrev {
+		if meta.PrevCursor, err = p.cursorAt(query(), items[0], true); err != nil {
+			return nil, pkgdto.PaginationResponse{}, err
+		}
+	}
+	if !r.IsCursor() {
+		meta.Page = r.Page
+	}
+	if r.WantCount() {
+		var total int64
+		if err := p.where(query()).Count(&total).Error; err != nil {
+			return nil, pkgdto.PaginationResponse{}, err
+		}
+		meta.Count = total
+		meta.MaxPage = total / int64(r.PerPage)
+		if total%int64(r.PerPage) != 0 {
+			meta.MaxPage++
+		}
+	}
+	return items, meta, nil
+}
+
+type condition struct {
+	sql  string
+	args []any
+}
+
+type sortKey struct {
+	name  string
+	field Field
+	desc  bool
+}
+
+// plan is a Request checked against its Schema.
+type plan struct {
+	conditions []condition
+	search     func(*gorm.DB) *gorm.DB
+	sorts      []sortKey
+	// sort is the canonical sort a cursor is issued for, key included
+	sort string
+	// from holds the sort values of the row the cursor points at; the page starts after it, or
+	// ends before it when backward
+	from     []any
+	backward bool
+}
+
+func compile(r Request, s Schema) (plan, error) {
+	var p plan
+	for _, f := range r.Filters {
+		c, err := compileFilter(f, s)
+		if err != nil {
+			return plan{}, err
+		}
+		p.conditions = append(p.conditions, c)
+	}
+	if r.Search != "" {
+		if s.Search == nil {
+			return plan{}, fmt.Errorf("%w: search is not supported here", ErrInvalidQuery)
+		}
+		term := r.Search
+		p.search = func(db *gorm.DB) *gorm.DB { return s.Search(db, term) }
+	}
+
+	sort := r.Sort
+	if sort == "" {
+		sort = s.DefaultSort
+	}
+	keys := s.Keys
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		f, ok := s.Fields[name]
+		if !ok || !f.Sort {
+			return plan{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+		}
+		if seen[name] {
+			return plan{}, fmt.Errorf("%w: %q is sorted by twice", ErrInvalidQuery, name)
+		}
+		seen[name] = true
+		p.sorts = append(p.sorts, sortKey{name: name, field: f, desc: desc})
+	}
+	// The keys make the order total, so pages neither skip nor repeat rows
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		f, ok := s.Fields[key]
+		if !ok {
+			return plan{}, fmt.Errorf("query: schema key %q is not one of its fields", key)
+		}
+		desc := len(p.sorts) > 0 && p.sorts[len(p.sorts)-1].desc
+		p.sorts = append(p.sorts, sortKey{name: key, field: f, desc: desc})
+	}
+	names := make([]string, len(p.sorts))
+	for i, k := range p.sorts {
+		names[i] = k.name
+		if k.desc {
+			names[i] = "-" + k.name
+		}
+	}
+	p.sort = strings.Join(names, ",")
+
+	if r.IsCursor() {
+		if err := p.decodeCursor(r.Cursor); err != nil {
+			return plan{}, err
+		}
+	}
+	return p, nil
+}
+
+func compileFilter(f Filter, s Schema) (condition, error) {
+	field, ok := s.Fields[f.Field]
+	if !ok {
+		return condition{}, fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, f.Field)
+	}
+	allowed := false
+	for _, op := range field.Ops {
+		allowed = allowed || op == f.Op
+	}
+	if !allowed {
+		return condition{}, fmt.Errorf("%w: cannot filter %q with %q", ErrInvalidQuery, f.Field, f.Op)
+	}
+
+	switch f.Op {
+	case In:
+		raw := strings.Split(f.Value, ",")
+		if len(raw) > MaxInValues {
+			return condition{}, fmt.Errorf("%w: filter[%s][in] takes at most %d values", ErrInvalidQuery, f.Field, MaxInValues)
+		}
+		values := make([]any, 0, len(raw))
+		for _, v := range raw {
+			parsed, err := parseValue(field.Type, strings.TrimSpace(v))
+			if err != nil {
+				return condition{}, fmt.Errorf("%w: filter[%s][in]: %v", ErrInvalidQuery, f.Field, err)
+			}
+			values = append(values, parsed)
+		}
+		return condition{sql: field.Column + " IN ?", args: []any{values}}, nil
+	case Like:
+		return condition{sql: field.Column + " ILIKE ?", args: []any{Contains(f.Value)}}, nil
+	}
+
+	value, err := parseValue(field.Type, f.Value)
+	if err != nil {
+		return condition{}, fmt.Errorf("%w: filter[%s][%s]: %v", ErrInvalidQuery, f.Field, f.Op, err)
+	}
+	operators := map[Op]string{Eq: "=", Ne: "<>", Gt: ">", Gte: ">=", Lt: "<", Lte: "<="}
+	sqlOp, ok := operators[f.Op]
+	if !ok {
+		return condition{}, fmt.Errorf("%w: unknown filter operator %q", ErrInvalidQuery, f.Op)
+	}
+	return condition{sql: field.Column + " " + sqlOp + " ?", args: []any{value}}, nil
+}
+
+// Contains is the ILIKE pattern matching values that contain term, whose own wildcards are
+// matched literally.
+func Contains(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term) + "%"
+}
+
+// parseValue parses a filter value. Times are RFC 3339 or a YYYY-MM-DD day at midnight UTC.
+func parseValue(t Type, v string) (any, error) {
+	switch t {
+	case Int:
+		return strconv.ParseInt(v, 10, 64)
+	case Float:
+		return strconv.ParseFloat(v, 64)
+	case Time:
+		if day, err := time.Parse(time.DateOnly, v); err == nil {
+			return day, nil
+		}
+		return time.Parse(time.RFC3339, v)
+	case Date:
+		day, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, err
+		}
+		return day.Format(time.DateOnly), nil
+	case UUID:
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	case Bool:
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+
+func (p plan) where(db *gorm.DB) *gorm.DB {
+	for _, c := range p.conditions {
+		db = db.Where(c.sql, c.args...)
+	}
+	if p.search != nil {
+		db = p.search(db)
+	}
+	return db
+}
+
+func (p plan) order() string {
+	parts := make([]string, len(p.sorts))
+	for i, k := range p.sorts {
+		parts[i] = k.field.Column + " ASC"
+		if p.descending(k) {
+			parts[i] = k.field.Column + " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// descending reports whether k is fetched in descending order, which a backward page reverses.
+func (p plan) descending(k sortKey) bool {
+	return k.desc != p.backward
+}
+
+// keyset selects the rows past p.from in fetch order. Directions may differ per key, so it is
+// spelled out as (a > ?) OR (a = ? AND b < ?) OR ... rather than a row comparison.
+func (p plan) keyset() (string, []any) {
+	var (
+		ors  []string
+		args []any
+	)
+	for i, k := range p.sorts {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, p.sorts[j].field.Column+" = ?")
+			args = append(args, p.from[j])
+		}
+		cmp := " > ?"
+		if p.descending(k) {
+			cmp = " < ?"
+		}
+		ands = append(ands, k.field.Column+cmp)
+		args = append(args, p.from[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// cursor is what next_cursor and prev_cursor encode: the sort they were issued for, as a
+// position means nothing under another, the sort values of the row they point at and whether
+// the page lies before that row.
+type cursor struct {
+	Sort     string            `json:"s"`
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+func (p plan) cursorAt(db *gorm.DB, row any, backward bool) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return "", err
+	}
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	c := cursor{Sort: p.sort, Backward: backward}
+	for _, k := range p.sorts {
+		column := k.field.Column[strings.LastIndex(k.field.Column, ".")+1:]
+		f := stmt.Schema.LookUpField(column)
+		if f == nil {
+			return "", fmt.Errorf("query: sort column %q is not a field of %s", column, stmt.Schema.Name)
+		}
+		value, _ := f.ValueOf(db.Statement.Context, rv)
+		if t, ok := value.(time.Time); ok && k.field.Type == Date {
+			value = t.Format(time.DateOnly)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, raw)
+	}
+	return pkgdto.EncodeCursor(c)
+}
+
+func (p *plan) decodeCursor(s string) error {
+	var c cursor
+	if err := pkgdto.DecodeCursor(s, &c); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	invalid := fmt.Errorf("%w: cursor was issued for another sort", ErrInvalidQuery)
+	if c.Sort != p.sort || len(c.Values) != len(p.sorts) {
+		return invalid
+	}
+	values := make([]any, len(p.sorts))
+	for i, k := range p.sorts {
+		var err error
+		switch k.field.Type {
+		case Int:
+			var n int64
+			err = json.Unmarshal(c.Values[i], &n)
+			values[i] = n
+		case Float:
+			var f float64
+			err = json.Unmarshal(c.Values[i], &f)
+			values[i] = f
+		case Time:
+			var t time.Time
+			err = json.Unmarshal(c.Values[i], &t)
+			values[i] = t
+		case Bool:
+			var b bool
+			err = json.Unmarshal(c.Values[i], &b)
+			values[i] = b
+		default:
+			var str string
+			err = json.Unmarshal(c.Values[i], &str)
+			values[i] = str
+		}
+		if err != nil {
+			return invalid
+		}
+	}
+	p.from, p.backward = values, c.Backward
+	return nil
+}
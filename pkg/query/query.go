@@ -0,0 +1,151 @@
+// Package query reads the list parameters every list endpoint shares and applies them to a GORM
+// query: page, per_page and search, filter[field][op]=value, sort, cursor and count. Each entity
+// whitelists what may be filtered and sorted in a Schema; anything else is rejected with
+// ErrInvalidQuery, so columns never come from the request.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	pkgdto "github.com/xkillx/go-gin-order-settlement/pkg/dto"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidQuery wraps every problem with the list parameters; callers answer it with 400.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// MaxInValues caps the comma-separated values of an in filter.
+const MaxInValues = 100
+
+// Type is how a field's filter and cursor values are parsed.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Time
+	// Date is a date column, compared as YYYY-MM-DD
+	Date
+	UUID
+	Bool
+)
+
+// Op is a filter operator, the second bracket of filter[field][op].
+type Op string
+
+const (
+	Eq   Op = "eq"
+	Ne   Op = "ne"
+	Gt   Op = "gt"
+	Gte  Op = "gte"
+	Lt   Op = "lt"
+	Lte  Op = "lte"
+	In   Op = "in"
+	Like Op = "like"
+)
+
+// Operator sets for the common kinds of field.
+var (
+	Equality = []Op{Eq, Ne, In}
+	Range    = []Op{Eq, Ne, Gt, Gte, Lt, Lte, In}
+	Text     = []Op{Eq, Ne, In, Like}
+)
+
+// Field is a field of the API and the column behind it.
+type Field struct {
+	// Column is the SQL column, qualified when the query joins other tables. A sortable field's
+	// column must belong to the listed entity and be NOT NULL, as cursors are built from it.
+	Column string
+	Type   Type
+	// Ops are the filters allowed on the field; none means it cannot be filtered.
+	Ops  []Op
+	Sort bool
+}
+
+// Schema whitelists an entity's fields for filtering and sorting.
+type Schema struct {
+	Fields map[string]Field
+	// Keys name the fields that together are unique; they break sort ties and end every cursor.
+	// "id" when empty.
+	Keys []string
+	// DefaultSort applies when the request has no sort, e.g. "-created_at".
+	DefaultSort string
+	// Search applies the search term; a search on an entity without one is rejected.
+	Search func(db *gorm.DB, term string) *gorm.DB
+}
+
+// Filter is one filter[field][op]=value parameter as it was sent.
+type Filter struct {
+	Field string
+	Op    Op
+	Value string
+}
+
+// Request is a list request's parameters, read by FromContext and checked against a Schema
+// by Find.
+type Request struct {
+	pkgdto.PaginationRequest
+	Filters []Filter
+	// Sort is a comma-separated list of fields, each prefixed with - for descending
+	Sort string
+}
+
+var filterParam = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// FromContext reads a list request from c's query string. filter[field]=value is shorthand for
+// filter[field][eq]=value.
+func FromContext(c *gin.Context) (Request, error) {
+	var r Request
+	if err := c.ShouldBindQuery(&r.PaginationRequest); err != nil {
+		return Request{}, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if r.Page < 0 || r.PerPage < 0 {
+		return Request{}, fmt.Errorf("%w: page and per_page must be positive", ErrInvalidQuery)
+	}
+	r.Default()
+	r.Search = strings.TrimSpace(r.Search)
+
+	params := c.Request.URL.Query()
+	r.Sort = strings.TrimSpace(params.Get("sort"))
+	filters, err := parseFilters(params)
+	if err != nil {
+		return Request{}, err
+	}
+	r.Filters = filters
+	return r, nil
+}
+
+func parseFilters(params url.Values) ([]Filter, error) {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	// Sorted so the same request always builds the same SQL
+	sort.Strings(keys)
+
+	var filters []Filter
+	for _, key := range keys {
+		values := params[key]
+		m := filterParam.FindStringSubmatch(key)
+		if m == nil {
+			return nil, fmt.Errorf("%w: malformed filter %q, want filter[field][op]", ErrInvalidQuery, key)
+		}
+		op := Op(m[2])
+		if op == "" {
+			op = Eq
+		}
+		for _, v := range values {
+			filters = append(filters, Filter{Field: m[1], Op: op, Value: v})
+		}
+	}
+	return filters, nil
+}
@@ -8,18 +8,35 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 )
 
-const (
-	// todo
-	KEY = "8e71bbce7451ba2835de5aea73e4f3f96821455240823d2fd8174975b8321bfc!"
+var (
+	ErrInvalidAESKey = errors.New("AES_KEY must be set to 64 hex characters (a 32 byte AES-256 key)")
+	ErrAESDecrypt    = errors.New("error in decrypting")
 )
 
+// aesKey reads the AES-256 key from AES_KEY; there is no fallback key.
+func aesKey() ([]byte, error) {
+	key, err := hex.DecodeString(os.Getenv("AES_KEY"))
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidAESKey
+	}
+	return key, nil
+}
+
+// ValidateAESKey reports a missing or malformed AES_KEY, so the service can refuse to start
+// instead of failing on the first encrypted field.
+func ValidateAESKey() error {
+	_, err := aesKey()
+	return err
+}
+
 // https://www.melvinvivas.com/how-to-encrypt-and-decrypt-data-using-aes
 
 func AESEncrypt(stringToEncrypt string) (encryptedString string, err error) {
 	//Since the key is in string, we need to convert decode it to bytes
-	key, err := hex.DecodeString(KEY)
+	key, err := aesKey()
 	if err != nil {
 		return "", err
 	}
@@ -54,13 +71,13 @@ func AESDecrypt(encryptedString string) (decryptedString string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			decryptedString = ""
-			err = errors.New("error in decrypting")
+			err = ErrAESDecrypt
 		}
 	}()
 
-	key, err := hex.DecodeString(KEY)
+	key, err := aesKey()
 	if err != nil {
-		return "", errors.New("error in decoding key")
+		return "", err
 	}
 
 	enc, err := hex.DecodeString(encryptedString)
@@ -82,6 +99,9 @@ func AESDecrypt(encryptedString string) (decryptedString string, err error) {
 
 	//Get the nonce size
 	nonceSize := aesGCM.NonceSize()
+	if len(enc) < nonceSize {
+		return "", ErrAESDecrypt
+	}
 
 	//Extract the nonce from the encrypted data
 	nonce, ciphertext := enc[:nonceSize], enc[nonceSize:]
@@ -89,7 +109,7 @@ func AESDecrypt(encryptedString string) (decryptedString string, err error) {
 	//Decrypt the data
 	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return "", nil
+		return "", ErrAESDecrypt
 	}
 
 	return string(plaintext), nil
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Backoff is base * 2^(attempts-1), capped at max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return wait
+}
+
+// RunEvery calls fn right away and then every interval until ctx is done, logging errors
+// under name.
+func RunEvery(ctx context.Context, interval time.Duration, name string, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
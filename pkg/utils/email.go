@@ -1,28 +1,96 @@
 package utils
 
 import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io"
+	"sync"
+
 	"github.com/xkillx/go-gin-order-settlement/config"
 
 	"gopkg.in/gomail.v2"
 )
 
-func SendMail(toEmail string, subject string, body string) error {
-	emailConfig, err := config.NewEmailConfig()
+//go:embed email-template/*.html
+var emailTemplates embed.FS
+
+// Attachment is a file sent along with a mail.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// RenderEmailTemplate executes one of the templates in email-template/ with data.
+func RenderEmailTemplate(name string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"cents": FormatCents}).ParseFS(emailTemplates, "email-template/"+name)
 	if err != nil {
-		return err
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
 	}
+	return buf.String(), nil
+}
 
+// NewMailMessage builds an HTML mail with attachments, ready to be sent or written out.
+func NewMailMessage(from, toEmail, subject, body string, attachments ...Attachment) *gomail.Message {
 	mailer := gomail.NewMessage()
-	mailer.SetHeader("From", emailConfig.AuthEmail)
+	mailer.SetHeader("From", from)
 	mailer.SetHeader("To", toEmail)
 	mailer.SetHeader("Subject", subject)
 	mailer.SetBody("text/html", body)
+	for _, a := range attachments {
+		data := a.Data
+		settings := []gomail.FileSetting{gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})}
+		if a.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}))
+		}
+		mailer.Attach(a.Filename, settings...)
+	}
+	return mailer
+}
+
+var (
+	emailConfigMu     sync.Mutex
+	cachedEmailConfig *config.EmailConfig
+)
+
+// emailConfig loads the SMTP settings once; a failed load is retried on the next call.
+func emailConfig() (*config.EmailConfig, error) {
+	emailConfigMu.Lock()
+	defer emailConfigMu.Unlock()
+	if cachedEmailConfig != nil {
+		return cachedEmailConfig, nil
+	}
+	cfg, err := config.NewEmailConfig()
+	if err != nil {
+		return nil, err
+	}
+	cachedEmailConfig = cfg
+	return cfg, nil
+}
+
+// SendMail sends synchronously over SMTP. Prefer the mail module's outbox, which retries and
+// keeps a record of every message.
+func SendMail(toEmail string, subject string, body string, attachments ...Attachment) error {
+	cfg, err := emailConfig()
+	if err != nil {
+		return err
+	}
+
+	mailer := NewMailMessage(cfg.AuthEmail, toEmail, subject, body, attachments...)
 
 	dialer := gomail.NewDialer(
-		emailConfig.Host,
-		emailConfig.Port,
-		emailConfig.AuthEmail,
-		emailConfig.AuthPassword,
+		cfg.Host,
+		cfg.Port,
+		cfg.AuthEmail,
+		cfg.AuthPassword,
 	)
 
 	err = dialer.DialAndSend(mailer)
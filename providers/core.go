@@ -1,18 +1,56 @@
 package providers
 
 import (
+	"log"
+	"runtime"
+	"time"
+
 	"github.com/xkillx/go-gin-order-settlement/config"
+	"github.com/xkillx/go-gin-order-settlement/middlewares"
+	merchantController "github.com/xkillx/go-gin-order-settlement/modules/merchant/controller"
+	merchantRepo "github.com/xkillx/go-gin-order-settlement/modules/merchant/repository"
+	merchantService "github.com/xkillx/go-gin-order-settlement/modules/merchant/service"
 	orderController "github.com/xkillx/go-gin-order-settlement/modules/order/controller"
 	orderRepo "github.com/xkillx/go-gin-order-settlement/modules/order/repository"
 	orderService "github.com/xkillx/go-gin-order-settlement/modules/order/service"
 	productController "github.com/xkillx/go-gin-order-settlement/modules/product/controller"
 	productRepo "github.com/xkillx/go-gin-order-settlement/modules/product/repository"
 	productService "github.com/xkillx/go-gin-order-settlement/modules/product/service"
+	reconciliationController "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/controller"
+	reconciliationRepo "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/repository"
+	reconciliationService "github.com/xkillx/go-gin-order-settlement/modules/reconciliation/service"
+	statementController "github.com/xkillx/go-gin-order-settlement/modules/statement/controller"
+	statementRepo "github.com/xkillx/go-gin-order-settlement/modules/statement/repository"
+	statementService "github.com/xkillx/go-gin-order-settlement/modules/statement/service"
 	jobRepo "github.com/xkillx/go-gin-order-settlement/modules/job/repository"
+	ledgerController "github.com/xkillx/go-gin-order-settlement/modules/ledger/controller"
+	ledgerRepo "github.com/xkillx/go-gin-order-settlement/modules/ledger/repository"
+	ledgerService "github.com/xkillx/go-gin-order-settlement/modules/ledger/service"
+	eventController "github.com/xkillx/go-gin-order-settlement/modules/event/controller"
+	eventRepo "github.com/xkillx/go-gin-order-settlement/modules/event/repository"
+	eventService "github.com/xkillx/go-gin-order-settlement/modules/event/service"
+	inventoryController "github.com/xkillx/go-gin-order-settlement/modules/inventory/controller"
+	inventoryRepo "github.com/xkillx/go-gin-order-settlement/modules/inventory/repository"
+	inventoryService "github.com/xkillx/go-gin-order-settlement/modules/inventory/service"
+	warehouseController "github.com/xkillx/go-gin-order-settlement/modules/warehouse/controller"
+	warehouseRepo "github.com/xkillx/go-gin-order-settlement/modules/warehouse/repository"
+	warehouseService "github.com/xkillx/go-gin-order-settlement/modules/warehouse/service"
+	reservationController "github.com/xkillx/go-gin-order-settlement/modules/reservation/controller"
+	reservationRepo "github.com/xkillx/go-gin-order-settlement/modules/reservation/repository"
+	reservationService "github.com/xkillx/go-gin-order-settlement/modules/reservation/service"
+	mailController "github.com/xkillx/go-gin-order-settlement/modules/mail/controller"
+	mailRepo "github.com/xkillx/go-gin-order-settlement/modules/mail/repository"
+	mailService "github.com/xkillx/go-gin-order-settlement/modules/mail/service"
+	webhookController "github.com/xkillx/go-gin-order-settlement/modules/webhook/controller"
+	webhookRepo "github.com/xkillx/go-gin-order-settlement/modules/webhook/repository"
+	webhookService "github.com/xkillx/go-gin-order-settlement/modules/webhook/service"
 	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
 	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	transactionController "github.com/xkillx/go-gin-order-settlement/modules/transaction/controller"
 	transactionRepo "github.com/xkillx/go-gin-order-settlement/modules/transaction/repository"
+	transactionService "github.com/xkillx/go-gin-order-settlement/modules/transaction/service"
 	"github.com/xkillx/go-gin-order-settlement/pkg/constants"
+	"github.com/xkillx/go-gin-order-settlement/pkg/utils"
 	"github.com/samber/do"
 	"gorm.io/gorm"
 )
@@ -29,18 +67,72 @@ func RegisterDependencies(injector *do.Injector) {
 	db := do.MustInvokeNamed[*gorm.DB](injector, constants.DB)
 
 	productRepository := productRepo.NewProductRepository(db)
+	merchantRepository := merchantRepo.NewMerchantRepository(db)
+	merchantStatementRepository := merchantRepo.NewStatementRepository(db)
 	orderRepository := orderRepo.NewOrderRepository(db)
+	inventoryRepository := inventoryRepo.NewInventoryRepository(db)
+	warehouseRepository := warehouseRepo.NewWarehouseRepository(db)
+	reservationRepository := reservationRepo.NewReservationRepository(db)
 	// Settlement job related repos
 	txRepository := transactionRepo.NewTransactionRepository(db)
 	stRepository := settlementRepo.NewSettlementRepository(db)
 	jobRepository := jobRepo.NewJobRepository(db)
+	reconciliationRepository := reconciliationRepo.NewReconciliationRepository(db)
+	statementRepository := statementRepo.NewStatementRepository(db)
+	ledgerRepository := ledgerRepo.NewLedgerRepository(db)
+	outboxRepository := eventRepo.NewOutboxRepository(db)
+	mailRepository := mailRepo.NewMailRepository(db)
+	webhookRepository := webhookRepo.NewWebhookRepository(db)
+
+	// Payout details and webhook secrets are encrypted with AES_KEY; refuse to start without a valid one
+	if err := utils.ValidateAESKey(); err != nil {
+		log.Fatalf("aes key: %v", err)
+	}
+
+	mailTransport, err := mailService.NewTransportFromEnv()
+	if err != nil {
+		log.Fatalf("mail transport: %v", err)
+	}
 
-	productService := productService.NewProductService(productRepository, db)
-	orderService := orderService.NewOrderService(orderRepository, productRepository, db)
+	// Domain events: written to the outbox in the same transaction as the change, relayed to the bus
+	outbox := eventService.NewOutboxService(outboxRepository, db, eventService.DefaultMaxAttempts)
+	eventBus := eventService.NewBus()
+	outboxRelay := eventService.NewRelay(outboxRepository, eventBus, time.Second, 5*time.Second)
+	jobEvents := eventService.NewJobEvents(outbox)
+	mailDispatcher := mailService.NewDispatcher(mailRepository, mailTransport, 5*time.Second, 30*time.Second)
+	mailOutbox := mailService.NewMailService(mailRepository, mailTransport, db, mailService.DefaultMaxAttempts)
+	mailService.NewJobNotifier(mailOutbox, mailService.JobNotifyRecipients()).Subscribe(eventBus)
+	webhooks := webhookService.NewWebhookService(webhookRepository, db, webhookService.DefaultMaxAttempts)
+	webhookDispatcher := webhookService.NewDispatcher(webhookRepository, db, nil, webhookService.DefaultMaxAttempts, 5*time.Second, 30*time.Second)
+	eventBus.Subscribe(eventService.AllEvents, "webhook", webhooks.HandleEvent)
+	// Stock lives in warehouses; orders take it by ORDER_ALLOCATION_RULE
+	inventory := inventoryService.NewInventoryService(inventoryRepository, productRepository, warehouseRepository, inventoryService.AllocationRuleFromEnv(), db)
+	warehouses := warehouseService.NewWarehouseService(warehouseRepository, db)
+	productService := productService.NewProductService(productRepository, inventory, db)
+	merchantStatementService := merchantService.NewStatementService(merchantStatementRepository, db)
+	merchantService := merchantService.NewMerchantService(merchantRepository, db)
+	orders := orderService.NewOrderService(orderRepository, productRepository, inventory, outbox, orderService.TaxRateFromEnv(), db)
+	// Checkout holds; expired ones are swept back into available stock every 30s
+	reservations := reservationService.NewReservationService(reservationRepository, productRepository, orders, db)
+	reservationSweeper := reservationService.NewSweeper(reservations, 30*time.Second)
+	// Order idempotency keys are kept for ORDER_IDEMPOTENCY_KEY_TTL and swept every 10m
+	idempotencySweeper := orderService.NewIdempotencySweeper(orders, 10*time.Minute, orderService.IdempotencyKeyTTLFromEnv())
+	importHandler := transactionService.NewImportHandler(txRepository)
+	reconciliationHandler := reconciliationService.NewReconciliationHandler(txRepository, reconciliationRepository, runtime.NumCPU(), 1000)
+	reconciliationService := reconciliationService.NewReconciliationService(reconciliationRepository, jobRepository, db)
+	statementHandler := statementService.NewStatementHandler(statementRepository, mailOutbox.SendMail, 3, 2*time.Second)
+	statementService := statementService.NewStatementService(statementRepository, jobRepository, db)
+	transactionService := transactionService.NewTransactionService(txRepository, db)
+	ledgerService := ledgerService.NewLedgerService(ledgerRepository, db)
 	// Provide JobManager as a singleton service so controllers can access the same instance for cancellation
 	do.Provide(
 		injector, func(i *do.Injector) (*settlementService.JobManager, error) {
-			return settlementService.NewJobManager(txRepository, stRepository, jobRepository), nil
+			jobManager := settlementService.NewJobManager(txRepository, stRepository, ledgerService, jobRepository, db)
+			jobManager.Register(importHandler)
+			jobManager.Register(reconciliationHandler)
+			jobManager.Register(statementHandler)
+			jobManager.SubscribeTx(jobEvents)
+			return jobManager, nil
 		},
 	)
 
@@ -50,9 +142,123 @@ func RegisterDependencies(injector *do.Injector) {
 		},
 	)
 
+	do.Provide(
+		injector, func(i *do.Injector) (merchantController.MerchantController, error) {
+			return merchantController.NewMerchantController(i, merchantService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (middlewares.MerchantAuthenticator, error) {
+			return merchantService, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (merchantController.StatementController, error) {
+			return merchantController.NewStatementController(i, merchantStatementService), nil
+		},
+	)
+
 	do.Provide(
 		injector, func(i *do.Injector) (orderController.OrderController, error) {
-			return orderController.NewOrderController(i, orderService), nil
+			return orderController.NewOrderController(i, orders), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (transactionController.TransactionController, error) {
+			return transactionController.NewTransactionController(i, transactionService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (reconciliationController.ReconciliationController, error) {
+			return reconciliationController.NewReconciliationController(i, reconciliationService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (statementController.StatementController, error) {
+			return statementController.NewStatementController(i, statementService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (ledgerController.LedgerController, error) {
+			return ledgerController.NewLedgerController(i, ledgerService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (inventoryController.InventoryController, error) {
+			return inventoryController.NewInventoryController(i, inventory), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (warehouseController.WarehouseController, error) {
+			return warehouseController.NewWarehouseController(i, warehouses), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (reservationController.ReservationController, error) {
+			return reservationController.NewReservationController(i, reservations), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*reservationService.Sweeper, error) {
+			return reservationSweeper, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*orderService.IdempotencySweeper, error) {
+			return idempotencySweeper, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (eventController.EventController, error) {
+			return eventController.NewEventController(i, outbox), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*eventService.Bus, error) {
+			return eventBus, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*eventService.Relay, error) {
+			return outboxRelay, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (mailController.MailController, error) {
+			return mailController.NewMailController(i, mailOutbox), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*mailService.Dispatcher, error) {
+			return mailDispatcher, nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (webhookController.WebhookController, error) {
+			return webhookController.NewWebhookController(i, webhooks), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (*webhookService.Dispatcher, error) {
+			return webhookDispatcher, nil
 		},
 	)
 }
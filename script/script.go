@@ -11,6 +11,9 @@ func Script(scriptName string, db *gorm.DB) error {
 	case "example_script":
 		exampleScript := NewExampleScript(db)
 		return exampleScript.Run()
+	case "verify_settlements":
+		verifySettlements := NewVerifySettlementsScript(db)
+		return verifySettlements.Run()
 	default:
 		return errors.New("script not found")
 	}
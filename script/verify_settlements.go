@@ -0,0 +1,75 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	settlementRepo "github.com/xkillx/go-gin-order-settlement/modules/settlement/repository"
+	settlementService "github.com/xkillx/go-gin-order-settlement/modules/settlement/service"
+	"gorm.io/gorm"
+)
+
+type (
+	// VerifySettlementsScript diffs settlements against transactions for a window.
+	// Usage: --script:verify_settlements [--from=YYYY-MM-DD] [--to=YYYY-MM-DD] [--out=report.csv]
+	// The window defaults to the last 30 days; to is exclusive.
+	VerifySettlementsScript struct {
+		db *gorm.DB
+	}
+)
+
+func NewVerifySettlementsScript(db *gorm.DB) *VerifySettlementsScript {
+	return &VerifySettlementsScript{
+		db: db,
+	}
+}
+
+func (s *VerifySettlementsScript) Run() error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -30), today.AddDate(0, 0, 1)
+	out := ""
+
+	for _, arg := range os.Args[1:] {
+		var err error
+		switch {
+		case strings.HasPrefix(arg, "--from="):
+			from, err = time.Parse("2006-01-02", strings.TrimPrefix(arg, "--from="))
+		case strings.HasPrefix(arg, "--to="):
+			to, err = time.Parse("2006-01-02", strings.TrimPrefix(arg, "--to="))
+		case strings.HasPrefix(arg, "--out="):
+			out = strings.TrimPrefix(arg, "--out=")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s, expected YYYY-MM-DD", arg)
+		}
+	}
+
+	verifier := settlementService.NewSettlementVerifier(settlementRepo.NewSettlementRepository(s.db))
+	report, err := verifier.Verify(context.Background(), from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("verified %d settlement days from %s to %s: %d discrepancies\n", report.Checked, report.From, report.To, len(report.Discrepancies))
+
+	if len(report.Discrepancies) > 0 {
+		w := os.Stdout
+		if out != "" {
+			f, err := os.Create(out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := report.WriteCSV(w); err != nil {
+			return err
+		}
+	}
+	if !report.OK {
+		return fmt.Errorf("%d settlement discrepancies found", len(report.Discrepancies))
+	}
+	return nil
+}